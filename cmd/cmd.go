@@ -38,9 +38,12 @@ func NewRootCmd() *cobra.Command {
 	)
 
 	cmd.AddCommand(NewApplyCmd())
+	cmd.AddCommand(NewBundleCmd())
 	cmd.AddCommand(NewDestroyCmd())
 	cmd.AddCommand(NewExportCmd())
+	cmd.AddCommand(NewImagesCmd())
 	cmd.AddCommand(NewListCmd())
+	cmd.AddCommand(NewStateCmd())
 
 	cmd.SetCompletionCommandGroupID("other")
 	cmd.SetHelpCommandGroupID("other")
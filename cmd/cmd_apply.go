@@ -23,7 +23,10 @@ var (
 		> kubitect apply --config cluster.yaml --action upgrade
 
 		To scale an existing cluster, add or remove node instances in current cluster config and run:
-		> kubitect apply --config cluster.yaml --action scale`)
+		> kubitect apply --config cluster.yaml --action scale
+
+		To create a cluster without network access, use a previously created bundle:
+		> kubitect apply --config cluster.yaml --bundle bundle.tar.gz`)
 )
 
 type ApplyOptions struct {
@@ -51,6 +54,7 @@ func NewApplyCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&o.Config, "config", "c", "", "specify path to the cluster config file")
 	cmd.PersistentFlags().StringVarP(&o.Action, "action", "a", DefaultAction, "specify cluster action [create, upgrade, scale]")
 	cmd.PersistentFlags().BoolVarP(&o.Local, "local", "l", false, "use a current directory as the cluster path")
+	cmd.PersistentFlags().StringVar(&o.Bundle, "bundle", "", "specify path to the bundle of external dependencies")
 	cmd.PersistentFlags().BoolVar(&o.AutoApprove, "auto-approve", false, "automatically approve any user permission requests")
 	cmd.PersistentFlags().BoolVar(&o.Debug, "debug", false, "enable debug messages")
 
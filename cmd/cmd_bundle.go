@@ -0,0 +1,40 @@
+package main
+
+import "github.com/spf13/cobra"
+
+var (
+	bundleShort = "Manage bundles of external dependencies"
+	bundleLong  = LongDesc(`
+		Manage bundles that contain all external dependencies required to apply the cluster without network access.`)
+
+	bundleExample = Example(`
+		Create a bundle for the cluster defined in the config file:
+		> kubitect bundle create --config cluster.yaml --output bundle.tar.gz
+
+		Apply the cluster using the bundle on a machine without network access:
+		> kubitect apply --config cluster.yaml --bundle bundle.tar.gz`)
+)
+
+func NewBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bundle",
+		GroupID: "mgmt",
+		Short:   bundleShort,
+		Long:    bundleLong,
+		Example: bundleExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddGroup(
+		&cobra.Group{
+			ID:    "main",
+			Title: "Commands:",
+		},
+	)
+
+	cmd.AddCommand(NewBundleCreateCmd())
+
+	return cmd
+}
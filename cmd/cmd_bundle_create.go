@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/cluster"
+
+	"github.com/spf13/cobra"
+)
+
+const DefaultBundleOutput = "kubitect-bundle.tar.gz"
+
+var (
+	bundleCreateShort = "Create a bundle of external dependencies"
+	bundleCreateLong  = LongDesc(`
+		Download all external dependencies required to apply the provided config file and pack them into a single archive.
+		This includes Git repositories, Python packages, the Terraform (or OpenTofu) binary with its providers, and the OS image.
+		The bundle has to be created on a machine with the same operating system, architecture and Python version
+		as the machine where it is used.`)
+
+	bundleCreateExample = Example(`
+		Create a bundle for the cluster defined in the config file:
+		> kubitect bundle create --config cluster.yaml --output bundle.tar.gz`)
+)
+
+type BundleCreateOptions struct {
+	Config string
+	Output string
+
+	app.AppContextOptions
+}
+
+func NewBundleCreateCmd() *cobra.Command {
+	var o BundleCreateOptions
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		GroupID: "main",
+		Short:   bundleCreateShort,
+		Long:    bundleCreateLong,
+		Example: bundleCreateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&o.Config, "config", "c", "", "specify path to the cluster config file")
+	cmd.PersistentFlags().StringVarP(&o.Output, "output", "o", DefaultBundleOutput, "specify path of the bundle archive")
+	cmd.PersistentFlags().BoolVar(&o.Debug, "debug", false, "enable debug messages")
+
+	cmd.MarkPersistentFlagRequired("config")
+
+	return cmd
+}
+
+func (o *BundleCreateOptions) Run() error {
+	c, err := cluster.NewCluster(o.AppContext(), o.Config)
+	if err != nil {
+		return err
+	}
+
+	return c.CreateBundle(o.Output)
+}
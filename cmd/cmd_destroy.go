@@ -40,6 +40,7 @@ func NewDestroyCmd() *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVar(&o.ClusterName, "cluster", "", "specify the cluster to be used")
+	cmd.PersistentFlags().StringVar(&o.Bundle, "bundle", "", "specify path to the bundle of external dependencies")
 	cmd.PersistentFlags().BoolVar(&o.AutoApprove, "auto-approve", false, "automatically approve any user permission requests")
 	cmd.PersistentFlags().BoolVar(&o.Debug, "debug", false, "enable debug messages")
 
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesShort = "Manage cached OS images"
+	imagesLong  = LongDesc(`
+		Manage OS images that are cached in the share directory and
+		shared among all clusters.`)
+)
+
+func NewImagesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Aliases: []string{"image"},
+		Use:     "images",
+		GroupID: "support",
+		Short:   imagesShort,
+		Long:    imagesLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddGroup(
+		&cobra.Group{
+			ID:    "main",
+			Title: "Commands:",
+		},
+	)
+
+	cmd.AddCommand(NewImagesListCmd())
+	cmd.AddCommand(NewImagesPruneCmd())
+
+	return cmd
+}
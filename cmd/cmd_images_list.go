@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path"
+
+	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/tools/images"
+	"github.com/MusicDin/kubitect/pkg/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesListShort = "List cached OS images"
+	imagesListLong  = LongDesc(`
+		Command list lists all OS images in the image cache.`)
+
+	imagesListExample = Example(`
+		List cached images:
+		> kubitect images list`)
+)
+
+type ImagesListOptions struct {
+	app.AppContextOptions
+}
+
+func NewImagesListCmd() *cobra.Command {
+	var o ImagesListOptions
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		GroupID: "main",
+		Short:   imagesListShort,
+		Long:    imagesListLong,
+		Example: imagesListExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+func (o *ImagesListOptions) Run() error {
+	imgs, err := imageCache(o.AppContext()).List()
+	if err != nil {
+		return err
+	}
+
+	if len(imgs) == 0 {
+		ui.Println(ui.INFO, "No images cached yet.")
+		return nil
+	}
+
+	ui.Println(ui.INFO, "Images:")
+
+	for _, img := range imgs {
+		ui.Printf(ui.INFO, "  - %s (%s, sha256:%.12s, downloaded %s)\n",
+			img.Source,
+			formatSize(img.Size),
+			img.Checksum,
+			img.Downloaded.Format("2006-01-02"),
+		)
+	}
+
+	return nil
+}
+
+// imageCache returns the OS image cache of the given application context.
+func imageCache(ctx app.AppContext) images.Cache {
+	return images.NewCache(path.Join(ctx.ShareDir(), "images"))
+}
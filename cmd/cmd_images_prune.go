@@ -0,0 +1,128 @@
+package main
+
+import (
+	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesPruneShort = "Remove unused OS images"
+	imagesPruneLong  = LongDesc(`
+		Command prune removes cached OS images that are not used by any
+		of the existing clusters. If flag '--all' is set, all cached images
+		are removed.`)
+
+	imagesPruneExample = Example(`
+		Remove unused images:
+		> kubitect images prune
+
+		Remove all images:
+		> kubitect images prune --all`)
+)
+
+type ImagesPruneOptions struct {
+	All bool
+
+	app.AppContextOptions
+}
+
+func NewImagesPruneCmd() *cobra.Command {
+	var o ImagesPruneOptions
+
+	cmd := &cobra.Command{
+		Use:     "prune",
+		GroupID: "main",
+		Short:   imagesPruneShort,
+		Long:    imagesPruneLong,
+		Example: imagesPruneExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVar(&o.All, "all", false, "remove all cached images")
+	cmd.PersistentFlags().BoolVar(&o.AutoApprove, "auto-approve", false, "automatically approve any user permission requests")
+
+	return cmd
+}
+
+func (o *ImagesPruneOptions) Run() error {
+	ctx := o.AppContext()
+	cache := imageCache(ctx)
+
+	imgs, err := cache.List()
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+
+	if !o.All {
+		used, err = usedImages(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	var sources []string
+
+	for _, img := range imgs {
+		if !used[img.Source] {
+			sources = append(sources, img.Source)
+		}
+	}
+
+	if len(sources) == 0 {
+		ui.Println(ui.INFO, "No images to remove.")
+		return nil
+	}
+
+	ui.Println(ui.INFO, "The following images will be removed:")
+
+	for _, s := range sources {
+		ui.Printf(ui.INFO, "  - %s\n", s)
+	}
+
+	if err := ui.Ask(); err != nil {
+		return err
+	}
+
+	for _, s := range sources {
+		if err := cache.Remove(s); err != nil {
+			return err
+		}
+	}
+
+	ui.Printf(ui.INFO, "Removed %d image(s).\n", len(sources))
+	return nil
+}
+
+// usedImages returns sources of the OS images that are used by the
+// applied configurations of existing clusters.
+func usedImages(ctx app.AppContext) (map[string]bool, error) {
+	clusters, err := AllClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+
+	for _, c := range clusters {
+		if !c.ContainsAppliedConfig() {
+			continue
+		}
+
+		cfg, err := file.ReadYaml(c.AppliedConfigPath(), config.Config{})
+		if err != nil {
+			return nil, err
+		}
+
+		used[string(cfg.Cluster.NodeTemplate.OS.Source)] = true
+	}
+
+	return used, nil
+}
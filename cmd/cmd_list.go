@@ -28,6 +28,7 @@ func NewListCmd() *cobra.Command {
 	)
 
 	cmd.AddCommand(NewListClustersCmd())
+	cmd.AddCommand(NewListOSCmd())
 	cmd.AddCommand(NewListPresetsCmd())
 
 	return cmd
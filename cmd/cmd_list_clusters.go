@@ -61,7 +61,7 @@ func (o *ListClustersOptions) Run() error {
 	for _, c := range clusters {
 		var opt []string
 
-		if c.ContainsTfStateConfig() {
+		if c.ContainsTfStateConfig() || c.ContainsLibvirtState() || c.IsStatic() {
 			opt = append(opt, "active")
 		}
 
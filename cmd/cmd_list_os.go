@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listOSShort = "List OS presets"
+	listOSLong  = LongDesc(`
+		Command list os lists all available OS presets, including the
+		built-in ones and the ones loaded from the OS presets directory
+		(~/.kubitect/presets/os).`)
+
+	listOSExample = Example(`
+		List all OS presets:
+		> kubitect list os`)
+)
+
+type ListOSOptions struct {
+	app.AppContextOptions
+}
+
+func NewListOSCmd() *cobra.Command {
+	var o ListOSOptions
+
+	cmd := &cobra.Command{
+		Use:     "os",
+		Aliases: []string{"distros"},
+		GroupID: "main",
+		Short:   listOSShort,
+		Long:    listOSLong,
+		Example: listOSExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+func (o *ListOSOptions) Run() error {
+	ac := o.AppContext()
+
+	presets, err := config.LoadOSPresets(config.OSPresetsDir(ac.HomeDir()))
+	if err != nil {
+		return err
+	}
+
+	ui.Println(ui.INFO, "Available OS presets:")
+
+	for _, p := range presets.List() {
+		opt := []string{fmt.Sprintf("user: %s", p.DefaultUser())}
+
+		if len(p.Managers) > 0 {
+			var managers []string
+
+			for _, m := range p.Managers {
+				managers = append(managers, string(m))
+			}
+
+			opt = append(opt, fmt.Sprintf("managers: %s", strings.Join(managers, "|")))
+		}
+
+		if p.Builtin {
+			opt = append(opt, "built-in")
+		}
+
+		ui.Printf(ui.INFO, "- %s (%s)\n", p.Name, strings.Join(opt, ", "))
+		ui.Printf(ui.INFO, "    %s\n", p.Source)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "github.com/spf13/cobra"
+
+var (
+	stateShort = "Manage the cluster state"
+	stateLong  = LongDesc(`
+		Manage the state of the provisioned cluster infrastructure.`)
+
+	stateExample = Example(`
+		Move the state of an existing cluster into the backend configured in the config file:
+		> kubitect state migrate --config cluster.yaml`)
+)
+
+func NewStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "state",
+		GroupID: "mgmt",
+		Short:   stateShort,
+		Long:    stateLong,
+		Example: stateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddGroup(
+		&cobra.Group{
+			ID:    "main",
+			Title: "Commands:",
+		},
+	)
+
+	cmd.AddCommand(NewStateMigrateCmd())
+
+	return cmd
+}
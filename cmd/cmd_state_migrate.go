@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/cluster"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	stateMigrateShort = "Migrate the cluster state to another backend"
+	stateMigrateLong  = LongDesc(`
+		Move the Terraform state of an existing cluster into the state backend configured in the provided config file.
+		Only the state backend is changed, other changes of the config file have to be applied with the apply command.`)
+
+	stateMigrateExample = Example(`
+		Set 'cluster.state.backend' in the cluster config and run:
+		> kubitect state migrate --config cluster.yaml`)
+)
+
+type StateMigrateOptions struct {
+	Config string
+
+	app.AppContextOptions
+}
+
+func NewStateMigrateCmd() *cobra.Command {
+	var o StateMigrateOptions
+
+	cmd := &cobra.Command{
+		SuggestFor: []string{"move", "mv"},
+		Use:        "migrate",
+		GroupID:    "main",
+		Short:      stateMigrateShort,
+		Long:       stateMigrateLong,
+		Example:    stateMigrateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&o.Config, "config", "c", "", "specify path to the cluster config file")
+	cmd.PersistentFlags().BoolVarP(&o.Local, "local", "l", false, "use a current directory as the cluster path")
+	cmd.PersistentFlags().BoolVar(&o.AutoApprove, "auto-approve", false, "automatically approve any user permission requests")
+	cmd.PersistentFlags().BoolVar(&o.Debug, "debug", false, "enable debug messages")
+
+	cmd.MarkPersistentFlagRequired("config")
+
+	return cmd
+}
+
+func (o *StateMigrateOptions) Run() error {
+	c, err := cluster.NewCluster(o.AppContext(), o.Config)
+	if err != nil {
+		return err
+	}
+
+	return c.MigrateState()
+}
@@ -52,8 +52,26 @@ func TestRootCmd_Help(t *testing.T) {
 	assert.Contains(t, out, rootLong)
 }
 
+func TestImagesCmd_Help(t *testing.T) {
+	out, err := Execute(t, NewImagesCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, imagesLong)
+}
+
 func TestExportCmd_Help(t *testing.T) {
 	out, err := Execute(t, NewExportCmd)
 	require.NoError(t, err)
 	assert.Contains(t, out, exportLong)
 }
+
+func TestStateCmd_Help(t *testing.T) {
+	out, err := Execute(t, NewStateCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, stateLong)
+}
+
+func TestBundleCmd_Help(t *testing.T) {
+	out, err := Execute(t, NewBundleCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, bundleLong)
+}
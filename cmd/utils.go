@@ -48,3 +48,20 @@ func presetName(fPath string) string {
 
 	return base[:len(base)-len(ext)]
 }
+
+// formatSize returns human readable size of the given number of bytes.
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -30,3 +30,12 @@ func TestPresetName(t *testing.T) {
 	assert.Equal(t, "test", presetName("test/test.yml"))
 	assert.Equal(t, "test.test", presetName("test.test.yml"))
 }
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "0 B", formatSize(0))
+	assert.Equal(t, "1023 B", formatSize(1023))
+	assert.Equal(t, "1.0 KiB", formatSize(1024))
+	assert.Equal(t, "1.5 MiB", formatSize(1536*1024))
+	assert.Equal(t, "600.0 MiB", formatSize(600*1024*1024))
+	assert.Equal(t, "2.0 GiB", formatSize(2*1024*1024*1024))
+}
@@ -0,0 +1,86 @@
+from __future__ import absolute_import, division, print_function
+
+__metaclass__ = type
+
+DOCUMENTATION = """
+    name: kubitect
+    type: stdout
+    short_description: Writes playbook events as JSON lines
+    description:
+      - Writes each playbook event as a single line of JSON to the standard
+        output. Kubitect parses the events to display the playbook progress.
+"""
+
+import json
+import sys
+
+# Message that replaces results of tasks with "no_log" set, which matches
+# the message of the built-in Ansible callbacks.
+CENSORED = "the output has been hidden due to the fact that 'no_log: true' was specified for this result"
+
+from ansible.parsing.ajson import AnsibleJSONEncoder
+from ansible.plugins.callback import CallbackBase
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = "stdout"
+    CALLBACK_NAME = "kubitect"
+
+    def _emit(self, event, **data):
+        data["event"] = event
+        sys.stdout.write(json.dumps(data, cls=AnsibleJSONEncoder, sort_keys=True) + "\n")
+        sys.stdout.flush()
+
+    def _emit_result(self, event, result, **data):
+        res = result._result
+        if getattr(result._task, "no_log", False):
+            res = self._censor(dict(res, _ansible_no_log=True))
+        else:
+            res = self._censor(res)
+
+        self._emit(
+            event,
+            host=result._host.get_name(),
+            task=result._task.get_name().strip(),
+            result=res,
+            **data
+        )
+
+    def _censor(self, result):
+        """Hides results (including loop results) that must not be logged."""
+        if not isinstance(result, dict):
+            return result
+
+        if result.get("_ansible_no_log", False):
+            return dict(censored=CENSORED, changed=result.get("changed", False))
+
+        if isinstance(result.get("results"), list):
+            result = dict(result, results=[self._censor(r) for r in result["results"]])
+
+        return result
+
+    def v2_playbook_on_play_start(self, play):
+        self._emit("play_start", play=play.get_name().strip())
+
+    def v2_playbook_on_task_start(self, task, is_conditional):
+        self._emit("task_start", task=task.get_name().strip())
+
+    def v2_playbook_on_handler_task_start(self, task):
+        self._emit("task_start", task=task.get_name().strip(), handler=True)
+
+    def v2_runner_on_ok(self, result):
+        self._emit_result("runner_ok", result, changed=result._result.get("changed", False))
+
+    def v2_runner_on_failed(self, result, ignore_errors=False):
+        self._emit_result("runner_failed", result, ignored=ignore_errors)
+
+    def v2_runner_on_unreachable(self, result):
+        self._emit_result("runner_unreachable", result)
+
+    def v2_runner_on_skipped(self, result):
+        self._emit_result("runner_skipped", result)
+
+    def v2_playbook_on_stats(self, stats):
+        hosts = sorted(stats.processed.keys())
+        self._emit("stats", stats=dict((h, stats.summarize(h)) for h in hosts))
@@ -0,0 +1,130 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+
+. /etc/os-release
+
+case "$ID" in
+	ubuntu|debian)
+		export DEBIAN_FRONTEND=noninteractive
+		apt-get update -q
+		apt-get install -y -q haproxy{{ if $v.Keepalived }} keepalived{{ end }}
+		;;
+	rocky|centos|rhel|almalinux)
+		dnf install -y -q haproxy{{ if $v.Keepalived }} keepalived{{ end }}
+		setsebool -P haproxy_connect_any 1 || true
+		;;
+	*)
+		echo "Unsupported operating system: $ID" >&2
+		exit 1
+		;;
+esac
+
+# Allow binding non-local IP.
+echo "net.ipv4.ip_nonlocal_bind = 1" > /etc/sysctl.d/99-haproxy.conf
+sysctl --system > /dev/null
+
+mkdir -p /run/haproxy
+
+cat > /etc/haproxy/haproxy.cfg <<'CONF'
+global
+    log /dev/log local0
+    log /dev/log local1 notice
+    stats socket /run/haproxy/admin.sock mode 660 level admin
+    stats timeout 30s
+    user haproxy
+    group haproxy
+    daemon
+
+defaults
+    log     global
+    mode    tcp
+    option  tcplog
+    option  dontlognull
+    timeout connect 5000
+    timeout client  50000
+    timeout server  50000
+
+{{- range $port := $v.Ports }}
+
+frontend control-plane-{{ $port }}
+    bind *:{{ $port }}
+    {{- if $v.VIP6 }}
+    bind :::{{ $port }} v6only
+    {{- end }}
+    default_backend control-plane-{{ $port }}
+
+backend control-plane-{{ $port }}
+    balance roundrobin
+    {{- range $v.Masters }}
+    server {{ .Name }} {{ .IP }}:{{ $port }} check fall 3 rise 2
+    {{- end }}
+{{- end }}
+{{- range $v.ForwardPorts }}
+
+frontend forward-{{ .Name }}
+    bind *:{{ .Port }}
+    {{- if $v.VIP6 }}
+    bind :::{{ .Port }} v6only
+    {{- end }}
+    default_backend forward-{{ .Name }}
+
+backend forward-{{ .Name }}
+    balance roundrobin
+    {{- $port := .TargetPort }}
+    {{- if or (eq .Target "masters" "all") (not $v.Workers) }}
+    {{- range $v.Masters }}
+    server {{ .Name }} {{ .IP }}:{{ $port }} check
+    {{- end }}
+    {{- end }}
+    {{- if eq .Target "workers" "all" }}
+    {{- range $v.Workers }}
+    server {{ .Name }} {{ .IP }}:{{ $port }} check
+    {{- end }}
+    {{- end }}
+{{- end }}
+CONF
+
+systemctl enable haproxy
+systemctl restart haproxy
+{{- if $v.Keepalived }}
+
+cat > /etc/keepalived/keepalived.conf <<'CONF'
+global_defs {
+    enable_script_security
+    script_user root
+}
+
+vrrp_script check_haproxy {
+    script "/usr/bin/killall -0 haproxy"
+    interval 2
+    weight 2
+}
+
+vrrp_instance VI_01 {
+    state BACKUP
+    interface {{ $v.Interface }}
+    virtual_router_id {{ $v.RouterId }}
+    priority {{ $v.Priority }}
+    advert_int 1
+
+    virtual_ipaddress {
+        {{ $v.VIP }}
+    }
+    {{- if $v.VIP6 }}
+
+    # Addresses of a different family must be excluded from VRRP adverts.
+    virtual_ipaddress_excluded {
+        {{ $v.VIP6 }}
+    }
+    {{- end }}
+
+    track_script {
+        check_haproxy
+    }
+}
+CONF
+
+systemctl enable keepalived
+systemctl restart keepalived
+{{- end }}
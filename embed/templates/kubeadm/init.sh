@@ -0,0 +1,26 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+
+export KUBECONFIG=/etc/kubernetes/admin.conf
+
+mkdir -p /etc/kubernetes
+
+cat > /etc/kubernetes/kubeadm.yaml <<'CONF'
+{{ $v.Config }}
+CONF
+
+# Initialize the control plane only once.
+if [ ! -f /etc/kubernetes/admin.conf ]; then
+	kubeadm init --config /etc/kubernetes/kubeadm.yaml --upload-certs
+fi
+
+{{- if eq $v.NetworkPlugin "flannel" }}
+
+curl -fsSL https://github.com/flannel-io/flannel/releases/download/{{ $v.FlannelVersion }}/kube-flannel.yml \
+	| sed "s#10.244.0.0/16#{{ $v.PodSubnet }}#" \
+	| kubectl apply -f -
+{{- else }}
+
+kubectl apply --server-side --force-conflicts -f https://raw.githubusercontent.com/projectcalico/calico/{{ $v.CalicoVersion }}/manifests/calico.yaml
+{{- end }}
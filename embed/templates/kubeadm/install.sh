@@ -0,0 +1,107 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+
+. /etc/os-release
+
+# Load kernel modules and configure networking required by Kubernetes.
+cat > /etc/modules-load.d/kubernetes.conf <<'CONF'
+overlay
+br_netfilter
+CONF
+
+modprobe overlay
+modprobe br_netfilter
+
+cat > /etc/sysctl.d/99-kubernetes.conf <<'CONF'
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+net.ipv4.ip_forward = 1
+CONF
+
+sysctl --system > /dev/null
+
+# Kubelet does not start when swap is enabled.
+swapoff -a
+sed -i '/\sswap\s/s/^/#/' /etc/fstab
+
+case "$ID" in
+	ubuntu|debian)
+		export DEBIAN_FRONTEND=noninteractive
+
+		apt-get update -q
+		apt-get install -y -q apt-transport-https ca-certificates curl gpg containerd
+
+		mkdir -p /etc/apt/keyrings
+		curl -fsSL https://pkgs.k8s.io/core:/stable:/v{{ $v.Minor }}/deb/Release.key | gpg --dearmor --yes -o /etc/apt/keyrings/kubernetes.gpg
+		echo "deb [signed-by=/etc/apt/keyrings/kubernetes.gpg] https://pkgs.k8s.io/core:/stable:/v{{ $v.Minor }}/deb/ /" > /etc/apt/sources.list.d/kubernetes.list
+
+		apt-get update -q
+
+		install_packages() {
+			local pkgs=()
+			for p in "$@"; do
+				pkgs+=("$p={{ $v.Version }}-*")
+			done
+
+			apt-get install -y -q --allow-downgrades --allow-change-held-packages "${pkgs[@]}"
+			apt-mark hold "$@" > /dev/null
+		}
+		;;
+	rocky|centos|rhel|almalinux)
+		setenforce 0 || true
+		sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
+
+		systemctl disable --now firewalld 2> /dev/null || true
+
+		dnf install -y -q dnf-plugins-core
+		dnf config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo
+
+		cat > /etc/yum.repos.d/kubernetes.repo <<'CONF'
+[kubernetes]
+name=Kubernetes
+baseurl=https://pkgs.k8s.io/core:/stable:/v{{ $v.Minor }}/rpm/
+enabled=1
+gpgcheck=1
+gpgkey=https://pkgs.k8s.io/core:/stable:/v{{ $v.Minor }}/rpm/repodata/repomd.xml.key
+exclude=kubelet kubeadm kubectl cri-tools kubernetes-cni
+CONF
+
+		dnf install -y -q containerd.io
+
+		install_packages() {
+			local pkgs=()
+			for p in "$@"; do
+				pkgs+=("$p-{{ $v.Version }}")
+			done
+
+			dnf install -y -q --disableexcludes=kubernetes "${pkgs[@]}"
+		}
+		;;
+	*)
+		echo "Unsupported operating system: $ID" >&2
+		exit 1
+		;;
+esac
+
+# Configure containerd to use systemd cgroup driver.
+if ! grep -q "SystemdCgroup = true" /etc/containerd/config.toml 2> /dev/null; then
+	mkdir -p /etc/containerd
+	containerd config default > /etc/containerd/config.toml
+	sed -i 's/SystemdCgroup = false/SystemdCgroup = true/' /etc/containerd/config.toml
+	systemctl restart containerd
+fi
+
+systemctl enable --now containerd
+
+install_packages kubeadm
+{{- if $v.UpgradeCommand }}
+
+{{ $v.UpgradeCommand }}
+{{- end }}
+
+install_packages kubelet kubectl
+
+systemctl daemon-reload
+systemctl enable kubelet
+systemctl restart kubelet
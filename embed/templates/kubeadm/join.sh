@@ -0,0 +1,14 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+
+mkdir -p /etc/kubernetes
+
+cat > /etc/kubernetes/kubeadm.yaml <<'CONF'
+{{ $v.Config }}
+CONF
+
+# Join the node only once.
+if [ ! -f /etc/kubernetes/kubelet.conf ]; then
+	kubeadm join --config /etc/kubernetes/kubeadm.yaml
+fi
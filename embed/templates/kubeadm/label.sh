@@ -0,0 +1,14 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+
+export KUBECONFIG=/etc/kubernetes/admin.conf
+{{- range $v }}
+{{- $name := .Name }}
+{{- range $k, $l := .Labels }}
+kubectl label node {{ $name }} "{{ $k }}={{ $l }}" --overwrite
+{{- end }}
+{{- range .Taints }}
+kubectl taint node {{ $name }} "{{ . }}" --overwrite
+{{- end }}
+{{- end }}
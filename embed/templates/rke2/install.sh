@@ -0,0 +1,20 @@
+{{- $v := .Values -}}
+#!/bin/bash
+set -euo pipefail
+{{- if $v.Config }}
+
+mkdir -p /etc/rancher/rke2
+
+cat > /etc/rancher/rke2/config.yaml <<'CONF'
+{{ $v.Config }}
+CONF
+{{- end }}
+
+curl -sfL https://get.rke2.io | INSTALL_RKE2_VERSION="{{ $v.Version }}" INSTALL_RKE2_TYPE="{{ $v.Type }}" sh -
+
+systemctl enable rke2-{{ $v.Type }}
+{{- if $v.Restart }}
+systemctl restart rke2-{{ $v.Type }}
+{{- else }}
+systemctl start rke2-{{ $v.Type }}
+{{- end }}
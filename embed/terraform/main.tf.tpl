@@ -1,6 +1,61 @@
 {{- $hosts := .Hosts -}}
 {{- $defHost := defaultHost $hosts -}}
 
+#================================
+# State backend
+#================================
+
+terraform {
+{{- with .Backend }}
+  {{- if eq .Type "http" }}
+  backend "http" {
+    address = {{ printf "%q" .HTTP.Address }}
+    {{- with .HTTP.LockAddress }}
+    lock_address = {{ printf "%q" . }}
+    {{- end }}
+    {{- with .HTTP.UnlockAddress }}
+    unlock_address = {{ printf "%q" . }}
+    {{- end }}
+    {{- if .HTTP.SkipCertVerification }}
+    skip_cert_verification = true
+    {{- end }}
+  }
+  {{- else if eq .Type "s3" }}
+  backend "s3" {
+    bucket = {{ printf "%q" .S3.Bucket }}
+    key    = {{ with .S3.Key }}{{ printf "%q" . }}{{ else }}"kubitect/{{ $.ClusterName }}/terraform.tfstate"{{ end }}
+    region = {{ with .S3.Region }}{{ printf "%q" . }}{{ else }}"us-east-1"{{ end }}
+    {{- with .S3.Endpoint }}
+      {{- if eq $.Binary "opentofu" }}
+    endpoints = {
+      s3 = {{ printf "%q" . }}
+    }
+    skip_requesting_account_id  = true
+    skip_s3_checksum            = true
+      {{- else }}
+    endpoint = {{ printf "%q" . }}
+      {{- end }}
+    skip_credentials_validation = true
+    skip_region_validation      = true
+    skip_metadata_api_check     = true
+    {{- end }}
+    {{- if .S3.UsePathStyle }}
+      {{- if eq $.Binary "opentofu" }}
+    use_path_style = true
+      {{- else }}
+    force_path_style = true
+      {{- end }}
+    {{- end }}
+  }
+  {{- else }}
+  backend "local" {
+    path = "../config/terraform/terraform.tfstate"
+  }
+  {{- end }}
+{{- end }}
+}
+
+
 #================================
 # Local variables
 #================================
@@ -13,6 +68,19 @@ locals {
   config          = yamldecode(file(var.config_path))
   infra_config    = try(yamldecode(file(var.infra_config_path)), null)
 
+  # Raw data disks that were attached as virtio disks by earlier versions
+  # of Kubitect (their device is not identified by WWN). Such disks keep
+  # their bus, since changing it would recreate the existing VMs.
+  infra_virtio_disks = {
+    for node in concat(
+      try(local.infra_config.nodes.master.instances, null) == null ? [] : local.infra_config.nodes.master.instances,
+      try(local.infra_config.nodes.worker.instances, null) == null ? [] : local.infra_config.nodes.worker.instances
+    ) : node.name => [
+      for disk in (try(node.dataDisks, null) == null ? [] : node.dataDisks) : disk.name
+      if !startswith(try(disk.device, null) == null ? "" : disk.device, "/dev/disk/by-id/wwn-")
+    ]
+  }
+
   node_types = {
     load_balancer = "lb"
     master        = "master"
@@ -63,39 +131,59 @@ module "host_{{ .Name }}" {
   cluster_nodeTemplate_user                = local.config.cluster.nodeTemplate.user
   cluster_nodeTemplate_ssh_privateKeyPath  = null #local.config.cluster.nodeTemplate.ssh.privateKeyPath
   cluster_nodeTemplate_ssh_addToKnownHosts = local.config.cluster.nodeTemplate.ssh.addToKnownHosts
-  cluster_nodeTemplate_os_source           = local.config.cluster.nodeTemplate.os.source
+  cluster_nodeTemplate_os_source           = {{ with $.ImagePath }}"{{ . }}"{{ else }}local.config.cluster.nodeTemplate.os.source{{ end }}
+  cluster_nodeTemplate_os_volume           = {{ with index $.ImageVolumes .Name }}"{{ . }}"{{ else }}null{{ end }}
   cluster_nodeTemplate_os_networkInterface = local.config.cluster.nodeTemplate.os.networkInterface
   cluster_nodeTemplate_updateOnBoot        = local.config.cluster.nodeTemplate.updateOnBoot
   cluster_nodeTemplate_cpuMode             = local.config.cluster.nodeTemplate.cpuMode
   cluster_nodeTemplate_dns                 = try(local.config.cluster.nodeTemplate.dns, null)
+  {{- with $.ProxyBootCmd }}
+  cluster_nodeTemplate_cloudInit = merge(try(local.config.cluster.nodeTemplate.cloudInit, {}), {
+    bootcmd = concat({{ hclList . }}, try(local.config.cluster.nodeTemplate.cloudInit.bootcmd, []))
+  })
+  {{- else }}
+  cluster_nodeTemplate_cloudInit           = try(local.config.cluster.nodeTemplate.cloudInit, {})
+  {{- end }}
 
   # Network configuration
-  cluster_network_mode    = local.config.cluster.network.mode
-  cluster_network_cidr    = local.config.cluster.network.cidr
-  cluster_network_gateway = try(local.config.cluster.network.gateway, null)
-  cluster_network_bridge  = try(local.config.cluster.network.bridge, null)
+  cluster_network_mode           = local.config.cluster.network.mode
+  cluster_network_cidr           = local.config.cluster.network.cidr
+  cluster_network_cidr6          = try(local.config.cluster.network.cidr6, null)
+  cluster_network_gateway        = try(local.config.cluster.network.gateway, null)
+  cluster_network_gateway6       = try(local.config.cluster.network.gateway6, null)
+  cluster_network_bridge         = try(local.config.cluster.network.bridge, null)
+  cluster_network_macvtap        = try(local.config.cluster.network.macvtap, null)
+  cluster_network_vlan           = try(local.config.cluster.network.vlan, null)
+  cluster_network_libvirtNetwork = try(local.config.cluster.network.libvirtNetwork, null)
+
+  # Additional networks
+  cluster_additionalNetworks = try(local.config.cluster.additionalNetworks, [])
 
   # HAProxy load balancer VMs parameters
-  cluster_nodes_loadBalancer_vip = try(local.config.cluster.nodes.loadBalancer.vip, null)
+  cluster_nodes_loadBalancer_vip       = try(local.config.cluster.nodes.loadBalancer.vip, null)
+  cluster_nodes_loadBalancer_cloudInit = try(local.config.cluster.nodes.loadBalancer.default.cloudInit, {})
   cluster_nodes_loadBalancer_instances = [
     for node in try(flatten([local.config.cluster.nodes.loadBalancer.instances]), []) : node
     if node != null && (try(node.host, null) == "{{ .Name }}"{{ $defSelector }})
   ]
 
   # Master node VMs parameters
+  cluster_nodes_master_cloudInit = try(local.config.cluster.nodes.master.default.cloudInit, {})
   cluster_nodes_master_instances = [
     for node in try(flatten([local.config.cluster.nodes.master.instances]), []) : node
     if node != null && (try(node.host, null) == "{{ .Name }}"{{ $defSelector }})
   ]
 
   # Worker node VMs parameters
+  cluster_nodes_worker_cloudInit = try(local.config.cluster.nodes.worker.default.cloudInit, {})
   cluster_nodes_worker_instances = [
     for node in try(flatten([local.config.cluster.nodes.worker.instances]), []) : node
     if node != null && (try(node.host, null) == "{{ .Name }}"{{ $defSelector }})
   ]
 
   # Other
-  node_types = local.node_types
+  node_types         = local.node_types
+  infra_virtio_disks = local.infra_virtio_disks
 
   providers = {
     libvirt = libvirt.{{ .Name }}
@@ -118,7 +206,8 @@ module "host_{{ .Name }}" {
 module "output" {
   source = "./modules/output"
 
-  lb_vip = try(local.config.cluster.nodes.loadBalancer.vip, null)
+  lb_vip  = try(local.config.cluster.nodes.loadBalancer.vip, null)
+  lb_vip6 = try(local.config.cluster.nodes.loadBalancer.vip6, null)
   lb_nodes = [
     for node in flatten([{{ $modules }}]) :
     node if node.type == local.node_types.load_balancer
@@ -7,7 +7,27 @@ locals {
   main_resource_pool_name = "${var.cluster_name}-main-resource-pool"
   network_name            = "${var.cluster_name}-network"
 
-  is_bridge = var.cluster_network_mode == "bridge"
+  # Network is created by Kubitect only in NAT and route modes. Networks
+  # that are not managed by Kubitect are never destroyed.
+  is_managed_network = contains(["nat", "route"], var.cluster_network_mode)
+
+  network_gateway  = var.cluster_network_gateway != null ? var.cluster_network_gateway : cidrhost(var.cluster_network_cidr, 1)
+  network_gateway6 = (var.cluster_network_cidr6 == null
+    ? null
+    : (var.cluster_network_gateway6 != null
+      ? var.cluster_network_gateway6
+      : cidrhost(var.cluster_network_cidr6, 1)
+    )
+  )
+
+  # Additional networks by name. Only networks managed by Kubitect have
+  # a network ID, while existing libvirt networks are referenced by name.
+  additional_networks = {
+    for net in var.cluster_additionalNetworks : net.name => merge(net, {
+      network_id   = contains(["nat", "route"], net.mode) ? module.additional_network_module[net.name].network_id : null
+      network_name = net.mode == "existing" ? net.libvirtNetwork : null
+    })
+  }
 }
 
 #======================================================================================
@@ -39,7 +59,17 @@ resource "libvirt_pool" "data_resource_pools" {
 resource "libvirt_volume" "base_volume" {
   name   = "base_volume"
   pool   = libvirt_pool.main_resource_pool.name
-  source = pathexpand(var.cluster_nodeTemplate_os_source)
+  source = var.cluster_nodeTemplate_os_volume == null ? pathexpand(var.cluster_nodeTemplate_os_source) : null
+
+  # Image uploaded into the shared image pool of a remote host #
+  base_volume_name = var.cluster_nodeTemplate_os_volume
+  base_volume_pool = var.cluster_nodeTemplate_os_volume == null ? null : "kubitect-images"
+
+  # Base volume of an existing cluster is not recreated when
+  # the image location changes (e.g. image cache is introduced) #
+  lifecycle {
+    ignore_changes = [source, base_volume_name, base_volume_pool]
+  }
 
   # Requires resource pool to be initialized #
   depends_on = [libvirt_pool.main_resource_pool]
@@ -57,12 +87,25 @@ resource "libvirt_volume" "base_volume" {
 module "network_module" {
   source = "../network/"
 
-  count = local.is_bridge ? 0 : 1
+  count = local.is_managed_network ? 1 : 0
 
   network_name   = local.network_name
   network_mode   = var.cluster_network_mode
   network_bridge = var.cluster_network_bridge
   network_cidr   = var.cluster_network_cidr
+  network_cidr6  = var.cluster_network_cidr6
+}
+
+# Creates additional networks #
+module "additional_network_module" {
+  source = "../network/"
+
+  for_each = { for net in var.cluster_additionalNetworks : net.name => net if contains(["nat", "route"], net.mode) }
+
+  network_name   = "${var.cluster_name}-${each.key}-network"
+  network_mode   = each.value.mode
+  network_bridge = each.value.bridge
+  network_cidr   = each.value.cidr
 }
 
 #================================
@@ -80,13 +123,18 @@ module "lb_module" {
   libvirt_provider_uri    = var.libvirt_provider_uri
   main_resource_pool_name = libvirt_pool.main_resource_pool.name
   base_volume_id          = libvirt_volume.base_volume.id
-  network_id              = local.is_bridge ? null : module.network_module.0.network_id
+  network_id              = local.is_managed_network ? module.network_module.0.network_id : null
 
   # Network related variables
-  network_mode    = var.cluster_network_mode
-  network_bridge  = var.cluster_network_bridge
-  network_gateway = var.cluster_network_gateway != null ? var.cluster_network_gateway : cidrhost(var.cluster_network_cidr, 1)
-  network_cidr    = var.cluster_network_cidr
+  network_mode     = var.cluster_network_mode
+  network_bridge   = var.cluster_network_bridge
+  network_macvtap  = var.cluster_network_macvtap
+  network_vlan     = var.cluster_network_vlan
+  network_name     = var.cluster_network_libvirtNetwork
+  network_gateway  = local.network_gateway
+  network_gateway6 = local.network_gateway6
+  network_cidr     = var.cluster_network_cidr
+  network_cidr6    = var.cluster_network_cidr6
 
   # Load balancer specific variables #
   vm_name              = "${var.cluster_name}-${var.node_types.load_balancer}-${each.value.id}"
@@ -97,6 +145,8 @@ module "lb_module" {
   vm_ssh_known_hosts   = var.cluster_nodeTemplate_ssh_addToKnownHosts
   vm_network_interface = var.cluster_nodeTemplate_os_networkInterface
   vm_dns               = var.cluster_nodeTemplate_dns
+  vm_cloud_init        = var.cluster_nodeTemplate_cloudInit
+  vm_cloud_init_type   = var.cluster_nodes_loadBalancer_cloudInit
   vm_cpuMode           = var.cluster_nodeTemplate_cpuMode
   vm_cpu               = each.value.cpu
   vm_ram               = each.value.ram
@@ -105,11 +155,19 @@ module "lb_module" {
   vm_id                = each.value.id
   vm_mac               = each.value.mac
   vm_ip                = each.value.ip
+  vm_ip6               = try(each.value.ip6, null)
+  vm_networks = [
+    for net in each.value.networks : merge(local.additional_networks[net.network], {
+      ip  = net.ip
+      mac = net.mac
+    })
+  ]
 
   # Dependancy takes care that resource pool is not removed before volumes are #
   # Also network must be created before VM is initialized #
   depends_on = [
     module.network_module,
+    module.additional_network_module,
     libvirt_pool.main_resource_pool,
     libvirt_pool.data_resource_pools,
     libvirt_volume.base_volume
@@ -127,13 +185,18 @@ module "master_module" {
   libvirt_provider_uri    = var.libvirt_provider_uri
   main_resource_pool_name = libvirt_pool.main_resource_pool.name
   base_volume_id          = libvirt_volume.base_volume.id
-  network_id              = local.is_bridge ? null : module.network_module.0.network_id
+  network_id              = local.is_managed_network ? module.network_module.0.network_id : null
 
   # Network related variables
-  network_mode    = var.cluster_network_mode
-  network_bridge  = var.cluster_network_bridge
-  network_gateway = var.cluster_network_gateway != null ? var.cluster_network_gateway : cidrhost(var.cluster_network_cidr, 1)
-  network_cidr    = var.cluster_network_cidr
+  network_mode     = var.cluster_network_mode
+  network_bridge   = var.cluster_network_bridge
+  network_macvtap  = var.cluster_network_macvtap
+  network_vlan     = var.cluster_network_vlan
+  network_name     = var.cluster_network_libvirtNetwork
+  network_gateway  = local.network_gateway
+  network_gateway6 = local.network_gateway6
+  network_cidr     = var.cluster_network_cidr
+  network_cidr6    = var.cluster_network_cidr6
 
   # Master node specific variables #
   vm_name              = "${var.cluster_name}-${var.node_types.master}-${each.value.id}"
@@ -144,19 +207,30 @@ module "master_module" {
   vm_ssh_known_hosts   = var.cluster_nodeTemplate_ssh_addToKnownHosts
   vm_network_interface = var.cluster_nodeTemplate_os_networkInterface
   vm_dns               = var.cluster_nodeTemplate_dns
+  vm_cloud_init        = var.cluster_nodeTemplate_cloudInit
+  vm_cloud_init_type   = var.cluster_nodes_master_cloudInit
   vm_cpuMode           = var.cluster_nodeTemplate_cpuMode
   vm_cpu               = each.value.cpu
   vm_ram               = each.value.ram
   vm_main_disk_size    = each.value.mainDiskSize
   vm_data_disks        = each.value.dataDisks
+  vm_virtio_disks      = lookup(var.infra_virtio_disks, "${var.cluster_name}-${var.node_types.master}-${each.value.id}", [])
   vm_id                = each.value.id
   vm_mac               = each.value.mac
   vm_ip                = each.value.ip
+  vm_ip6               = try(each.value.ip6, null)
+  vm_networks = [
+    for net in each.value.networks : merge(local.additional_networks[net.network], {
+      ip  = net.ip
+      mac = net.mac
+    })
+  ]
 
   # Dependancy takes care that resource pool is not removed before volumes are #
   # Also network must be created before VM is initialized #
   depends_on = [
     module.network_module,
+    module.additional_network_module,
     libvirt_pool.main_resource_pool,
     libvirt_pool.data_resource_pools,
     libvirt_volume.base_volume
@@ -174,13 +248,18 @@ module "worker_module" {
   libvirt_provider_uri    = var.libvirt_provider_uri
   main_resource_pool_name = libvirt_pool.main_resource_pool.name
   base_volume_id          = libvirt_volume.base_volume.id
-  network_id              = local.is_bridge ? null : module.network_module.0.network_id
+  network_id              = local.is_managed_network ? module.network_module.0.network_id : null
 
   # Network related variables
-  network_mode    = var.cluster_network_mode
-  network_bridge  = var.cluster_network_bridge
-  network_gateway = var.cluster_network_gateway != null ? var.cluster_network_gateway : cidrhost(var.cluster_network_cidr, 1)
-  network_cidr    = var.cluster_network_cidr
+  network_mode     = var.cluster_network_mode
+  network_bridge   = var.cluster_network_bridge
+  network_macvtap  = var.cluster_network_macvtap
+  network_vlan     = var.cluster_network_vlan
+  network_name     = var.cluster_network_libvirtNetwork
+  network_gateway  = local.network_gateway
+  network_gateway6 = local.network_gateway6
+  network_cidr     = var.cluster_network_cidr
+  network_cidr6    = var.cluster_network_cidr6
 
   # Worker node specific variables #
   vm_name              = "${var.cluster_name}-${var.node_types.worker}-${each.value.id}"
@@ -191,19 +270,30 @@ module "worker_module" {
   vm_ssh_known_hosts   = var.cluster_nodeTemplate_ssh_addToKnownHosts
   vm_network_interface = var.cluster_nodeTemplate_os_networkInterface
   vm_dns               = var.cluster_nodeTemplate_dns
+  vm_cloud_init        = var.cluster_nodeTemplate_cloudInit
+  vm_cloud_init_type   = var.cluster_nodes_worker_cloudInit
   vm_cpuMode           = var.cluster_nodeTemplate_cpuMode
   vm_cpu               = each.value.cpu
   vm_ram               = each.value.ram
   vm_main_disk_size    = each.value.mainDiskSize
   vm_data_disks        = each.value.dataDisks
+  vm_virtio_disks      = lookup(var.infra_virtio_disks, "${var.cluster_name}-${var.node_types.worker}-${each.value.id}", [])
   vm_id                = each.value.id
   vm_mac               = each.value.mac
   vm_ip                = each.value.ip
+  vm_ip6               = try(each.value.ip6, null)
+  vm_networks = [
+    for net in each.value.networks : merge(local.additional_networks[net.network], {
+      ip  = net.ip
+      mac = net.mac
+    })
+  ]
 
   # Dependancies takes care that resource pool is not removed before volumes are.
   # Also network must be created before VM is initialized.
   depends_on = [
     module.network_module,
+    module.additional_network_module,
     libvirt_pool.main_resource_pool,
     libvirt_pool.data_resource_pools,
     libvirt_volume.base_volume
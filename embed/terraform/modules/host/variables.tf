@@ -74,6 +74,12 @@ variable "cluster_nodeTemplate_os_source" {
   description = "OS source, which can be path on host's filesystem or URL."
 }
 
+variable "cluster_nodeTemplate_os_volume" {
+  type        = string
+  description = "Name of the volume within the shared image pool that contains the OS image. If set, source is ignored."
+  default     = null
+}
+
 variable "cluster_nodeTemplate_os_networkInterface" {
   type        = string
   description = "Operating system (os) network interface, which is predefined for the os image."
@@ -86,6 +92,13 @@ variable "cluster_nodeTemplate_dns" {
   nullable    = false
 }
 
+variable "cluster_nodeTemplate_cloudInit" {
+  type        = any
+  description = "User supplied cloud-config merged into the cloud-init user data of all virtual machines."
+  default     = {}
+  nullable    = false
+}
+
 variable "cluster_nodeTemplate_updateOnBoot" {
   type        = bool
   description = "Update system on boot."
@@ -113,17 +126,69 @@ variable "cluster_network_bridge" {
   nullable    = true
 }
 
+variable "cluster_network_macvtap" {
+  type        = string
+  description = "Host interface used for macvtap attachment (bridge mode)."
+  nullable    = true
+  default     = null
+}
+
+variable "cluster_network_vlan" {
+  type        = number
+  description = "VLAN ID of the node instances (bridge mode)."
+  nullable    = true
+  default     = null
+}
+
+variable "cluster_network_libvirtNetwork" {
+  type        = string
+  description = "Name of the existing libvirt network (existing mode)."
+  nullable    = true
+  default     = null
+}
+
 variable "cluster_network_gateway" {
   type        = string
   description = "Network gateway."
   nullable    = true
 }
 
+variable "cluster_network_gateway6" {
+  type        = string
+  description = "IPv6 network gateway."
+  nullable    = true
+  default     = null
+}
+
 variable "cluster_network_cidr" {
   type        = string
   description = "Network CIDR."
 }
 
+variable "cluster_network_cidr6" {
+  type        = string
+  description = "IPv6 network CIDR (dual-stack)."
+  nullable    = true
+  default     = null
+}
+
+#================================
+# Additional networks
+#================================
+
+variable "cluster_additionalNetworks" {
+  type = list(object({
+    name           = string
+    mode           = string
+    cidr           = string
+    bridge         = optional(string)
+    libvirtNetwork = optional(string)
+  }))
+  description = "Additional networks that node instances can be attached to."
+  default     = []
+  nullable    = false
+}
+
 #======================================================================================
 # HAProxy load balancer VMs parameters
 #======================================================================================
@@ -133,15 +198,28 @@ variable "cluster_nodes_loadBalancer_vip" {
   description = "HAProxy load balancer virtual IP address (VIP)."
 }
 
+variable "cluster_nodes_loadBalancer_cloudInit" {
+  type        = any
+  description = "User supplied cloud-config merged into the cloud-init user data of HAProxy load balancer VMs."
+  default     = {}
+  nullable    = false
+}
+
 variable "cluster_nodes_loadBalancer_instances" {
   type = list(object({
     id           = string
     host         = optional(string)
     mac          = optional(string)
     ip           = optional(string)
+    ip6          = optional(string)
     cpu          = optional(number)
     ram          = optional(number)
     mainDiskSize = optional(number)
+    networks = optional(list(object({
+      network = string
+      ip      = optional(string)
+      mac     = optional(string)
+    })), [])
   }))
   description = "HAProxy load balancer node instances."
 }
@@ -150,12 +228,20 @@ variable "cluster_nodes_loadBalancer_instances" {
 # Master node VMs parameters
 #======================================================================================
 
+variable "cluster_nodes_master_cloudInit" {
+  type        = any
+  description = "User supplied cloud-config merged into the cloud-init user data of master node VMs."
+  default     = {}
+  nullable    = false
+}
+
 variable "cluster_nodes_master_instances" {
   type = list(object({
     id           = string
     host         = optional(string)
     mac          = optional(string)
     ip           = optional(string)
+    ip6          = optional(string)
     cpu          = number
     ram          = number
     mainDiskSize = number
@@ -163,7 +249,15 @@ variable "cluster_nodes_master_instances" {
       name : string
       pool : optional(string)
       size : number
+      filesystem : optional(string)
+      mountPath : optional(string)
+      mountOptions : optional(list(string))
     })))
+    networks = optional(list(object({
+      network = string
+      ip      = optional(string)
+      mac     = optional(string)
+    })), [])
   }))
   description = "Master node instances (control plane)"
 }
@@ -172,12 +266,20 @@ variable "cluster_nodes_master_instances" {
 # Worker node VMs parameters
 #======================================================================================
 
+variable "cluster_nodes_worker_cloudInit" {
+  type        = any
+  description = "User supplied cloud-config merged into the cloud-init user data of worker node VMs."
+  default     = {}
+  nullable    = false
+}
+
 variable "cluster_nodes_worker_instances" {
   type = list(object({
     id           = string
     host         = optional(string)
     mac          = optional(string)
     ip           = optional(string)
+    ip6          = optional(string)
     cpu          = number
     ram          = number
     mainDiskSize = number
@@ -185,7 +287,15 @@ variable "cluster_nodes_worker_instances" {
       name : string
       pool : optional(string)
       size : number
+      filesystem : optional(string)
+      mountPath : optional(string)
+      mountOptions : optional(list(string))
     })))
+    networks = optional(list(object({
+      network = string
+      ip      = optional(string)
+      mac     = optional(string)
+    })), [])
   }))
   description = "Worker node instances."
 }
@@ -202,3 +312,10 @@ variable "node_types" {
   })
   description = "Node types."
 }
+
+variable "infra_virtio_disks" {
+  type        = map(list(string))
+  description = "Names of the data disks attached as virtio disks by earlier versions, keyed by VM name."
+  default     = {}
+  nullable    = false
+}
@@ -6,7 +6,7 @@ resource "libvirt_network" "network" {
   name      = var.network_name
   mode      = var.network_mode
   bridge    = var.network_bridge
-  addresses = [var.network_cidr]
+  addresses = compact([var.network_cidr, var.network_cidr6])
   autostart = true
 
   dns {
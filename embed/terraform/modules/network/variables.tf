@@ -21,3 +21,9 @@ variable "network_cidr" {
   type        = string
   description = "Network CIDR"
 }
+
+variable "network_cidr6" {
+  type        = string
+  description = "IPv6 network CIDR (dual-stack)"
+  default     = null
+}
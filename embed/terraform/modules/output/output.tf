@@ -11,6 +11,13 @@ output "nodes" {
           )
         )
       )
+      vip6 = (length(var.lb_nodes) == 0
+        ? var.master_nodes[0].ip6
+        : (var.lb_vip6 != null
+          ? var.lb_vip6
+          : (length(var.lb_nodes) == 1 ? var.lb_nodes[0].ip6 : null)
+        )
+      )
       instances = var.lb_nodes
     }
     master = {
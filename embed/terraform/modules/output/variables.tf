@@ -7,6 +7,12 @@ variable "lb_vip" {
   description = "Load balancer virtual IP address (VIP)"
 }
 
+variable "lb_vip6" {
+  type        = string
+  description = "Load balancer virtual IPv6 address (used only in dual-stack networks)"
+  default     = null
+}
+
 # variable "vm_user" {
 #   type        = string
 #   description = "SSH user for VMs"
@@ -37,10 +43,14 @@ variable "worker_nodes" {
     id   = string
     name = string
     ip   = string
+    ip6  = optional(string)
     dataDisks = list(object({
-      name = string
-      size = number
-      pool = string
+      name       = string
+      size       = number
+      pool       = string
+      filesystem = optional(string)
+      mountPath  = optional(string)
+      device     = optional(string)
     }))
   }))
   description = "Worker nodes info"
@@ -51,10 +61,14 @@ variable "master_nodes" {
     id   = string
     name = string
     ip   = string
+    ip6  = optional(string)
     dataDisks = list(object({
-      name = string
-      size = number
-      pool = string
+      name       = string
+      size       = number
+      pool       = string
+      filesystem = optional(string)
+      mountPath  = optional(string)
+      device     = optional(string)
     }))
   }))
   description = "Master nodes info"
@@ -65,6 +79,7 @@ variable "lb_nodes" {
     id   = string
     name = string
     ip   = string
+    ip6  = optional(string)
   }))
   description = "Load balancers info"
 }
@@ -3,12 +3,16 @@ output "vm_info" {
     id   = var.vm_id
     type = var.vm_type
     name = libvirt_domain.vm_domain.name,
-    ip   = try(libvirt_domain.vm_domain.network_interface.0.addresses.0, null)
+    ip   = local.vm_ipv4
+    ip6  = local.vm_ipv6
     dataDisks = [
       for disk in var.vm_data_disks : {
-        name = disk.name
-        size = disk.size
-        pool = disk.pool == null ? "main" : disk.pool
+        name       = disk.name
+        size       = disk.size
+        pool       = disk.pool == null ? "main" : disk.pool
+        filesystem = disk.filesystem
+        mountPath  = disk.mountPath
+        device     = local.vm_data_disk_devices[disk.name]
     }]
   }
   description = "VM's info"
@@ -41,16 +41,46 @@ variable "network_bridge" {
   description = "Network bridge (used only when network mode is 'bridge')"
 }
 
+variable "network_name" {
+  type        = string
+  description = "Name of the existing libvirt network (used only when network mode is 'existing')"
+  default     = null
+}
+
+variable "network_macvtap" {
+  type        = string
+  description = "Host interface used for macvtap attachment (used only when network mode is 'bridge')"
+  default     = null
+}
+
+variable "network_vlan" {
+  type        = number
+  description = "VLAN ID configured on top of the VM's network interface (used only when network mode is 'bridge')"
+  default     = null
+}
+
 variable "network_gateway" {
   type        = string
   description = "Network gateway (used only when network mode is 'bridge')"
 }
 
+variable "network_gateway6" {
+  type        = string
+  description = "IPv6 network gateway (used only in dual-stack networks)"
+  default     = null
+}
+
 variable "network_cidr" {
   type        = string
   description = "Network CIDR"
 }
 
+variable "network_cidr6" {
+  type        = string
+  description = "IPv6 network CIDR (used only in dual-stack networks)"
+  default     = null
+}
+
 # ==================================== #
 # VM variables                         #
 # ==================================== #
@@ -89,6 +119,20 @@ variable "vm_dns" {
   description = "List of DNS servers used by VMs"
 }
 
+variable "vm_cloud_init" {
+  type        = any
+  description = "User supplied cloud-config of the node template"
+  default     = {}
+  nullable    = false
+}
+
+variable "vm_cloud_init_type" {
+  type        = any
+  description = "User supplied cloud-config of the node type (overrides the node template one)"
+  default     = {}
+  nullable    = false
+}
+
 variable "vm_update" {
   type        = bool
   description = "Update system when ready"
@@ -133,12 +177,22 @@ variable "vm_data_disks" {
     name : string
     size : number
     pool : optional(string, "main")
+    filesystem : optional(string)
+    mountPath : optional(string)
+    mountOptions : optional(list(string))
   }))
   description = "Additional data disks attached to the virtual machine"
   default     = []
   nullable    = false
 }
 
+variable "vm_virtio_disks" {
+  type        = list(string)
+  description = "Names of the data disks that are attached as virtio disks instead of SCSI disks with a WWN"
+  default     = []
+  nullable    = false
+}
+
 variable "vm_mac" {
   type        = string
   description = "The MAC address of the virtual machine"
@@ -148,3 +202,25 @@ variable "vm_ip" {
   type        = string
   description = "The IP address of the virtual machine"
 }
+
+variable "vm_ip6" {
+  type        = string
+  description = "The IPv6 address of the virtual machine"
+  default     = null
+}
+
+variable "vm_networks" {
+  type = list(object({
+    name         = string
+    mode         = string
+    cidr         = string
+    bridge       = optional(string)
+    network_id   = optional(string)
+    network_name = optional(string)
+    ip           = optional(string)
+    mac          = optional(string)
+  }))
+  description = "Additional networks the virtual machine is attached to"
+  default     = []
+  nullable    = false
+}
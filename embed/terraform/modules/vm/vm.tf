@@ -1,3 +1,102 @@
+#================================
+# Local variables
+#================================
+
+locals {
+  # Static addresses with network prefix length (used by cloud-init).
+  vm_cidr  = var.vm_ip == null ? "" : "${var.vm_ip}/${split("/", var.network_cidr)[1]}"
+  vm_cidr6 = var.vm_ip6 == null || var.network_cidr6 == null ? "" : "${var.vm_ip6}/${split("/", var.network_cidr6)[1]}"
+
+  # Addresses reported by the VM. In dual-stack networks, the first
+  # reported address is not necessarily an IPv4 address.
+  vm_addresses = libvirt_domain.vm_domain.network_interface.0.addresses
+  vm_ipv4      = try([for ip in local.vm_addresses : ip if length(regexall(":", ip)) == 0][0], null)
+  vm_ipv6 = (var.vm_ip6 != null
+    ? var.vm_ip6
+    : try([for ip in local.vm_addresses : ip if length(regexall(":", ip)) > 0 && !startswith(ip, "fe80")][0], null)
+  )
+
+  # User supplied cloud-config of the node template and the node type.
+  # List values are concatenated, while other values are overridden.
+  cloud_init = {
+    for k in distinct(concat(keys(var.vm_cloud_init), keys(var.vm_cloud_init_type))) : k => try(
+      concat(var.vm_cloud_init[k], var.vm_cloud_init_type[k]),
+      var.vm_cloud_init_type[k],
+      var.vm_cloud_init[k]
+    )
+  }
+
+  # Keys that are appended to the lists of the generated user data.
+  cloud_init_lists = ["bootcmd", "fs_setup", "mounts", "packages", "runcmd"]
+  cloud_init_extra = { for k, v in local.cloud_init : k => v if !contains(local.cloud_init_lists, k) }
+
+  # Additional network interfaces are matched by their MAC address within
+  # cloud-init. Therefore, a stable MAC address is derived from the VM and
+  # network name, unless it is set explicitly.
+  vm_networks = [
+    for net in var.vm_networks : merge(net, {
+      mac = (net.mac != null
+        ? net.mac
+        : join(":", concat(["52", "54", "00"], regex("^(..)(..)(..)", md5("${var.vm_name}-${net.name}"))))
+      )
+      vm_cidr = net.ip == null ? "" : "${net.ip}/${split("/", net.cidr)[1]}"
+    })
+  ]
+
+  # Data disks are attached to a SCSI controller with a stable WWN, so
+  # they can be identified within the VM regardless of the order in which
+  # the disks are detected or the disks that are added later. Raw disks
+  # attached by earlier versions as virtio disks are left as they are, to
+  # prevent recreation of the existing VMs.
+  vm_data_disk_wwns = {
+    for disk in var.vm_data_disks : disk.name => (contains(var.vm_virtio_disks, disk.name)
+      ? null
+      : "05abcd${substr(md5("${var.vm_name}-${disk.name}"), 0, 10)}"
+    )
+  }
+
+  # Virtio disks are attached in the order of their names, following the
+  # main disk. Their device names are therefore determined by their
+  # position among the virtio disks.
+  vm_virtio_disk_names = sort([
+    for disk in var.vm_data_disks : disk.name if contains(var.vm_virtio_disks, disk.name)
+  ])
+
+  vm_data_disk_devices = {
+    for disk in var.vm_data_disks : disk.name => (local.vm_data_disk_wwns[disk.name] == null
+      ? "/dev/vd${substr("bcdefghijklmnopqrstuvwxyz", index(local.vm_virtio_disk_names, disk.name), 1)}"
+      : "/dev/disk/by-id/wwn-0x${local.vm_data_disk_wwns[disk.name]}"
+    )
+  }
+
+  vm_formatted_disks = [
+    for disk in var.vm_data_disks : merge(disk, {
+      device = local.vm_data_disk_devices[disk.name]
+    }) if disk.filesystem != null
+  ]
+
+  # Filesystems and mounts of the formatted data disks (cloud-config).
+  disk_fs_setup = [
+    for disk in local.vm_formatted_disks : {
+      device     = disk.device
+      filesystem = disk.filesystem
+      label      = substr(disk.name, 0, 12)
+      overwrite  = false
+    }
+  ]
+
+  disk_mounts = [
+    for disk in local.vm_formatted_disks : [
+      disk.device,
+      disk.mountPath,
+      disk.filesystem,
+      join(",", disk.mountOptions != null ? disk.mountOptions : ["defaults", "nofail"]),
+      "0",
+      "2"
+    ] if disk.mountPath != null
+  ]
+}
+
 #================================
 # Cloud-init
 #================================
@@ -17,17 +116,29 @@ resource "libvirt_cloudinit_disk" "cloud_init" {
     user           = var.vm_user
     update         = var.vm_update
     ssh_public_key = data.local_file.ssh_public_key.content
+    bootcmd        = try(local.cloud_init.bootcmd, [])
+    fs_setup       = concat(local.disk_fs_setup, try(local.cloud_init.fs_setup, []))
+    mounts         = concat(local.disk_mounts, try(local.cloud_init.mounts, []))
+    packages       = try(local.cloud_init.packages, [])
+    runcmd         = try(local.cloud_init.runcmd, [])
+    extra          = length(local.cloud_init_extra) == 0 ? "" : yamlencode(local.cloud_init_extra)
   })
 
-  network_config = templatefile(var.network_mode != "nat" && var.vm_ip != null
-    ? "./templates/cloud_init/cloud_init_network_static.tpl"
-    : "./templates/cloud_init/cloud_init_network_dhcp.tpl"
-    , {
-      network_interface = var.vm_network_interface
-      network_bridge    = var.network_bridge
-      network_gateway   = var.network_gateway
-      vm_dns_list       = length(var.vm_dns) == 0 ? var.network_gateway : join(", ", var.vm_dns)
-      vm_cidr           = var.vm_ip == null ? "" : "${var.vm_ip}/${split("/", var.network_cidr)[1]}"
+  network_config = templatefile("./templates/cloud_init/cloud_init_network.tpl", {
+    network_interface = var.vm_network_interface
+    vlan              = var.network_vlan == null ? 0 : var.network_vlan
+    vm_networks       = local.vm_networks
+    primary = trimspace(templatefile(var.network_mode != "nat" && var.vm_ip != null
+      ? "./templates/cloud_init/cloud_init_network_static.tpl"
+      : "./templates/cloud_init/cloud_init_network_dhcp.tpl"
+      , {
+        network_gateway  = var.network_gateway
+        network_gateway6 = var.network_gateway6 == null ? "" : var.network_gateway6
+        dhcp6            = var.network_cidr6 != null
+        vm_dns_list      = length(var.vm_dns) == 0 ? var.network_gateway : join(", ", var.vm_dns)
+        vm_cidr          = local.vm_cidr
+        vm_cidr6         = local.vm_cidr6
+    }))
   })
 }
 
@@ -71,25 +182,42 @@ resource "libvirt_domain" "vm_domain" {
 
   cloudinit = libvirt_cloudinit_disk.cloud_init.id
 
-  qemu_agent = (var.network_mode == "bridge")
+  # Addresses of VMs in networks not managed by Kubitect are reported
+  # by the guest agent.
+  qemu_agent = contains(["bridge", "existing"], var.network_mode)
 
   # Network configuration #
   network_interface {
     network_id     = var.network_id
+    network_name   = var.network_mode == "existing" ? var.network_name : null
     mac            = var.vm_mac
     bridge         = var.network_bridge
-    addresses      = var.network_mode == "nat" && var.vm_ip != null ? [var.vm_ip] : null
+    macvtap        = var.network_macvtap
+    addresses      = var.network_mode == "nat" && var.vm_ip != null ? compact([var.vm_ip, var.vm_ip6]) : null
     wait_for_lease = true
   }
 
+  dynamic "network_interface" {
+    for_each = local.vm_networks
+    content {
+      network_id   = network_interface.value.network_id
+      network_name = network_interface.value.network_name
+      bridge       = network_interface.value.mode == "bridge" ? network_interface.value.bridge : null
+      mac          = network_interface.value.mac
+      addresses    = network_interface.value.mode == "nat" && network_interface.value.ip != null ? [network_interface.value.ip] : null
+    }
+  }
+
   # Storage configuration #
   dynamic "disk" {
     for_each = concat(
-      [{ "id" : libvirt_volume.vm_main_disk.id }],
-      [for disk in libvirt_volume.vm_data_disks : { "id" : disk.id }]
+      [{ "id" : libvirt_volume.vm_main_disk.id, "wwn" : null }],
+      [for name, disk in libvirt_volume.vm_data_disks : { "id" : disk.id, "wwn" : local.vm_data_disk_wwns[name] }]
     )
     content {
       volume_id = disk.value.id
+      scsi      = disk.value.wwn != null
+      wwn       = disk.value.wwn
     }
   }
 
@@ -115,7 +243,7 @@ resource "libvirt_domain" "vm_domain" {
   provisioner "remote-exec" {
 
     connection {
-      host        = self.network_interface.0.addresses.0
+      host        = [for ip in self.network_interface.0.addresses : ip if length(regexall(":", ip)) == 0][0]
       type        = "ssh"
       user        = var.vm_user
       private_key = file(var.vm_ssh_private_key)
@@ -137,7 +265,7 @@ resource "null_resource" "ssh_known_hosts" {
   count = var.vm_ssh_known_hosts ? 1 : 0
 
   triggers = {
-    vm_ip = libvirt_domain.vm_domain.network_interface.0.addresses.0
+    vm_ip = local.vm_ipv4
   }
 
   provisioner "local-exec" {
@@ -152,7 +280,7 @@ resource "null_resource" "ssh_known_hosts" {
 
     environment = {
       HOME  = pathexpand("~")
-      VM_IP = libvirt_domain.vm_domain.network_interface.0.addresses.0
+      VM_IP = local.vm_ipv4
     }
 
     quiet = true
@@ -15,12 +15,39 @@ package_upgrade: ${update}
 
 packages:
   - qemu-guest-agent
+%{ for p in packages ~}
+  - ${jsonencode(p)}
+%{ endfor ~}
 
 bootcmd:
   # Disable qemu-guest-agent to prevent reporting IP addresses
   # before cloud-init has configured the network.
   - cloud-init-per once disable-qemu-ga systemctl stop qemu-guest-agent.service
+%{ for c in bootcmd ~}
+  - ${jsonencode(c)}
+%{ endfor ~}
 
 runcmd:
   - [ systemctl, enable, qemu-guest-agent.service ]
   - [ systemctl, start, qemu-guest-agent.service ]
+%{ for c in runcmd ~}
+  - ${jsonencode(c)}
+%{ endfor ~}
+%{ if length(fs_setup) > 0 ~}
+
+fs_setup:
+%{ for f in fs_setup ~}
+  - ${jsonencode(f)}
+%{ endfor ~}
+%{ endif ~}
+%{ if length(mounts) > 0 ~}
+
+mounts:
+%{ for m in mounts ~}
+  - ${jsonencode(m)}
+%{ endfor ~}
+%{ endif ~}
+%{ if extra != "" ~}
+
+${extra}
+%{ endif ~}
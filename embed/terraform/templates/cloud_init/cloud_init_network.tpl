@@ -0,0 +1,30 @@
+version: 2
+ethernets:
+  ${network_interface}:
+%{ if vlan == 0 ~}
+    ${indent(4, primary)}
+%{ else ~}
+    dhcp4: false
+    dhcp6: false
+%{ endif ~}
+%{ for net in vm_networks ~}
+  net-${net.name}:
+    match:
+      macaddress: "${net.mac}"
+%{ if net.vm_cidr != "" ~}
+    dhcp4: false
+    addresses: [${net.vm_cidr}]
+%{ else ~}
+    dhcp4: true
+    dhcp4-overrides:
+      use-routes: false
+      use-dns: false
+%{ endif ~}
+%{ endfor ~}
+%{ if vlan != 0 ~}
+vlans:
+  ${network_interface}.${vlan}:
+    id: ${vlan}
+    link: ${network_interface}
+    ${indent(4, primary)}
+%{ endif ~}
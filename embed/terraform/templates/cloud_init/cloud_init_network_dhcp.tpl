@@ -1,7 +1,4 @@
-version: 2
-ethernets:
-  ${network_interface}:
-    dhcp4: true
-    dhcp6: false
-    nameservers:
-      addresses: [${vm_dns_list}]
+dhcp4: true
+dhcp6: ${dhcp6}
+nameservers:
+  addresses: [${vm_dns_list}]
@@ -1,9 +1,9 @@
-version: 2
-ethernets:
-  ${network_interface}:
-    dhcp4: false
-    dhcp6: false
-    addresses: [${vm_cidr}]
-    gateway4: ${network_gateway}
-    nameservers:
-      addresses: [${vm_dns_list}]
+dhcp4: false
+dhcp6: ${dhcp6 && vm_cidr6 == ""}
+addresses: [${vm_cidr}%{ if vm_cidr6 != "" }, ${vm_cidr6}%{ endif }]
+gateway4: ${network_gateway}
+%{ if network_gateway6 != "" ~}
+gateway6: ${network_gateway6}
+%{ endif ~}
+nameservers:
+  addresses: [${vm_dns_list}]
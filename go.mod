@@ -3,8 +3,10 @@ module github.com/MusicDin/kubitect
 go 1.25.4
 
 require (
+	github.com/ProtonMail/go-crypto v1.3.0
 	github.com/apenella/go-ansible v1.3.0
 	github.com/creasty/defaults v1.8.0
+	github.com/digitalocean/go-libvirt v0.0.0-20240812180835-9c6c0a310c6c
 	github.com/go-git/go-git/v5 v5.16.5
 	github.com/go-playground/validator/v10 v10.30.1
 	github.com/hashicorp/hc-install v0.9.2
@@ -40,7 +42,6 @@ require (
 
 require (
 	github.com/Microsoft/go-winio v0.6.2 // indirect
-	github.com/ProtonMail/go-crypto v1.3.0 // indirect
 	github.com/apenella/go-common-utils/data v0.0.0-20221227202648-5452d804e940 // indirect
 	github.com/apenella/go-common-utils/error v0.0.0-20221227202648-5452d804e940 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
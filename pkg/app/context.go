@@ -17,6 +17,11 @@ const (
 	defaultClustersDir = "clusters"
 )
 
+// envTerraformBinary is an environment variable that globally selects the
+// binary used by the Terraform provisioner. It can be overridden in the
+// cluster configuration.
+const envTerraformBinary = "KUBITECT_TERRAFORM_BINARY"
+
 type AppContextOptions struct {
 	// Automatically approve user prompts
 	AutoApprove bool
@@ -33,6 +38,14 @@ type AppContextOptions struct {
 	// Show terraform plan
 	ShowTerraformPlan bool
 
+	// Binary used by the Terraform provisioner (terraform or opentofu).
+	// Defaults to the value of KUBITECT_TERRAFORM_BINARY.
+	TerraformBinary string
+
+	// Path of the bundle archive from which external dependencies
+	// are obtained instead of being downloaded.
+	Bundle string
+
 	// AppContext instance.
 	appContext AppContext
 }
@@ -75,6 +88,20 @@ type (
 		// ShowTerraformPlan indicates that terraform plan should
 		// be always shown.
 		ShowTerraformPlan() bool
+
+		// TerraformBinary returns the binary used by the Terraform
+		// provisioner, unless the cluster configuration specifies
+		// its own.
+		//
+		// Default is empty, which means Terraform is used.
+		TerraformBinary() string
+
+		// BundlePath returns path of the bundle archive that
+		// contains external dependencies.
+		//
+		// Default is empty, which means dependencies are
+		// downloaded.
+		BundlePath() string
 	}
 
 	appContext struct {
@@ -82,6 +109,8 @@ type (
 		homeDir    string
 		local      bool
 		showTfPlan bool
+		tfBinary   string
+		bundlePath string
 	}
 )
 
@@ -110,6 +139,10 @@ func (o AppContextOptions) AppContext() AppContext {
 		home = filepath.Join(userHomeDir, defaultHomeDir)
 	}
 
+	if o.TerraformBinary == "" {
+		o.TerraformBinary = os.Getenv(envTerraformBinary)
+	}
+
 	uiOpts := ui.UiOptions{
 		Debug:       o.Debug,
 		NoColor:     o.NoColor,
@@ -124,6 +157,8 @@ func (o AppContextOptions) AppContext() AppContext {
 		workingDir: wd,
 		local:      o.Local,
 		showTfPlan: o.ShowTerraformPlan,
+		tfBinary:   o.TerraformBinary,
+		bundlePath: o.Bundle,
 	}
 
 	return o.appContext
@@ -137,6 +172,14 @@ func (c *appContext) ShowTerraformPlan() bool {
 	return c.showTfPlan
 }
 
+func (c *appContext) TerraformBinary() string {
+	return c.tfBinary
+}
+
+func (c *appContext) BundlePath() string {
+	return c.bundlePath
+}
+
 func (c *appContext) WorkingDir() string {
 	return c.workingDir
 }
@@ -44,6 +44,8 @@ func MockAppContext(t *testing.T, opts ...AppContextOptions) AppContextMock {
 		homeDir:    tmpDir,
 		local:      o.Local,
 		showTfPlan: o.ShowTerraformPlan,
+		tfBinary:   o.TerraformBinary,
+		bundlePath: o.Bundle,
 	}
 
 	o.appContext = &ctx
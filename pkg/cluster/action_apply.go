@@ -64,6 +64,14 @@ func (c *Cluster) Apply(a string) error {
 		return err
 	}
 
+	if err := c.openBundle(); err != nil {
+		return err
+	}
+
+	if err := setProxyEnv(c.NewConfig); err != nil {
+		return err
+	}
+
 	if c.AppliedConfig == nil && (action == SCALE || action == UPGRADE) {
 		ui.Printf(ui.INFO, "Cannot %s cluster %q. It has not been created yet.\n\n", action, c.Name)
 
@@ -175,6 +183,14 @@ func (c *Cluster) plan(action ApplyAction) (event.Events, error) {
 		ui.Println(ui.INFO, "Above warnings indicate potentially dangerous actions.")
 	}
 
+	// Print allowed changes that describe an action (e.g. installation
+	// of a Helm chart).
+	for _, e := range events {
+		if e.Rule.IsOfType(event.Allow) && e.Rule.Message != "" {
+			ui.Printf(ui.INFO, "%s: %s\n", e.Change.Path, e.Rule.Message)
+		}
+	}
+
 	return events, ui.Ask()
 }
 
@@ -5,10 +5,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
 	"github.com/MusicDin/kubitect/pkg/env"
 	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToApplyAction(t *testing.T) {
@@ -84,6 +87,161 @@ func TestPlan(t *testing.T) {
 	assert.EqualError(t, err, "Configuration file contains errors.")
 }
 
+func TestPlan_ScaleNodeGroup(t *testing.T) {
+	c := MockCluster(t)
+
+	worker := &c.NewConfig.Cluster.Nodes.Worker
+	worker.Groups = []config.NodeGroup{{Name: "group", Count: 1, IPRange: "192.168.113.100-192.168.113.110"}}
+	require.NoError(t, defaults.Set(worker))
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	// Increase the group count.
+	worker.Groups[0].Count = 2
+	require.NoError(t, defaults.Set(worker))
+
+	events, err := c.plan(SCALE)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.Action_ScaleUp, events[0].Rule.ActionType)
+	assert.Equal(t, config.IPv4("192.168.113.101"), events[0].Change.ValueAfter.(config.WorkerInstance).IP)
+}
+
+func TestPlan_AttachAdditionalNetwork(t *testing.T) {
+	c := MockCluster(t)
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Cluster.AdditionalNetworks = []config.AdditionalNetwork{
+		{Name: "storage", Mode: config.NAT, CIDR: "10.10.0.0/24"},
+	}
+	c.NewConfig.Cluster.Nodes.Master.Instances[0].Networks = []config.NetworkAttachment{
+		{Network: "storage", IP: "10.10.0.10"},
+	}
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, event.Allow, events[0].Rule.Type)
+	assert.Equal(t, event.Warn, events[1].Rule.Type)
+}
+
+func TestPlan_CloudInit(t *testing.T) {
+	c := MockCluster(t)
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Cluster.NodeTemplate.CloudInit = config.CloudInit{
+		"packages": []interface{}{"nfs-common"},
+	}
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.Warn, events[0].Rule.Type)
+	assert.Contains(t, events[0].Rule.Message, "will recreate all nodes")
+}
+
+func TestPlan_HelmCharts(t *testing.T) {
+	c := MockCluster(t)
+
+	chart := func(name string) config.HelmChart {
+		return config.HelmChart{
+			Name:      name,
+			Repo:      "https://charts.example.com",
+			Chart:     name,
+			Namespace: "default",
+		}
+	}
+
+	c.NewConfig.Addons.Charts = []config.HelmChart{chart("a"), chart("b")}
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Addons.Charts = []config.HelmChart{chart("b"), chart("c"), chart("d")}
+	c.NewConfig.Addons.Charts[0].Version = "1.0.0"
+	c.NewConfig.Addons.Charts[0].Namespace = "b"
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	messages := make(map[string]string)
+	for _, e := range events {
+		messages[e.Change.Path] = e.Rule.Message
+	}
+
+	assert.Equal(t, map[string]string{
+		"addons.charts.a": "Helm chart will be uninstalled.",
+		"addons.charts.b": "Helm chart will be upgraded.",
+		"addons.charts.c": "Helm chart will be installed.",
+		"addons.charts.d": "Helm chart will be installed.",
+	}, messages)
+
+	assert.Contains(t, c.Ui().ReadStdout(t), "addons.charts.c: Helm chart will be installed.")
+}
+
+func TestPlan_DisableAddon(t *testing.T) {
+	c := MockCluster(t)
+	c.NewConfig.Addons.Rook.Enabled = true
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Addons.Rook.Enabled = false
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.Warn, events[0].Rule.Type)
+	assert.Contains(t, events[0].Rule.Message, "permanently destroy all data")
+}
+
+func TestPlan_EnableAddon(t *testing.T) {
+	c := MockCluster(t)
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Addons.Rook.Enabled = true
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.Allow, events[0].Rule.Type)
+}
+
+func TestPlan_RemoveRookPool(t *testing.T) {
+	c := MockCluster(t)
+	c.NewConfig.Addons.Rook.Enabled = true
+	c.NewConfig.Addons.Rook.BlockPools = []config.RookBlockPool{
+		{Name: "fast"},
+		{Name: "slow"},
+	}
+
+	assert.NoError(t, c.ApplyNewConfig())
+	assert.NoError(t, c.Sync())
+
+	c.NewConfig.Addons.Rook.BlockPools = c.NewConfig.Addons.Rook.BlockPools[:1]
+	c.NewConfig.Addons.Rook.BlockPools[0].Replicas = 2
+
+	events, err := c.plan(CREATE)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	for _, e := range events {
+		if e.Change.Path == "addons.rook.blockPools.slow" {
+			assert.Equal(t, event.Warn, e.Rule.Type)
+		} else {
+			assert.Equal(t, event.Allow, e.Rule.Type)
+		}
+	}
+}
+
 func TestApply_Create(t *testing.T) {
 	c := MockCluster(t)
 
@@ -158,3 +316,22 @@ func TestApply_Scale(t *testing.T) {
 
 	assert.NoError(t, c.Apply(SCALE.String()))
 }
+
+func TestSetProxyEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+
+	cfg := config.MockConfig(t)
+	require.NoError(t, setProxyEnv(&cfg))
+	assert.Empty(t, os.Getenv("HTTPS_PROXY"))
+
+	cfg.Proxy.HTTPS = "http://proxy.example.com:3128"
+	require.NoError(t, setProxyEnv(&cfg))
+	assert.Equal(t, "http://proxy.example.com:3128", os.Getenv("HTTPS_PROXY"))
+	assert.Equal(t, "http://proxy.example.com:3128", os.Getenv("https_proxy"))
+	assert.Equal(t, "localhost,127.0.0.1,192.168.113.0/24,10.233.64.0/18,10.233.0.0/18", os.Getenv("no_proxy"))
+
+	assert.NoError(t, setProxyEnv(nil))
+}
@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MusicDin/kubitect/embed"
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/ui"
+)
+
+// CreateBundle downloads all external dependencies required to apply the
+// new configuration and packs them into an archive on the given path.
+// The archive can be later used to apply the configuration without
+// network access.
+func (c *Cluster) CreateBundle(archivePath string) error {
+	if err := setProxyEnv(c.NewConfig); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kubitect-bundle-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	// Project files are mirrored into a temporary cluster directory,
+	// since some dependencies (e.g. pip3 requirements) are read from
+	// them. This way an existing cluster is never modified.
+	clusterPath := c.Path
+	defer func() { c.Path = clusterPath }()
+
+	c.Path = filepath.Join(tmpDir, "cluster")
+
+	for _, rf := range env.ProjectRequiredFiles {
+		if err := embed.MirrorResource(rf, c.Path); err != nil {
+			return err
+		}
+	}
+
+	ui.Printf(ui.INFO, "Creating bundle for cluster %q...\n", c.Name)
+
+	w := bundle.NewWriter(filepath.Join(tmpDir, "bundle"))
+
+	components := []any{
+		c.Provisioner(),
+		c.Manager(),
+	}
+
+	for _, comp := range components {
+		b, ok := comp.(bundle.Bundler)
+		if !ok {
+			continue
+		}
+
+		if err := b.Bundle(w); err != nil {
+			return fmt.Errorf("create bundle: %v", err)
+		}
+	}
+
+	if err := w.Write(archivePath); err != nil {
+		return err
+	}
+
+	ui.Printf(ui.INFO, "Bundle has been successfully created (%s).\n", archivePath)
+	return nil
+}
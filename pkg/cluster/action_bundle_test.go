@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"path"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/app"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBundle(t *testing.T) {
+	c := MockCluster(t)
+	clusterPath := c.Path
+	archive := path.Join(t.TempDir(), "bundle.tar.gz")
+
+	require.NoError(t, c.CreateBundle(archive))
+	assert.FileExists(t, archive)
+	assert.Equal(t, clusterPath, c.Path)
+	assert.NoDirExists(t, c.Path)
+}
+
+func TestOpenBundle(t *testing.T) {
+	c := MockCluster(t)
+	archive := path.Join(t.TempDir(), "bundle.tar.gz")
+	require.NoError(t, c.CreateBundle(archive))
+
+	c.ClusterMeta.AppContext = app.MockAppContext(t, app.AppContextOptions{Bundle: archive})
+	require.NoError(t, c.openBundle())
+	assert.NotNil(t, c.bundle)
+	assert.DirExists(t, c.BundlesDir())
+}
+
+func TestOpenBundle_NotSet(t *testing.T) {
+	c := MockCluster(t)
+	require.NoError(t, c.openBundle())
+	assert.Nil(t, c.bundle)
+}
+
+func TestOpenBundle_Missing(t *testing.T) {
+	c := MockCluster(t)
+	c.ClusterMeta.AppContext = app.MockAppContext(t, app.AppContextOptions{Bundle: "missing.tar.gz"})
+	assert.ErrorContains(t, c.openBundle(), "open bundle: open missing.tar.gz: no such file or directory")
+}
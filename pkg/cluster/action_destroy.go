@@ -4,23 +4,42 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/ui"
 	"github.com/MusicDin/kubitect/pkg/utils/file"
 )
 
 // Destroy destroys the cluster and removes cluster's directory.
-// Terraform resources are wiped if terraform state file is found.
+// Terraform and libvirt resources are wiped if their state file is found,
+// while Kubernetes is only reset on machines of static clusters.
 func (c *ClusterMeta) Destroy() error {
 	if !file.Exists(c.Path) {
 		return fmt.Errorf("cluster %q does not exist", c.Name)
 	}
 
+	if err := c.openBundle(); err != nil {
+		return err
+	}
+
+	// Proxy is set only if the applied configuration can be read, since
+	// the cluster is destroyed regardless of the configuration.
+	if cfg, err := readConfigIfExists(c.AppliedConfigPath(), config.Config{}); err == nil {
+		if err := setProxyEnv(cfg); err != nil {
+			return err
+		}
+	}
+
 	ui.Printf(ui.INFO, "Cluster %q will be destroyed.\n", c.Name)
 	if err := ui.Ask(); err != nil {
 		return err
 	}
 
-	if c.ContainsTfStateConfig() {
+	if c.IsStatic() {
+		ui.Println(ui.INFO, "Resetting Kubernetes on cluster machines...")
+		if err := c.Provisioner().Destroy(); err != nil {
+			return err
+		}
+	} else if c.ContainsTfStateConfig() || c.ContainsLibvirtState() {
 		ui.Println(ui.INFO, "Removing cluster resources...")
 		if err := c.Provisioner().Destroy(); err != nil {
 			return err
@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+// MigrateState moves the Terraform state of an existing cluster into the
+// state backend configured in the new configuration file. Other changes
+// of the configuration are ignored and have to be applied afterwards.
+func (c *Cluster) MigrateState() error {
+	if c.AppliedConfig == nil {
+		return fmt.Errorf("cluster %q has not been created yet", c.Name)
+	}
+
+	prov := defaults.Default(c.AppliedConfig.Provisioner, config.ProvisionerTerraform)
+	if prov != config.ProvisionerTerraform {
+		return fmt.Errorf("state migration is only supported for clusters provisioned by %s (actual: %s)", config.ProvisionerTerraform, prov)
+	}
+
+	from := c.AppliedConfig.Cluster.State.Backend
+	to := c.NewConfig.Cluster.State.Backend
+
+	if reflect.DeepEqual(from, to) {
+		ui.Println(ui.INFO, "State backend has not changed.")
+		return nil
+	}
+
+	ui.Printf(ui.INFO, "State of cluster %q will be migrated from %q to %q backend.\n", c.Name, from, to)
+	if err := ui.Ask(); err != nil {
+		return err
+	}
+
+	// Only the state backend of the applied configuration is changed.
+	cfg := *c.AppliedConfig
+	cfg.Cluster.State = c.NewConfig.Cluster.State
+	c.NewConfig = &cfg
+
+	if err := c.prepare(); err != nil {
+		return err
+	}
+
+	if err := c.Provisioner().Init(nil); err != nil {
+		return err
+	}
+
+	m, ok := c.Provisioner().(provisioner.StateMigrator)
+	if !ok {
+		return fmt.Errorf("provisioner does not support state migration")
+	}
+
+	if err := m.MigrateState(); err != nil {
+		return err
+	}
+
+	if err := file.WriteYaml(c.NewConfig, c.AppliedConfigPath(), 0644); err != nil {
+		return err
+	}
+
+	ui.Printf(ui.INFO, "State of cluster %q has been successfully migrated.\n", c.Name)
+	return nil
+}
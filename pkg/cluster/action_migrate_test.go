@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"path"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateState(t *testing.T) {
+	c := MockCluster(t)
+	require.NoError(t, c.ApplyNewConfig())
+	require.NoError(t, c.Sync())
+	assert.False(t, c.ContainsTfStateConfig())
+
+	c.NewConfig.Cluster.State.Backend = config.StateBackend{
+		Type: config.StateBackendS3,
+		S3:   config.S3StateBackend{Bucket: "states"},
+	}
+
+	// Other changes are not applied during migration.
+	c.NewConfig.Kubernetes.Version = "v0.0.0"
+
+	require.NoError(t, c.MigrateState())
+	require.NoError(t, c.Sync())
+
+	assert.Equal(t, config.StateBackendS3, c.AppliedConfig.Cluster.State.Backend.Type)
+	assert.NotEqual(t, config.KubernetesVersion("v0.0.0"), c.AppliedConfig.Kubernetes.Version)
+	assert.True(t, c.ContainsTfStateConfig())
+}
+
+func TestMigrateState_Unchanged(t *testing.T) {
+	c := MockCluster(t)
+	require.NoError(t, c.ApplyNewConfig())
+	require.NoError(t, c.Sync())
+
+	// Project is not prepared if backend has not changed.
+	assert.NoError(t, c.MigrateState())
+	assert.NoDirExists(t, path.Join(c.Path, "terraform"))
+}
+
+func TestMigrateState_ClusterNotCreated(t *testing.T) {
+	c := MockCluster(t)
+	assert.EqualError(t, c.MigrateState(), `cluster "cluster-mock" has not been created yet`)
+}
+
+func TestMigrateState_StaticCluster(t *testing.T) {
+	c := MockCluster(t)
+	c.NewConfig.Provisioner = config.ProvisionerStatic
+	require.NoError(t, c.ApplyNewConfig())
+	require.NoError(t, c.Sync())
+
+	assert.EqualError(t, c.MigrateState(), "state migration is only supported for clusters provisioned by terraform (actual: static)")
+}
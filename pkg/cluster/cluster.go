@@ -11,6 +11,8 @@ import (
 	"github.com/MusicDin/kubitect/pkg/cluster/interfaces"
 	"github.com/MusicDin/kubitect/pkg/cluster/managers"
 	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/libvirt"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/static"
 	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/terraform"
 	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/models/infra"
@@ -28,17 +30,28 @@ type Cluster struct {
 	NewConfig     *config.Config
 	AppliedConfig *config.Config
 	InfraConfig   *infra.Config
+
+	// IP addresses allocated by the built-in IPAM.
+	ipAllocs IPAllocations
 }
 
 // NewCluster returns new Cluster instance with populated general fields.
 // Cluster name and path are extracted from the provided configuration file.
 // Previously applied configuration is also read, if cluster already exists.
 func NewCluster(ctx app.AppContext, configPath string) (*Cluster, error) {
+	osPresets, err := config.LoadOSPresets(config.OSPresetsDir(ctx.HomeDir()))
+	if err != nil {
+		return nil, err
+	}
+
 	newCfg, err := readConfig(configPath, config.Config{})
 	if err != nil {
 		return nil, err
 	}
 
+	// OS presets must be set before configuration defaults are set.
+	newCfg.SetOSPresets(osPresets)
+
 	c := &Cluster{
 		ClusterMeta: ClusterMeta{
 			AppContext: ctx,
@@ -70,6 +83,16 @@ func NewCluster(ctx app.AppContext, configPath string) (*Cluster, error) {
 	c.Name = c.NewConfig.Cluster.Name
 	c.Path = filepath.Join(c.ClustersDir(), c.Name)
 
+	if err := c.allocateIPs(); err != nil {
+		return nil, err
+	}
+
+	// Validate allocated IP addresses.
+	if err := validateConfig(c.NewConfig); err != nil {
+		ui.PrintBlockE(err...)
+		return nil, fmt.Errorf("invalid configuration file")
+	}
+
 	return c, c.Sync()
 }
 
@@ -125,11 +148,49 @@ func (c *Cluster) Manager() interfaces.Manager {
 			c.ConfigDir(),
 			c.CacheDir(),
 			c.ShareDir(),
+			c.bundle,
+			c.NewConfig,
+			c.InfraConfig,
+		)
+	case config.ManagerKubeadm:
+		c.exec = managers.NewKubeadmManager(
+			c.Name,
+			c.Path,
+			c.PrivateSshKeyPath(),
+			c.ConfigDir(),
+			c.CacheDir(),
+			c.ShareDir(),
+			c.bundle,
+			c.NewConfig,
+			c.InfraConfig,
+		)
+	case config.ManagerRke2:
+		c.exec = managers.NewRke2Manager(
+			c.Name,
+			c.Path,
+			c.PrivateSshKeyPath(),
+			c.ConfigDir(),
+			c.CacheDir(),
+			c.ShareDir(),
+			c.bundle,
 			c.NewConfig,
 			c.InfraConfig,
 		)
 	case config.ManagerKubespray:
 		c.exec = managers.NewKubesprayManager(
+			c.Name,
+			c.Path,
+			c.PrivateSshKeyPath(),
+			c.ConfigDir(),
+			c.CacheDir(),
+			c.ShareDir(),
+			c.bundle,
+			c.NewConfig,
+			c.InfraConfig,
+		)
+	default:
+		c.exec = managers.NewPluginManager(
+			string(c.NewConfig.Kubernetes.Manager),
 			c.Name,
 			c.Path,
 			c.PrivateSshKeyPath(),
@@ -152,12 +213,33 @@ func (c *Cluster) Provisioner() provisioner.Provisioner {
 		return c.prov
 	}
 
-	c.prov = terraform.NewTerraformProvisioner(
-		c.Path,
-		c.ShareDir(),
-		c.ShowTerraformPlan(),
-		c.NewConfig,
-	)
+	switch c.NewConfig.Provisioner {
+	case config.ProvisionerStatic:
+		c.prov = static.NewStaticProvisioner(
+			c.InfrastructureConfigPath(),
+			c.PrivateSshKeyPath(),
+			c.NewConfig,
+		)
+	case config.ProvisionerLibvirt:
+		c.prov = libvirt.NewLibvirtProvisioner(
+			c.LibvirtStatePath(),
+			c.InfrastructureConfigPath(),
+			c.ShareDir(),
+			c.PrivateSshKeyPath(),
+			c.ShowTerraformPlan(),
+			c.bundle,
+			c.NewConfig,
+		)
+	default:
+		c.prov = terraform.NewTerraformProvisioner(
+			c.Path,
+			c.ShareDir(),
+			c.terraformBinary(c.NewConfig),
+			c.ShowTerraformPlan(),
+			c.bundle,
+			c.NewConfig,
+		)
+	}
 
 	return c.prov
 }
@@ -169,6 +251,10 @@ func (c *Cluster) ApplyNewConfig() error {
 		return err
 	}
 
+	if err := c.storeIPAllocations(); err != nil {
+		return fmt.Errorf("failed to store IP allocations: %v", err)
+	}
+
 	return file.WriteYaml(c.NewConfig, c.AppliedConfigPath(), 0644)
 }
 
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/MusicDin/kubitect/pkg/app"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/libvirt"
+	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/utils/template"
 
 	"github.com/stretchr/testify/assert"
@@ -120,3 +122,28 @@ func TestStoreNewConfigFile(t *testing.T) {
 	assert.NoError(t, c.StoreNewConfig())
 	assert.FileExists(t, archiveFile)
 }
+
+func TestTerraformBinary(t *testing.T) {
+	ctx := app.MockAppContext(t, app.AppContextOptions{TerraformBinary: "opentofu"})
+
+	c, err := NewCluster(ctx, ConfigMock{}.Write(t))
+	require.NoError(t, err)
+	assert.Equal(t, config.TerraformBinaryOpenTofu, c.terraformBinary(c.NewConfig))
+
+	// Binary in the cluster configuration overrides the global one.
+	c.NewConfig.Terraform.Binary = config.TerraformBinaryTerraform
+	assert.Equal(t, config.TerraformBinaryTerraform, c.terraformBinary(c.NewConfig))
+}
+
+func TestProvisioner_Libvirt(t *testing.T) {
+	c := MockCluster(t)
+	c.prov = nil
+
+	// Libvirt state is sufficient to select the libvirt provisioner.
+	require.NoError(t, os.MkdirAll(path.Dir(c.LibvirtStatePath()), os.ModePerm))
+	require.NoError(t, os.WriteFile(c.LibvirtStatePath(), []byte("hosts: []\n"), os.ModePerm))
+
+	assert.True(t, c.ContainsLibvirtState())
+	assert.IsType(t, libvirt.NewLibvirtProvisioner("", "", "", "", false, nil, nil), c.ClusterMeta.Provisioner())
+	assert.NoError(t, c.ClusterMeta.Provisioner().Destroy())
+}
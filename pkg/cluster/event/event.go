@@ -2,6 +2,7 @@ package event
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/MusicDin/kubitect/pkg/utils/cmp"
 )
@@ -59,7 +60,8 @@ func (events Events) FilterByAction(t ActionType) Events {
 // GenerateEvents evaluates the changes from the comparison tree against the
 // provided rules and returns a list of corresponding events. Each event
 // encapsulates a matched change and its associated rule. A single change can
-// match at most one rule and thus produce at most one event.
+// match at most one rule and thus produce at most one event. Changes matching
+// a rule of type Ignore do not produce any event.
 // Note that provided rules are validated prior the event generation.
 func GenerateEvents(node *cmp.DiffNode, rules []Rule) ([]Event, error) {
 	for _, r := range rules {
@@ -79,7 +81,7 @@ func generateEvents(node *cmp.DiffNode, rules []Rule, events []Event) []Event {
 
 	if node.IsLeaf() && node.HasChanged() {
 		rule := matchRule(node, rules)
-		if rule != nil {
+		if rule != nil && !rule.IsOfType(Ignore) {
 			events = createAndAddEvent(node, rule, events)
 		}
 	}
@@ -97,6 +99,8 @@ func generateEvents(node *cmp.DiffNode, rules []Rule, events []Event) []Event {
 // event with the same rule, change, and rule path already exists in the list.
 // If it does, it only appends the node's path to the MatchedChangePaths of an
 // existing event. Otherwise, it appends the new event to the list.
+// Changes of different anchor nodes (e.g. different list elements) therefore
+// always produce separate events.
 func createAndAddEvent(node *cmp.DiffNode, rule *Rule, events []Event) []Event {
 	targetNode := node
 	rulePath := rule.MatchPath
@@ -125,8 +129,9 @@ func createAndAddEvent(node *cmp.DiffNode, rule *Rule, events []Event) []Event {
 			isSameRulePath := e.Rule.MatchPath.Path() == event.Rule.MatchPath.Path()
 			isSameRuleType := e.Rule.Type == event.Rule.Type
 			isSameChangeType := e.Change.Type == event.Change.Type
+			isSameChangePath := e.Change.Path == event.Change.Path
 
-			if isSameRuleType && isSameChangeType && isSameRulePath {
+			if isSameRuleType && isSameChangeType && isSameChangePath && isSameRulePath {
 				events[i].MatchedChangePaths = append(events[i].MatchedChangePaths, node.Path())
 				return events
 			}
@@ -139,7 +144,8 @@ func createAndAddEvent(node *cmp.DiffNode, rule *Rule, events []Event) []Event {
 }
 
 // matchRule determines the most appropriate rule for a given change. A change
-// matches a rule if both the path and change type align. Each change can
+// matches a rule if both the path and change type align, and the new value of
+// the change matches the rule's expected value (if set). Each change can
 // correspond to either no rule or one rule. If multiple rules match, they are
 // prioritized by path length, wildcard count, and rule priority. If no rule
 // matches, it returns nil.
@@ -150,6 +156,10 @@ func matchRule(node *cmp.DiffNode, rules []Rule) *Rule {
 			continue
 		}
 
+		if rule.MatchValueAfter != nil && !reflect.DeepEqual(rule.MatchValueAfter, node.ToChange().ValueAfter) {
+			continue
+		}
+
 		if rule.MatchPath.Matches(node.Path()) {
 			if bestMatch == nil || isBetterMatch(rule, *bestMatch) {
 				bestMatch = &rules[i]
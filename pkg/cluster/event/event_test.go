@@ -132,6 +132,21 @@ func TestEvent_RulePrecedenceByPriority_Custom(t *testing.T) {
 	assert.Equal(t, r2, events[0].Rule)
 }
 
+// Test expects a change matching an ignore rule not to trigger an event,
+// even if a less specific rule also matches the change.
+func TestEvent_IgnoreRule(t *testing.T) {
+	type Map map[string]any
+
+	v1 := map[string]Map{"a": {"b": "Yes"}}
+	v2 := map[string]Map{"a": {"b": "No"}}
+
+	r1 := Rule{Type: Error, MatchPath: NewRulePath("a")}
+	r2 := Rule{Type: Ignore, MatchPath: NewRulePath("a.b")}
+
+	events := mustGenEvents(t, v1, v2, []Rule{r1, r2})
+	require.Len(t, events, 0)
+}
+
 func TestEvent_RulePathWildcard(t *testing.T) {
 	v1 := map[string]map[string]string{"A": {"a": "Yes"}}
 	v2 := map[string]map[string]string{"A": {"a": "No"}}
@@ -152,6 +167,44 @@ func TestEvent_RulePathWildcard(t *testing.T) {
 	assert.Equal(t, "A.a", events[0].MatchedChangePaths[0])
 }
 
+// Test expects a rule with an expected value to match only the changes
+// with the same new value.
+func TestEvent_RuleMatchValueAfter(t *testing.T) {
+	r1 := Rule{Type: Allow, MatchPath: NewRulePath("a")}
+	r2 := Rule{Type: Warn, MatchPath: NewRulePath("a"), MatchValueAfter: false}
+
+	events := mustGenEvents(t, map[string]bool{"a": true}, map[string]bool{"a": false}, []Rule{r1, r2})
+	require.Len(t, events, 1)
+	assert.Equal(t, r2, events[0].Rule)
+
+	events = mustGenEvents(t, map[string]bool{"a": false}, map[string]bool{"a": true}, []Rule{r1, r2})
+	require.Len(t, events, 1)
+	assert.Equal(t, r1, events[0].Rule)
+}
+
+// Test expects changes of the same anchor node to be grouped into a single
+// event, while changes of different anchor nodes produce separate events.
+func TestEvent_AnchorGrouping(t *testing.T) {
+	v1 := map[string]map[string]string{"A": {"a": "Yes", "b": "Yes"}, "B": {"a": "Yes"}}
+	v2 := map[string]map[string]string{"A": {"a": "No", "b": "No"}, "B": {"a": "No"}}
+
+	r := Rule{MatchPath: NewRulePath("@")}
+
+	events := mustGenEvents(t, v1, v2, []Rule{r})
+	require.Len(t, events, 2)
+
+	for _, e := range events {
+		switch e.Change.Path {
+		case "A":
+			assert.ElementsMatch(t, []string{"A.a", "A.b"}, e.MatchedChangePaths)
+		case "B":
+			assert.Equal(t, []string{"B.a"}, e.MatchedChangePaths)
+		default:
+			t.Errorf("unexpected event change path %q", e.Change.Path)
+		}
+	}
+}
+
 func TestEvent_RulePathOption(t *testing.T) {
 	v1 := map[string]map[string]string{"A": {"a": "Yes"}}
 	v2 := map[string]map[string]string{"A": {"b": "No"}, "B": {"c": ""}}
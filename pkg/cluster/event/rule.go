@@ -116,6 +116,10 @@ type Rule struct {
 	MatchPath       RulePath
 	MatchChangeType cmp.ChangeType
 
+	// MatchValueAfter, if set, restricts the rule to the changes whose
+	// new value equals the given value (e.g. a flag being set to false).
+	MatchValueAfter any
+
 	// Optional fields.
 	ActionType ActionType
 	Message    string
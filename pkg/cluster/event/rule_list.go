@@ -4,6 +4,14 @@ import (
 	"github.com/MusicDin/kubitect/pkg/utils/cmp"
 )
 
+// Node groups are expanded into node instances, therefore changing the
+// group (e.g. its count) is reflected in the instance changes.
+var nodeGroupsRule = Rule{
+	Type:            Ignore,
+	MatchChangeType: cmp.Any,
+	MatchPath:       NewRulePath("cluster.nodes.{master, worker}.groups"),
+}
+
 var UpgradeRules = []Rule{
 	{
 		Type:            Allow,
@@ -56,6 +64,13 @@ var ScaleRules = []Rule{
 		MatchPath:       NewRulePath("cluster.nodes.master.instances.@"),
 		Message:         "Currently, control plane cannot be scaled.",
 	},
+	nodeGroupsRule,
+	// Reserving IP ranges does not affect existing nodes.
+	{
+		Type:            Allow,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.network.ipam.reserved"),
+	},
 	// Allow addition and deletion of hosts.
 	{
 		Type:            Allow,
@@ -77,6 +92,21 @@ var ScaleRules = []Rule{
 }
 
 var ModifyRules = []Rule{
+	{
+		// Allow switching between Terraform and OpenTofu, since both
+		// binaries use the same state and project.
+		Type:            Allow,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("terraform"),
+	},
+	{
+		// Prevent state backend changes, since the existing state has to
+		// be moved into the new backend.
+		Type:            Error,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.state"),
+		Message:         "Changing the state backend is not allowed during apply. To move the existing state into the new backend run 'kubitect state migrate' command.",
+	},
 	{
 		// Warn about main resource pool path change (will replace the VM).
 		Type:            Warn,
@@ -104,6 +134,13 @@ var ModifyRules = []Rule{
 		MatchChangeType: cmp.Any,
 		MatchPath:       NewRulePath("hosts.*.dataResourcePools.*"),
 	},
+	{
+		// Allow changes of IPAM reserved ranges, since they only affect
+		// addresses allocated in the future.
+		Type:            Allow,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.network.ipam.reserved"),
+	},
 	{
 		// Prevent cluster network changes.
 		Type:            Error,
@@ -111,6 +148,52 @@ var ModifyRules = []Rule{
 		MatchPath:       NewRulePath("cluster.network"),
 		Message:         "Once the cluster is created, further changes to the network properties are not allowed. Such action may render the cluster unusable.",
 	},
+	{
+		// Allow addition and removal of additional networks.
+		Type:            Allow,
+		MatchChangeType: cmp.Create,
+		MatchPath:       NewRulePath("cluster.additionalNetworks.@"),
+	},
+	{
+		Type:            Allow,
+		MatchChangeType: cmp.Delete,
+		MatchPath:       NewRulePath("cluster.additionalNetworks.@"),
+	},
+	{
+		// Prevent additional network changes.
+		Type:            Error,
+		MatchChangeType: cmp.Modify,
+		MatchPath:       NewRulePath("cluster.additionalNetworks.*"),
+		Message:         "Changing properties of an existing additional network is not allowed. Remove the network and add it again with a different name instead.",
+	},
+	{
+		// Warn about cloud-init changes (will recreate the VMs).
+		Type:            Warn,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.nodeTemplate.cloudInit"),
+		Message:         "Changing cloud-init configuration of the node template will recreate all nodes.",
+	},
+	{
+		// Warn about proxy changes (will recreate the VMs, since the
+		// proxy is configured through cloud-init).
+		Type:            Warn,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("proxy"),
+		Message:         "Changing proxy configuration will recreate all provisioned nodes, since the proxy is configured through cloud-init.",
+	},
+	{
+		// Allow OS image checksum changes, since the base volume of an
+		// existing cluster is never recreated.
+		Type:            Allow,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.nodeTemplate.os.checksum"),
+	},
+	{
+		// Allow OS image checksum file changes for the same reason.
+		Type:            Allow,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.nodeTemplate.os.checksumFile"),
+	},
 	{
 		// Prevent nodeTemplate changes.
 		Type:            Error,
@@ -118,6 +201,7 @@ var ModifyRules = []Rule{
 		MatchPath:       NewRulePath("cluster.nodeTemplate"),
 		Message:         "Once the cluster is created, further changes to the nodeTemplate properties are not allowed. Such action may render the cluster unusable.",
 	},
+	nodeGroupsRule,
 	{
 		// Prevent removing nodes.
 		Type:            Error,
@@ -132,6 +216,13 @@ var ModifyRules = []Rule{
 		MatchPath:       NewRulePath("cluster.nodes.{master, worker, loadBalancer}.instances.@"),
 		Message:         "To add new nodes run apply command with '--action scale' flag.",
 	},
+	{
+		// Warn about node type specific cloud-init changes (will recreate the VMs).
+		Type:            Warn,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.nodes.{master, worker, loadBalancer}.default.cloudInit"),
+		Message:         "Changing cloud-init configuration of the node type will recreate all nodes of that type.",
+	},
 	{
 		// Prevent default cpu, ram and main disk size changes.
 		Type:            Error,
@@ -150,7 +241,7 @@ var ModifyRules = []Rule{
 		// Prevent IP and MAC changes.
 		Type:            Error,
 		MatchChangeType: cmp.Modify,
-		MatchPath:       NewRulePath("cluster.nodes.{master, worker, loadBalancer}.instances.@.{ip, mac}"),
+		MatchPath:       NewRulePath("cluster.nodes.{master, worker, loadBalancer}.instances.@.{ip, ip6, mac}"),
 		Message:         "Changing IP or MAC address of the node is not allowed. Such action may render the cluster unusable.",
 	},
 	{
@@ -167,6 +258,13 @@ var ModifyRules = []Rule{
 		MatchPath:       NewRulePath("cluster.nodes.{master, worker}.instances.*.dataDisks.*"),
 		Message:         "One or more data disks will be removed.",
 	},
+	{
+		// Warn about network attachment changes (will recreate the VM).
+		Type:            Warn,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("cluster.nodes.{master, worker, loadBalancer}.instances.*.networks.@"),
+		Message:         "Changing network attachments of the node will recreate the node.",
+	},
 	{
 		// Allow changes to LB forward ports.
 		Type:            Allow,
@@ -177,7 +275,7 @@ var ModifyRules = []Rule{
 		// Prevent VIP changes.
 		Type:            Error,
 		MatchChangeType: cmp.Any,
-		MatchPath:       NewRulePath("cluster.nodes.loadBalancer.vip"),
+		MatchPath:       NewRulePath("cluster.nodes.loadBalancer.{vip, vip6}"),
 		Message:         "Once the cluster is created, changing virtual IP (VIP) is not allowed. Such action may render the cluster unusable.",
 	},
 	{
@@ -193,6 +291,68 @@ var ModifyRules = []Rule{
 		MatchPath:       NewRulePath("kubernetes.version"),
 		Message:         "Changing Kubernetes is allowed only when upgrading the cluster.\nTo upgrade the cluster run apply command with '--action upgrade' flag.",
 	},
+	{
+		// Prevent pod and service subnet changes.
+		Type:            Error,
+		MatchChangeType: cmp.Any,
+		MatchPath:       NewRulePath("kubernetes.network"),
+		Message:         "Once the cluster is created, changing pod or service subnets is not allowed. Such action may render the cluster unusable.",
+	},
+	{
+		// Warn about disabling Rook (will uninstall Rook and wipe its data).
+		Type:            Warn,
+		MatchChangeType: cmp.Modify,
+		MatchPath:       NewRulePath("addons.rook.enabled"),
+		MatchValueAfter: false,
+		Message:         "Disabling Rook addon will uninstall Rook, including its CRDs, and permanently destroy all data stored in the Ceph cluster. Data disks used by Rook will be wiped.",
+	},
+	{
+		// Warn about removing Rook pools (data loss).
+		Type:            Warn,
+		MatchChangeType: cmp.Delete,
+		MatchPath:       NewRulePath("addons.rook.blockPools.@"),
+		Message:         "Removing Rook block pool will permanently destroy all data stored in it.",
+	},
+	{
+		Type:            Warn,
+		MatchChangeType: cmp.Delete,
+		MatchPath:       NewRulePath("addons.rook.fileSystems.@"),
+		Message:         "Removing Rook filesystem will permanently destroy all data stored in it.",
+	},
+	{
+		Type:            Warn,
+		MatchChangeType: cmp.Delete,
+		MatchPath:       NewRulePath("addons.rook.objectStores.@"),
+		Message:         "Removing Rook object store will permanently destroy all data stored in it.",
+	},
+	{
+		// Warn about disabling MetalLB (services lose their IPs).
+		Type:            Warn,
+		MatchChangeType: cmp.Modify,
+		MatchPath:       NewRulePath("addons.metallb.enabled"),
+		MatchValueAfter: false,
+		Message:         "Disabling MetalLB addon will uninstall MetalLB and release IP addresses of all services of type LoadBalancer.",
+	},
+	{
+		// Allow Helm chart changes. Each chart produces its own event,
+		// since the anchor is set on the chart.
+		Type:            Allow,
+		MatchChangeType: cmp.Create,
+		MatchPath:       NewRulePath("addons.charts.@"),
+		Message:         "Helm chart will be installed.",
+	},
+	{
+		Type:            Allow,
+		MatchChangeType: cmp.Modify,
+		MatchPath:       NewRulePath("addons.charts.@"),
+		Message:         "Helm chart will be upgraded.",
+	},
+	{
+		Type:            Allow,
+		MatchChangeType: cmp.Delete,
+		MatchPath:       NewRulePath("addons.charts.@"),
+		Message:         "Helm chart will be uninstalled.",
+	},
 	{
 		// Allow addons changes.
 		Type:            Allow,
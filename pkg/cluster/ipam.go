@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+	"github.com/MusicDin/kubitect/pkg/utils/ipam"
+)
+
+// IPAllocations maps node type names and instance IDs to IP addresses
+// that were allocated by the built-in IPAM.
+type IPAllocations map[string]map[string]config.IPv4
+
+func (a IPAllocations) get(typeName, id string) config.IPv4 {
+	return a[typeName][id]
+}
+
+func (a IPAllocations) set(typeName, id string, ip config.IPv4) {
+	if a[typeName] == nil {
+		a[typeName] = make(map[string]config.IPv4)
+	}
+
+	a[typeName][id] = ip
+}
+
+// ipamNode references the IP address of a single node instance within
+// the configuration.
+type ipamNode struct {
+	typeName string
+	id       string
+	ip       *config.IPv4
+}
+
+// ipamNodes returns references to the IP addresses of all node instances.
+func ipamNodes(cfg *config.Config) []ipamNode {
+	var nodes []ipamNode
+
+	n := &cfg.Cluster.Nodes
+
+	for i := range n.Master.Instances {
+		in := &n.Master.Instances[i]
+		nodes = append(nodes, ipamNode{in.GetTypeName(), in.Id, &in.IP})
+	}
+
+	for i := range n.Worker.Instances {
+		in := &n.Worker.Instances[i]
+		nodes = append(nodes, ipamNode{in.GetTypeName(), in.Id, &in.IP})
+	}
+
+	for i := range n.LoadBalancer.Instances {
+		in := &n.LoadBalancer.Instances[i]
+		nodes = append(nodes, ipamNode{in.GetTypeName(), in.Id, &in.IP})
+	}
+
+	return nodes
+}
+
+// readIPAllocations reads IP allocations stored in the cluster directory.
+// If allocations file does not exist, empty allocations are returned.
+func (c *Cluster) readIPAllocations() (IPAllocations, error) {
+	if !file.Exists(c.IPAllocationsPath()) {
+		return IPAllocations{}, nil
+	}
+
+	allocs, err := file.ReadYaml(c.IPAllocationsPath(), IPAllocations{})
+	if err != nil {
+		return nil, err
+	}
+
+	return *allocs, nil
+}
+
+// allocateIPs assigns free IP addresses from the cluster network CIDR to
+// node instances without an explicitly configured IP address. Previously
+// allocated addresses are reused, so nodes keep their IP addresses across
+// applies. Allocation is skipped if IPAM is not enabled.
+func (c *Cluster) allocateIPs() error {
+	net := c.NewConfig.Cluster.Network
+
+	if !net.IPAM.Enabled {
+		return nil
+	}
+
+	allocs, err := c.readIPAllocations()
+	if err != nil {
+		return fmt.Errorf("failed to read IP allocations: %v", err)
+	}
+
+	alloc, err := ipam.NewAllocator(string(net.CIDR))
+	if err != nil {
+		return err
+	}
+
+	if net.Gateway != nil {
+		alloc.Reserve(string(*net.Gateway))
+	} else {
+		// Libvirt uses the first address as a gateway by default.
+		prefix, _ := netip.ParsePrefix(string(net.CIDR))
+		alloc.Reserve(prefix.Masked().Addr().Next().String())
+	}
+
+	alloc.Reserve(string(c.NewConfig.Cluster.Nodes.LoadBalancer.VIP))
+	alloc.Reserve(c.NewConfig.Cluster.Nodes.IPs()...)
+
+	for _, r := range net.IPAM.Reserved {
+		if first, last, ok := r.Bounds(); ok {
+			alloc.ReserveRange(first, last)
+		}
+	}
+
+	// Addresses of MetalLB pools are assigned to the services.
+	for _, p := range c.NewConfig.Addons.MetalLB.Pools {
+		for _, a := range p.Addresses {
+			if first, last, ok := a.Bounds(); ok {
+				alloc.ReserveRange(first, last)
+			}
+		}
+	}
+
+	var allocated, pending []ipamNode
+
+	// Reuse previous allocations where possible.
+	for _, n := range ipamNodes(c.NewConfig) {
+		if *n.ip != "" {
+			continue
+		}
+
+		ip := allocs.get(n.typeName, n.id)
+		if ip != "" && alloc.IsFree(string(ip)) {
+			alloc.Reserve(string(ip))
+			*n.ip = ip
+			allocated = append(allocated, n)
+			continue
+		}
+
+		pending = append(pending, n)
+	}
+
+	for _, n := range pending {
+		ip, err := alloc.Allocate()
+		if err != nil {
+			return fmt.Errorf("failed to allocate IP address for %s node %q: %v", n.typeName, n.id, err)
+		}
+
+		*n.ip = config.IPv4(ip)
+		allocated = append(allocated, n)
+	}
+
+	c.ipAllocs = IPAllocations{}
+
+	for _, n := range allocated {
+		c.ipAllocs.set(n.typeName, n.id, *n.ip)
+	}
+
+	return nil
+}
+
+// storeIPAllocations writes IP allocations of the new configuration into
+// the cluster directory.
+func (c *Cluster) storeIPAllocations() error {
+	if c.ipAllocs == nil {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(c.IPAllocationsPath()), 0744)
+	if err != nil {
+		return err
+	}
+
+	return file.WriteYaml(c.ipAllocs, c.IPAllocationsPath(), 0644)
+}
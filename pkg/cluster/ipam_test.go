@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockIPAMCluster(t *testing.T) *ClusterMock {
+	t.Helper()
+
+	c := MockCluster(t)
+	c.NewConfig.Cluster.Network.IPAM.Enabled = true
+	c.NewConfig.Cluster.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "1"},
+		{Id: "2", IP: "192.168.113.3"},
+		{Id: "3"},
+	}
+
+	return c
+}
+
+func TestAllocateIPs(t *testing.T) {
+	c := mockIPAMCluster(t)
+
+	require.NoError(t, c.allocateIPs())
+
+	nodes := c.NewConfig.Cluster.Nodes
+	assert.Equal(t, config.IPv4("192.168.113.2"), nodes.Master.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.4"), nodes.Worker.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.3"), nodes.Worker.Instances[1].IP)
+	assert.Equal(t, config.IPv4("192.168.113.5"), nodes.Worker.Instances[2].IP)
+}
+
+func TestAllocateIPs_Disabled(t *testing.T) {
+	c := mockIPAMCluster(t)
+	c.NewConfig.Cluster.Network.IPAM.Enabled = false
+
+	require.NoError(t, c.allocateIPs())
+	assert.Empty(t, c.NewConfig.Cluster.Nodes.Worker.Instances[0].IP)
+	assert.NoError(t, c.storeIPAllocations())
+	assert.NoFileExists(t, c.IPAllocationsPath())
+}
+
+func TestAllocateIPs_Reserved(t *testing.T) {
+	c := mockIPAMCluster(t)
+
+	gateway := config.IPv4("192.168.113.10")
+	c.NewConfig.Cluster.Network.Gateway = &gateway
+	c.NewConfig.Cluster.Network.IPAM.Reserved = []config.IPRange{"192.168.113.1-192.168.113.9"}
+	c.NewConfig.Cluster.Nodes.LoadBalancer.VIP = "192.168.113.11"
+
+	require.NoError(t, c.allocateIPs())
+
+	nodes := c.NewConfig.Cluster.Nodes
+	assert.Equal(t, config.IPv4("192.168.113.12"), nodes.Master.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.13"), nodes.Worker.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.14"), nodes.Worker.Instances[2].IP)
+}
+
+func TestAllocateIPs_MetalLBPools(t *testing.T) {
+	c := mockIPAMCluster(t)
+	c.NewConfig.Addons.MetalLB.Pools = []config.MetalLBPool{
+		{Name: "default", Addresses: []config.MetalLBAddress{"192.168.113.4/31"}},
+	}
+
+	require.NoError(t, c.allocateIPs())
+
+	nodes := c.NewConfig.Cluster.Nodes
+	assert.Equal(t, config.IPv4("192.168.113.2"), nodes.Master.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.6"), nodes.Worker.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.3"), nodes.Worker.Instances[1].IP)
+	assert.Equal(t, config.IPv4("192.168.113.7"), nodes.Worker.Instances[2].IP)
+}
+
+func TestAllocateIPs_Exhausted(t *testing.T) {
+	c := mockIPAMCluster(t)
+	c.NewConfig.Cluster.Network.CIDR = "192.168.113.0/30"
+
+	assert.ErrorContains(t, c.allocateIPs(), "failed to allocate IP address for worker node")
+}
+
+// Test ensures that previously allocated IP addresses are reused, even
+// if lower addresses become available.
+func TestAllocateIPs_Stable(t *testing.T) {
+	c := mockIPAMCluster(t)
+
+	require.NoError(t, c.allocateIPs())
+	require.NoError(t, c.storeIPAllocations())
+	require.FileExists(t, c.IPAllocationsPath())
+
+	// Remove the first worker and add a new one.
+	c.NewConfig.Cluster.Nodes.Master.Instances[0].IP = ""
+	c.NewConfig.Cluster.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "2", IP: "192.168.113.3"},
+		{Id: "3"},
+		{Id: "4"},
+	}
+
+	require.NoError(t, c.allocateIPs())
+
+	nodes := c.NewConfig.Cluster.Nodes
+	assert.Equal(t, config.IPv4("192.168.113.2"), nodes.Master.Instances[0].IP)
+	assert.Equal(t, config.IPv4("192.168.113.5"), nodes.Worker.Instances[1].IP)
+	assert.Equal(t, config.IPv4("192.168.113.4"), nodes.Worker.Instances[2].IP)
+}
+
+func TestAllocateIPs_InvalidAllocationsFile(t *testing.T) {
+	c := mockIPAMCluster(t)
+
+	require.NoError(t, c.allocateIPs())
+	require.NoError(t, c.storeIPAllocations())
+
+	// Overwrite allocations with an invalid content.
+	require.NoError(t, os.WriteFile(c.IPAllocationsPath(), []byte("invalid"), 0644))
+	assert.ErrorContains(t, c.allocateIPs(), "failed to read IP allocations")
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/models/infra"
 	"github.com/MusicDin/kubitect/pkg/tools/ansible"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/utils/exec"
+	"gopkg.in/yaml.v3"
 )
 
 type common struct {
@@ -20,6 +22,7 @@ type common struct {
 	ConfigDir         string
 	CacheDir          string
 	SharedDir         string
+	OfflineBundle     *bundle.Bundle
 	Config            *config.Config
 	InfraConfig       *infra.Config
 
@@ -123,3 +126,27 @@ func extractNewNodes(events []event.Event) ([]config.Instance, error) {
 
 	return nodes, nil
 }
+
+// writeVars writes the given variables into an Ansible variables file.
+// If there are no variables, the file is removed.
+func writeVars(vars map[string]any, dstPath string) error {
+	if len(vars) == 0 {
+		err := os.Remove(dstPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	out, err := yaml.Marshal(vars)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(dstPath, append([]byte("---\n"), out...), 0600)
+}
@@ -1,6 +1,7 @@
 package managers
 
 import (
+	"os"
 	"path/filepath"
 
 	"github.com/MusicDin/kubitect/pkg/tools/ansible"
@@ -20,11 +21,40 @@ func (e common) HAProxy() error {
 	return e.Ansible.Exec(pb)
 }
 
+// Registries calls playbook that copies CA certificates of container
+// registries to the Kubernetes nodes. Playbook is skipped when no
+// certificates are configured.
+func (e common) Registries() error {
+	certs, err := registryCerts(e.Config.Kubernetes.Registries)
+	if err != nil || len(certs) == 0 {
+		return err
+	}
+
+	pb := ansible.Playbook{
+		Path:       filepath.Join(e.ClusterPath, "ansible/kubitect/registries.yaml"),
+		Inventory:  filepath.Join(e.ClusterPath, "config/nodes.yaml"),
+		Become:     true,
+		User:       e.SshUser(),
+		PrivateKey: e.SshPKey(),
+		Timeout:    3000,
+	}
+
+	return e.Ansible.Exec(pb)
+}
+
 // finalize calls playbook that finalizes Kubernetes cluster installation.
 // This includes exp
 func (e common) Finalize() error {
+	// Working directory is used to resolve relative paths within
+	// the configuration file (e.g. Helm chart values files).
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
 	vars := map[string]string{
-		"bin_dir": e.SharedDir,
+		"bin_dir":  e.SharedDir,
+		"work_dir": wd,
 	}
 
 	pb := ansible.Playbook{
@@ -5,16 +5,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/MusicDin/kubitect/pkg/cluster/event"
 	"github.com/MusicDin/kubitect/pkg/env"
 	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/models/infra"
 	"github.com/MusicDin/kubitect/pkg/tools/ansible"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/tools/git"
 	"github.com/MusicDin/kubitect/pkg/tools/virtualenv"
 	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 	"github.com/MusicDin/kubitect/pkg/utils/exec"
+	"gopkg.in/yaml.v3"
 )
 
 type k3s struct {
@@ -42,6 +46,7 @@ func NewK3sManager(
 	configDir string,
 	cacheDir string,
 	sharedDir string,
+	b *bundle.Bundle,
 	cfg *config.Config,
 	infraCfg *infra.Config,
 ) *k3s {
@@ -53,6 +58,7 @@ func NewK3sManager(
 			ConfigDir:         configDir,
 			CacheDir:          cacheDir,
 			SharedDir:         sharedDir,
+			OfflineBundle:     b,
 			Config:            cfg,
 			InfraConfig:       infraCfg,
 		},
@@ -69,7 +75,7 @@ func (e *k3s) Init() error {
 	}
 
 	// Clone repository with k3s playbooks.
-	err = git.NewGitRepo(env.ConstK3sURL).WithRef(env.ConstK3sVersion).Clone(e.ProjectDir)
+	err = git.NewGitRepo(env.ConstK3sURL).WithRef(env.ConstK3sVersion).WithBundle(e.OfflineBundle).Clone(e.ProjectDir)
 	if err != nil {
 		return err
 	}
@@ -78,7 +84,7 @@ func (e *k3s) Init() error {
 		// Virtual environment.
 		reqPath := filepath.Join(e.ClusterPath, "ansible/kubitect/requirements.txt")
 		venvPath := path.Join(e.SharedDir, "venv", "k3s", env.ConstK3sVersion)
-		err = virtualenv.NewVirtualEnv(venvPath, reqPath).Init()
+		err = virtualenv.NewVirtualEnv(venvPath, reqPath).WithBundle(e.OfflineBundle).Init()
 		if err != nil {
 			return fmt.Errorf("k3s: initialize virtual environment: %v", err)
 		}
@@ -90,17 +96,133 @@ func (e *k3s) Init() error {
 	return nil
 }
 
-// Sync regenerates Ansible inventory.
+// Bundle adds k3s project and pip3 requirements of Kubitect playbooks
+// into the bundle.
+func (e *k3s) Bundle(w *bundle.Writer) error {
+	repo := git.NewGitRepo(env.ConstK3sURL).WithRef(env.ConstK3sVersion)
+
+	err := w.AddRepository(repo.Url(), repo.Ref(), repo.Clone)
+	if err != nil {
+		return err
+	}
+
+	reqPath := filepath.Join(e.ClusterPath, "ansible/kubitect/requirements.txt")
+	venvPath := path.Join(e.SharedDir, "venv", "k3s", env.ConstK3sVersion)
+	return w.AddRequirements(reqPath, virtualenv.NewVirtualEnv(venvPath, reqPath).Download)
+}
+
+// Sync regenerates Ansible inventory, registry and proxy variables.
 func (e *k3s) Sync() error {
-	nodes := struct {
-		ConfigNodes config.Nodes
-		InfraNodes  config.Nodes
-	}{
-		ConfigNodes: e.Config.Cluster.Nodes,
-		InfraNodes:  e.InfraConfig.Nodes,
+	serverConfig, err := k3sConfigLines(e.Config.Addons.K3s.Server)
+	if err != nil {
+		return fmt.Errorf("k3s: server configuration: %v", err)
+	}
+
+	agentConfig, err := k3sConfigLines(e.Config.Addons.K3s.Agent)
+	if err != nil {
+		return fmt.Errorf("k3s: agent configuration: %v", err)
+	}
+
+	values := k3sInventory{
+		ConfigNodes:  e.Config.Cluster.Nodes,
+		InfraNodes:   e.InfraConfig.Nodes,
+		Network:      e.network(),
+		ServerConfig: serverConfig,
+		AgentConfig:  agentConfig,
+	}
+
+	err = NewTemplate("k3s/inventory.yaml", values).Write(filepath.Join(e.ConfigDir, "nodes.yaml"))
+	if err != nil {
+		return err
+	}
+
+	registries, err := k3sRegistries(e.Config.Kubernetes.Registries)
+	if err != nil {
+		return fmt.Errorf("k3s: %v", err)
+	}
+
+	vars := make(map[string]any)
+	if len(registries) > 0 {
+		vars["k3s_kubitect_registries"] = registries
+	}
+
+	registriesPath := filepath.Join(e.ConfigDir, "group_vars", "all", "registries.yaml")
+	err = writeRegistryVars(e.Config.Kubernetes.Registries, vars, registriesPath)
+	if err != nil {
+		return err
+	}
+
+	return writeVars(k3sProxy(e.Config), filepath.Join(e.ConfigDir, "group_vars", "all", "proxy.yaml"))
+}
+
+// k3sInventory contains values of the k3s inventory template. Server
+// and agent configurations are appended to the configuration of each
+// server and agent node respectively.
+type k3sInventory struct {
+	ConfigNodes  config.Nodes
+	InfraNodes   config.Nodes
+	Network      k3sNetwork
+	ServerConfig []string
+	AgentConfig  []string
+}
+
+// k3sConfigLines returns the given k3s configuration as YAML lines, which
+// are appended to the configuration of each node in the inventory.
+func k3sConfigLines(cfg map[string]any) ([]string, error) {
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+
+	var out strings.Builder
+
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimRight(out.String(), "\n"), "\n"), nil
+}
+
+// k3sNetwork contains k3s cluster and service CIDRs. Empty values indicate
+// that k3s defaults are used.
+type k3sNetwork struct {
+	DualStack   bool
+	ClusterCIDR string
+	ServiceCIDR string
+}
+
+// network returns k3s network configuration.
+func (e *k3s) network() k3sNetwork {
+	return k3sNetworkConfig(e.Config)
+}
+
+// k3sNetworkConfig returns network configuration for k3s and RKE2, which
+// share default subnets. In dual-stack clusters, both IPv4 and IPv6
+// subnets are always set.
+func k3sNetworkConfig(cfg *config.Config) k3sNetwork {
+	net := cfg.Kubernetes.Network
+
+	if !cfg.Cluster.Network.IsDualStack() {
+		return k3sNetwork{
+			ClusterCIDR: string(net.PodSubnet),
+			ServiceCIDR: string(net.ServiceSubnet),
+		}
 	}
 
-	return NewTemplate("k3s/inventory.yaml", nodes).Write(filepath.Join(e.ConfigDir, "nodes.yaml"))
+	// IPv6 subnets have no default in k3s, therefore they need to be
+	// set explicitly in dual-stack clusters.
+	pod := defaults.Default(string(net.PodSubnet), config.K3sDefaultPodSubnet)
+	pod6 := defaults.Default(string(net.PodSubnet6), config.K3sDefaultPodSubnet6)
+	svc := defaults.Default(string(net.ServiceSubnet), config.K3sDefaultServiceSubnet)
+	svc6 := defaults.Default(string(net.ServiceSubnet6), config.K3sDefaultServiceSubnet6)
+
+	return k3sNetwork{
+		DualStack:   true,
+		ClusterCIDR: pod + "," + pod6,
+		ServiceCIDR: svc + "," + svc6,
+	}
 }
 
 // Create creates a Kubernetes cluster by calling appropriate k3s
@@ -111,7 +233,12 @@ func (e *k3s) Create() error {
 	}
 
 	inventory := filepath.Join(e.ConfigDir, "nodes.yaml")
-	err := e.K3sCreate(inventory)
+	err := e.K3sPrepare(inventory)
+	if err != nil {
+		return err
+	}
+
+	err = e.K3sCreate(inventory)
 	if err != nil {
 		return err
 	}
@@ -142,7 +269,12 @@ func (e *k3s) Create() error {
 // Upgrades upgrades a Kubernetes cluster by calling appropriate k3s
 // playbooks.
 func (e *k3s) Upgrade() error {
-	err := e.K3sUpgrade()
+	err := e.K3sPrepare(filepath.Join(e.ConfigDir, "nodes.yaml"))
+	if err != nil {
+		return err
+	}
+
+	err = e.K3sUpgrade()
 	if err != nil {
 		return err
 	}
@@ -175,6 +307,12 @@ func (e *k3s) ScaleUp(events event.Events) error {
 	}
 
 	defer os.Remove(inventory)
+
+	err = e.K3sPrepare(inventory)
+	if err != nil {
+		return err
+	}
+
 	return e.K3sCreate(inventory)
 }
 
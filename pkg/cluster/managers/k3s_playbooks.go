@@ -5,7 +5,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/tools/ansible"
 )
 
@@ -25,7 +28,7 @@ func (e *k3s) K3sCreate(inventory string) error {
 		"user_kubectl":      "true", // Set to false to kubectl via root user.
 		"cluster_context":   "default",
 		"kubeconfig":        filepath.Join(e.ConfigDir, "admin.conf"),
-		"extra_server_args": "",
+		"extra_server_args": e.extraServerArgs(),
 		"extra_agent_args":  "",
 	}
 
@@ -51,7 +54,7 @@ func (e *k3s) K3sUpgrade() error {
 		"api_endpoint":      string(e.InfraConfig.Nodes.LoadBalancer.VIP),
 		"api_port":          "6443",
 		"user_kubectl":      "true", // Set to false to kubectl via root user.
-		"extra_server_args": "",
+		"extra_server_args": e.extraServerArgs(),
 		"extra_agent_args":  "",
 	}
 
@@ -68,3 +71,36 @@ func (e *k3s) K3sUpgrade() error {
 
 	return e.Ansible.Exec(pb)
 }
+
+// K3sPrepare calls a playbook that prepares nodes for k3s installation,
+// such as configuring container registries.
+func (e *k3s) K3sPrepare(inventory string) error {
+	pb := ansible.Playbook{
+		Path:       filepath.Join(e.ClusterPath, "ansible/kubitect/k3s.yaml"),
+		Inventory:  inventory,
+		Become:     true,
+		User:       e.SshUser(),
+		PrivateKey: e.SshPKey(),
+		Timeout:    600,
+	}
+
+	return e.Ansible.Exec(pb)
+}
+
+// extraServerArgs returns additional arguments of the k3s server.
+func (e *k3s) extraServerArgs() string {
+	var args []string
+
+	disable := slices.Clone(e.Config.Addons.K3s.Disable)
+
+	// MetalLB replaces the built-in service load balancer.
+	if e.Config.Addons.MetalLB.Enabled && !slices.Contains(disable, config.K3sServiceLB) {
+		disable = append(disable, config.K3sServiceLB)
+	}
+
+	for _, c := range disable {
+		args = append(args, fmt.Sprintf("--disable %s", c))
+	}
+
+	return strings.Join(args, " ")
+}
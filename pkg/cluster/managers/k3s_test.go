@@ -0,0 +1,53 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func MockK3sManager(t *testing.T) *k3s {
+	return &k3s{common: MockManager(t).common}
+}
+
+func TestK3sNetwork(t *testing.T) {
+	e := MockK3sManager(t)
+	assert.Equal(t, k3sNetwork{}, e.network())
+
+	e.Config.Kubernetes.Network.PodSubnet = "10.10.0.0/16"
+	assert.Equal(t, k3sNetwork{ClusterCIDR: "10.10.0.0/16"}, e.network())
+}
+
+func TestK3sNetwork_DualStack(t *testing.T) {
+	e := MockK3sManager(t)
+	e.Config.Cluster.Network.CIDR = "192.168.113.0/24"
+	e.Config.Cluster.Network.CIDR6 = "fd00:113::/64"
+	e.Config.Kubernetes.Network.ServiceSubnet6 = "fd00:43::/112"
+
+	expect := k3sNetwork{
+		DualStack:   true,
+		ClusterCIDR: "10.42.0.0/16,fd00:10:42::/56",
+		ServiceCIDR: "10.43.0.0/16,fd00:43::/112",
+	}
+
+	assert.Equal(t, expect, e.network())
+}
+
+func TestK3sExtraServerArgs(t *testing.T) {
+	e := MockK3sManager(t)
+	assert.Empty(t, e.extraServerArgs())
+
+	e.Config.Addons.MetalLB.Enabled = true
+	assert.Equal(t, "--disable servicelb", e.extraServerArgs())
+}
+
+func TestK3sExtraServerArgs_Disable(t *testing.T) {
+	e := MockK3sManager(t)
+	e.Config.Addons.K3s.Disable = []config.K3sComponent{config.K3sTraefik, config.K3sServiceLB}
+	e.Config.Addons.MetalLB.Enabled = true
+
+	assert.Equal(t, "--disable traefik --disable servicelb", e.extraServerArgs())
+	assert.Len(t, e.Config.Addons.K3s.Disable, 2)
+}
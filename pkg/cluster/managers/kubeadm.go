@@ -0,0 +1,320 @@
+package managers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+)
+
+// Versions of network plugins installed by the kubeadm manager.
+const (
+	kubeadmCalicoVersion  = "v3.30.3"
+	kubeadmFlannelVersion = "v0.27.3"
+)
+
+// kubeadmKubectl is a kubectl command that uses the admin kubeconfig on
+// master nodes.
+const kubeadmKubectl = "kubectl --kubeconfig /etc/kubernetes/admin.conf"
+
+type kubeadm struct {
+	sshCommon
+}
+
+func NewKubeadmManager(
+	clusterName string,
+	clusterPath string,
+	sshPrivateKeyPath string,
+	configDir string,
+	cacheDir string,
+	sharedDir string,
+	b *bundle.Bundle,
+	cfg *config.Config,
+	infraCfg *infra.Config,
+) *kubeadm {
+	return &kubeadm{
+		sshCommon: sshCommon{common: common{
+			ClusterName:       clusterName,
+			ClusterPath:       clusterPath,
+			SshPrivateKeyPath: sshPrivateKeyPath,
+			ConfigDir:         configDir,
+			CacheDir:          cacheDir,
+			SharedDir:         sharedDir,
+			OfflineBundle:     b,
+			Config:            cfg,
+			InfraConfig:       infraCfg,
+		}},
+	}
+}
+
+// Sync generates Ansible inventory, which is used by Kubitect playbooks
+// that install addons.
+func (e *kubeadm) Sync() error {
+	values := struct {
+		InfraNodes config.Nodes
+	}{
+		InfraNodes: e.InfraConfig.Nodes,
+	}
+
+	return NewTemplate("kubeadm/inventory.yaml", values).Write(filepath.Join(e.ConfigDir, "nodes.yaml"))
+}
+
+// Create configures load balancers, installs Kubernetes packages on all
+// nodes, initializes the control plane on the first master node and
+// joins the remaining nodes.
+func (e *kubeadm) Create() error {
+	err := e.configureLoadBalancers(6443)
+	if err != nil {
+		return err
+	}
+
+	nodes := e.nodes()
+	leader := nodes[0]
+
+	for _, n := range nodes {
+		err := e.install(n, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = e.initControlPlane(leader)
+	if err != nil {
+		return err
+	}
+
+	err = e.join(nodes[1:])
+	if err != nil {
+		return err
+	}
+
+	err = e.label(nodes)
+	if err != nil {
+		return err
+	}
+
+	err = e.fetchKubeconfig(leader.IP, "/etc/kubernetes/admin.conf")
+	if err != nil {
+		return err
+	}
+
+	err = e.finalize(leader.IP, kubeadmKubectl)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite kubeconfig before merging to prevent accidental
+	// overwrite of an existing configuration.
+	err = e.rewriteKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	if e.Config.Kubernetes.Other.MergeKubeconfig {
+		err := e.mergeKubeconfig()
+		if err != nil {
+			// Just warn about failure, since deployment has succeeded.
+			ui.Print(ui.WARN, "Failed to merge kubeconfig:", err)
+		}
+	}
+
+	return nil
+}
+
+// Upgrade upgrades the cluster node by node. The control plane is
+// upgraded on the first master node, while the remaining nodes only
+// upgrade their local configuration. Each node is drained before it
+// is upgraded.
+func (e *kubeadm) Upgrade() error {
+	nodes := e.nodes()
+	leader := nodes[0]
+
+	for i, n := range nodes {
+		cmd := "kubeadm upgrade node"
+		if i == 0 {
+			cmd = fmt.Sprintf("kubeadm upgrade apply v%s --yes", e.K8sVersion())
+		}
+
+		err := e.drain(leader.IP, kubeadmKubectl, n.Name)
+		if err != nil {
+			return err
+		}
+
+		err = e.install(n, cmd)
+		if err != nil {
+			return err
+		}
+
+		err = e.run(leader.IP, fmt.Sprintf("%s uncordon %s", kubeadmKubectl, n.Name))
+		if err != nil {
+			return fmt.Errorf("uncordon node %q: %v", n.Name, err)
+		}
+	}
+
+	return e.finalize(leader.IP, kubeadmKubectl)
+}
+
+// ScaleUp installs Kubernetes packages on new nodes and joins them to
+// the cluster.
+func (e *kubeadm) ScaleUp(events event.Events) error {
+	newNodes, err := extractNewNodes(events)
+	if err != nil {
+		return err
+	}
+
+	if len(newNodes) == 0 {
+		// No new nodes.
+		return nil
+	}
+
+	err = e.configureLoadBalancers(6443)
+	if err != nil {
+		return err
+	}
+
+	var nodes []clusterNode
+	for _, n := range e.nodes() {
+		for _, nn := range newNodes {
+			if n.Name == e.nodeName(nn) {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		err := e.install(n, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = e.join(nodes)
+	if err != nil {
+		return err
+	}
+
+	return e.label(nodes)
+}
+
+// ScaleDown gracefully removes nodes from the cluster. Removed nodes are
+// drained and reset, which also removes etcd members of the removed
+// control plane nodes.
+func (e *kubeadm) ScaleDown(events event.Events) error {
+	rmNodes, err := extractRemovedNodes(events)
+	if err != nil {
+		return err
+	}
+
+	if len(rmNodes) == 0 {
+		// No removed nodes.
+		return nil
+	}
+
+	leader := e.nodes()[0].IP
+
+	for _, n := range rmNodes {
+		name := e.nodeName(n)
+
+		ip, err := e.nodeIP(name)
+		if err != nil {
+			return err
+		}
+
+		err = e.drain(leader, kubeadmKubectl, name)
+		if err != nil {
+			return err
+		}
+
+		err = e.run(ip, "kubeadm reset --force")
+		if err != nil {
+			return fmt.Errorf("reset node %q: %v", name, err)
+		}
+
+		err = e.run(leader, fmt.Sprintf("%s delete node %s", kubeadmKubectl, name))
+		if err != nil {
+			return fmt.Errorf("delete node %q: %v", name, err)
+		}
+	}
+
+	// No need for further cleanup. This instance will be removed.
+	return nil
+}
+
+// rewriteKubeconfig replaces "kubernetes-admin" context and user in
+// kubeconfig with a cluster name. Cluster name is already set by
+// kubeadm.
+func (e *kubeadm) rewriteKubeconfig() error {
+	// Context is replaced first, since it contains the user name.
+	err := e.common.rewriteKubeconfig(map[string]string{
+		"kubernetes-admin@" + e.ClusterName: e.ClusterName,
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.common.rewriteKubeconfig(map[string]string{
+		"kubernetes-admin": e.ClusterName,
+	})
+}
+
+// endpoint returns the control plane endpoint, which is the load
+// balancer VIP. When the cluster has no load balancers, provisioners
+// set the VIP to the IP address of the first master node.
+func (e *kubeadm) endpoint() string {
+	return string(e.InfraConfig.Nodes.LoadBalancer.VIP)
+}
+
+// schedulable returns true if workloads need to be scheduled on master
+// nodes, which is the case when the cluster has no worker nodes.
+func (e *kubeadm) schedulable() bool {
+	return len(e.InfraConfig.Nodes.Worker.Instances) == 0
+}
+
+// podSubnet returns the configured pod subnet or a default one. Kubeadm
+// does not set a pod subnet by default, but network plugins require one.
+func (e *kubeadm) podSubnet() string {
+	return defaults.Default(string(e.Config.Kubernetes.Network.PodSubnet), config.KubeadmDefaultPodSubnet)
+}
+
+// serviceSubnet returns the configured service subnet or a default one.
+func (e *kubeadm) serviceSubnet() string {
+	return defaults.Default(string(e.Config.Kubernetes.Network.ServiceSubnet), config.KubeadmDefaultServiceSubnet)
+}
+
+// kubeadmJoin contains values required for joining a node to the
+// cluster.
+type kubeadmJoin struct {
+	Token          string
+	CACertHash     string
+	CertificateKey string
+}
+
+// parseJoinCommand extracts join values from the command printed by
+// "kubeadm token create --print-join-command".
+func parseJoinCommand(cmd string) (kubeadmJoin, error) {
+	var join kubeadmJoin
+
+	fields := strings.Fields(cmd)
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "--token":
+			join.Token = fields[i+1]
+		case "--discovery-token-ca-cert-hash":
+			join.CACertHash = fields[i+1]
+		case "--certificate-key":
+			join.CertificateKey = fields[i+1]
+		}
+	}
+
+	if join.Token == "" || join.CACertHash == "" {
+		return join, fmt.Errorf("invalid join command: %q", strings.TrimSpace(cmd))
+	}
+
+	return join, nil
+}
@@ -0,0 +1,184 @@
+package managers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/ui"
+)
+
+// kubeadmTokenScript uploads control plane certificates, so that other
+// control plane nodes can join the cluster, and prints a join command
+// with a fresh bootstrap token.
+const kubeadmTokenScript = `set -euo pipefail
+key=$(kubeadm init phase upload-certs --upload-certs | tail -n 1)
+kubeadm token create --ttl 30m --print-join-command --certificate-key "$key"`
+
+// install installs containerd and Kubernetes packages on the given node.
+// If upgrade command is provided, it is run after kubeadm is upgraded
+// and before kubelet is upgraded.
+func (e *kubeadm) install(n clusterNode, upgradeCmd string) error {
+	ui.Printf(ui.INFO, "kubeadm: Installing Kubernetes v%s on node %q...\n", e.K8sVersion(), n.Name)
+
+	values := struct {
+		Version        string
+		Minor          string
+		UpgradeCommand string
+	}{
+		Version:        e.K8sVersion(),
+		Minor:          minorVersion(e.K8sVersion()),
+		UpgradeCommand: upgradeCmd,
+	}
+
+	script, err := populateScript("kubeadm/install.sh", values)
+	if err != nil {
+		return err
+	}
+
+	err = e.run(n.IP, script)
+	if err != nil {
+		return fmt.Errorf("install Kubernetes on node %q: %v", n.Name, err)
+	}
+
+	return nil
+}
+
+// initControlPlane initializes the control plane on the given node and
+// installs the network plugin.
+func (e *kubeadm) initControlPlane(n clusterNode) error {
+	ui.Printf(ui.INFO, "kubeadm: Initializing control plane on node %q...\n", n.Name)
+
+	cfg, err := populateScript("kubeadm/init.yaml", struct {
+		Node          clusterNode
+		ClusterName   string
+		Version       string
+		Endpoint      string
+		Schedulable   bool
+		PodSubnet     string
+		ServiceSubnet string
+	}{
+		Node:          n,
+		ClusterName:   e.ClusterName,
+		Version:       e.K8sVersion(),
+		Endpoint:      e.endpoint(),
+		Schedulable:   e.schedulable(),
+		PodSubnet:     e.podSubnet(),
+		ServiceSubnet: e.serviceSubnet(),
+	})
+	if err != nil {
+		return err
+	}
+
+	script, err := populateScript("kubeadm/init.sh", struct {
+		Config         string
+		NetworkPlugin  config.NetworkPlugin
+		PodSubnet      string
+		CalicoVersion  string
+		FlannelVersion string
+	}{
+		Config:         cfg,
+		NetworkPlugin:  e.Config.Kubernetes.NetworkPlugin,
+		PodSubnet:      e.podSubnet(),
+		CalicoVersion:  kubeadmCalicoVersion,
+		FlannelVersion: kubeadmFlannelVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = e.run(n.IP, script)
+	if err != nil {
+		return fmt.Errorf("initialize control plane on node %q: %v", n.Name, err)
+	}
+
+	return nil
+}
+
+// join joins the given nodes to the cluster using a bootstrap token
+// created on the first master node.
+func (e *kubeadm) join(nodes []clusterNode) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	leader := e.nodes()[0]
+
+	out, err := e.output(leader.IP, kubeadmTokenScript)
+	if err != nil {
+		return fmt.Errorf("create join token on node %q: %v", leader.Name, err)
+	}
+
+	join, err := parseJoinCommand(out)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		ui.Printf(ui.INFO, "kubeadm: Joining node %q...\n", n.Name)
+
+		cfg, err := populateScript("kubeadm/join.yaml", struct {
+			Node         clusterNode
+			Join         kubeadmJoin
+			Endpoint     string
+			ControlPlane bool
+			Schedulable  bool
+		}{
+			Node:         n,
+			Join:         join,
+			Endpoint:     e.endpoint(),
+			ControlPlane: n.ControlPlane,
+			Schedulable:  e.schedulable(),
+		})
+		if err != nil {
+			return err
+		}
+
+		script, err := populateScript("kubeadm/join.sh", struct{ Config string }{cfg})
+		if err != nil {
+			return err
+		}
+
+		err = e.run(n.IP, script)
+		if err != nil {
+			return fmt.Errorf("join node %q: %v", n.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// label applies configured labels and taints to the given nodes.
+func (e *kubeadm) label(nodes []clusterNode) error {
+	labeled := false
+	for _, n := range nodes {
+		labeled = labeled || len(n.Labels) > 0 || len(n.Taints) > 0
+	}
+
+	if !labeled {
+		return nil
+	}
+
+	script, err := populateScript("kubeadm/label.sh", nodes)
+	if err != nil {
+		return err
+	}
+
+	err = e.run(e.nodes()[0].IP, script)
+	if err != nil {
+		return fmt.Errorf("label nodes: %v", err)
+	}
+
+	return nil
+}
+
+// minorVersion returns the major and minor part of the given version
+// (e.g. 1.33.4 -> 1.33).
+func minorVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
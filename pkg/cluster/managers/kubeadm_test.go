@@ -0,0 +1,225 @@
+package managers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockJoinCommand = "kubeadm join 192.168.113.10:6443 --token abc.def --discovery-token-ca-cert-hash sha256:123 --control-plane --certificate-key 456\n"
+
+const mockKubeconfig = `contexts:
+- context:
+    cluster: mock
+    user: kubernetes-admin
+  name: kubernetes-admin@mock
+current-context: kubernetes-admin@mock
+`
+
+// nodeRunnerMock records scripts run on the nodes.
+type nodeRunnerMock struct {
+	hosts   []string
+	scripts []string
+
+	// Whether the Rook namespace exists in the cluster.
+	rook bool
+}
+
+func (r *nodeRunnerMock) Run(host string, script string, stdout io.Writer) error {
+	r.hosts = append(r.hosts, host)
+	r.scripts = append(r.scripts, script)
+
+	switch {
+	case script == kubeadmTokenScript:
+		_, err := io.WriteString(stdout, mockJoinCommand)
+		return err
+	case script == "cat /etc/kubernetes/admin.conf":
+		_, err := io.WriteString(stdout, mockKubeconfig)
+		return err
+	case script == "cat "+rke2TokenPath:
+		_, err := io.WriteString(stdout, mockRke2Token+"\n")
+		return err
+	case script == "cat "+rke2Kubeconfig:
+		_, err := io.WriteString(stdout, mockRke2Kubeconfig)
+		return err
+	case strings.HasSuffix(script, "get namespace rook-ceph --ignore-not-found --output name") && r.rook:
+		_, err := io.WriteString(stdout, "namespace/rook-ceph\n")
+		return err
+	}
+
+	return nil
+}
+
+func MockKubeadmManager(t *testing.T) (*kubeadm, *nodeRunnerMock) {
+	runner := &nodeRunnerMock{}
+
+	e := &kubeadm{sshCommon: sshCommon{common: MockManager(t).common, Nodes: runner}}
+	e.Config.Kubernetes.NetworkPlugin = config.CALICO
+	e.InfraConfig.Nodes.LoadBalancer.VIP = "192.168.113.10"
+	e.InfraConfig.Nodes.Master.Instances = []config.MasterInstance{
+		{Id: "1", Name: "mock-master-1", IP: "192.168.113.10"},
+		{Id: "2", Name: "mock-master-2", IP: "192.168.113.11"},
+	}
+	e.InfraConfig.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "1", Name: "mock-worker-1", IP: "192.168.113.20"},
+	}
+	e.Config.Cluster.Nodes.Master.Instances = e.InfraConfig.Nodes.Master.Instances
+	e.Config.Cluster.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "1", IP: "192.168.113.20", Labels: config.Labels{"disk": "ssd"}},
+	}
+
+	return e, runner
+}
+
+func TestNewKubeadmManager(t *testing.T) {
+	e := NewKubeadmManager("mock", "", "", "", "", "", nil, &config.Config{}, nil)
+	assert.NotNil(t, e)
+	assert.NoError(t, e.Init())
+	assert.NotNil(t, e.Nodes)
+	assert.Nil(t, e.Ansible)
+}
+
+func TestKubeadm_Create(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+	require.NoError(t, e.Create())
+
+	// Install on each node, init, token, two joins, labels, kubeconfig
+	// and Rook check.
+	assert.Equal(t, []string{
+		"192.168.113.10",
+		"192.168.113.11",
+		"192.168.113.20",
+		"192.168.113.10",
+		"192.168.113.10",
+		"192.168.113.11",
+		"192.168.113.20",
+		"192.168.113.10",
+		"192.168.113.10",
+		"192.168.113.10",
+	}, runner.hosts)
+
+	assert.Contains(t, runner.scripts[3], "kubeadm init --config /etc/kubernetes/kubeadm.yaml --upload-certs")
+	assert.Contains(t, runner.scripts[3], "projectcalico/calico/"+kubeadmCalicoVersion)
+	assert.Contains(t, runner.scripts[5], `certificateKey: "456"`)
+	assert.NotContains(t, runner.scripts[6], "certificateKey")
+	assert.Contains(t, runner.scripts[7], `kubectl label node mock-worker-1 "disk=ssd" --overwrite`)
+
+	kubeconfig, err := os.ReadFile(filepath.Join(e.ConfigDir, "admin.conf"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(kubeconfig), "kubernetes-admin")
+	assert.Contains(t, string(kubeconfig), "current-context: mock\n")
+}
+
+func TestKubeadm_Upgrade(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+	require.NoError(t, e.Upgrade())
+
+	// Drain, install and uncordon for each node and Rook check.
+	require.Len(t, runner.scripts, 10)
+	assert.Contains(t, runner.scripts[0], "drain mock-master-1")
+	assert.Contains(t, runner.scripts[1], "kubeadm upgrade apply v1.33.4 --yes")
+	assert.Contains(t, runner.scripts[2], "uncordon mock-master-1")
+	assert.Contains(t, runner.scripts[4], "kubeadm upgrade node")
+	assert.Equal(t, "192.168.113.11", runner.hosts[4])
+}
+
+func TestKubeadm_ScaleUp(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+
+	events := MockEvents(t, e.Config.Cluster.Nodes.Worker.Instances[0], event.Action_ScaleUp)
+	require.NoError(t, e.ScaleUp(events))
+
+	// Install, token, join and labels.
+	assert.Equal(t, []string{
+		"192.168.113.20",
+		"192.168.113.10",
+		"192.168.113.20",
+		"192.168.113.10",
+	}, runner.hosts)
+}
+
+func TestKubeadm_ScaleDown(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+
+	// IP addresses of provisioned nodes are not set in the config.
+	e.Config.Cluster.Nodes.Master.Instances = []config.MasterInstance{{Id: "1"}, {Id: "2"}}
+
+	events := MockEvents(t, config.WorkerInstance{Id: "1"}, event.Action_ScaleDown)
+	require.NoError(t, e.ScaleDown(events))
+
+	assert.Equal(t, []string{"192.168.113.10", "192.168.113.20", "192.168.113.10"}, runner.hosts)
+	assert.Contains(t, runner.scripts[0], "drain mock-worker-1")
+	assert.Equal(t, "kubeadm reset --force", runner.scripts[1])
+	assert.Contains(t, runner.scripts[2], "delete node mock-worker-1")
+}
+
+func TestKubeadm_ScaleDown_UnknownNode(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+
+	events := MockEvents(t, config.WorkerInstance{Id: "2"}, event.Action_ScaleDown)
+	assert.EqualError(t, e.ScaleDown(events), `IP address of node "mock-worker-2" not found in the infrastructure configuration`)
+	assert.Empty(t, runner.scripts)
+}
+
+func TestKubeadmTemplate_Init(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+	e.InfraConfig.Nodes.Worker.Instances = nil
+	e.Config.Kubernetes.NetworkPlugin = config.FLANNEL
+	e.Config.Kubernetes.Network.PodSubnet = "10.10.0.0/16"
+
+	require.NoError(t, e.initControlPlane(e.nodes()[0]))
+
+	expect := strings.Join([]string{
+		"---",
+		"apiVersion: kubeadm.k8s.io/v1beta4",
+		"kind: InitConfiguration",
+		"localAPIEndpoint:",
+		"  advertiseAddress: 192.168.113.10",
+		"  bindPort: 6443",
+		"nodeRegistration:",
+		"  name: mock-master-1",
+		"  criSocket: unix:///run/containerd/containerd.sock",
+		"  taints: []",
+		"  kubeletExtraArgs:",
+		"    - name: node-ip",
+		"      value: 192.168.113.10",
+		"---",
+		"apiVersion: kubeadm.k8s.io/v1beta4",
+		"kind: ClusterConfiguration",
+		"clusterName: mock",
+		"kubernetesVersion: v1.33.4",
+		"controlPlaneEndpoint: 192.168.113.10:6443",
+		"apiServer:",
+		"  certSANs:",
+		"    - 192.168.113.10",
+		"networking:",
+		"  podSubnet: 10.10.0.0/16",
+		"  serviceSubnet: 10.96.0.0/12",
+	}, "\n")
+
+	assert.Contains(t, runner.scripts[0], expect)
+	assert.Contains(t, runner.scripts[0], `sed "s#10.244.0.0/16#10.10.0.0/16#"`)
+}
+
+func TestParseJoinCommand(t *testing.T) {
+	join, err := parseJoinCommand(mockJoinCommand)
+	require.NoError(t, err)
+	assert.Equal(t, kubeadmJoin{Token: "abc.def", CACertHash: "sha256:123", CertificateKey: "456"}, join)
+
+	_, err = parseJoinCommand("invalid")
+	assert.EqualError(t, err, fmt.Sprintf("invalid join command: %q", "invalid"))
+}
+
+func TestMinorVersion(t *testing.T) {
+	assert.Equal(t, "1.33", minorVersion("1.33.4"))
+	assert.Equal(t, "1", minorVersion("1"))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/MusicDin/kubitect/pkg/models/config"
 	"github.com/MusicDin/kubitect/pkg/models/infra"
 	"github.com/MusicDin/kubitect/pkg/tools/ansible"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/tools/git"
 	"github.com/MusicDin/kubitect/pkg/tools/virtualenv"
 	"github.com/MusicDin/kubitect/pkg/ui"
@@ -28,6 +29,7 @@ func NewKubesprayManager(
 	configDir string,
 	cacheDir string,
 	sharedDir string,
+	b *bundle.Bundle,
 	cfg *config.Config,
 	infraCfg *infra.Config,
 ) *kubespray {
@@ -39,6 +41,7 @@ func NewKubesprayManager(
 			ConfigDir:         configDir,
 			CacheDir:          cacheDir,
 			SharedDir:         sharedDir,
+			OfflineBundle:     b,
 			Config:            cfg,
 			InfraConfig:       infraCfg,
 		},
@@ -58,7 +61,7 @@ func (e *kubespray) Init() error {
 	}
 
 	// Clone repository with Kubespray playbooks.
-	err = git.NewGitRepo(url).WithRef(ver).Clone(dst)
+	err = git.NewGitRepo(url).WithRef(ver).WithBundle(e.OfflineBundle).Clone(dst)
 	if err != nil {
 		return err
 	}
@@ -67,7 +70,7 @@ func (e *kubespray) Init() error {
 		// Virtual environment.
 		reqPath := filepath.Join(e.ClusterPath, "ansible/kubespray/requirements.txt")
 		venvPath := filepath.Join(e.SharedDir, "venv", "kubespray", env.ConstKubesprayVersion)
-		err = virtualenv.NewVirtualEnv(venvPath, reqPath).Init()
+		err = virtualenv.NewVirtualEnv(venvPath, reqPath).WithBundle(e.OfflineBundle).Init()
 		if err != nil {
 			return fmt.Errorf("kubespray: initialize virtual environment: %v", err)
 		}
@@ -79,6 +82,28 @@ func (e *kubespray) Init() error {
 	return nil
 }
 
+// Bundle adds Kubespray project and its pip3 requirements into the bundle.
+func (e *kubespray) Bundle(w *bundle.Writer) error {
+	repo := git.NewGitRepo(env.ConstKubesprayUrl).WithRef(env.ConstKubesprayVersion)
+
+	var reqPath string
+	err := w.AddRepository(repo.Url(), repo.Ref(), func(dst string) error {
+		reqPath = filepath.Join(dst, "requirements.txt")
+		return repo.Clone(dst)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if reqPath == "" {
+		return nil
+	}
+
+	venvPath := filepath.Join(e.SharedDir, "venv", "kubespray", env.ConstKubesprayVersion)
+	return w.AddRequirements(reqPath, virtualenv.NewVirtualEnv(venvPath, reqPath).Download)
+}
+
 // Sync regenerates required Ansible inventories and Kubespray group
 // variables.
 func (e *kubespray) Sync() error {
@@ -98,6 +123,11 @@ func (e *kubespray) Create() error {
 		return err
 	}
 
+	err = e.Registries()
+	if err != nil {
+		return err
+	}
+
 	err = e.KubesprayCreate()
 	if err != nil {
 		return err
@@ -129,7 +159,12 @@ func (e *kubespray) Create() error {
 // Upgrades upgrades a Kubernetes cluster by calling appropriate Kubespray
 // playbooks.
 func (e *kubespray) Upgrade() error {
-	err := e.KubesprayUpgrade()
+	err := e.Registries()
+	if err != nil {
+		return err
+	}
+
+	err = e.KubesprayUpgrade()
 	if err != nil {
 		return err
 	}
@@ -156,6 +191,11 @@ func (e *kubespray) ScaleUp(events event.Events) error {
 		return err
 	}
 
+	err = e.Registries()
+	if err != nil {
+		return err
+	}
+
 	return e.KubesprayScale()
 }
 
@@ -233,7 +273,17 @@ func (e *kubespray) generateGroupVars() error {
 		return err
 	}
 
-	return nil
+	registries, err := kubesprayRegistries(e.Config.Kubernetes.Registries)
+	if err != nil {
+		return err
+	}
+
+	err = writeRegistryVars(e.Config.Kubernetes.Registries, registries, filepath.Join(groupVarsDir, "k8s_cluster", "registries.yaml"))
+	if err != nil {
+		return err
+	}
+
+	return writeVars(kubesprayProxy(e.Config), filepath.Join(groupVarsDir, "all", "proxy.yaml"))
 }
 
 // rewriteKubeconfig replaces context/cluster/user in kubeconfig with the
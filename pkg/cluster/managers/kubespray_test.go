@@ -81,6 +81,7 @@ func TestNewManager(t *testing.T) {
 		path.Join(tmpDir, "config"),
 		path.Join(tmpDir, "cache"),
 		path.Join(tmpDir, "share"),
+		nil,
 		&config.Config{},
 		&infra.Config{},
 	)
@@ -0,0 +1,428 @@
+package managers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/exec"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pluginPrefix is a prefix of external manager plugin executables. The
+// plugin for manager "<name>" is an executable "kubitect-manager-<name>".
+const pluginPrefix = "kubitect-manager-"
+
+// pluginProtocolVersions are versions of the manager plugin protocol
+// supported by Kubitect.
+var pluginProtocolVersions = []int{1}
+
+// Plugin methods. Each method corresponds to a call of the manager
+// interface, except for handshake, which negotiates the protocol version.
+const (
+	pluginMethodHandshake = "handshake"
+	pluginMethodInit      = "init"
+	pluginMethodSync      = "sync"
+	pluginMethodCreate    = "create"
+	pluginMethodUpgrade   = "upgrade"
+	pluginMethodScaleUp   = "scale_up"
+	pluginMethodScaleDown = "scale_down"
+)
+
+// Types of messages that plugins write to the standard output.
+const (
+	pluginMessageHandshake = "handshake"
+	pluginMessageLog       = "log"
+	pluginMessageError     = "error"
+)
+
+// pluginRequest is written as JSON to the standard input of the plugin
+// on each call.
+type pluginRequest struct {
+	ProtocolVersion  int           `json:"protocolVersion,omitempty"`
+	ProtocolVersions []int         `json:"protocolVersions,omitempty"`
+	Method           string        `json:"method"`
+	Cluster          pluginCluster `json:"cluster"`
+	Config           any           `json:"config,omitempty"`
+	InfraConfig      any           `json:"infraConfig,omitempty"`
+	Events           []pluginEvent `json:"events,omitempty"`
+}
+
+// pluginCluster contains cluster paths. Plugins are expected to write
+// the cluster kubeconfig into the config directory (admin.conf).
+type pluginCluster struct {
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	ConfigDir         string `json:"configDir"`
+	CacheDir          string `json:"cacheDir"`
+	ShareDir          string `json:"shareDir"`
+	SshPrivateKeyPath string `json:"sshPrivateKeyPath"`
+}
+
+// pluginEvent is a configuration change that triggered the call.
+type pluginEvent struct {
+	Action string `json:"action"`
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// pluginMessage is a single line of JSON written by the plugin to the
+// standard output.
+type pluginMessage struct {
+	Type            string `json:"type"`
+	Level           string `json:"level,omitempty"`
+	Message         string `json:"message,omitempty"`
+	ProtocolVersion int    `json:"protocolVersion,omitempty"`
+}
+
+type plugin struct {
+	common
+
+	Name string
+
+	// Path to the plugin executable. If empty, the plugin is looked up
+	// during initialization.
+	Path string
+
+	// Negotiated protocol version.
+	ProtocolVersion int
+}
+
+func NewPluginManager(
+	name string,
+	clusterName string,
+	clusterPath string,
+	sshPrivateKeyPath string,
+	configDir string,
+	cacheDir string,
+	sharedDir string,
+	cfg *config.Config,
+	infraCfg *infra.Config,
+) *plugin {
+	return &plugin{
+		Name: name,
+		common: common{
+			ClusterName:       clusterName,
+			ClusterPath:       clusterPath,
+			SshPrivateKeyPath: sshPrivateKeyPath,
+			ConfigDir:         configDir,
+			CacheDir:          cacheDir,
+			SharedDir:         sharedDir,
+			Config:            cfg,
+			InfraConfig:       infraCfg,
+		},
+	}
+}
+
+// Init looks up the plugin executable, negotiates the protocol version
+// and initializes the plugin.
+func (e *plugin) Init() error {
+	if e.Path == "" {
+		path, err := findPlugin(e.Name, e.SharedDir)
+		if err != nil {
+			return err
+		}
+
+		e.Path = path
+	}
+
+	if e.ProtocolVersion == 0 {
+		err := e.handshake()
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.call(pluginMethodInit, nil)
+}
+
+func (e *plugin) Sync() error {
+	return e.call(pluginMethodSync, nil)
+}
+
+func (e *plugin) Create() error {
+	err := e.call(pluginMethodCreate, nil)
+	if err != nil {
+		return err
+	}
+
+	if e.Config.Kubernetes.Other.MergeKubeconfig {
+		err := e.mergeKubeconfig()
+		if err != nil {
+			// Just warn about failure, since deployment has succeeded.
+			ui.Print(ui.WARN, "Failed to merge kubeconfig:", err)
+		}
+	}
+
+	return nil
+}
+
+func (e *plugin) Upgrade() error {
+	return e.call(pluginMethodUpgrade, nil)
+}
+
+func (e *plugin) ScaleUp(events event.Events) error {
+	return e.call(pluginMethodScaleUp, events.FilterByAction(event.Action_ScaleUp))
+}
+
+func (e *plugin) ScaleDown(events event.Events) error {
+	return e.call(pluginMethodScaleDown, events.FilterByAction(event.Action_ScaleDown))
+}
+
+// handshake negotiates the protocol version with the plugin. The plugin
+// receives all protocol versions supported by Kubitect and responds with
+// the one it uses.
+func (e *plugin) handshake() error {
+	req := pluginRequest{
+		Method:           pluginMethodHandshake,
+		ProtocolVersions: pluginProtocolVersions,
+		Cluster:          e.cluster(),
+	}
+
+	msgs, err := e.exec(req)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		if m.Type != pluginMessageHandshake {
+			continue
+		}
+
+		if !slices.Contains(pluginProtocolVersions, m.ProtocolVersion) {
+			return e.error(pluginMethodHandshake, fmt.Sprintf(
+				"Plugin uses protocol version %d, while Kubitect supports versions %v.",
+				m.ProtocolVersion, pluginProtocolVersions,
+			))
+		}
+
+		e.ProtocolVersion = m.ProtocolVersion
+		return nil
+	}
+
+	return e.error(pluginMethodHandshake, "Plugin did not respond with a protocol version.")
+}
+
+// call calls the given plugin method with the current configuration and
+// the given events.
+func (e *plugin) call(method string, events event.Events) error {
+	req := pluginRequest{
+		ProtocolVersion: e.ProtocolVersion,
+		Method:          method,
+		Cluster:         e.cluster(),
+	}
+
+	var err error
+
+	req.Config, err = toPluginValue(e.Config)
+	if err != nil {
+		return err
+	}
+
+	if e.InfraConfig != nil {
+		req.InfraConfig, err = toPluginValue(e.InfraConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ev := range events {
+		pe := pluginEvent{
+			Action: string(ev.Rule.ActionType),
+			Type:   string(ev.Change.Type),
+			Path:   ev.Change.Path,
+		}
+
+		pe.Before, err = toPluginValue(ev.Change.ValueBefore)
+		if err != nil {
+			return err
+		}
+
+		pe.After, err = toPluginValue(ev.Change.ValueAfter)
+		if err != nil {
+			return err
+		}
+
+		req.Events = append(req.Events, pe)
+	}
+
+	_, err = e.exec(req)
+	return err
+}
+
+// exec runs the plugin with the given request on the standard input.
+// Logs are printed as they are received, while errors reported by the
+// plugin are returned once the plugin exits.
+func (e *plugin) exec(req pluginRequest) ([]pluginMessage, error) {
+	stdin, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pluginOutput{}
+
+	c := exec.NewLocalClient().WithWorkingDir(e.ClusterPath)
+	c.SetStdin(bytes.NewReader(stdin))
+	c.SetStdout(out)
+	c.SetStderr(ui.Streams().Err().File())
+
+	runErr := c.Run(e.Path)
+	out.Flush()
+
+	var errs []string
+	for _, m := range out.messages {
+		if m.Type == pluginMessageError {
+			errs = append(errs, m.Message)
+		}
+	}
+
+	if runErr != nil {
+		errs = append(errs, runErr.Error())
+	}
+
+	if len(errs) > 0 {
+		return nil, e.error(req.Method, errs...)
+	}
+
+	return out.messages, nil
+}
+
+// error returns an error block describing the failed plugin call.
+func (e *plugin) error(method string, errs ...string) error {
+	return ui.NewErrorBlock(ui.ERROR,
+		[]ui.Content{
+			ui.NewErrorLine("Error type:", "Manager Plugin Error"),
+			ui.NewErrorSection("Plugin:", e.Path),
+			ui.NewErrorSection("Method:", method),
+			ui.NewErrorSection("Error:", errs...),
+		},
+	)
+}
+
+func (e *plugin) cluster() pluginCluster {
+	return pluginCluster{
+		Name:              e.ClusterName,
+		Path:              e.ClusterPath,
+		ConfigDir:         e.ConfigDir,
+		CacheDir:          e.CacheDir,
+		ShareDir:          e.SharedDir,
+		SshPrivateKeyPath: e.SshPrivateKeyPath,
+	}
+}
+
+// pluginOutput parses lines written by the plugin. Lines that are not
+// valid plugin messages are printed as they are.
+type pluginOutput struct {
+	buf      []byte
+	messages []pluginMessage
+}
+
+func (o *pluginOutput) Write(p []byte) (int, error) {
+	o.buf = append(o.buf, p...)
+
+	for {
+		i := bytes.IndexByte(o.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		o.handle(o.buf[:i])
+		o.buf = o.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush handles the remaining output that does not end with a newline.
+func (o *pluginOutput) Flush() {
+	if len(o.buf) > 0 {
+		o.handle(o.buf)
+		o.buf = nil
+	}
+}
+
+func (o *pluginOutput) handle(line []byte) {
+	var m pluginMessage
+
+	err := json.Unmarshal(line, &m)
+	if err != nil || m.Type == "" {
+		ui.Println(ui.INFO, string(line))
+		return
+	}
+
+	o.messages = append(o.messages, m)
+
+	switch m.Type {
+	case pluginMessageLog:
+		ui.Println(pluginLogLevel(m.Level), m.Message)
+	case pluginMessageError:
+		ui.Println(ui.DEBUG, m.Message)
+	}
+}
+
+// pluginLogLevel converts the log level of a plugin message into a UI
+// level. Unknown levels are printed as info.
+func pluginLogLevel(level string) ui.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return ui.DEBUG
+	case "warn", "warning":
+		return ui.WARN
+	case "error":
+		return ui.ERROR
+	default:
+		return ui.INFO
+	}
+}
+
+// findPlugin returns the path of the plugin executable for the manager
+// with the given name. Plugins in the plugins directory within the
+// shared directory take precedence over the ones found in PATH.
+func findPlugin(name string, sharedDir string) (string, error) {
+	bin := pluginPrefix + name
+
+	if sharedDir != "" {
+		path := filepath.Join(sharedDir, "plugins", bin)
+
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return path, nil
+		}
+	}
+
+	path, err := osexec.LookPath(bin)
+	if err != nil {
+		return "", fmt.Errorf("manager plugin %q not found in %q or PATH", bin, filepath.Join(sharedDir, "plugins"))
+	}
+
+	return path, nil
+}
+
+// toPluginValue converts the given value into a JSON compatible value,
+// where field names match the ones in the configuration file.
+func toPluginValue(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	err = yaml.Unmarshal(data, &out)
+	return out, err
+}
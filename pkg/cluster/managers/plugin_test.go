@@ -0,0 +1,144 @@
+package managers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPluginScript stores each request into the requests directory and
+// responds to the handshake with the given protocol version.
+const mockPluginScript = `#!/bin/sh
+req=$(cat)
+n=$(ls "%[1]s" | wc -l)
+echo "$req" > "%[1]s/$n.json"
+case "$req" in
+	*'"method":"handshake"'*)
+		echo '{"type":"handshake","protocolVersion":%[2]d}'
+		;;
+	*'"method":"create"'*)
+		echo 'plain output'
+		echo '{"type":"log","level":"warn","message":"creating"}'
+		echo '{"type":"error","message":"create failed"}'
+		;;
+esac
+`
+
+func MockPluginManager(t *testing.T, protocolVersion int) (*plugin, string) {
+	tmpDir := t.TempDir()
+	reqDir := filepath.Join(tmpDir, "requests")
+	require.NoError(t, os.MkdirAll(reqDir, 0700))
+
+	sharedDir := filepath.Join(tmpDir, "share")
+	pluginPath := filepath.Join(sharedDir, "plugins", "kubitect-manager-mock")
+	require.NoError(t, os.MkdirAll(filepath.Dir(pluginPath), 0700))
+
+	script := fmt.Sprintf(mockPluginScript, reqDir, protocolVersion)
+	require.NoError(t, os.WriteFile(pluginPath, []byte(script), 0700))
+
+	cfg := &config.Config{}
+	cfg.Kubernetes.Version = "v1.33.4"
+	cfg.Kubernetes.Manager = "mock"
+
+	e := NewPluginManager("mock", "mock", tmpDir, "id_rsa", "", "", sharedDir, cfg, &infra.Config{})
+
+	return e, reqDir
+}
+
+// readPluginRequest reads the n-th request received by the mock plugin.
+func readPluginRequest(t *testing.T, reqDir string, n int) map[string]any {
+	data, err := os.ReadFile(filepath.Join(reqDir, fmt.Sprintf("%d.json", n)))
+	require.NoError(t, err)
+
+	var req map[string]any
+	require.NoError(t, json.Unmarshal(data, &req))
+	return req
+}
+
+func TestPlugin_Init(t *testing.T) {
+	e, reqDir := MockPluginManager(t, 1)
+	require.NoError(t, e.Init())
+	assert.Equal(t, 1, e.ProtocolVersion)
+
+	handshake := readPluginRequest(t, reqDir, 0)
+	assert.Equal(t, "handshake", handshake["method"])
+	assert.Equal(t, []any{float64(1)}, handshake["protocolVersions"])
+
+	init := readPluginRequest(t, reqDir, 1)
+	assert.Equal(t, "init", init["method"])
+	assert.Equal(t, float64(1), init["protocolVersion"])
+	assert.Equal(t, "id_rsa", init["cluster"].(map[string]any)["sshPrivateKeyPath"])
+
+	// Configuration is passed with field names of the configuration file.
+	k8s := init["config"].(map[string]any)["kubernetes"].(map[string]any)
+	assert.Equal(t, "v1.33.4", k8s["version"])
+	assert.Equal(t, "mock", k8s["manager"])
+}
+
+func TestPlugin_UnsupportedProtocolVersion(t *testing.T) {
+	e, _ := MockPluginManager(t, 9)
+	assert.ErrorContains(t, e.Init(), "Plugin uses protocol version 9, while Kubitect supports versions [1].")
+}
+
+func TestPlugin_NotFound(t *testing.T) {
+	e := NewPluginManager("missing", "mock", "", "", "", "", t.TempDir(), &config.Config{}, nil)
+	assert.ErrorContains(t, e.Init(), `manager plugin "kubitect-manager-missing" not found`)
+}
+
+func TestPlugin_Error(t *testing.T) {
+	e, _ := MockPluginManager(t, 1)
+	require.NoError(t, e.Init())
+
+	err := e.Create()
+	assert.ErrorContains(t, err, "Manager Plugin Error")
+	assert.ErrorContains(t, err, "create failed")
+}
+
+func TestPlugin_ScaleUp(t *testing.T) {
+	e, reqDir := MockPluginManager(t, 1)
+	require.NoError(t, e.Init())
+
+	w := config.WorkerInstance{Id: "1", IP: "192.168.113.20"}
+	events := MockEvents(t, w, event.Action_ScaleUp)
+	events = append(events, MockEvents(t, w, event.Action_ScaleDown)...)
+
+	require.NoError(t, e.ScaleUp(events))
+
+	req := readPluginRequest(t, reqDir, 2)
+	assert.Equal(t, "scale_up", req["method"])
+
+	evs := req["events"].([]any)
+	require.Len(t, evs, 1)
+	assert.Equal(t, "scale_up", evs[0].(map[string]any)["action"])
+	assert.Equal(t, "192.168.113.20", evs[0].(map[string]any)["after"].(map[string]any)["ip"])
+}
+
+func TestPluginOutput(t *testing.T) {
+	out := &pluginOutput{}
+
+	_, err := out.Write([]byte("{\"type\":\"log\",\"message\":\"a\"}\nplain\n{\"type\":\"err"))
+	require.NoError(t, err)
+	require.Len(t, out.messages, 1)
+
+	_, err = out.Write([]byte("or\",\"message\":\"b\"}"))
+	require.NoError(t, err)
+	require.Len(t, out.messages, 1)
+
+	out.Flush()
+	require.Len(t, out.messages, 2)
+	assert.Equal(t, pluginMessage{Type: "error", Message: "b"}, out.messages[1])
+}
+
+func TestPluginLogLevel(t *testing.T) {
+	assert.Equal(t, pluginLogLevel("WARNING"), pluginLogLevel("warn"))
+	assert.Equal(t, pluginLogLevel("info"), pluginLogLevel("unknown"))
+}
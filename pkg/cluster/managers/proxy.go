@@ -0,0 +1,54 @@
+package managers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+)
+
+// kubesprayProxy returns Kubespray variables that configure the proxy.
+// Kubespray additionally excludes cluster nodes and internal Kubernetes
+// addresses from the proxy.
+func kubesprayProxy(cfg *config.Config) map[string]any {
+	vars := make(map[string]any)
+
+	if cfg.Proxy.IsEmpty() {
+		return vars
+	}
+
+	if cfg.Proxy.HTTP != "" {
+		vars["http_proxy"] = string(cfg.Proxy.HTTP)
+	}
+
+	if cfg.Proxy.HTTPS != "" {
+		vars["https_proxy"] = string(cfg.Proxy.HTTPS)
+	}
+
+	vars["additional_no_proxy"] = strings.Join(cfg.NoProxy(), ",")
+
+	return vars
+}
+
+// k3sProxy returns k3s-ansible variables that configure the proxy. Proxy
+// environment variables are set for the k3s service, which also passes
+// them to the embedded container runtime.
+func k3sProxy(cfg *config.Config) map[string]any {
+	vars := make(map[string]any)
+
+	env := cfg.ProxyEnv()
+	if env == nil {
+		return vars
+	}
+
+	var envs []string
+	for k, v := range env {
+		envs = append(envs, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	sort.Strings(envs)
+	vars["extra_service_envs"] = envs
+
+	return vars
+}
@@ -0,0 +1,62 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubesprayProxy(t *testing.T) {
+	e := MockManager(t)
+	assert.Empty(t, kubesprayProxy(e.Config))
+
+	e.Config.Cluster.Network.CIDR = "192.168.113.0/24"
+	e.Config.Proxy.HTTPS = "http://proxy.example.com:3128"
+	e.Config.Proxy.NoProxy = []string{".example.com"}
+
+	expect := map[string]any{
+		"https_proxy":         "http://proxy.example.com:3128",
+		"additional_no_proxy": "localhost,127.0.0.1,192.168.113.0/24,.example.com",
+	}
+
+	assert.Equal(t, expect, kubesprayProxy(e.Config))
+}
+
+func TestK3sProxy(t *testing.T) {
+	e := MockK3sManager(t)
+	assert.Empty(t, k3sProxy(e.Config))
+
+	e.Config.Cluster.Network.CIDR = "192.168.113.0/24"
+	e.Config.Proxy.HTTP = "http://proxy.example.com:3128"
+
+	expect := map[string]any{
+		"extra_service_envs": []string{
+			"HTTP_PROXY=http://proxy.example.com:3128",
+			"NO_PROXY=localhost,127.0.0.1,192.168.113.0/24",
+			"http_proxy=http://proxy.example.com:3128",
+			"no_proxy=localhost,127.0.0.1,192.168.113.0/24",
+		},
+	}
+
+	assert.Equal(t, expect, k3sProxy(e.Config))
+}
+
+func TestKubesprayGroupVars_Proxy(t *testing.T) {
+	e := MockManager(t)
+	proxyPath := filepath.Join(e.ConfigDir, "group_vars", "all", "proxy.yaml")
+
+	e.Config.Proxy.HTTP = "http://proxy.example.com:3128"
+	require.NoError(t, e.generateGroupVars())
+
+	out, err := os.ReadFile(proxyPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "http_proxy: http://proxy.example.com:3128")
+
+	// Variables are removed once the proxy is unset.
+	e.Config.Proxy.HTTP = ""
+	require.NoError(t, e.generateGroupVars())
+	assert.NoFileExists(t, proxyPath)
+}
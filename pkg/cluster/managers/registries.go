@@ -0,0 +1,289 @@
+package managers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+)
+
+// registryCertsDir is a directory on the nodes where CA certificates of
+// container registries are copied by Kubitect playbooks.
+const registryCertsDir = "/etc/kubitect/registries"
+
+// registryCert is a CA certificate of a registry that is copied from the
+// local path to the nodes.
+type registryCert struct {
+	Registry string `yaml:"registry"`
+	Src      string `yaml:"src"`
+}
+
+// registryCertPath returns the path of the registry CA certificate on
+// the nodes.
+func registryCertPath(registry string) string {
+	return path.Join(registryCertsDir, registry, "ca.crt")
+}
+
+// registryCerts returns CA certificates of the configured registries.
+func registryCerts(regs config.Registries) ([]registryCert, error) {
+	var certs []registryCert
+
+	for _, c := range regs.Configs {
+		if c.CA == "" {
+			continue
+		}
+
+		src, err := localPath(string(c.CA))
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, registryCert{
+			Registry: c.Registry,
+			Src:      src,
+		})
+	}
+
+	return certs, nil
+}
+
+// registryPassword returns an Ansible lookup that reads the registry
+// password on the local machine whenever a playbook is run. This way,
+// the password is never written into the cluster directory. An error
+// is returned if the password source is not available.
+func registryPassword(a config.RegistryAuth) (string, error) {
+	if a.PasswordEnv != "" {
+		if _, ok := os.LookupEnv(a.PasswordEnv); !ok {
+			return "", fmt.Errorf("registry password: environment variable %q is not set", a.PasswordEnv)
+		}
+
+		return fmt.Sprintf("{{ lookup('env', '%s') }}", a.PasswordEnv), nil
+	}
+
+	p, err := localPath(string(a.PasswordFile))
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.ReadFile(p); err != nil {
+		return "", fmt.Errorf("registry password: %v", err)
+	}
+
+	return fmt.Sprintf("{{ lookup('file', %q) }}", p), nil
+}
+
+// registryConfig returns the configuration of the registry with the
+// given host, or nil if the registry is not configured.
+func registryConfig(regs config.Registries, host string) *config.RegistryConfig {
+	for i := range regs.Configs {
+		if regs.Configs[i].Registry == host {
+			return &regs.Configs[i]
+		}
+	}
+
+	return nil
+}
+
+// endpointHost returns the host (with port) of the registry endpoint.
+func endpointHost(endpoint config.URL) string {
+	u, err := url.Parse(string(endpoint))
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
+
+// k3sRegistries returns the content of the k3s registries configuration
+// file (registries.yaml).
+func k3sRegistries(regs config.Registries) (map[string]any, error) {
+	mirrors := make(map[string]any)
+	for _, m := range regs.Mirrors {
+		mirrors[m.Registry] = map[string]any{
+			"endpoint": m.Endpoints,
+		}
+	}
+
+	configs := make(map[string]any)
+	for _, c := range regs.Configs {
+		cfg := make(map[string]any)
+
+		if c.Auth.Username != "" {
+			pass, err := registryPassword(c.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("registry %q: %v", c.Registry, err)
+			}
+
+			cfg["auth"] = map[string]any{
+				"username": c.Auth.Username,
+				"password": pass,
+			}
+		}
+
+		tls := make(map[string]any)
+		if c.CA != "" {
+			tls["ca_file"] = registryCertPath(c.Registry)
+		}
+
+		if c.InsecureSkipVerify {
+			tls["insecure_skip_verify"] = true
+		}
+
+		if len(tls) > 0 {
+			cfg["tls"] = tls
+		}
+
+		configs[c.Registry] = cfg
+	}
+
+	out := make(map[string]any)
+
+	if len(mirrors) > 0 {
+		out["mirrors"] = mirrors
+	}
+
+	if len(configs) > 0 {
+		out["configs"] = configs
+	}
+
+	return out, nil
+}
+
+// kubesprayRegistryHost is a registry host in the containerd hosts
+// configuration generated by Kubespray.
+type kubesprayRegistryHost struct {
+	Host         string   `yaml:"host"`
+	Capabilities []string `yaml:"capabilities"`
+	SkipVerify   bool     `yaml:"skip_verify"`
+	CA           []string `yaml:"ca,omitempty"`
+}
+
+// kubesprayRegistryMirror is an item of Kubespray's
+// containerd_registries_mirrors variable.
+type kubesprayRegistryMirror struct {
+	Prefix  string                  `yaml:"prefix"`
+	Mirrors []kubesprayRegistryHost `yaml:"mirrors"`
+}
+
+// kubesprayRegistryAuth is an item of Kubespray's containerd_registry_auth
+// variable.
+type kubesprayRegistryAuth struct {
+	Registry string `yaml:"registry"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// kubesprayRegistryHostFor returns the containerd host configuration of
+// the given endpoint, including TLS settings of the registry configured
+// on the same host.
+func kubesprayRegistryHostFor(regs config.Registries, endpoint config.URL) kubesprayRegistryHost {
+	h := kubesprayRegistryHost{
+		Host:         string(endpoint),
+		Capabilities: []string{"pull", "resolve"},
+	}
+
+	if c := registryConfig(regs, endpointHost(endpoint)); c != nil {
+		h.SkipVerify = c.InsecureSkipVerify
+		if c.CA != "" {
+			h.CA = []string{registryCertPath(c.Registry)}
+		}
+	}
+
+	return h
+}
+
+// kubesprayRegistries returns Kubespray variables that configure
+// containerd registries. Registries that are not mirrored, but require
+// TLS configuration, are configured as their own mirrors.
+func kubesprayRegistries(regs config.Registries) (map[string]any, error) {
+	var mirrors []kubesprayRegistryMirror
+	var auths []kubesprayRegistryAuth
+
+	for _, m := range regs.Mirrors {
+		mirror := kubesprayRegistryMirror{Prefix: m.Registry}
+		for _, e := range m.Endpoints {
+			mirror.Mirrors = append(mirror.Mirrors, kubesprayRegistryHostFor(regs, e))
+		}
+
+		mirrors = append(mirrors, mirror)
+	}
+
+	for _, c := range regs.Configs {
+		if c.CA != "" || c.InsecureSkipVerify {
+			mirrored := false
+			for _, m := range regs.Mirrors {
+				mirrored = mirrored || m.Registry == c.Registry
+			}
+
+			if !mirrored {
+				endpoint := config.URL("https://" + c.Registry)
+				mirrors = append(mirrors, kubesprayRegistryMirror{
+					Prefix:  c.Registry,
+					Mirrors: []kubesprayRegistryHost{kubesprayRegistryHostFor(regs, endpoint)},
+				})
+			}
+		}
+
+		if c.Auth.Username == "" {
+			continue
+		}
+
+		pass, err := registryPassword(c.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("registry %q: %v", c.Registry, err)
+		}
+
+		auths = append(auths, kubesprayRegistryAuth{
+			Registry: c.Registry,
+			Username: c.Auth.Username,
+			Password: pass,
+		})
+	}
+
+	vars := make(map[string]any)
+
+	if len(mirrors) > 0 {
+		vars["containerd_registries_mirrors"] = mirrors
+	}
+
+	if len(auths) > 0 {
+		vars["containerd_registry_auth"] = auths
+	}
+
+	return vars, nil
+}
+
+// writeRegistryVars writes the given registry variables together with
+// registry CA certificates into an Ansible variables file. If there are
+// no variables, the file is removed.
+func writeRegistryVars(regs config.Registries, vars map[string]any, dstPath string) error {
+	certs, err := registryCerts(regs)
+	if err != nil {
+		return err
+	}
+
+	if len(certs) > 0 {
+		vars["registry_certs"] = certs
+	}
+
+	return writeVars(vars, dstPath)
+}
+
+// localPath returns an absolute path of the local file, with the leading
+// "~" replaced by the home directory of the current user.
+func localPath(p string) (string, error) {
+	if strings.HasPrefix(p, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		p = strings.Replace(p, "~", home, 1)
+	}
+
+	return filepath.Abs(p)
+}
@@ -0,0 +1,172 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func MockRegistries(t *testing.T) config.Registries {
+	t.Helper()
+
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(ca, []byte("cert"), 0600))
+
+	t.Setenv("REGISTRY_PASSWORD", "secret")
+
+	return config.Registries{
+		Mirrors: []config.RegistryMirror{
+			{
+				Registry:  "docker.io",
+				Endpoints: []config.URL{"https://mirror.example.com:5000"},
+			},
+		},
+		Configs: []config.RegistryConfig{
+			{
+				Registry: "mirror.example.com:5000",
+				Auth: config.RegistryAuth{
+					Username:    "user",
+					PasswordEnv: "REGISTRY_PASSWORD",
+				},
+				CA: config.File(ca),
+			},
+			{
+				Registry:           "registry.local",
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+}
+
+func TestK3sRegistries(t *testing.T) {
+	regs := MockRegistries(t)
+
+	expect := map[string]any{
+		"mirrors": map[string]any{
+			"docker.io": map[string]any{
+				"endpoint": []config.URL{"https://mirror.example.com:5000"},
+			},
+		},
+		"configs": map[string]any{
+			"mirror.example.com:5000": map[string]any{
+				"auth": map[string]any{
+					"username": "user",
+					"password": "{{ lookup('env', 'REGISTRY_PASSWORD') }}",
+				},
+				"tls": map[string]any{
+					"ca_file": "/etc/kubitect/registries/mirror.example.com:5000/ca.crt",
+				},
+			},
+			"registry.local": map[string]any{
+				"tls": map[string]any{
+					"insecure_skip_verify": true,
+				},
+			},
+		},
+	}
+
+	out, err := k3sRegistries(regs)
+	require.NoError(t, err)
+	assert.Equal(t, expect, out)
+}
+
+func TestKubesprayRegistries(t *testing.T) {
+	regs := MockRegistries(t)
+
+	expect := map[string]any{
+		"containerd_registries_mirrors": []kubesprayRegistryMirror{
+			{
+				Prefix: "docker.io",
+				Mirrors: []kubesprayRegistryHost{
+					{
+						Host:         "https://mirror.example.com:5000",
+						Capabilities: []string{"pull", "resolve"},
+						CA:           []string{"/etc/kubitect/registries/mirror.example.com:5000/ca.crt"},
+					},
+				},
+			},
+			{
+				Prefix: "mirror.example.com:5000",
+				Mirrors: []kubesprayRegistryHost{
+					{
+						Host:         "https://mirror.example.com:5000",
+						Capabilities: []string{"pull", "resolve"},
+						CA:           []string{"/etc/kubitect/registries/mirror.example.com:5000/ca.crt"},
+					},
+				},
+			},
+			{
+				Prefix: "registry.local",
+				Mirrors: []kubesprayRegistryHost{
+					{
+						Host:         "https://registry.local",
+						Capabilities: []string{"pull", "resolve"},
+						SkipVerify:   true,
+					},
+				},
+			},
+		},
+		"containerd_registry_auth": []kubesprayRegistryAuth{
+			{
+				Registry: "mirror.example.com:5000",
+				Username: "user",
+				Password: "{{ lookup('env', 'REGISTRY_PASSWORD') }}",
+			},
+		},
+	}
+
+	out, err := kubesprayRegistries(regs)
+	require.NoError(t, err)
+	assert.Equal(t, expect, out)
+}
+
+func TestRegistryPassword_File(t *testing.T) {
+	pass := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(pass, []byte("secret"), 0600))
+
+	out, err := registryPassword(config.RegistryAuth{PasswordFile: config.File(pass)})
+	require.NoError(t, err)
+	assert.Equal(t, "{{ lookup('file', \""+pass+"\") }}", out)
+	assert.NotContains(t, out, "secret")
+}
+
+func TestRegistryPassword_Missing(t *testing.T) {
+	_, err := registryPassword(config.RegistryAuth{PasswordEnv: "KUBITECT_TEST_MISSING_PASSWORD"})
+	assert.EqualError(t, err, `registry password: environment variable "KUBITECT_TEST_MISSING_PASSWORD" is not set`)
+
+	_, err = registryPassword(config.RegistryAuth{PasswordFile: config.File(filepath.Join(t.TempDir(), "missing"))})
+	assert.ErrorContains(t, err, "no such file or directory")
+}
+
+func TestWriteRegistryVars(t *testing.T) {
+	regs := MockRegistries(t)
+	dst := filepath.Join(t.TempDir(), "group_vars", "all", "registries.yaml")
+
+	require.NoError(t, writeRegistryVars(regs, map[string]any{}, dst))
+
+	out, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "registry_certs:")
+	assert.Contains(t, string(out), "src: "+string(regs.Configs[0].CA))
+
+	// File is removed when no registries are configured.
+	require.NoError(t, writeRegistryVars(config.Registries{}, map[string]any{}, dst))
+	assert.NoFileExists(t, dst)
+}
+
+func TestKubesprayGroupVars_Registries(t *testing.T) {
+	e := MockManager(t)
+	e.Config.Kubernetes.Registries = MockRegistries(t)
+
+	require.NoError(t, e.generateGroupVars())
+
+	out, err := os.ReadFile(filepath.Join(e.ConfigDir, "group_vars", "k8s_cluster", "registries.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "containerd_registries_mirrors:")
+	assert.NotContains(t, string(out), "secret")
+}
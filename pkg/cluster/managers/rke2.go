@@ -0,0 +1,365 @@
+package managers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/ui"
+)
+
+// Paths on RKE2 server nodes.
+const (
+	rke2Kubeconfig = "/etc/rancher/rke2/rke2.yaml"
+	rke2TokenPath  = "/var/lib/rancher/rke2/server/node-token"
+)
+
+// rke2Kubectl is a kubectl command that uses the admin kubeconfig on
+// server nodes.
+const rke2Kubectl = "/var/lib/rancher/rke2/bin/kubectl --kubeconfig " + rke2Kubeconfig
+
+// rke2ControlPlaneTaint is applied to server nodes when the cluster has
+// dedicated worker nodes.
+const rke2ControlPlaneTaint = "node-role.kubernetes.io/control-plane:NoSchedule"
+
+type rke2 struct {
+	sshCommon
+}
+
+func NewRke2Manager(
+	clusterName string,
+	clusterPath string,
+	sshPrivateKeyPath string,
+	configDir string,
+	cacheDir string,
+	sharedDir string,
+	b *bundle.Bundle,
+	cfg *config.Config,
+	infraCfg *infra.Config,
+) *rke2 {
+	return &rke2{
+		sshCommon: sshCommon{common: common{
+			ClusterName:       clusterName,
+			ClusterPath:       clusterPath,
+			SshPrivateKeyPath: sshPrivateKeyPath,
+			ConfigDir:         configDir,
+			CacheDir:          cacheDir,
+			SharedDir:         sharedDir,
+			OfflineBundle:     b,
+			Config:            cfg,
+			InfraConfig:       infraCfg,
+		}},
+	}
+}
+
+// Sync generates Ansible inventory, which is used by Kubitect playbooks
+// that install addons.
+func (e *rke2) Sync() error {
+	values := struct {
+		InfraNodes config.Nodes
+	}{
+		InfraNodes: e.InfraConfig.Nodes,
+	}
+
+	return NewTemplate("rke2/inventory.yaml", values).Write(filepath.Join(e.ConfigDir, "nodes.yaml"))
+}
+
+// Create configures load balancers, bootstraps the first server node
+// and joins the remaining servers and agents using the token of the
+// first server. Nodes register through the control plane endpoint.
+func (e *rke2) Create() error {
+	err := e.configureLoadBalancers(6443, 9345)
+	if err != nil {
+		return err
+	}
+
+	nodes := e.nodes()
+	leader := nodes[0]
+
+	err = e.install(leader, "", false)
+	if err != nil {
+		return err
+	}
+
+	err = e.join(nodes[1:])
+	if err != nil {
+		return err
+	}
+
+	err = e.fetchKubeconfig(leader.IP, rke2Kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	// Kubeconfig points to the local API server.
+	err = e.common.rewriteKubeconfig(map[string]string{
+		"https://127.0.0.1:6443": fmt.Sprintf("https://%s:6443", e.endpoint()),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = e.finalize(leader.IP, rke2Kubectl)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite kubeconfig before merging to prevent accidental
+	// overwrite of an existing configuration.
+	err = e.rewriteKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	if e.Config.Kubernetes.Other.MergeKubeconfig {
+		err := e.mergeKubeconfig()
+		if err != nil {
+			// Just warn about failure, since deployment has succeeded.
+			ui.Print(ui.WARN, "Failed to merge kubeconfig:", err)
+		}
+	}
+
+	return nil
+}
+
+// Upgrade upgrades the cluster node by node, starting with server nodes.
+// Each node is drained, upgraded to the new RKE2 release and uncordoned
+// once it becomes ready.
+func (e *rke2) Upgrade() error {
+	nodes := e.nodes()
+	leader := nodes[0]
+
+	for _, n := range nodes {
+		err := e.drain(leader.IP, rke2Kubectl, n.Name)
+		if err != nil {
+			return err
+		}
+
+		err = e.install(n, "", true)
+		if err != nil {
+			return err
+		}
+
+		cmd := fmt.Sprintf("%s wait --for=condition=Ready node/%s --timeout=10m", rke2Kubectl, n.Name)
+		err = e.run(leader.IP, cmd)
+		if err != nil {
+			return fmt.Errorf("wait for node %q: %v", n.Name, err)
+		}
+
+		err = e.run(leader.IP, fmt.Sprintf("%s uncordon %s", rke2Kubectl, n.Name))
+		if err != nil {
+			return fmt.Errorf("uncordon node %q: %v", n.Name, err)
+		}
+	}
+
+	return e.finalize(leader.IP, rke2Kubectl)
+}
+
+// ScaleUp installs RKE2 on new nodes and joins them to the cluster.
+func (e *rke2) ScaleUp(events event.Events) error {
+	newNodes, err := extractNewNodes(events)
+	if err != nil {
+		return err
+	}
+
+	if len(newNodes) == 0 {
+		// No new nodes.
+		return nil
+	}
+
+	err = e.configureLoadBalancers(6443, 9345)
+	if err != nil {
+		return err
+	}
+
+	var nodes []clusterNode
+	for _, n := range e.nodes() {
+		for _, nn := range newNodes {
+			if n.Name == e.nodeName(nn) {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	return e.join(nodes)
+}
+
+// ScaleDown gracefully removes nodes from the cluster. Removed nodes are
+// drained, RKE2 services are stopped and nodes are deleted, which also
+// removes etcd members of the removed server nodes.
+func (e *rke2) ScaleDown(events event.Events) error {
+	rmNodes, err := extractRemovedNodes(events)
+	if err != nil {
+		return err
+	}
+
+	if len(rmNodes) == 0 {
+		// No removed nodes.
+		return nil
+	}
+
+	leader := e.nodes()[0].IP
+
+	for _, n := range rmNodes {
+		name := e.nodeName(n)
+
+		ip, err := e.nodeIP(name)
+		if err != nil {
+			return err
+		}
+
+		err = e.drain(leader, rke2Kubectl, name)
+		if err != nil {
+			return err
+		}
+
+		nodeType := "agent"
+		if n.GetTypeName() == "master" {
+			nodeType = "server"
+		}
+
+		err = e.run(ip, fmt.Sprintf("systemctl disable --now rke2-%s", nodeType))
+		if err != nil {
+			return fmt.Errorf("stop RKE2 on node %q: %v", name, err)
+		}
+
+		err = e.run(leader, fmt.Sprintf("%s delete node %s", rke2Kubectl, name))
+		if err != nil {
+			return fmt.Errorf("delete node %q: %v", name, err)
+		}
+	}
+
+	// No need for further cleanup. This instance will be removed.
+	return nil
+}
+
+// install installs RKE2 on the given node. If token is provided, the
+// node joins an existing cluster. Upgraded nodes are restarted and keep
+// their existing configuration.
+func (e *rke2) install(n clusterNode, token string, upgrade bool) error {
+	ui.Printf(ui.INFO, "rke2: Installing RKE2 %s on node %q...\n", e.version(), n.Name)
+
+	var cfg string
+	if !upgrade {
+		taints := make([]string, 0, len(n.Taints))
+		for _, t := range n.Taints {
+			taints = append(taints, string(t))
+		}
+
+		if n.ControlPlane && !e.schedulable() {
+			taints = append(taints, rke2ControlPlaneTaint)
+		}
+
+		var err error
+		cfg, err = populateScript("rke2/config.yaml", struct {
+			Node     clusterNode
+			Token    string
+			Endpoint string
+			CNI      config.NetworkPlugin
+			Network  k3sNetwork
+			Taints   []string
+		}{
+			Node:     n,
+			Token:    token,
+			Endpoint: e.endpoint(),
+			CNI:      e.Config.Kubernetes.NetworkPlugin,
+			Network:  k3sNetworkConfig(e.Config),
+			Taints:   taints,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	script, err := populateScript("rke2/install.sh", struct {
+		Config  string
+		Version string
+		Type    string
+		Restart bool
+	}{
+		Config:  cfg,
+		Version: e.version(),
+		Type:    rke2NodeType(n),
+		Restart: upgrade,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = e.run(n.IP, script)
+	if err != nil {
+		return fmt.Errorf("install RKE2 on node %q: %v", n.Name, err)
+	}
+
+	return nil
+}
+
+// join joins the given nodes to the cluster using the token of the
+// first server node. Server nodes are joined before agents.
+func (e *rke2) join(nodes []clusterNode) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	leader := e.nodes()[0]
+
+	token, err := e.output(leader.IP, "cat "+rke2TokenPath)
+	if err != nil {
+		return fmt.Errorf("read join token on node %q: %v", leader.Name, err)
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("join token on node %q is empty", leader.Name)
+	}
+
+	for _, n := range nodes {
+		err := e.install(n, token, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rewriteKubeconfig replaces "default" context/cluster/user in kubeconfig
+// with a cluster name.
+func (e *rke2) rewriteKubeconfig() error {
+	return e.common.rewriteKubeconfig(map[string]string{
+		"default": e.ClusterName,
+	})
+}
+
+// version returns the RKE2 release of the configured Kubernetes version.
+func (e *rke2) version() string {
+	return env.ProjectRke2Releases["v"+e.K8sVersion()]
+}
+
+// endpoint returns the control plane endpoint, which is the load
+// balancer VIP. When the cluster has no load balancers, provisioners
+// set the VIP to the IP address of the first master node.
+func (e *rke2) endpoint() string {
+	return string(e.InfraConfig.Nodes.LoadBalancer.VIP)
+}
+
+// schedulable returns true if workloads need to be scheduled on server
+// nodes, which is the case when the cluster has no worker nodes.
+func (e *rke2) schedulable() bool {
+	return len(e.InfraConfig.Nodes.Worker.Instances) == 0
+}
+
+// rke2NodeType returns the RKE2 node type (server or agent) of the given
+// node.
+func rke2NodeType(n clusterNode) string {
+	if n.ControlPlane {
+		return "server"
+	}
+
+	return "agent"
+}
@@ -0,0 +1,159 @@
+package managers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockRke2Token = "K10abc::server:def"
+
+const mockRke2Kubeconfig = `clusters:
+- cluster:
+    server: https://127.0.0.1:6443
+  name: default
+current-context: default
+`
+
+func MockRke2Manager(t *testing.T) (*rke2, *nodeRunnerMock) {
+	e, runner := MockKubeadmManager(t)
+	return &rke2{sshCommon: e.sshCommon}, runner
+}
+
+func TestNewRke2Manager(t *testing.T) {
+	e := NewRke2Manager("mock", "", "", "", "", "", nil, &config.Config{}, nil)
+	assert.NotNil(t, e)
+	assert.NoError(t, e.Init())
+	assert.NotNil(t, e.Nodes)
+	assert.Nil(t, e.Ansible)
+}
+
+func TestRke2_Create(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+	require.NoError(t, e.Create())
+
+	// Install first server, token, join server and agent, kubeconfig and
+	// Rook check.
+	assert.Equal(t, []string{
+		"192.168.113.10",
+		"192.168.113.10",
+		"192.168.113.11",
+		"192.168.113.20",
+		"192.168.113.10",
+		"192.168.113.10",
+	}, runner.hosts)
+
+	assert.Contains(t, runner.scripts[0], `INSTALL_RKE2_VERSION="v1.33.4+rke2r1" INSTALL_RKE2_TYPE="server"`)
+	assert.Contains(t, runner.scripts[0], "cni: calico")
+	assert.Contains(t, runner.scripts[0], rke2ControlPlaneTaint)
+	assert.NotContains(t, runner.scripts[0], "token:")
+	assert.Contains(t, runner.scripts[2], "server: https://192.168.113.10:9345")
+	assert.Contains(t, runner.scripts[2], `token: "`+mockRke2Token+`"`)
+	assert.Contains(t, runner.scripts[3], `INSTALL_RKE2_TYPE="agent"`)
+	assert.Contains(t, runner.scripts[3], `- "disk=ssd"`)
+	assert.NotContains(t, runner.scripts[3], "cni:")
+
+	kubeconfig, err := os.ReadFile(filepath.Join(e.ConfigDir, "admin.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(kubeconfig), "server: https://192.168.113.10:6443")
+	assert.Contains(t, string(kubeconfig), "current-context: mock\n")
+}
+
+func TestRke2_Create_DualStack(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+	e.InfraConfig.Nodes.Worker.Instances = nil
+	e.Config.Cluster.Network.CIDR = "10.0.0.0/24"
+	e.Config.Cluster.Network.CIDR6 = "2001:db8::/64"
+
+	require.NoError(t, e.install(clusterNode{Name: "n", IP: "10.0.0.1", IP6: "2001:db8::1", ControlPlane: true}, "", false))
+
+	assert.Contains(t, runner.scripts[0], "node-ip: 10.0.0.1,2001:db8::1")
+	assert.Contains(t, runner.scripts[0], "cluster-cidr: 10.42.0.0/16,")
+	assert.NotContains(t, runner.scripts[0], rke2ControlPlaneTaint)
+}
+
+func TestRke2_Upgrade(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+	require.NoError(t, e.Upgrade())
+
+	// Drain, install, wait and uncordon for each node and Rook check.
+	require.Len(t, runner.scripts, 13)
+	assert.Contains(t, runner.scripts[0], "drain mock-master-1")
+	assert.Contains(t, runner.scripts[1], "systemctl restart rke2-server")
+	assert.NotContains(t, runner.scripts[1], "config.yaml")
+	assert.Contains(t, runner.scripts[2], "wait --for=condition=Ready node/mock-master-1")
+	assert.Contains(t, runner.scripts[3], "uncordon mock-master-1")
+	assert.Contains(t, runner.scripts[9], "systemctl restart rke2-agent")
+}
+
+func TestRke2_ScaleUp(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+
+	events := MockEvents(t, e.Config.Cluster.Nodes.Worker.Instances[0], event.Action_ScaleUp)
+	require.NoError(t, e.ScaleUp(events))
+
+	// Token and install.
+	assert.Equal(t, []string{"192.168.113.10", "192.168.113.20"}, runner.hosts)
+	assert.Contains(t, runner.scripts[1], "systemctl start rke2-agent")
+}
+
+func TestRke2_ScaleDown(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+
+	// IP addresses of provisioned nodes are not set in the config.
+	e.Config.Cluster.Nodes.Master.Instances = []config.MasterInstance{{Id: "1"}, {Id: "2"}}
+
+	events := MockEvents(t, config.WorkerInstance{Id: "1"}, event.Action_ScaleDown)
+	require.NoError(t, e.ScaleDown(events))
+
+	assert.Equal(t, []string{"192.168.113.10", "192.168.113.20", "192.168.113.10"}, runner.hosts)
+	assert.Contains(t, runner.scripts[0], "drain mock-worker-1")
+	assert.Equal(t, "systemctl disable --now rke2-agent", runner.scripts[1])
+	assert.Contains(t, runner.scripts[2], "delete node mock-worker-1")
+}
+
+func TestRke2_ScaleDown_Server(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+
+	events := MockEvents(t, config.MasterInstance{Id: "2"}, event.Action_ScaleDown)
+	require.NoError(t, e.ScaleDown(events))
+
+	assert.Equal(t, []string{"192.168.113.10", "192.168.113.11", "192.168.113.10"}, runner.hosts)
+	assert.Equal(t, "systemctl disable --now rke2-server", runner.scripts[1])
+}
+
+func TestRke2_ScaleDown_UnknownNode(t *testing.T) {
+	e, runner := MockRke2Manager(t)
+
+	events := MockEvents(t, config.WorkerInstance{Id: "2"}, event.Action_ScaleDown)
+	assert.EqualError(t, e.ScaleDown(events), `IP address of node "mock-worker-2" not found in the infrastructure configuration`)
+	assert.Empty(t, runner.scripts)
+}
+
+func TestRke2Releases(t *testing.T) {
+	var versions []string
+	for _, r := range env.ProjectRke2Versions {
+		var major, minor, first, last int
+		_, err := fmt.Sscanf(r, "v%d.%d.%d - v%d.%d.%d", &major, &minor, &first, &major, &minor, &last)
+		require.NoError(t, err)
+
+		for patch := first; patch <= last; patch++ {
+			versions = append(versions, fmt.Sprintf("v%d.%d.%d", major, minor, patch))
+		}
+	}
+
+	// Each supported version has a release of the same version.
+	require.Len(t, env.ProjectRke2Releases, len(versions))
+	for _, ver := range versions {
+		assert.Regexp(t, `^`+regexp.QuoteMeta(ver)+`\+rke2r[0-9]+$`, env.ProjectRke2Releases[ver])
+	}
+}
@@ -0,0 +1,327 @@
+package managers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/tools/ansible"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/tools/virtualenv"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/exec"
+	"github.com/MusicDin/kubitect/pkg/utils/template"
+)
+
+// sshCommon provides functions that are common for managers that deploy
+// Kubernetes by running scripts on the nodes over SSH.
+type sshCommon struct {
+	common
+
+	// Nodes runs scripts on the cluster nodes.
+	Nodes nodeRunner
+}
+
+// nodeRunner runs shell scripts on the cluster nodes.
+type nodeRunner interface {
+	// Run runs the script as a super user on the node with the given
+	// IP address and writes its standard output to the given writer.
+	Run(host string, script string, stdout io.Writer) error
+}
+
+// sshRunner runs scripts on the nodes over SSH.
+type sshRunner struct {
+	User           string
+	PrivateKeyPath string
+
+	// Environment variables set for each script (e.g. proxy).
+	Env map[string]string
+}
+
+func (r sshRunner) Run(host string, script string, stdout io.Writer) error {
+	ssh := exec.NewSSHClient(r.User, host).
+		WithPrivateKeyFile(r.PrivateKeyPath).
+		WithSuperUser(true)
+
+	defer ssh.Close()
+
+	for k, v := range r.Env {
+		ssh.SetEnv(k, v)
+	}
+
+	ssh.SetStdin(strings.NewReader(script))
+	ssh.SetStdout(stdout)
+	ssh.SetStderr(ui.Streams().Err().File())
+
+	return ssh.Run("bash", "-s")
+}
+
+// Init initializes the SSH connection to the nodes. Kubernetes is
+// deployed without Ansible, therefore the virtual environment is
+// initialized only when addons, which are installed by Kubitect
+// playbooks, are used.
+func (e *sshCommon) Init() error {
+	if e.Nodes == nil {
+		e.Nodes = sshRunner{
+			User:           e.SshUser(),
+			PrivateKeyPath: e.SshPKey(),
+			Env:            e.Config.ProxyEnv(),
+		}
+	}
+
+	if !e.addonsUsed() {
+		return nil
+	}
+
+	return e.initAnsible()
+}
+
+// initAnsible initializes the virtual environment with Ansible, which
+// runs Kubitect playbooks, unless it is already initialized.
+func (e *sshCommon) initAnsible() error {
+	if e.Ansible != nil {
+		return nil
+	}
+
+	reqPath := filepath.Join(e.ClusterPath, "ansible/kubitect/requirements.txt")
+	venvPath := path.Join(e.SharedDir, "venv", "kubitect", env.ConstProjectVersion)
+	err := virtualenv.NewVirtualEnv(venvPath, reqPath).WithBundle(e.OfflineBundle).Init()
+	if err != nil {
+		return fmt.Errorf("initialize virtual environment: %v", err)
+	}
+
+	ansibleBinDir := path.Join(venvPath, "bin")
+	e.Ansible = ansible.NewAnsible(ansibleBinDir, e.CacheDir)
+
+	return nil
+}
+
+// Bundle adds pip3 requirements of Kubitect playbooks, which install
+// addons, into the bundle.
+func (e *sshCommon) Bundle(w *bundle.Writer) error {
+	reqPath := filepath.Join(e.ClusterPath, "ansible/kubitect/requirements.txt")
+	venvPath := path.Join(e.SharedDir, "venv", "kubitect", env.ConstProjectVersion)
+	return w.AddRequirements(reqPath, virtualenv.NewVirtualEnv(venvPath, reqPath).Download)
+}
+
+// addonsUsed returns true if any of the addons is enabled or Helm
+// releases, which need to be uninstalled, were previously installed.
+func (e *sshCommon) addonsUsed() bool {
+	addons := e.Config.Addons
+	if addons.Rook.Enabled || addons.MetalLB.Enabled || len(addons.Charts) > 0 {
+		return true
+	}
+
+	_, err := os.Stat(filepath.Join(e.ConfigDir, "helm", "releases.yaml"))
+	return err == nil
+}
+
+// finalize installs addons, if any are used. Rook is uninstalled by the
+// same playbook, therefore it also runs when Rook is disabled, but still
+// deployed in the cluster. This is checked using the given kubectl
+// command on the node with the given IP address.
+func (e *sshCommon) finalize(host string, kubectl string) error {
+	if !e.addonsUsed() {
+		out, err := e.output(host, kubectl+" get namespace rook-ceph --ignore-not-found --output name")
+		if err != nil {
+			return fmt.Errorf("check Rook namespace: %v", err)
+		}
+
+		if strings.TrimSpace(out) == "" {
+			return nil
+		}
+	}
+
+	err := e.initAnsible()
+	if err != nil {
+		return err
+	}
+
+	return e.Finalize()
+}
+
+// run runs the script on the node with the given IP address, while
+// streaming its output.
+func (e *sshCommon) run(host string, script string) error {
+	return e.Nodes.Run(host, script, ui.Streams().Out().File())
+}
+
+// output runs the script on the node with the given IP address and
+// returns its output.
+func (e *sshCommon) output(host string, script string) (string, error) {
+	var out bytes.Buffer
+	err := e.Nodes.Run(host, script, &out)
+	return out.String(), err
+}
+
+// clusterNode is a Kubernetes node on which scripts are run.
+type clusterNode struct {
+	Name         string
+	IP           string
+	IP6          string
+	ControlPlane bool
+	Labels       config.Labels
+	Taints       []config.Taint
+}
+
+// nodes returns provisioned master and worker nodes, where the first
+// master node is always the first node in the list.
+func (e *sshCommon) nodes() []clusterNode {
+	var nodes []clusterNode
+
+	cfg := e.Config.Cluster.Nodes
+	inf := e.InfraConfig.Nodes
+
+	for _, n := range inf.Master.Instances {
+		node := clusterNode{Name: n.Name, IP: string(n.IP), IP6: string(n.IP6), ControlPlane: true}
+		for _, i := range cfg.Master.Instances {
+			if i.Id == n.Id {
+				node.Labels = i.Labels
+				node.Taints = i.Taints
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	for _, n := range inf.Worker.Instances {
+		node := clusterNode{Name: n.Name, IP: string(n.IP), IP6: string(n.IP6)}
+		for _, i := range cfg.Worker.Instances {
+			if i.Id == n.Id {
+				node.Labels = i.Labels
+				node.Taints = i.Taints
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// nodeIP returns the IP address of the provisioned node with the given
+// name. The IP address of a node instance in the config is not set when
+// it is assigned by DHCP or IPAM.
+func (e *sshCommon) nodeIP(name string) (string, error) {
+	for _, n := range e.nodes() {
+		if n.Name == name && n.IP != "" {
+			return n.IP, nil
+		}
+	}
+
+	return "", fmt.Errorf("IP address of node %q not found in the infrastructure configuration", name)
+}
+
+// nodeName returns the name of the given node instance.
+func (e *sshCommon) nodeName(n config.Instance) string {
+	return fmt.Sprintf("%s-%s-%s", e.ClusterName, n.GetTypeName(), n.GetID())
+}
+
+// populateScript populates the embedded template on the given path with
+// the given values.
+func populateScript[T any](templatePath string, values T) (string, error) {
+	return template.Populate(NewTemplate(templatePath, values))
+}
+
+// drain drains the node with the given name using the given kubectl
+// command on the node with the given IP address.
+func (e *sshCommon) drain(host string, kubectl string, name string) error {
+	cmd := fmt.Sprintf("%s drain %s --ignore-daemonsets --delete-emptydir-data --force", kubectl, name)
+
+	err := e.run(host, cmd)
+	if err != nil {
+		return fmt.Errorf("drain node %q: %v", name, err)
+	}
+
+	return nil
+}
+
+// fetchKubeconfig copies the kubeconfig on the given path from the node
+// with the given IP address into the cluster config directory.
+func (e *sshCommon) fetchKubeconfig(host string, kubeconfigPath string) error {
+	kubeconfig, err := e.output(host, "cat "+kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("fetch kubeconfig from %q: %v", host, err)
+	}
+
+	err = os.MkdirAll(e.ConfigDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(e.ConfigDir, "admin.conf"), []byte(kubeconfig), 0600)
+}
+
+// configureLoadBalancers installs and configures HAProxy on all load
+// balancers, which forward the given control plane ports to master
+// nodes. Keepalived is configured only when the control plane endpoint
+// is a virtual IP shared among load balancers.
+func (e *sshCommon) configureLoadBalancers(ports ...int) error {
+	lb := e.InfraConfig.Nodes.LoadBalancer
+	cfg := e.Config.Cluster.Nodes.LoadBalancer
+
+	keepalived := true
+	for _, i := range lb.Instances {
+		if i.IP == lb.VIP {
+			keepalived = false
+		}
+	}
+
+	// Router ID and priority default to the values used by the HAProxy
+	// playbook.
+	routerId := uint8(51)
+	if cfg.VirtualRouterId != nil {
+		routerId = uint8(*cfg.VirtualRouterId)
+	}
+
+	for _, i := range lb.Instances {
+		ui.Printf(ui.INFO, "Configuring load balancer %q...\n", i.Name)
+
+		priority := uint8(10)
+		for _, ci := range cfg.Instances {
+			if ci.Id == i.Id && ci.Priority != nil {
+				priority = uint8(*ci.Priority)
+			}
+		}
+
+		script, err := populateScript("common/loadbalancer.sh", struct {
+			VIP          config.IPv4
+			VIP6         config.IPv6
+			Keepalived   bool
+			Interface    config.OSNetworkInterface
+			RouterId     uint8
+			Priority     uint8
+			Ports        []int
+			Masters      []config.MasterInstance
+			Workers      []config.WorkerInstance
+			ForwardPorts []config.LBPortForward
+		}{
+			VIP:          lb.VIP,
+			VIP6:         lb.VIP6,
+			Keepalived:   keepalived,
+			Interface:    e.Config.Cluster.NodeTemplate.OS.NetworkInterface,
+			RouterId:     routerId,
+			Priority:     priority,
+			Ports:        ports,
+			Masters:      e.InfraConfig.Nodes.Master.Instances,
+			Workers:      e.InfraConfig.Nodes.Worker.Instances,
+			ForwardPorts: cfg.ForwardPorts,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = e.run(string(i.IP), script)
+		if err != nil {
+			return fmt.Errorf("configure load balancer %q: %v", i.Name, err)
+		}
+	}
+
+	return nil
+}
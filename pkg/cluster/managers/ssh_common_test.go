@@ -0,0 +1,53 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureLoadBalancers(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+
+	e.Config.Cluster.NodeTemplate.OS.NetworkInterface = "ens3"
+	e.Config.Cluster.Nodes.LoadBalancer.ForwardPorts = []config.LBPortForward{
+		{Name: "http", Port: 80, TargetPort: 80, Target: config.ALL},
+	}
+	e.InfraConfig.Nodes.LoadBalancer.VIP = "192.168.113.200"
+	e.InfraConfig.Nodes.LoadBalancer.Instances = []config.LBInstance{
+		{Id: "1", Name: "mock-lb-1", IP: "192.168.113.5"},
+	}
+
+	require.NoError(t, e.configureLoadBalancers(6443, 9345))
+	require.Len(t, runner.scripts, 1)
+
+	script := runner.scripts[0]
+	assert.Contains(t, script, "server mock-master-2 192.168.113.11:6443 check fall 3 rise 2")
+	assert.Contains(t, script, "server mock-master-2 192.168.113.11:9345 check fall 3 rise 2")
+	assert.Contains(t, script, "server mock-master-1 192.168.113.10:80 check")
+	assert.Contains(t, script, "server mock-worker-1 192.168.113.20:80 check")
+	assert.Contains(t, script, "virtual_router_id 51")
+	assert.Contains(t, script, "interface ens3")
+
+	// Keepalived is not required when VIP is the load balancer IP.
+	e.InfraConfig.Nodes.LoadBalancer.VIP = "192.168.113.5"
+	require.NoError(t, e.configureLoadBalancers(6443))
+	assert.NotContains(t, runner.scripts[1], "keepalived")
+}
+
+func TestFinalize_RookInstalled(t *testing.T) {
+	e, runner := MockKubeadmManager(t)
+	e.Ansible = &invalidAnsibleMock{}
+
+	// Playbook is skipped when no addons are used or deployed.
+	require.NoError(t, e.finalize("192.168.113.10", kubeadmKubectl))
+	require.Len(t, runner.scripts, 1)
+	assert.Contains(t, runner.scripts[0], "get namespace rook-ceph")
+
+	// Playbook uninstalls Rook that is still deployed.
+	runner.rook = true
+	assert.Error(t, e.finalize("192.168.113.10", kubeadmKubectl))
+}
@@ -3,6 +3,7 @@ package managers
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/MusicDin/kubitect/pkg/env"
@@ -35,6 +36,32 @@ func TestKubesprayTemplate_K8sCluster(t *testing.T) {
 	assert.Contains(t, pop, "auto_renew_certificates: false")
 }
 
+func TestKubesprayTemplate_K8sCluster_DualStack(t *testing.T) {
+	cfg := config.MockConfig(t)
+	cfg.Cluster.Network.CIDR6 = "fd00:113::/64"
+	cfg.Kubernetes.Network.PodSubnet = "10.10.0.0/16"
+	cfg.Kubernetes.Network.ServiceSubnet6 = "fd00:43::/112"
+
+	tpl := NewTemplate("kubespray/k8s-cluster.yaml", cfg)
+	pop, err := template.Populate(tpl)
+
+	require.NoError(t, err)
+	assert.Contains(t, pop, "kube_pods_subnet: 10.10.0.0/16")
+	assert.Contains(t, pop, "ipv4_stack: true\nipv6_stack: true")
+	assert.Contains(t, pop, "kube_service_addresses_ipv6: fd00:43::/112")
+	assert.NotContains(t, pop, "kube_service_addresses:")
+	assert.NotContains(t, pop, "kube_pods_subnet_ipv6")
+}
+
+func TestKubesprayTemplate_K8sCluster_SingleStack(t *testing.T) {
+	tpl := NewTemplate("kubespray/k8s-cluster.yaml", config.MockConfig(t))
+	pop, err := template.Populate(tpl)
+
+	require.NoError(t, err)
+	assert.NotContains(t, pop, "ipv6_stack")
+	assert.NotContains(t, pop, "kube_pods_subnet")
+}
+
 func TestKubesprayTemplate_Etcd(t *testing.T) {
 	tpl := NewTemplate("kubespray/etcd.yaml", "")
 	pop, err := template.Populate(tpl)
@@ -177,3 +204,75 @@ func TestTemplate_Inventory_NoWorkers(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expect, pop)
 }
+
+func TestKubesprayTemplate_Inventory_IP6(t *testing.T) {
+	nodes := config.MockNodes(t)
+	nodes.Master.Instances[0].IP6 = "fd00:113::11"
+
+	values := struct {
+		ConfigNodes config.Nodes
+		InfraNodes  config.Nodes
+	}{
+		ConfigNodes: nodes,
+		InfraNodes:  nodes,
+	}
+
+	pop, err := template.Populate(NewTemplate("kubespray/inventory.yaml", values))
+
+	require.NoError(t, err)
+	assert.Contains(t, pop, "cls-master-1:\n      ansible_host: 192.168.113.11\n      ip6: fd00:113::11\n")
+	assert.Equal(t, 1, strings.Count(pop, "ip6:"))
+}
+
+func TestK3sTemplate_Inventory_DualStack(t *testing.T) {
+	nodes := config.MockNodes(t)
+	nodes.Master.Instances[0].IP6 = "fd00:113::11"
+	nodes.Worker.Instances[0].IP6 = "fd00:113::21"
+
+	values := k3sInventory{
+		ConfigNodes: nodes,
+		InfraNodes:  nodes,
+		Network: k3sNetwork{
+			DualStack:   true,
+			ClusterCIDR: "10.42.0.0/16,fd00:10:42::/56",
+			ServiceCIDR: "10.43.0.0/16,fd00:10:43::/112",
+		},
+	}
+
+	pop, err := template.Populate(NewTemplate("k3s/inventory.yaml", values))
+
+	require.NoError(t, err)
+	assert.Contains(t, pop, "cluster-cidr: 10.42.0.0/16,fd00:10:42::/56")
+	assert.Contains(t, pop, "service-cidr: 10.43.0.0/16,fd00:10:43::/112")
+	assert.Contains(t, pop, "node-ip: 192.168.113.11,fd00:113::11")
+	assert.Contains(t, pop, "node-ip: 192.168.113.21,fd00:113::21")
+	assert.Equal(t, 2, strings.Count(pop, "node-ip:"))
+}
+
+func TestK3sTemplate_Inventory_Config(t *testing.T) {
+	nodes := config.MockNodes(t)
+
+	server, err := k3sConfigLines(map[string]any{
+		"write-kubeconfig-mode": "0644",
+		"kube-apiserver-arg":    []string{"audit-log-maxage=30"},
+	})
+	require.NoError(t, err)
+
+	agent, err := k3sConfigLines(map[string]any{"kubelet-arg": []string{"max-pods=200"}})
+	require.NoError(t, err)
+
+	values := k3sInventory{
+		ConfigNodes:  nodes,
+		InfraNodes:   nodes,
+		ServerConfig: server,
+		AgentConfig:  agent,
+	}
+
+	pop, err := template.Populate(NewTemplate("k3s/inventory.yaml", values))
+
+	require.NoError(t, err)
+	assert.Contains(t, pop, "cls-master-3:\n      ansible_host: 192.168.113.13\n      server_config_yaml: |-\n        ---\n        tls-san: 192.168.113.200\n        kube-apiserver-arg:\n          - audit-log-maxage=30\n        write-kubeconfig-mode: \"0644\"")
+	assert.Contains(t, pop, "cls-worker-3:\n      ansible_host: 192.168.113.23\n      server_config_yaml: |-\n        ---\n        kubelet-arg:\n          - max-pods=200")
+	assert.Equal(t, 3, strings.Count(pop, "write-kubeconfig-mode"))
+	assert.Equal(t, 3, strings.Count(pop, "kubelet-arg"))
+}
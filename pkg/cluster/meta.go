@@ -1,12 +1,19 @@
 package cluster
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/MusicDin/kubitect/pkg/app"
 	"github.com/MusicDin/kubitect/pkg/cluster/interfaces"
 	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/libvirt"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/static"
 	"github.com/MusicDin/kubitect/pkg/cluster/provisioner/terraform"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/ui"
 	"github.com/MusicDin/kubitect/pkg/utils/file"
 )
 
@@ -14,12 +21,15 @@ const (
 	DefaultConfigDir    = "config"
 	DefaultCacheDir     = "cache"
 	DefaultTerraformDir = DefaultConfigDir + "/terraform"
+	DefaultLibvirtDir   = DefaultConfigDir + "/libvirt"
 
 	DefaultNewConfigFilename     = "kubitect.yaml"
 	DefaultAppliedConfigFilename = "kubitect-applied.yaml"
 	DefaultInfraConfigFilename   = "infrastructure.yaml"
+	DefaultIPAllocationsFilename = "ipam.yaml"
 
 	DefaultTerraformStateFilename = "terraform.tfstate"
+	DefaultLibvirtStateFilename   = "state.yaml"
 	DefaultKubeconfigFilename     = "admin.conf"
 )
 
@@ -30,8 +40,9 @@ type ClusterMeta struct {
 	Path  string
 	Local bool
 
-	exec interfaces.Manager
-	prov provisioner.Provisioner
+	exec   interfaces.Manager
+	prov   provisioner.Provisioner
+	bundle *bundle.Bundle
 }
 
 func (c ClusterMeta) ConfigDir() string {
@@ -55,10 +66,18 @@ func (c ClusterMeta) InfrastructureConfigPath() string {
 	return filepath.Join(c.ConfigDir(), DefaultInfraConfigFilename)
 }
 
+func (c ClusterMeta) IPAllocationsPath() string {
+	return filepath.Join(c.ConfigDir(), DefaultIPAllocationsFilename)
+}
+
 func (c ClusterMeta) TfStatePath() string {
 	return filepath.Join(c.Path, DefaultTerraformDir, DefaultTerraformStateFilename)
 }
 
+func (c ClusterMeta) LibvirtStatePath() string {
+	return filepath.Join(c.Path, DefaultLibvirtDir, DefaultLibvirtStateFilename)
+}
+
 func (c ClusterMeta) KubeconfigPath() string {
 	return filepath.Join(c.ConfigDir(), DefaultKubeconfigFilename)
 }
@@ -67,29 +86,131 @@ func (c ClusterMeta) PrivateSshKeyPath() string {
 	return filepath.Join(c.ConfigDir(), ".ssh", "id_rsa")
 }
 
+func (c ClusterMeta) BundlesDir() string {
+	return filepath.Join(c.ShareDir(), "bundles")
+}
+
 func (c ClusterMeta) ContainsAppliedConfig() bool {
 	return file.Exists(c.AppliedConfigPath())
 }
 
+// ContainsTfStateConfig returns true if the cluster contains Terraform
+// state, either locally or in a remote backend configured by the applied
+// configuration.
 func (c ClusterMeta) ContainsTfStateConfig() bool {
-	return file.Exists(c.TfStatePath())
+	if file.Exists(c.TfStatePath()) {
+		return true
+	}
+
+	cfg, err := readConfigIfExists(c.AppliedConfigPath(), config.Config{})
+	return err == nil && cfg != nil && cfg.Cluster.State.Backend.IsRemote()
+}
+
+func (c ClusterMeta) ContainsLibvirtState() bool {
+	return file.Exists(c.LibvirtStatePath())
 }
 
 func (c ClusterMeta) ContainsKubeconfig() bool {
 	return file.Exists(c.KubeconfigPath())
 }
 
+// IsStatic returns true if the cluster was applied using the static
+// provisioner.
+func (c ClusterMeta) IsStatic() bool {
+	cfg, err := readConfigIfExists(c.AppliedConfigPath(), config.Config{})
+	return err == nil && cfg != nil && cfg.Provisioner == config.ProvisionerStatic
+}
+
+// openBundle opens the bundle archive set in the application context, so
+// that the provisioner and the manager obtain external dependencies from
+// it. Nothing is done if bundle is not set.
+func (c *ClusterMeta) openBundle() error {
+	if c.bundle != nil || c.BundlePath() == "" {
+		return nil
+	}
+
+	ui.Printf(ui.INFO, "Opening bundle %q...\n", c.BundlePath())
+
+	b, err := bundle.Open(c.BundlePath(), c.BundlesDir())
+	if err != nil {
+		return err
+	}
+
+	c.bundle = b
+	return nil
+}
+
+// setProxyEnv sets proxy environment variables of the current process
+// according to the given configuration. This way, the proxy is used by
+// all tools that Kubitect runs (e.g. Git, pip3, Terraform and Ansible).
+// Variables must be set before the first HTTP request is made, since Go
+// HTTP clients read them only once.
+func setProxyEnv(cfg *config.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	for k, v := range cfg.ProxyEnv() {
+		if err := os.Setenv(k, v); err != nil {
+			return fmt.Errorf("set proxy environment: %v", err)
+		}
+	}
+
+	return nil
+}
+
 func (c *ClusterMeta) Provisioner() provisioner.Provisioner {
 	if c.prov != nil {
 		return c.prov
 	}
 
+	cfg, err := readConfigIfExists(c.AppliedConfigPath(), config.Config{})
+	if err == nil && cfg != nil && cfg.Provisioner == config.ProvisionerStatic {
+		c.prov = static.NewStaticProvisioner(
+			c.InfrastructureConfigPath(),
+			c.PrivateSshKeyPath(),
+			cfg,
+		)
+
+		return c.prov
+	}
+
+	// Libvirt state contains everything required to remove the
+	// resources, hence it is sufficient even if the applied
+	// configuration is missing.
+	if c.ContainsLibvirtState() || (err == nil && cfg != nil && cfg.Provisioner == config.ProvisionerLibvirt) {
+		c.prov = libvirt.NewLibvirtProvisioner(
+			c.LibvirtStatePath(),
+			c.InfrastructureConfigPath(),
+			c.ShareDir(),
+			c.PrivateSshKeyPath(),
+			c.ShowTerraformPlan(),
+			c.bundle,
+			cfg,
+		)
+
+		return c.prov
+	}
+
 	c.prov = terraform.NewTerraformProvisioner(
 		c.Path,
 		c.ShareDir(),
+		c.terraformBinary(cfg),
 		c.ShowTerraformPlan(),
+		c.bundle,
 		nil,
 	)
 
 	return c.prov
 }
+
+// terraformBinary returns the binary used by the Terraform provisioner.
+// Binary set in the given configuration takes precedence over the global
+// one.
+func (c ClusterMeta) terraformBinary(cfg *config.Config) config.TerraformBinary {
+	if cfg != nil && cfg.Terraform.Binary != "" {
+		return cfg.Terraform.Binary
+	}
+
+	return config.TerraformBinary(c.TerraformBinary())
+}
@@ -0,0 +1,297 @@
+package libvirt
+
+import (
+	"encoding/json"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/MusicDin/kubitect/embed"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/utils/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cloudInitLists are cloud-config keys whose values are appended to the
+// lists of the generated user data.
+var cloudInitLists = []string{"bootcmd", "fs_setup", "mounts", "packages", "runcmd"}
+
+type (
+	// cloudInitTemplate is an embedded cloud-init template.
+	cloudInitTemplate struct {
+		name   string
+		Values any
+	}
+
+	userDataValues struct {
+		Hostname     string
+		User         string
+		Update       bool
+		SSHPublicKey string
+		Packages     []any
+		BootCmd      []any
+		RunCmd       []any
+		FsSetup      []any
+		Mounts       []any
+		Extra        string
+	}
+
+	networkConfigValues struct {
+		Interface string
+		VLAN      int
+		Static    bool
+		DHCP6     bool
+		CIDR      string
+		CIDR6     string
+		Gateway   string
+		Gateway6  string
+		DNS       string
+		Networks  []cloudInitNetwork
+	}
+
+	// cloudInitNetwork is an interface attached to an additional network.
+	cloudInitNetwork struct {
+		Name string
+		MAC  string
+		CIDR string
+	}
+)
+
+func (t cloudInitTemplate) Name() string {
+	return t.name
+}
+
+func (t cloudInitTemplate) Template() (string, error) {
+	tpl, err := embed.GetTemplate(path.Join("libvirt", t.name))
+	if err != nil {
+		return "", err
+	}
+
+	return template.TrimTemplate(string(tpl.Content)), nil
+}
+
+func (t cloudInitTemplate) Functions() map[string]any {
+	return map[string]any{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
+
+// cloudInit returns the cloud-init ISO image of the given node. Formatted
+// data disks are identified by the given devices.
+func (b builder) cloudInit(n node, devices map[string]string, nets []cloudInitNetwork) ([]byte, error) {
+	userDataValues, err := b.userData(n, devices)
+	if err != nil {
+		return nil, err
+	}
+
+	userData, err := template.Populate(cloudInitTemplate{
+		name:   "user-data.yaml",
+		Values: userDataValues,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	networkConfig, err := template.Populate(cloudInitTemplate{
+		name:   "network-config.yaml",
+		Values: b.networkConfig(n, nets),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"user-data":      []byte(userData + "\n"),
+		"network-config": []byte(networkConfig + "\n"),
+		"meta-data":      []byte("instance-id: " + n.name + "\nlocal-hostname: " + n.name + "\n"),
+	}
+
+	return newCloudInitISO(files), nil
+}
+
+// userData returns values of the user data template of the given node.
+func (b builder) userData(n node, devices map[string]string) (userDataValues, error) {
+	tpl := b.cfg.Cluster.NodeTemplate
+	ci := mergeCloudInit(tpl.CloudInit, b.typeCloudInit(n.instance))
+
+	v := userDataValues{
+		Hostname:     n.name,
+		User:         string(tpl.User),
+		Update:       tpl.UpdateOnBoot != nil && *tpl.UpdateOnBoot,
+		SSHPublicKey: strings.TrimSpace(b.sshPublicKey),
+		Packages:     cloudInitList(ci, "packages"),
+		RunCmd:       cloudInitList(ci, "runcmd"),
+	}
+
+	// Proxy is configured before any user supplied boot command, since
+	// these may already require network access.
+	for _, c := range provisioner.ProxyBootCmd(b.cfg) {
+		v.BootCmd = append(v.BootCmd, c)
+	}
+
+	v.BootCmd = append(v.BootCmd, cloudInitList(ci, "bootcmd")...)
+
+	for _, d := range n.dataDisks {
+		dev, ok := devices[d.Name]
+		if !ok {
+			continue
+		}
+
+		label := d.Name
+		if len(label) > 12 {
+			label = label[:12]
+		}
+
+		v.FsSetup = append(v.FsSetup, map[string]any{
+			"device":     dev,
+			"filesystem": d.Filesystem,
+			"label":      label,
+			"overwrite":  false,
+		})
+
+		if d.MountPath == "" {
+			continue
+		}
+
+		opts := d.MountOptions
+		if len(opts) == 0 {
+			opts = []string{"defaults", "nofail"}
+		}
+
+		v.Mounts = append(v.Mounts, []string{dev, d.MountPath, string(d.Filesystem), strings.Join(opts, ","), "0", "2"})
+	}
+
+	v.FsSetup = append(v.FsSetup, cloudInitList(ci, "fs_setup")...)
+	v.Mounts = append(v.Mounts, cloudInitList(ci, "mounts")...)
+
+	extra := make(map[string]any)
+	for k, val := range ci {
+		if !slices.Contains(cloudInitLists, k) {
+			extra[k] = val
+		}
+	}
+
+	if len(extra) > 0 {
+		out, err := yaml.Marshal(extra)
+		if err != nil {
+			return v, err
+		}
+
+		v.Extra = strings.TrimSpace(string(out))
+	}
+
+	return v, nil
+}
+
+// networkConfig returns values of the network config template of the
+// given node.
+func (b builder) networkConfig(n node, nets []cloudInitNetwork) networkConfigValues {
+	net := b.cfg.Cluster.Network
+	ip := string(n.instance.GetIP())
+	ip6 := string(n.instance.GetIP6())
+
+	v := networkConfigValues{
+		Interface: string(b.cfg.Cluster.NodeTemplate.OS.NetworkInterface),
+		VLAN:      int(net.VLAN),
+		Static:    net.Mode != config.NAT && ip != "",
+		DHCP6:     net.CIDR6 != "",
+		Networks:  nets,
+	}
+
+	if ip != "" {
+		v.CIDR = withPrefix(ip, string(net.CIDR))
+	}
+
+	if ip6 != "" && net.CIDR6 != "" {
+		v.CIDR6 = withPrefix(ip6, string(net.CIDR6))
+	}
+
+	if net.Gateway != nil {
+		v.Gateway = string(*net.Gateway)
+	} else {
+		v.Gateway = firstHost(string(net.CIDR))
+	}
+
+	if net.Gateway6 != nil {
+		v.Gateway6 = string(*net.Gateway6)
+	} else if net.CIDR6 != "" {
+		v.Gateway6 = firstHost(string(net.CIDR6))
+	}
+
+	var dns []string
+	for _, ip := range b.cfg.Cluster.NodeTemplate.DNS {
+		dns = append(dns, string(ip))
+	}
+
+	v.DNS = strings.Join(dns, ", ")
+	if v.DNS == "" {
+		v.DNS = v.Gateway
+	}
+
+	return v
+}
+
+// typeCloudInit returns the cloud-config of the node type of the given
+// instance.
+func (b builder) typeCloudInit(i config.Instance) config.CloudInit {
+	nodes := b.cfg.Cluster.Nodes
+
+	switch i.(type) {
+	case config.LBInstance:
+		return nodes.LoadBalancer.Default.CloudInit
+	case config.MasterInstance:
+		return nodes.Master.Default.CloudInit
+	case config.WorkerInstance:
+		return nodes.Worker.Default.CloudInit
+	}
+
+	return nil
+}
+
+// mergeCloudInit merges the cloud-config of the node template and the
+// node type. List values are concatenated, while other values of the node
+// type override the ones of the node template.
+func mergeCloudInit(tpl config.CloudInit, typ config.CloudInit) map[string]any {
+	ci := make(map[string]any)
+
+	for k, v := range tpl {
+		ci[k] = v
+	}
+
+	for k, v := range typ {
+		a, okA := ci[k].([]any)
+		b, okB := v.([]any)
+
+		if okA && okB {
+			ci[k] = append(append([]any{}, a...), b...)
+		} else {
+			ci[k] = v
+		}
+	}
+
+	return ci
+}
+
+// cloudInitList returns the list value of the given cloud-config key.
+func cloudInitList(ci map[string]any, key string) []any {
+	l, _ := ci[key].([]any)
+	return l
+}
+
+// firstHost returns the first host address of the given CIDR.
+func firstHost(cidr string) string {
+	p, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return ""
+	}
+
+	return p.Masked().Addr().Next().String()
+}
@@ -0,0 +1,195 @@
+package libvirt
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/utils/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func MockBuilder(t *testing.T) builder {
+	cfg := MockConfig(t)
+
+	update := true
+	cfg.Cluster.NodeTemplate.UpdateOnBoot = &update
+	cfg.Cluster.NodeTemplate.CloudInit = config.CloudInit{
+		"packages": []any{"vim"},
+		"timezone": "UTC",
+	}
+
+	cfg.Cluster.Nodes.Worker.Default.CloudInit = config.CloudInit{
+		"packages": []any{"nfs-common"},
+		"timezone": "Europe/Ljubljana",
+	}
+
+	cfg.Cluster.Nodes.Worker.Instances[0].DataDisks = []config.DataDisk{
+		{Name: "raw", Size: 8},
+		{Name: "data", Size: 16, Filesystem: config.EXT4, MountPath: "/data"},
+	}
+
+	return builder{cfg: cfg, sshPublicKey: "ssh-rsa AAAA mock\n"}
+}
+
+// populate returns the parsed cloud-init template with given values.
+func populate(t *testing.T, name string, values any) map[string]any {
+	out, err := template.Populate(cloudInitTemplate{name: name, Values: values})
+	require.NoError(t, err)
+
+	var res map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(out), &res), out)
+	return res
+}
+
+func TestUserData(t *testing.T) {
+	b := MockBuilder(t)
+	w := b.cfg.Cluster.Nodes.Worker.Instances[0]
+
+	n := node{instance: w, name: "mock-worker-1", dataDisks: dataDisks(w)}
+	v, err := b.userData(n, map[string]string{"data": "/dev/disk/by-id/wwn-0x05abcd"})
+	require.NoError(t, err)
+
+	ud := populate(t, "user-data.yaml", v)
+	assert.Equal(t, "mock-worker-1", ud["hostname"])
+	assert.Equal(t, true, ud["package_upgrade"])
+	assert.Equal(t, []any{"qemu-guest-agent", "vim", "nfs-common"}, ud["packages"])
+	assert.Equal(t, "Europe/Ljubljana", ud["timezone"])
+	assert.Equal(t, "ssh-rsa AAAA mock", ud["users"].([]any)[0].(map[string]any)["ssh_authorized_keys"].([]any)[0])
+	assert.Equal(t, []any{"/dev/disk/by-id/wwn-0x05abcd", "/data", "ext4", "defaults,nofail", "0", "2"}, ud["mounts"].([]any)[0])
+	assert.Len(t, ud["fs_setup"], 1)
+}
+
+func TestUserData_Proxy(t *testing.T) {
+	b := MockBuilder(t)
+	b.cfg.Proxy.HTTP = "http://proxy.example.com:3128"
+	b.cfg.Cluster.NodeTemplate.CloudInit["bootcmd"] = []any{"echo user"}
+
+	m := b.cfg.Cluster.Nodes.Master.Instances[0]
+	v, err := b.userData(node{instance: m, name: "mock-master-1"}, nil)
+	require.NoError(t, err)
+
+	ud := populate(t, "user-data.yaml", v)
+	bootCmd := ud["bootcmd"].([]any)
+
+	// Proxy is configured after qemu-guest-agent is disabled, but before
+	// user supplied commands.
+	assert.Contains(t, bootCmd[1], "/etc/environment")
+	assert.Contains(t, bootCmd[1], "HTTP_PROXY|NO_PROXY|http_proxy|no_proxy")
+	assert.Equal(t, "echo user", bootCmd[len(bootCmd)-1])
+}
+
+func TestNetworkConfig(t *testing.T) {
+	b := MockBuilder(t)
+	b.cfg.Cluster.Network.Mode = config.BRIDGE
+	b.cfg.Cluster.NodeTemplate.DNS = []config.IP{"1.1.1.1", "8.8.8.8"}
+
+	m := b.cfg.Cluster.Nodes.Master.Instances[0]
+	nets := []cloudInitNetwork{{Name: "storage", MAC: "52:54:00:00:00:01", CIDR: "10.20.0.10/24"}}
+
+	nc := populate(t, "network-config.yaml", b.networkConfig(node{instance: m}, nets))
+	eth := nc["ethernets"].(map[string]any)
+
+	assert.Equal(t, map[string]any{
+		"dhcp4":     false,
+		"dhcp6":     false,
+		"addresses": []any{"10.10.0.10/24"},
+		"gateway4":  "10.10.0.1",
+		"nameservers": map[string]any{
+			"addresses": []any{"1.1.1.1", "8.8.8.8"},
+		},
+	}, eth["ens3"])
+
+	assert.Equal(t, map[string]any{
+		"match":     map[string]any{"macaddress": "52:54:00:00:00:01"},
+		"dhcp4":     false,
+		"addresses": []any{"10.20.0.10/24"},
+	}, eth["net-storage"])
+
+	// Primary interface is configured within the VLAN.
+	b.cfg.Cluster.Network.VLAN = 100
+
+	nc = populate(t, "network-config.yaml", b.networkConfig(node{instance: m}, nil))
+	assert.Equal(t, map[string]any{"dhcp4": false, "dhcp6": false}, nc["ethernets"].(map[string]any)["ens3"])
+	assert.Equal(t, 100, nc["vlans"].(map[string]any)["ens3.100"].(map[string]any)["id"])
+}
+
+func TestNetworkConfig_DHCP(t *testing.T) {
+	b := MockBuilder(t)
+	b.cfg.Cluster.Network.CIDR6 = "fd00::/64"
+
+	w := b.cfg.Cluster.Nodes.Worker.Instances[0]
+
+	nc := populate(t, "network-config.yaml", b.networkConfig(node{instance: w}, nil))
+	assert.Equal(t, map[string]any{
+		"dhcp4": true,
+		"dhcp6": true,
+		"nameservers": map[string]any{
+			"addresses": []any{"10.10.0.1"},
+		},
+	}, nc["ethernets"].(map[string]any)["ens3"])
+}
+
+func TestMergeCloudInit(t *testing.T) {
+	ci := mergeCloudInit(
+		config.CloudInit{"runcmd": []any{"a"}, "timezone": "UTC", "ntp": map[string]any{"enabled": true}},
+		config.CloudInit{"runcmd": []any{"b"}, "timezone": "CET"},
+	)
+
+	assert.Equal(t, map[string]any{
+		"runcmd":   []any{"a", "b"},
+		"timezone": "CET",
+		"ntp":      map[string]any{"enabled": true},
+	}, ci)
+}
+
+func TestBuildNode_DataDisks(t *testing.T) {
+	b := MockBuilder(t)
+	b.image = image{format: "qcow2", capacity: 1}
+
+	hosts, nodes, err := b.build()
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	var dom string
+	for _, r := range hosts[0].Resources {
+		if r.Kind == kindDomain && r.Name == "mock-worker-1" {
+			dom = r.XML
+		}
+	}
+
+	assert.Contains(t, dom, `<source pool="mock-main-resource-pool" volume="mock-worker-1-raw-data-disk"></source>`)
+	assert.Contains(t, dom, `<target dev="sda" bus="scsi"></target>`)
+	assert.Contains(t, dom, `<target dev="sdb" bus="scsi"></target>`)
+	assert.Contains(t, dom, "<wwn>"+diskWWN("mock-worker-1", "data")+"</wwn>")
+	assert.Contains(t, dom, "<wwn>"+diskWWN("mock-worker-1", "raw")+"</wwn>")
+	assert.Contains(t, dom, `<controller type="scsi" model="virtio-scsi"></controller>`)
+	assert.Contains(t, dom, `<mac address="`+stableMAC("mock-worker-1")+`"></mac>`)
+}
+
+func TestInfraDataDisks(t *testing.T) {
+	disks := []config.DataDisk{
+		{Name: "rook", Size: 10},
+		{Name: "data", Size: 10, Pool: "fast", Filesystem: config.EXT4},
+	}
+
+	expect := []config.DataDisk{
+		{Name: "rook", Size: 10, Pool: "main", Device: "/dev/disk/by-id/wwn-0x" + diskWWN("mock-worker-1", "rook")},
+		{Name: "data", Size: 10, Pool: "fast", Filesystem: config.EXT4, Device: "/dev/disk/by-id/wwn-0x" + diskWWN("mock-worker-1", "data")},
+	}
+
+	assert.Equal(t, expect, infraDataDisks("mock-worker-1", disks))
+	assert.Empty(t, disks[0].Device)
+}
+
+func TestNetworkDef(t *testing.T) {
+	n := networkDef("mock-network", config.NAT, "", "10.10.0.0/24", "fd00::/120")
+
+	require.Len(t, n.IPs, 2)
+	assert.Equal(t, "10.10.0.1", n.IPs[0].Address)
+	assert.Equal(t, networkDHCPRangeXML{Start: "10.10.0.2", End: "10.10.0.254"}, n.IPs[0].DHCP.Ranges[0])
+	assert.Equal(t, "ipv6", n.IPs[1].Family)
+	assert.Equal(t, networkDHCPRangeXML{Start: "fd00::2", End: "fd00::fe"}, n.IPs[1].DHCP.Ranges[0])
+}
@@ -0,0 +1,356 @@
+package libvirt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/tools/virt"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+)
+
+// hypervisor manages libvirt resources on a single host.
+type hypervisor interface {
+	// Exists returns true if the resource exists on the host.
+	Exists(r resource) (bool, error)
+
+	// Create creates and starts the resource.
+	Create(r resource) error
+
+	// Delete stops and removes the resource. Resources that do not
+	// exist are ignored.
+	Delete(r resource) error
+
+	// Addresses returns IP addresses of the domain's interface with the
+	// given MAC address. If network is set, addresses are obtained from
+	// DHCP leases of the network, otherwise they are reported by the
+	// guest agent.
+	Addresses(domain string, network string, mac string) ([]string, error)
+
+	// Close closes the connection to the host.
+	Close() error
+}
+
+// rpcHypervisor manages libvirt resources over libvirt RPC protocol.
+type rpcHypervisor struct {
+	l *golibvirt.Libvirt
+}
+
+// connect connects to libvirt on the given host.
+func connect(h config.Host) (hypervisor, error) {
+	l, err := virt.Connect(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcHypervisor{l: l}, nil
+}
+
+func (h *rpcHypervisor) Close() error {
+	return h.l.Disconnect()
+}
+
+func (h *rpcHypervisor) Exists(r resource) (bool, error) {
+	var err error
+
+	switch r.Kind {
+	case kindPool:
+		_, err = h.l.StoragePoolLookupByName(r.Name)
+	case kindVolume:
+		var pool golibvirt.StoragePool
+
+		pool, err = h.l.StoragePoolLookupByName(r.Pool)
+		if err == nil {
+			_, err = h.l.StorageVolLookupByName(pool, r.Name)
+		}
+	case kindNetwork:
+		_, err = h.l.NetworkLookupByName(r.Name)
+	case kindDomain:
+		_, err = h.l.DomainLookupByName(r.Name)
+	case kindDHCPHost:
+		// Static leases are owned by the network.
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown resource kind %q", r.Kind)
+	}
+
+	if virt.IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (h *rpcHypervisor) Create(r resource) error {
+	switch r.Kind {
+	case kindPool:
+		return h.createPool(r)
+	case kindVolume:
+		return h.createVolume(r)
+	case kindNetwork:
+		return h.createNetwork(r)
+	case kindDHCPHost:
+		return h.updateDHCPHost(r, golibvirt.NetworkUpdateCommandAddLast)
+	case kindDomain:
+		return h.createDomain(r)
+	default:
+		return fmt.Errorf("unknown resource kind %q", r.Kind)
+	}
+}
+
+func (h *rpcHypervisor) Delete(r resource) error {
+	var err error
+
+	switch r.Kind {
+	case kindPool:
+		err = h.deletePool(r)
+	case kindVolume:
+		err = h.deleteVolume(r)
+	case kindNetwork:
+		err = h.deleteNetwork(r)
+	case kindDHCPHost:
+		err = h.updateDHCPHost(r, golibvirt.NetworkUpdateCommandDelete)
+	case kindDomain:
+		err = h.deleteDomain(r)
+	default:
+		return fmt.Errorf("unknown resource kind %q", r.Kind)
+	}
+
+	if virt.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (h *rpcHypervisor) Addresses(domain string, network string, mac string) ([]string, error) {
+	var ips []string
+
+	if network != "" {
+		net, err := h.l.NetworkLookupByName(network)
+		if err != nil {
+			return nil, err
+		}
+
+		leases, _, err := h.l.NetworkGetDhcpLeases(net, golibvirt.OptString{mac}, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range leases {
+			ips = append(ips, l.Ipaddr)
+		}
+
+		return ips, nil
+	}
+
+	dom, err := h.l.DomainLookupByName(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces, err := h.l.DomainInterfaceAddresses(dom, uint32(golibvirt.DomainInterfaceAddressesSrcAgent), 0)
+	if err != nil {
+		// Guest agent is not running until cloud-init configures
+		// the network.
+		return nil, nil
+	}
+
+	for _, i := range ifaces {
+		if len(i.Hwaddr) == 0 || !strings.EqualFold(i.Hwaddr[0], mac) {
+			continue
+		}
+
+		for _, a := range i.Addrs {
+			ips = append(ips, a.Addr)
+		}
+	}
+
+	return ips, nil
+}
+
+func (h *rpcHypervisor) createPool(r resource) error {
+	pool, err := h.l.StoragePoolDefineXML(r.XML, 0)
+	if err != nil {
+		return err
+	}
+
+	err = h.l.StoragePoolBuild(pool, golibvirt.StoragePoolBuildNew)
+	if err == nil {
+		err = h.l.StoragePoolCreate(pool, 0)
+	}
+
+	if err == nil {
+		err = h.l.StoragePoolSetAutostart(pool, 1)
+	}
+
+	if err != nil {
+		_ = h.deletePool(r)
+	}
+
+	return err
+}
+
+func (h *rpcHypervisor) deletePool(r resource) error {
+	pool, err := h.l.StoragePoolLookupByName(r.Name)
+	if err != nil {
+		return err
+	}
+
+	// Pool may already be inactive.
+	_ = h.l.StoragePoolDestroy(pool)
+	_ = h.l.StoragePoolDelete(pool, golibvirt.StoragePoolDeleteNormal)
+
+	return h.l.StoragePoolUndefine(pool)
+}
+
+func (h *rpcHypervisor) createVolume(r resource) error {
+	pool, err := h.l.StoragePoolLookupByName(r.Pool)
+	if err != nil {
+		return err
+	}
+
+	vol, err := h.l.StorageVolCreateXML(pool, r.XML, 0)
+	if err != nil {
+		return err
+	}
+
+	var src io.Reader
+	var size int64
+
+	switch {
+	case r.Source != "":
+		f, err := os.Open(r.Source)
+		if err != nil {
+			_ = h.l.StorageVolDelete(vol, 0)
+			return err
+		}
+
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			_ = h.l.StorageVolDelete(vol, 0)
+			return err
+		}
+
+		src, size = f, info.Size()
+	case r.Content != nil:
+		src, size = bytes.NewReader(r.Content), int64(len(r.Content))
+	default:
+		return nil
+	}
+
+	err = h.l.StorageVolUpload(vol, src, 0, uint64(size), 0)
+	if err != nil {
+		_ = h.l.StorageVolDelete(vol, 0)
+		return fmt.Errorf("upload volume: %v", err)
+	}
+
+	return nil
+}
+
+func (h *rpcHypervisor) deleteVolume(r resource) error {
+	pool, err := h.l.StoragePoolLookupByName(r.Pool)
+	if err != nil {
+		return err
+	}
+
+	vol, err := h.l.StorageVolLookupByName(pool, r.Name)
+	if err != nil {
+		return err
+	}
+
+	return h.l.StorageVolDelete(vol, 0)
+}
+
+func (h *rpcHypervisor) createNetwork(r resource) error {
+	net, err := h.l.NetworkDefineXML(r.XML)
+	if err != nil {
+		return err
+	}
+
+	err = h.l.NetworkCreate(net)
+	if err == nil {
+		err = h.l.NetworkSetAutostart(net, 1)
+	}
+
+	if err != nil {
+		_ = h.deleteNetwork(r)
+	}
+
+	return err
+}
+
+func (h *rpcHypervisor) deleteNetwork(r resource) error {
+	net, err := h.l.NetworkLookupByName(r.Name)
+	if err != nil {
+		return err
+	}
+
+	// Network may already be inactive.
+	_ = h.l.NetworkDestroy(net)
+
+	return h.l.NetworkUndefine(net)
+}
+
+func (h *rpcHypervisor) updateDHCPHost(r resource, cmd golibvirt.NetworkUpdateCommand) error {
+	net, err := h.l.NetworkLookupByName(r.Network)
+	if err != nil {
+		return err
+	}
+
+	flags := golibvirt.NetworkUpdateAffectLive | golibvirt.NetworkUpdateAffectConfig
+
+	return h.l.NetworkUpdate(net, uint32(cmd), uint32(golibvirt.NetworkSectionIPDhcpHost), -1, r.XML, flags)
+}
+
+func (h *rpcHypervisor) createDomain(r resource) error {
+	dom, err := h.l.DomainDefineXML(r.XML)
+	if err != nil {
+		return err
+	}
+
+	err = h.l.DomainSetAutostart(dom, 1)
+	if err == nil {
+		err = h.l.DomainCreate(dom)
+	}
+
+	if err != nil {
+		_ = h.deleteDomain(r)
+	}
+
+	return err
+}
+
+func (h *rpcHypervisor) deleteDomain(r resource) error {
+	dom, err := h.l.DomainLookupByName(r.Name)
+	if err != nil {
+		return err
+	}
+
+	// Domain may already be stopped.
+	_ = h.l.DomainDestroy(dom)
+
+	return h.l.DomainUndefineFlags(dom, golibvirt.DomainUndefineNvram|golibvirt.DomainUndefineManagedSave)
+}
+
+// expandHome replaces the leading "~" in the given path with the home
+// directory of the current user.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
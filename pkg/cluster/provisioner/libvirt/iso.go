@@ -0,0 +1,247 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	isoSectorSize = 2048
+
+	// Fixed sectors of the ISO image. The first 16 sectors form
+	// the system area, which is left empty.
+	isoPrimaryDescSector  = 16
+	isoJolietDescSector   = 17
+	isoTerminatorSector   = 18
+	isoPathTableSector    = 19 // L and M tables of both descriptors.
+	isoPrimaryRootSector  = 23
+	isoJolietRootSector   = 24
+	isoFirstFileSector    = 25
+	isoPathTableRecordLen = 10
+)
+
+// isoFile is a file stored in the root directory of the ISO image.
+type isoFile struct {
+	name    string
+	content []byte
+	sector  uint32
+}
+
+// newCloudInitISO returns an ISO 9660 image with the volume label "cidata"
+// that contains the given files in its root directory. This is the format
+// of the NoCloud data source of cloud-init.
+//
+// Original file names are stored using Joliet extension, while the primary
+// volume descriptor contains their 8.3 representation. The output does not
+// contain any timestamps, so the same files always produce the same image.
+func newCloudInitISO(files map[string][]byte) []byte {
+	var fs []*isoFile
+
+	for name, content := range files {
+		fs = append(fs, &isoFile{name: name, content: content})
+	}
+
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].name < fs[j].name
+	})
+
+	sector := uint32(isoFirstFileSector)
+	for _, f := range fs {
+		f.sector = sector
+		sector += isoSectors(len(f.content))
+	}
+
+	img := make([]byte, int(sector)*isoSectorSize)
+
+	// Volume descriptors.
+	copy(img[isoPrimaryDescSector*isoSectorSize:], isoVolumeDescriptor(false, sector))
+	copy(img[isoJolietDescSector*isoSectorSize:], isoVolumeDescriptor(true, sector))
+	copy(img[isoTerminatorSector*isoSectorSize:], []byte{255, 'C', 'D', '0', '0', '1', 1})
+
+	// Path tables (L and M) of the primary and Joliet descriptors.
+	for i, root := range []uint32{isoPrimaryRootSector, isoPrimaryRootSector, isoJolietRootSector, isoJolietRootSector} {
+		order := binary.ByteOrder(binary.LittleEndian)
+		if i%2 == 1 {
+			order = binary.BigEndian
+		}
+
+		rec := img[(isoPathTableSector+i)*isoSectorSize:]
+		rec[0] = 1
+		order.PutUint32(rec[2:], root)
+		order.PutUint16(rec[6:], 1)
+	}
+
+	// Root directories.
+	copy(img[isoPrimaryRootSector*isoSectorSize:], isoRootDirectory(fs, false))
+	copy(img[isoJolietRootSector*isoSectorSize:], isoRootDirectory(fs, true))
+
+	// Files.
+	for _, f := range fs {
+		copy(img[int(f.sector)*isoSectorSize:], f.content)
+	}
+
+	return img
+}
+
+// isoVolumeDescriptor returns a primary or a Joliet supplementary volume
+// descriptor of an image with the given size in sectors.
+func isoVolumeDescriptor(joliet bool, size uint32) []byte {
+	d := make([]byte, isoSectorSize)
+
+	d[0] = 1
+	pathTable := uint32(isoPathTableSector)
+	root := uint32(isoPrimaryRootSector)
+	text := isoPadded
+
+	if joliet {
+		d[0] = 2
+		pathTable += 2
+		root = isoJolietRootSector
+		text = isoPaddedUCS2
+
+		// UCS-2 level 3 escape sequence.
+		copy(d[88:], "%/E")
+	}
+
+	copy(d[1:], "CD001")
+	d[6] = 1
+
+	copy(d[8:40], text("", 32))
+	copy(d[40:72], text("cidata", 32))
+	isoPutBoth32(d[80:], size)
+	isoPutBoth16(d[120:], 1)
+	isoPutBoth16(d[124:], 1)
+	isoPutBoth16(d[128:], isoSectorSize)
+	isoPutBoth32(d[132:], isoPathTableRecordLen)
+	binary.LittleEndian.PutUint32(d[140:], pathTable)
+	binary.BigEndian.PutUint32(d[148:], pathTable+1)
+	copy(d[156:190], isoDirectoryRecord([]byte{0}, root, isoSectorSize, true))
+
+	for _, f := range [][2]int{{190, 318}, {318, 446}, {446, 574}, {574, 702}, {702, 739}, {739, 776}, {776, 813}} {
+		copy(d[f[0]:f[1]], text("", f[1]-f[0]))
+	}
+
+	// Unspecified creation, modification, expiration and effective dates.
+	for _, off := range []int{813, 830, 847, 864} {
+		copy(d[off:off+16], strings.Repeat("0", 16))
+	}
+
+	d[881] = 1
+
+	return d
+}
+
+// isoRootDirectory returns the root directory containing the given files.
+func isoRootDirectory(fs []*isoFile, joliet bool) []byte {
+	root := uint32(isoPrimaryRootSector)
+	if joliet {
+		root = isoJolietRootSector
+	}
+
+	var buf bytes.Buffer
+	buf.Write(isoDirectoryRecord([]byte{0}, root, isoSectorSize, true))
+	buf.Write(isoDirectoryRecord([]byte{1}, root, isoSectorSize, true))
+
+	for _, f := range fs {
+		name := isoShortName(f.name)
+		if joliet {
+			name = isoUCS2(f.name)
+		}
+
+		buf.Write(isoDirectoryRecord(name, f.sector, uint32(len(f.content)), false))
+	}
+
+	return buf.Bytes()
+}
+
+// isoDirectoryRecord returns a directory record of the file or directory
+// with the given identifier.
+func isoDirectoryRecord(id []byte, sector uint32, size uint32, dir bool) []byte {
+	l := 33 + len(id)
+	if l%2 == 1 {
+		l++
+	}
+
+	r := make([]byte, l)
+	r[0] = byte(l)
+	isoPutBoth32(r[2:], sector)
+	isoPutBoth32(r[10:], size)
+
+	if dir {
+		r[25] = 2
+	}
+
+	isoPutBoth16(r[28:], 1)
+	r[32] = byte(len(id))
+	copy(r[33:], id)
+
+	return r
+}
+
+// isoShortName returns an ISO 9660 level 1 file identifier of the given
+// file name (e.g. "user-data" -> "USER_DAT.;1").
+func isoShortName(name string) []byte {
+	var b strings.Builder
+
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	s := b.String()
+	if len(s) > 8 {
+		s = s[:8]
+	}
+
+	return []byte(s + ".;1")
+}
+
+// isoUCS2 returns the given string encoded in big-endian UCS-2.
+func isoUCS2(s string) []byte {
+	var b []byte
+
+	for _, r := range utf16.Encode([]rune(s)) {
+		b = binary.BigEndian.AppendUint16(b, r)
+	}
+
+	return b
+}
+
+// isoPadded returns the given string padded with spaces to length n.
+func isoPadded(s string, n int) []byte {
+	return []byte(s + strings.Repeat(" ", n-len(s)))
+}
+
+// isoPaddedUCS2 returns the given string encoded in UCS-2 and padded
+// with spaces to length n (in bytes).
+func isoPaddedUCS2(s string, n int) []byte {
+	return isoUCS2(s + strings.Repeat(" ", n/2-len(s)))
+}
+
+// isoPutBoth16 writes v in both little and big-endian byte order.
+func isoPutBoth16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b, v)
+	binary.BigEndian.PutUint16(b[2:], v)
+}
+
+// isoPutBoth32 writes v in both little and big-endian byte order.
+func isoPutBoth32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
+
+// isoSectors returns the number of sectors required to store n bytes.
+// Empty files still occupy a sector.
+func isoSectors(n int) uint32 {
+	if n == 0 {
+		return 1
+	}
+
+	return uint32((n + isoSectorSize - 1) / isoSectorSize)
+}
@@ -0,0 +1,75 @@
+package libvirt
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readISO returns files of the root directory of the Joliet tree.
+func readISO(t *testing.T, img []byte) map[string]string {
+	desc := img[isoJolietDescSector*isoSectorSize:]
+	require.Equal(t, byte(2), desc[0])
+	require.Equal(t, "CD001", string(desc[1:6]))
+
+	root := desc[156:]
+	dir := img[int(binary.LittleEndian.Uint32(root[2:]))*isoSectorSize:]
+
+	files := make(map[string]string)
+
+	for off := 0; dir[off] != 0; off += int(dir[off]) {
+		rec := dir[off:]
+		id := rec[33 : 33+int(rec[32])]
+
+		if rec[25]&2 != 0 {
+			continue
+		}
+
+		var name []uint16
+		for i := 0; i < len(id); i += 2 {
+			name = append(name, binary.BigEndian.Uint16(id[i:]))
+		}
+
+		start := int(binary.LittleEndian.Uint32(rec[2:])) * isoSectorSize
+		size := int(binary.LittleEndian.Uint32(rec[10:]))
+		files[string(utf16.Decode(name))] = string(img[start : start+size])
+	}
+
+	return files
+}
+
+func TestNewCloudInitISO(t *testing.T) {
+	files := map[string][]byte{
+		"user-data":      []byte("#cloud-config\n"),
+		"meta-data":      []byte("instance-id: mock\n"),
+		"network-config": make([]byte, 3000),
+	}
+
+	img := newCloudInitISO(files)
+
+	// 25 reserved sectors + 1 sector for each small file + 2 for the
+	// large one.
+	assert.Len(t, img, 29*isoSectorSize)
+
+	pvd := img[isoPrimaryDescSector*isoSectorSize:]
+	assert.Equal(t, "CD001", string(pvd[1:6]))
+	assert.Equal(t, "cidata", string(pvd[40:46]))
+	assert.Equal(t, uint32(29), binary.LittleEndian.Uint32(pvd[80:]))
+
+	assert.Equal(t, map[string]string{
+		"user-data":      "#cloud-config\n",
+		"meta-data":      "instance-id: mock\n",
+		"network-config": string(make([]byte, 3000)),
+	}, readISO(t, img))
+
+	// Image is reproducible.
+	assert.Equal(t, img, newCloudInitISO(files))
+}
+
+func TestIsoShortName(t *testing.T) {
+	assert.Equal(t, "USER_DAT.;1", string(isoShortName("user-data")))
+	assert.Equal(t, "META.;1", string(isoShortName("meta")))
+}
@@ -0,0 +1,587 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/tools/images"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	"github.com/MusicDin/kubitect/pkg/utils/exec"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+// cloudInitWaitScript waits until cloud-init finishes its tasks.
+const cloudInitWaitScript = `while ! grep "Cloud-init .* finished" /var/log/cloud-init.log > /dev/null 2>&1; do
+	echo "Waiting for cloud-init to finish..."
+	sleep 2
+done`
+
+// knownHostsScript replaces the SSH key of the given IP address within
+// the known hosts file.
+const knownHostsScript = `mkdir -p "$HOME/.ssh" \
+	&& touch "$HOME/.ssh/known_hosts" \
+	&& ssh-keygen -R "$1" \
+	&& ssh-keyscan -t rsa "$1" >> "$HOME/.ssh/known_hosts" \
+	&& rm -f "$HOME/.ssh/known_hosts.old"`
+
+type (
+	libvirt struct {
+		// Path of the state containing created resources.
+		statePath string
+
+		// Path where infrastructure configuration is written.
+		infraConfigPath string
+
+		// Dir of the OS image cache shared among all clusters.
+		imageDir string
+
+		// Path to the private key used to access the virtual machines.
+		sshPrivateKeyPath string
+
+		// If true, planned changes are shown and confirmation is
+		// required before they are applied.
+		showPlan bool
+
+		// Configuration file containing the desired infrastructure.
+		cfg *config.Config
+
+		// Evaluated during Init.
+		desired []hostResources
+		nodes   []node
+
+		// Connects to libvirt on the given host.
+		connect func(config.Host) (hypervisor, error)
+
+		// Runs scripts on the virtual machines.
+		runner nodeRunner
+
+		// Interval and timeout of polling for the addresses of the
+		// virtual machines.
+		pollInterval time.Duration
+		pollTimeout  time.Duration
+	}
+
+	// nodeRunner runs shell scripts on the virtual machines.
+	nodeRunner interface {
+		Run(host string, script string, sudo bool) error
+	}
+
+	// sshRunner runs scripts on the virtual machines over SSH.
+	sshRunner struct {
+		user           string
+		privateKeyPath string
+	}
+)
+
+func (r sshRunner) Run(host string, script string, sudo bool) error {
+	ssh := exec.NewSSHClient(r.user, host).
+		WithPrivateKeyFile(r.privateKeyPath).
+		WithSuperUser(sudo)
+
+	defer ssh.Close()
+
+	ssh.SetStdin(strings.NewReader(script))
+	ssh.SetStdout(io.Discard)
+	ssh.SetStderr(ui.Streams().Err().File())
+
+	return ssh.Run("bash", "-s")
+}
+
+// NewLibvirtProvisioner returns a provisioner that creates virtual
+// machines by talking to libvirt directly over its RPC protocol.
+// Resources created by the provisioner are recorded in the state file.
+// If bundle is set, the OS image is obtained from it.
+func NewLibvirtProvisioner(
+	statePath string,
+	infraConfigPath string,
+	sharedPath string,
+	sshPrivateKeyPath string,
+	showPlan bool,
+	b *bundle.Bundle,
+	cfg *config.Config,
+) provisioner.Provisioner {
+	imageDir := path.Join(sharedPath, "images")
+	if b != nil {
+		imageDir = b.ImageDir()
+	}
+
+	return &libvirt{
+		statePath:         statePath,
+		infraConfigPath:   infraConfigPath,
+		imageDir:          imageDir,
+		sshPrivateKeyPath: sshPrivateKeyPath,
+		showPlan:          showPlan,
+		cfg:               cfg,
+		connect:           connect,
+		pollInterval:      2 * time.Second,
+		pollTimeout:       10 * time.Minute,
+	}
+}
+
+// Init ensures the OS image is present in the image cache and evaluates
+// the desired resources. Events are ignored, since resources of removed
+// hosts are found in the state.
+func (p *libvirt) Init([]event.Event) error {
+	if p.cfg == nil {
+		return fmt.Errorf("libvirt: configuration is required")
+	}
+
+	pubKey, err := os.ReadFile(p.sshPrivateKeyPath + ".pub")
+	if err != nil {
+		return fmt.Errorf("libvirt: read SSH public key: %v", err)
+	}
+
+	img, err := p.prepareImage()
+	if err != nil {
+		return fmt.Errorf("libvirt: %v", err)
+	}
+
+	b := builder{
+		cfg:          p.cfg,
+		image:        *img,
+		sshPublicKey: string(pubKey),
+	}
+
+	p.desired, p.nodes, err = b.build()
+	if err != nil {
+		return fmt.Errorf("libvirt: %v", err)
+	}
+
+	if p.runner == nil {
+		p.runner = sshRunner{
+			user:           string(p.cfg.Cluster.NodeTemplate.User),
+			privateKeyPath: p.sshPrivateKeyPath,
+		}
+	}
+
+	return nil
+}
+
+// Bundle adds the OS image into the bundle.
+func (p *libvirt) Bundle(w *bundle.Writer) error {
+	nodeOS := p.cfg.Cluster.NodeTemplate.OS
+
+	_, err := images.NewCache(w.ImageDir()).Ensure(string(nodeOS.Source), string(nodeOS.Checksum), string(nodeOS.ChecksumFile), nodeOS.SkipChecksum)
+	return err
+}
+
+// prepareImage ensures the OS image is present in the shared image cache
+// and detects its format and virtual size.
+func (p *libvirt) prepareImage() (*image, error) {
+	nodeOS := p.cfg.Cluster.NodeTemplate.OS
+
+	img, err := images.NewCache(p.imageDir).Ensure(string(nodeOS.Source), string(nodeOS.Checksum), string(nodeOS.ChecksumFile), nodeOS.SkipChecksum)
+	if err != nil {
+		return nil, err
+	}
+
+	return inspectImage(img.Path)
+}
+
+// Plan prints resources that will be created, replaced or deleted and
+// returns true if there are any changes.
+func (p *libvirt) Plan() (bool, error) {
+	current, err := readState(p.statePath)
+	if err != nil {
+		return false, fmt.Errorf("libvirt: %v", err)
+	}
+
+	changed := false
+
+	for _, c := range diff(current, p.desired) {
+		deleted := make(map[string]bool)
+		for _, r := range c.delete {
+			deleted[r.id()] = true
+		}
+
+		for _, r := range c.delete {
+			if !c.contains(r) {
+				p.printPlan("-", "delete", c.host.Host.Name, r)
+			}
+		}
+
+		for _, r := range c.create {
+			if deleted[r.id()] {
+				p.printPlan("~", "replace", c.host.Host.Name, r)
+			} else {
+				p.printPlan("+", "create", c.host.Host.Name, r)
+			}
+		}
+
+		changed = changed || len(c.create) > 0 || len(c.delete) > 0
+	}
+
+	if !file.Exists(p.infraConfigPath) {
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// printPlan prints a planned change of the resource, if plan is shown.
+func (p *libvirt) printPlan(sign string, action string, host string, r resource) {
+	if p.showPlan {
+		ui.Printf(ui.INFO, "  %s %s will be %sd (host %q)\n", sign, r, action, host)
+	}
+}
+
+// Apply creates and removes resources, so that they match the desired
+// ones, and writes infrastructure configuration. In case any changes are
+// detected, user confirmation is required.
+func (p *libvirt) Apply() error {
+	changes, err := p.Plan()
+	if err != nil {
+		return err
+	}
+
+	if changes && p.showPlan {
+		if err := ui.Ask("Proceed with libvirt apply?"); err != nil {
+			return err
+		}
+	}
+
+	current, err := readState(p.statePath)
+	if err != nil {
+		return fmt.Errorf("libvirt: %v", err)
+	}
+
+	created := make(map[string]bool)
+
+	for _, c := range diff(current, p.desired) {
+		if len(c.create) == 0 && len(c.delete) == 0 {
+			continue
+		}
+
+		err := p.applyChanges(current, c)
+		if err != nil {
+			return fmt.Errorf("libvirt: host %q: %v", c.host.Host.Name, err)
+		}
+
+		for _, r := range c.create {
+			if r.Kind == kindDomain {
+				created[r.Name] = true
+			}
+		}
+	}
+
+	infraCfg, err := p.infraConfig(created)
+	if err != nil {
+		return fmt.Errorf("libvirt: %v", err)
+	}
+
+	err = file.WriteYaml(infraCfg, p.infraConfigPath, 0600)
+	if err != nil {
+		return fmt.Errorf("libvirt: write infrastructure file: %v", err)
+	}
+
+	return nil
+}
+
+// applyChanges applies changes of a single host. State is written after
+// each change, so that a failed apply can be resumed.
+func (p *libvirt) applyChanges(s *state, c changes) error {
+	h, err := p.connect(c.host.Host)
+	if err != nil {
+		return err
+	}
+
+	defer h.Close()
+
+	for _, r := range c.delete {
+		ui.Printf(ui.INFO, "Deleting %s on host %q...\n", r, c.host.Host.Name)
+
+		if err := h.Delete(r); err != nil {
+			return fmt.Errorf("delete %s: %v", r, err)
+		}
+
+		s.remove(c.host.Host.Name, r)
+		if err := s.write(p.statePath); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range c.create {
+		exists, err := h.Exists(r)
+		if err != nil {
+			return fmt.Errorf("lookup %s: %v", r, err)
+		}
+
+		if exists {
+			return fmt.Errorf("%s already exists and is not managed by Kubitect", r)
+		}
+
+		ui.Printf(ui.INFO, "Creating %s on host %q...\n", r, c.host.Host.Name)
+
+		if err := h.Create(r); err != nil {
+			return fmt.Errorf("create %s: %v", r, err)
+		}
+
+		s.add(c.host, r)
+		if err := s.write(p.statePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Destroy removes all resources recorded in the state.
+func (p *libvirt) Destroy() error {
+	current, err := readState(p.statePath)
+	if err != nil {
+		return fmt.Errorf("libvirt: %v", err)
+	}
+
+	for _, c := range diff(current, nil) {
+		err := p.applyChanges(current, c)
+		if err != nil {
+			return fmt.Errorf("libvirt: host %q: %v", c.host.Host.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// infraConfig returns infrastructure configuration of the provisioned
+// virtual machines. Addresses that are not configured explicitly are
+// obtained from libvirt. For newly created virtual machines, it is also
+// awaited that cloud-init finishes.
+func (p *libvirt) infraConfig(created map[string]bool) (infra.Config, error) {
+	var nodes config.Nodes
+
+	conns := make(map[string]hypervisor)
+	defer func() {
+		for _, h := range conns {
+			h.Close()
+		}
+	}()
+
+	for _, n := range p.nodes {
+		h, ok := conns[n.host]
+		if !ok {
+			var err error
+
+			h, err = p.connect(p.host(n.host))
+			if err != nil {
+				return infra.Config{}, err
+			}
+
+			conns[n.host] = h
+		}
+
+		ip, ip6, err := p.addresses(h, n)
+		if err != nil {
+			return infra.Config{}, err
+		}
+
+		if created[n.name] {
+			err := p.awaitNode(n, ip)
+			if err != nil {
+				return infra.Config{}, err
+			}
+		}
+
+		switch i := n.instance.(type) {
+		case config.LBInstance:
+			nodes.LoadBalancer.Instances = append(nodes.LoadBalancer.Instances, config.LBInstance{
+				Id:   i.Id,
+				Name: n.name,
+				IP:   config.IPv4(ip),
+				IP6:  config.IPv6(ip6),
+			})
+		case config.MasterInstance:
+			nodes.Master.Instances = append(nodes.Master.Instances, config.MasterInstance{
+				Id:        i.Id,
+				Name:      n.name,
+				IP:        config.IPv4(ip),
+				IP6:       config.IPv6(ip6),
+				DataDisks: infraDataDisks(n.name, i.DataDisks),
+			})
+		case config.WorkerInstance:
+			nodes.Worker.Instances = append(nodes.Worker.Instances, config.WorkerInstance{
+				Id:        i.Id,
+				Name:      n.name,
+				IP:        config.IPv4(ip),
+				IP6:       config.IPv6(ip6),
+				DataDisks: infraDataDisks(n.name, i.DataDisks),
+			})
+		}
+	}
+
+	cfgLB := p.cfg.Cluster.Nodes.LoadBalancer
+	lbs := nodes.LoadBalancer.Instances
+
+	switch {
+	case len(lbs) == 0 && len(nodes.Master.Instances) > 0:
+		nodes.LoadBalancer.VIP = nodes.Master.Instances[0].IP
+		nodes.LoadBalancer.VIP6 = nodes.Master.Instances[0].IP6
+	case len(lbs) == 1:
+		nodes.LoadBalancer.VIP = defaults.Default(cfgLB.VIP, lbs[0].IP)
+		nodes.LoadBalancer.VIP6 = defaults.Default(cfgLB.VIP6, lbs[0].IP6)
+	default:
+		nodes.LoadBalancer.VIP = cfgLB.VIP
+		nodes.LoadBalancer.VIP6 = cfgLB.VIP6
+	}
+
+	return infra.Config{Nodes: nodes}, nil
+}
+
+// infraDataDisks returns data disks of the instance as they are written
+// to the infrastructure config. Disks keep their configured order and
+// contain the device under which they are attached, the same as the data
+// disks reported by the Terraform provisioner.
+func infraDataDisks(vm string, disks []config.DataDisk) []config.DataDisk {
+	var res []config.DataDisk
+
+	for _, d := range disks {
+		d.Pool = defaults.Default(d.Pool, "main")
+		d.Device = diskDevice(vm, d.Name)
+		res = append(res, d)
+	}
+
+	return res
+}
+
+// addresses returns IPv4 and IPv6 address of the given node. Configured
+// addresses take precedence over the ones reported by libvirt.
+func (p *libvirt) addresses(h hypervisor, n node) (string, string, error) {
+	ip := string(n.instance.GetIP())
+	ip6 := string(n.instance.GetIP6())
+
+	if ip != "" && (ip6 != "" || p.cfg.Cluster.Network.CIDR6 == "") {
+		return ip, ip6, nil
+	}
+
+	ui.Printf(ui.INFO, "Waiting for IP address of virtual machine %q...\n", n.name)
+
+	deadline := time.Now().Add(p.pollTimeout)
+
+	for {
+		addrs, err := h.Addresses(n.name, n.network, n.mac)
+		if err != nil {
+			return "", "", fmt.Errorf("get addresses of virtual machine %q: %v", n.name, err)
+		}
+
+		for _, a := range addrs {
+			if !strings.Contains(a, ":") {
+				ip = defaults.Default(ip, a)
+			} else if !strings.HasPrefix(a, "fe80") {
+				ip6 = defaults.Default(ip6, a)
+			}
+		}
+
+		if ip != "" {
+			return ip, ip6, nil
+		}
+
+		if time.Now().After(deadline) {
+			return "", "", fmt.Errorf("virtual machine %q did not obtain an IP address within %s", n.name, p.pollTimeout)
+		}
+
+		time.Sleep(p.pollInterval)
+	}
+}
+
+// awaitNode waits until cloud-init finishes on the newly created node and
+// optionally adds its SSH key to the known hosts.
+func (p *libvirt) awaitNode(n node, ip string) error {
+	ui.Printf(ui.INFO, "Waiting for cloud-init to finish on virtual machine %q...\n", n.name)
+
+	deadline := time.Now().Add(p.pollTimeout)
+
+	for {
+		err := p.runner.Run(ip, cloudInitWaitScript, true)
+		if err == nil {
+			break
+		}
+
+		// SSH server is not reachable until the virtual machine boots.
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wait for cloud-init on virtual machine %q: %v", n.name, err)
+		}
+
+		time.Sleep(p.pollInterval)
+	}
+
+	if !p.cfg.Cluster.NodeTemplate.SSH.AddToKnownHosts {
+		return nil
+	}
+
+	c := exec.NewLocalClient()
+
+	err := c.Run("sh", "-c", knownHostsScript, "sh", ip)
+	if err != nil {
+		return fmt.Errorf("add virtual machine %q to known hosts: %v", n.name, err)
+	}
+
+	return nil
+}
+
+// host returns the configured host with the given name.
+func (p *libvirt) host(name string) config.Host {
+	for _, h := range p.cfg.Hosts {
+		if h.Name == name {
+			return h
+		}
+	}
+
+	return config.Host{Name: name}
+}
+
+// contains returns true if the resource is also created.
+func (c changes) contains(r resource) bool {
+	for _, cr := range c.create {
+		if cr.id() == r.id() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inspectImage returns the format and the virtual size of the image on
+// the given path. Images that are not in the QCOW2 format are treated as
+// raw images.
+func inspectImage(path string) (*image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	img := &image{
+		path:     path,
+		format:   "raw",
+		capacity: uint64(info.Size()),
+	}
+
+	// QCOW2 header starts with magic "QFI\xfb", while the virtual
+	// size is stored at offset 24.
+	header := make([]byte, 32)
+
+	_, err = io.ReadFull(f, header)
+	if err == nil && bytes.Equal(header[:4], []byte{'Q', 'F', 'I', 0xfb}) {
+		img.format = "qcow2"
+		img.capacity = binary.BigEndian.Uint64(header[24:])
+	}
+
+	return img, nil
+}
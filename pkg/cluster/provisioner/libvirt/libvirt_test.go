@@ -0,0 +1,303 @@
+package libvirt
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hypervisorMock keeps resources in memory and reports the given
+// addresses of all domains.
+type hypervisorMock struct {
+	resources map[string]resource
+	created   []string
+	deleted   []string
+	addresses []string
+	failOn    string
+}
+
+func (h *hypervisorMock) Exists(r resource) (bool, error) {
+	_, ok := h.resources[r.id()]
+	return ok, nil
+}
+
+func (h *hypervisorMock) Create(r resource) error {
+	if r.Name == h.failOn {
+		return errors.New("mock failure")
+	}
+
+	h.resources[r.id()] = r
+	h.created = append(h.created, r.id())
+	return nil
+}
+
+func (h *hypervisorMock) Delete(r resource) error {
+	delete(h.resources, r.id())
+	h.deleted = append(h.deleted, r.id())
+	return nil
+}
+
+func (h *hypervisorMock) Addresses(domain string, network string, mac string) ([]string, error) {
+	return h.addresses, nil
+}
+
+func (h *hypervisorMock) Close() error {
+	return nil
+}
+
+// nodeRunnerMock records hosts on which scripts are run.
+type nodeRunnerMock struct {
+	hosts []string
+}
+
+func (r *nodeRunnerMock) Run(host string, script string, sudo bool) error {
+	r.hosts = append(r.hosts, host)
+	return nil
+}
+
+// MockImage creates a QCOW2 image header with the given virtual size.
+func MockImage(t *testing.T, size uint64) string {
+	header := make([]byte, 512)
+	copy(header, []byte{'Q', 'F', 'I', 0xfb})
+	binary.BigEndian.PutUint64(header[24:], size)
+
+	path := filepath.Join(t.TempDir(), "image.qcow2")
+	require.NoError(t, os.WriteFile(path, header, 0644))
+
+	return path
+}
+
+func MockConfig(t *testing.T) *config.Config {
+	cfg := &config.Config{}
+	cfg.Hosts = []config.Host{
+		{Name: "localhost", MainResourcePoolPath: "/var/lib/libvirt/images"},
+	}
+
+	cfg.Cluster.Name = "mock"
+	cfg.Cluster.Network.Mode = config.NAT
+	cfg.Cluster.Network.CIDR = "10.10.0.0/24"
+	cfg.Cluster.NodeTemplate.User = "k8s"
+	cfg.Cluster.NodeTemplate.CpuMode = config.CUSTOM
+	cfg.Cluster.NodeTemplate.OS.NetworkInterface = "ens3"
+	cfg.Cluster.NodeTemplate.OS.Source = config.OSSource(MockImage(t, 2<<30))
+	cfg.Cluster.Nodes.Master.Instances = []config.MasterInstance{
+		{Id: "1", IP: "10.10.0.10", CPU: 2, RAM: 4, MainDiskSize: 32},
+	}
+	cfg.Cluster.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "1", CPU: 2, RAM: 4, MainDiskSize: 32},
+	}
+
+	return cfg
+}
+
+func MockLibvirtProvisioner(t *testing.T, cfg *config.Config) (*libvirt, *hypervisorMock, *nodeRunnerMock) {
+	tmpDir := t.TempDir()
+
+	pkey := filepath.Join(tmpDir, "id_rsa")
+	require.NoError(t, os.WriteFile(pkey+".pub", []byte("ssh-rsa AAAA mock\n"), 0600))
+
+	hv := &hypervisorMock{
+		resources: make(map[string]resource),
+		addresses: []string{"fe80::1", "10.10.0.50"},
+	}
+
+	runner := &nodeRunnerMock{}
+
+	p := NewLibvirtProvisioner(
+		filepath.Join(tmpDir, "libvirt", "state.yaml"),
+		filepath.Join(tmpDir, "infrastructure.yaml"),
+		filepath.Join(tmpDir, "share"),
+		pkey,
+		false,
+		nil,
+		cfg,
+	).(*libvirt)
+
+	p.connect = func(config.Host) (hypervisor, error) { return hv, nil }
+	p.runner = runner
+	p.pollInterval = time.Millisecond
+	p.pollTimeout = time.Second
+
+	return p, hv, runner
+}
+
+func TestLibvirt_Apply(t *testing.T) {
+	p, hv, runner := MockLibvirtProvisioner(t, MockConfig(t))
+	require.NoError(t, p.Init(nil))
+
+	changes, err := p.Plan()
+	require.NoError(t, err)
+	assert.True(t, changes)
+
+	require.NoError(t, p.Apply())
+
+	assert.Equal(t, []string{
+		"pool///mock-main-resource-pool",
+		"volume/mock-main-resource-pool//base_volume",
+		"network///mock-network",
+		"volume/mock-main-resource-pool//mock-master-1-main-disk",
+		"volume/mock-main-resource-pool//mock-master-1-cloud-init.iso",
+		"dhcpHost//mock-network/mock-master-1",
+		"domain///mock-master-1",
+		"volume/mock-main-resource-pool//mock-worker-1-main-disk",
+		"volume/mock-main-resource-pool//mock-worker-1-cloud-init.iso",
+		"domain///mock-worker-1",
+	}, hv.created)
+
+	// Cloud-init is awaited on newly created virtual machines.
+	assert.Equal(t, []string{"10.10.0.10", "10.10.0.50"}, runner.hosts)
+
+	infraCfg, err := file.ReadYaml(p.infraConfigPath, infra.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "mock-master-1", infraCfg.Nodes.Master.Instances[0].Name)
+	assert.Equal(t, config.IPv4("10.10.0.10"), infraCfg.Nodes.Master.Instances[0].IP)
+	assert.Equal(t, config.IPv4("10.10.0.50"), infraCfg.Nodes.Worker.Instances[0].IP)
+	assert.Equal(t, config.IPv4("10.10.0.10"), infraCfg.Nodes.LoadBalancer.VIP)
+
+	changes, err = p.Plan()
+	require.NoError(t, err)
+	assert.False(t, changes)
+}
+
+func TestLibvirt_Replace(t *testing.T) {
+	cfg := MockConfig(t)
+
+	p, hv, _ := MockLibvirtProvisioner(t, cfg)
+	require.NoError(t, p.Init(nil))
+	require.NoError(t, p.Apply())
+
+	hv.created = nil
+
+	cfg.Cluster.Nodes.Worker.Instances[0].RAM = 8
+	cfg.Cluster.Nodes.Worker.Instances = append(cfg.Cluster.Nodes.Worker.Instances[:1:1], config.WorkerInstance{Id: "2", MainDiskSize: 16})
+	cfg.Cluster.Nodes.Master.Instances = nil
+
+	require.NoError(t, p.Init(nil))
+	require.NoError(t, p.Apply())
+
+	assert.Equal(t, []string{
+		"domain///mock-worker-1",
+		"domain///mock-master-1",
+		"dhcpHost//mock-network/mock-master-1",
+		"volume/mock-main-resource-pool//mock-master-1-cloud-init.iso",
+		"volume/mock-main-resource-pool//mock-master-1-main-disk",
+	}, hv.deleted)
+
+	assert.Equal(t, []string{
+		"domain///mock-worker-1",
+		"volume/mock-main-resource-pool//mock-worker-2-main-disk",
+		"volume/mock-main-resource-pool//mock-worker-2-cloud-init.iso",
+		"domain///mock-worker-2",
+	}, hv.created)
+}
+
+func TestLibvirt_Destroy(t *testing.T) {
+	p, hv, _ := MockLibvirtProvisioner(t, MockConfig(t))
+	require.NoError(t, p.Init(nil))
+	require.NoError(t, p.Apply())
+	require.NoError(t, p.Destroy())
+
+	assert.Empty(t, hv.resources)
+	assert.Equal(t, "domain///mock-worker-1", hv.deleted[0])
+	assert.Equal(t, "pool///mock-main-resource-pool", hv.deleted[len(hv.deleted)-1])
+
+	s, err := readState(p.statePath)
+	require.NoError(t, err)
+	assert.Empty(t, s.Hosts)
+}
+
+func TestLibvirt_RemovedHost(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Hosts = append(cfg.Hosts, config.Host{Name: "remote", MainResourcePoolPath: "/pool"})
+	cfg.Cluster.Nodes.Worker.Instances[0].Host = "remote"
+
+	p, _, _ := MockLibvirtProvisioner(t, cfg)
+
+	remote := &hypervisorMock{resources: make(map[string]resource), addresses: []string{"10.10.0.60"}}
+	local := &hypervisorMock{resources: make(map[string]resource)}
+	p.connect = func(h config.Host) (hypervisor, error) {
+		if h.Name == "remote" {
+			return remote, nil
+		}
+
+		return local, nil
+	}
+
+	require.NoError(t, p.Init(nil))
+	require.NoError(t, p.Apply())
+
+	cfg.Hosts = cfg.Hosts[:1]
+	cfg.Cluster.Nodes.Worker.Instances = nil
+
+	require.NoError(t, p.Init(nil))
+	require.NoError(t, p.Apply())
+
+	assert.Equal(t, []string{
+		"domain///mock-worker-1",
+		"volume/mock-main-resource-pool//mock-worker-1-cloud-init.iso",
+		"volume/mock-main-resource-pool//mock-worker-1-main-disk",
+		"network///mock-network",
+		"volume/mock-main-resource-pool//base_volume",
+		"pool///mock-main-resource-pool",
+	}, remote.deleted)
+
+	assert.Empty(t, remote.resources)
+	assert.Empty(t, local.deleted)
+}
+
+func TestLibvirt_FailedApply(t *testing.T) {
+	p, hv, _ := MockLibvirtProvisioner(t, MockConfig(t))
+	hv.failOn = "mock-worker-1"
+
+	require.NoError(t, p.Init(nil))
+	assert.EqualError(t, p.Apply(), `libvirt: host "localhost": create domain "mock-worker-1": mock failure`)
+
+	// Created resources are recorded, so the apply can be resumed.
+	s, err := readState(p.statePath)
+	require.NoError(t, err)
+	assert.Len(t, s.Hosts[0].Resources, 9)
+
+	hv.failOn = ""
+	hv.created = nil
+
+	require.NoError(t, p.Apply())
+	assert.Equal(t, []string{"domain///mock-worker-1"}, hv.created)
+}
+
+func TestLibvirt_UnmanagedResource(t *testing.T) {
+	p, hv, _ := MockLibvirtProvisioner(t, MockConfig(t))
+	hv.resources["network///mock-network"] = resource{}
+
+	require.NoError(t, p.Init(nil))
+	assert.EqualError(t, p.Apply(), `libvirt: host "localhost": network "mock-network" already exists and is not managed by Kubitect`)
+}
+
+func TestLibvirt_NoConfig(t *testing.T) {
+	p := NewLibvirtProvisioner("", "", "", "", false, nil, nil)
+	assert.EqualError(t, p.Init(nil), "libvirt: configuration is required")
+}
+
+func TestInspectImage(t *testing.T) {
+	img, err := inspectImage(MockImage(t, 1024))
+	require.NoError(t, err)
+	assert.Equal(t, "qcow2", img.format)
+	assert.Equal(t, uint64(1024), img.capacity)
+
+	raw := filepath.Join(t.TempDir(), "image.raw")
+	require.NoError(t, os.WriteFile(raw, []byte("raw"), 0644))
+
+	img, err = inspectImage(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "raw", img.format)
+	assert.Equal(t, uint64(3), img.capacity)
+}
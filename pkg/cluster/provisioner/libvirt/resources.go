@@ -0,0 +1,772 @@
+package libvirt
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+)
+
+// baseVolumeName is the name of the volume containing the OS image, which
+// backs main disks of all virtual machines on the host.
+const baseVolumeName = "base_volume"
+
+type resourceKind string
+
+const (
+	kindPool     resourceKind = "pool"
+	kindVolume   resourceKind = "volume"
+	kindNetwork  resourceKind = "network"
+	kindDHCPHost resourceKind = "dhcpHost"
+	kindDomain   resourceKind = "domain"
+)
+
+type (
+	// resource is a libvirt object managed by the provisioner.
+	resource struct {
+		Kind resourceKind `yaml:"kind"`
+		Name string       `yaml:"name"`
+
+		// Pool containing the volume.
+		Pool string `yaml:"pool,omitempty"`
+
+		// Network containing the static DHCP lease.
+		Network string `yaml:"network,omitempty"`
+
+		// XML definition of the resource.
+		XML string `yaml:"xml"`
+
+		// Hash of the resource definition. Resource is replaced
+		// whenever its hash changes.
+		Hash string `yaml:"hash"`
+
+		// Path of the local file uploaded into the volume.
+		Source string `yaml:"-"`
+
+		// Content uploaded into the volume.
+		Content []byte `yaml:"-"`
+	}
+
+	// hostResources are resources of a single host. Resources are
+	// created in the listed order and deleted in the reverse order.
+	hostResources struct {
+		Host      config.Host `yaml:"host"`
+		Resources []resource  `yaml:"resources"`
+	}
+
+	// node is a virtual machine of the cluster.
+	node struct {
+		instance config.Instance
+		name     string
+		host     string
+
+		// Network whose DHCP leases contain the node's address. If
+		// empty, the address is reported by the guest agent.
+		network string
+
+		// MAC address of the node's primary interface.
+		mac string
+
+		dataDisks []config.DataDisk
+	}
+
+	// image is the OS image from which main disks are created.
+	image struct {
+		path     string
+		format   string
+		capacity uint64
+	}
+)
+
+// id returns an identifier of the resource that is unique within a host.
+func (r resource) id() string {
+	return fmt.Sprintf("%s/%s/%s/%s", r.Kind, r.Pool, r.Network, r.Name)
+}
+
+// String returns a human readable description of the resource.
+func (r resource) String() string {
+	switch r.Kind {
+	case kindVolume:
+		return fmt.Sprintf("volume %q (pool %q)", r.Name, r.Pool)
+	case kindDHCPHost:
+		return fmt.Sprintf("DHCP host %q (network %q)", r.Name, r.Network)
+	default:
+		return fmt.Sprintf("%s %q", r.Kind, r.Name)
+	}
+}
+
+// newResource returns a resource with the given XML definition. Hash of
+// the resource is computed from the definition and the given values
+// (e.g. content or hashes of the resources it depends on).
+func newResource(kind resourceKind, name string, def any, values ...string) (resource, error) {
+	x, err := marshalXML(def)
+	if err != nil {
+		return resource{}, fmt.Errorf("%s %q: %v", kind, name, err)
+	}
+
+	return resource{
+		Kind: kind,
+		Name: name,
+		XML:  x,
+		Hash: hash(append([]string{x}, values...)...),
+	}, nil
+}
+
+// builder builds resources of the cluster from its configuration.
+type builder struct {
+	cfg          *config.Config
+	image        image
+	sshPublicKey string
+}
+
+// build returns resources of each configured host and nodes of the
+// cluster.
+func (b builder) build() ([]hostResources, []node, error) {
+	var hosts []hostResources
+	var nodes []node
+
+	for _, h := range b.cfg.Hosts {
+		res, hostNodes, err := b.buildHost(h)
+		if err != nil {
+			return nil, nil, fmt.Errorf("host %q: %v", h.Name, err)
+		}
+
+		hosts = append(hosts, res)
+		nodes = append(nodes, hostNodes...)
+	}
+
+	return hosts, nodes, nil
+}
+
+// buildHost returns resources and nodes of the given host.
+func (b builder) buildHost(h config.Host) (hostResources, []node, error) {
+	res := hostResources{Host: h}
+	add := func(r resource, err error) (resource, error) {
+		if err == nil {
+			res.Resources = append(res.Resources, r)
+		}
+
+		return r, err
+	}
+
+	// Resource pools.
+	mainPool, err := add(newResource(kindPool, b.mainPoolName(), b.poolXML(b.mainPoolName(), h.MainResourcePoolPath)))
+	if err != nil {
+		return res, nil, err
+	}
+
+	pools := map[string]resource{"main": mainPool}
+
+	for _, p := range h.DataResourcePools {
+		name := b.dataPoolName(p.Name)
+
+		pools[p.Name], err = add(newResource(kindPool, name, b.poolXML(name, p.Path)))
+		if err != nil {
+			return res, nil, err
+		}
+	}
+
+	// Base volume is never replaced, since main disks of the existing
+	// virtual machines depend on it.
+	base := volumeXML{
+		Name:     baseVolumeName,
+		Capacity: volumeSizeXML{Unit: "bytes", Value: b.image.capacity},
+		Target:   volumeTargetXML{Format: volumeFormatXML{Type: b.image.format}},
+	}
+
+	baseVol, err := newResource(kindVolume, baseVolumeName, base)
+	if err != nil {
+		return res, nil, err
+	}
+
+	baseVol.Pool = mainPool.Name
+	baseVol.Source = b.image.path
+	baseVol.Hash = hash(mainPool.Hash, baseVolumeName)
+	res.Resources = append(res.Resources, baseVol)
+
+	// Networks.
+	networks := make(map[string]resource)
+
+	net := b.cfg.Cluster.Network
+	if isManagedNetwork(net.Mode) {
+		networks[""], err = add(newResource(kindNetwork, b.networkName(), networkDef(b.networkName(), net.Mode, net.Bridge, net.CIDR, net.CIDR6)))
+		if err != nil {
+			return res, nil, err
+		}
+	}
+
+	for _, n := range b.cfg.Cluster.AdditionalNetworks {
+		if !isManagedNetwork(n.Mode) {
+			continue
+		}
+
+		name := b.additionalNetworkName(n.Name)
+
+		networks[n.Name], err = add(newResource(kindNetwork, name, networkDef(name, n.Mode, n.Bridge, n.CIDR, "")))
+		if err != nil {
+			return res, nil, err
+		}
+	}
+
+	// Virtual machines.
+	var nodes []node
+
+	for _, i := range b.instances(h) {
+		vm, err := b.buildNode(i, h, pools, baseVol, networks)
+		if err != nil {
+			return res, nil, err
+		}
+
+		res.Resources = append(res.Resources, vm.resources...)
+		nodes = append(nodes, vm.node)
+	}
+
+	return res, nodes, nil
+}
+
+// vm contains the node and resources of its virtual machine.
+type vm struct {
+	node      node
+	resources []resource
+}
+
+// buildNode returns resources of the virtual machine of the given instance.
+func (b builder) buildNode(i config.Instance, h config.Host, pools map[string]resource, baseVol resource, networks map[string]resource) (vm, error) {
+	name := b.instanceName(i)
+	net := b.cfg.Cluster.Network
+	mainPool := pools["main"]
+
+	n := node{
+		instance:  i,
+		name:      name,
+		host:      h.Name,
+		mac:       string(i.GetMAC()),
+		dataDisks: dataDisks(i),
+	}
+
+	if n.mac == "" {
+		n.mac = stableMAC(name)
+	}
+
+	var res []resource
+
+	// Main disk.
+	mainDisk := volumeXML{
+		Name:     name + "-main-disk",
+		Capacity: volumeSizeXML{Unit: "GiB", Value: uint64(mainDiskSize(i))},
+		Target:   volumeTargetXML{Format: volumeFormatXML{Type: "qcow2"}},
+		BackingStore: &volumeTargetXML{
+			Path:   filepath.Join(poolPath(h.MainResourcePoolPath, mainPool.Name), baseVolumeName),
+			Format: volumeFormatXML{Type: b.image.format},
+		},
+	}
+
+	r, err := newResource(kindVolume, mainDisk.Name, mainDisk, baseVol.Hash)
+	if err != nil {
+		return vm{}, err
+	}
+
+	r.Pool = mainPool.Name
+	res = append(res, r)
+
+	dom := domainDef(name, i, b.cfg.Cluster.NodeTemplate.CpuMode)
+	dom.Devices.Disks = append(dom.Devices.Disks, diskDef(mainPool.Name, mainDisk.Name, "qcow2", "vda", "virtio"))
+
+	// Data disks.
+	cloudInitDisks := make(map[string]string)
+
+	for k, d := range n.dataDisks {
+		pool, ok := pools[d.Pool]
+		if !ok {
+			return vm{}, fmt.Errorf("data disk %q of instance %q: pool %q is not configured", d.Name, name, d.Pool)
+		}
+
+		disk := volumeXML{
+			Name:     fmt.Sprintf("%s-%s-data-disk", name, d.Name),
+			Capacity: volumeSizeXML{Unit: "GiB", Value: uint64(d.Size)},
+			Target:   volumeTargetXML{Format: volumeFormatXML{Type: "qcow2"}},
+		}
+
+		r, err := newResource(kindVolume, disk.Name, disk, pool.Hash)
+		if err != nil {
+			return vm{}, err
+		}
+
+		r.Pool = pool.Name
+		res = append(res, r)
+
+		dd := diskDef(pool.Name, disk.Name, "qcow2", fmt.Sprintf("sd%c", 'a'+k), "scsi")
+		dd.WWN = diskWWN(name, d.Name)
+		dom.Devices.Disks = append(dom.Devices.Disks, dd)
+
+		if !d.IsRaw() {
+			cloudInitDisks[d.Name] = diskDevice(name, d.Name)
+		}
+	}
+
+	if len(n.dataDisks) > 0 {
+		dom.Devices.Controllers = append(dom.Devices.Controllers, domainControllerXML{Type: "scsi", Model: "virtio-scsi"})
+	}
+
+	// Network interfaces.
+	var nets []cloudInitNetwork
+	var dhcpHosts []resource
+	var attached []resource
+
+	iface, err := b.primaryInterface(n.mac)
+	if err != nil {
+		return vm{}, err
+	}
+
+	dom.Devices.Interfaces = append(dom.Devices.Interfaces, iface)
+
+	if isManagedNetwork(net.Mode) {
+		n.network = b.networkName()
+		attached = append(attached, networks[""])
+
+		if net.Mode == config.NAT && i.GetIP() != "" {
+			r, err := dhcpHostDef(networks[""], name, n.mac, string(i.GetIP()))
+			if err != nil {
+				return vm{}, err
+			}
+
+			dhcpHosts = append(dhcpHosts, r)
+		}
+	}
+
+	for _, a := range i.GetNetworks() {
+		an, ok := b.additionalNetwork(a.Network)
+		if !ok {
+			return vm{}, fmt.Errorf("instance %q: additional network %q is not configured", name, a.Network)
+		}
+
+		mac := string(a.MAC)
+		if mac == "" {
+			mac = stableMAC(name + "-" + an.Name)
+		}
+
+		iface := domainInterfaceXML{
+			MAC:   &domainInterfaceMACXML{Address: mac},
+			Model: domainInterfaceModelXML{Type: "virtio"},
+		}
+
+		switch an.Mode {
+		case config.BRIDGE:
+			iface.Type = "bridge"
+			iface.Source.Bridge = string(an.Bridge)
+		case config.EXISTING:
+			iface.Type = "network"
+			iface.Source.Network = an.LibvirtNetwork
+		default:
+			iface.Type = "network"
+			iface.Source.Network = b.additionalNetworkName(an.Name)
+		}
+
+		dom.Devices.Interfaces = append(dom.Devices.Interfaces, iface)
+
+		if r, ok := networks[an.Name]; ok {
+			attached = append(attached, r)
+		}
+
+		cn := cloudInitNetwork{Name: an.Name, MAC: mac}
+		if a.IP != "" {
+			cn.CIDR = withPrefix(string(a.IP), string(an.CIDR))
+		}
+
+		nets = append(nets, cn)
+
+		if an.Mode == config.NAT && a.IP != "" {
+			r, err := dhcpHostDef(networks[an.Name], name, mac, string(a.IP))
+			if err != nil {
+				return vm{}, err
+			}
+
+			dhcpHosts = append(dhcpHosts, r)
+		}
+	}
+
+	// Cloud-init.
+	iso, err := b.cloudInit(n, cloudInitDisks, nets)
+	if err != nil {
+		return vm{}, fmt.Errorf("cloud-init of instance %q: %v", name, err)
+	}
+
+	isoVol := volumeXML{
+		Name:     name + "-cloud-init.iso",
+		Capacity: volumeSizeXML{Unit: "bytes", Value: uint64(len(iso))},
+		Target:   volumeTargetXML{Format: volumeFormatXML{Type: "raw"}},
+	}
+
+	r, err = newResource(kindVolume, isoVol.Name, isoVol, mainPool.Hash, hash(string(iso)))
+	if err != nil {
+		return vm{}, err
+	}
+
+	r.Pool = mainPool.Name
+	r.Content = iso
+	res = append(res, r)
+
+	cdrom := diskDef(mainPool.Name, isoVol.Name, "raw", "hdd", "ide")
+	cdrom.Device = "cdrom"
+	cdrom.ReadOnly = &struct{}{}
+	dom.Devices.Disks = append(dom.Devices.Disks, cdrom)
+
+	// Domain depends on all of its volumes and networks.
+	var deps []string
+	for _, r := range res {
+		deps = append(deps, r.Hash)
+	}
+
+	for _, r := range attached {
+		deps = append(deps, r.Hash)
+	}
+
+	res = append(res, dhcpHosts...)
+
+	r, err = newResource(kindDomain, name, dom, deps...)
+	if err != nil {
+		return vm{}, err
+	}
+
+	res = append(res, r)
+
+	return vm{node: n, resources: res}, nil
+}
+
+// primaryInterface returns the interface attached to the cluster network.
+func (b builder) primaryInterface(mac string) (domainInterfaceXML, error) {
+	net := b.cfg.Cluster.Network
+
+	iface := domainInterfaceXML{
+		MAC:   &domainInterfaceMACXML{Address: mac},
+		Model: domainInterfaceModelXML{Type: "virtio"},
+	}
+
+	switch net.Mode {
+	case config.NAT, config.ROUTE:
+		iface.Type = "network"
+		iface.Source.Network = b.networkName()
+	case config.EXISTING:
+		iface.Type = "network"
+		iface.Source.Network = net.LibvirtNetwork
+	case config.BRIDGE:
+		if net.Macvtap != "" {
+			iface.Type = "direct"
+			iface.Source.Dev = string(net.Macvtap)
+			iface.Source.Mode = "bridge"
+		} else {
+			iface.Type = "bridge"
+			iface.Source.Bridge = string(net.Bridge)
+		}
+	default:
+		return iface, fmt.Errorf("unsupported network mode %q", net.Mode)
+	}
+
+	return iface, nil
+}
+
+// instances returns instances placed on the given host. Instances without
+// a host are placed on the default host.
+func (b builder) instances(h config.Host) []config.Instance {
+	def := defaultHost(b.cfg.Hosts)
+
+	var ins []config.Instance
+
+	for _, i := range b.cfg.Cluster.Nodes.Instances() {
+		host := instanceHost(i)
+		if host == h.Name || (host == "" && h.Name == def) {
+			ins = append(ins, i)
+		}
+	}
+
+	return ins
+}
+
+// additionalNetwork returns the additional network with the given name.
+func (b builder) additionalNetwork(name string) (config.AdditionalNetwork, bool) {
+	for _, n := range b.cfg.Cluster.AdditionalNetworks {
+		if n.Name == name {
+			return n, true
+		}
+	}
+
+	return config.AdditionalNetwork{}, false
+}
+
+func (b builder) poolXML(name string, dir string) poolXML {
+	return poolXML{
+		Type:   "dir",
+		Name:   name,
+		Target: poolTargetXML{Path: poolPath(dir, name)},
+	}
+}
+
+func (b builder) mainPoolName() string {
+	return b.cfg.Cluster.Name + "-main-resource-pool"
+}
+
+func (b builder) dataPoolName(name string) string {
+	return fmt.Sprintf("%s-%s-data-resource-pool", b.cfg.Cluster.Name, name)
+}
+
+func (b builder) networkName() string {
+	return b.cfg.Cluster.Name + "-network"
+}
+
+func (b builder) additionalNetworkName(name string) string {
+	return fmt.Sprintf("%s-%s-network", b.cfg.Cluster.Name, name)
+}
+
+// instanceName returns the name of the virtual machine of the given
+// instance, which matches the name used by the Terraform provisioner.
+func (b builder) instanceName(i config.Instance) string {
+	return fmt.Sprintf("%s-%s-%s", b.cfg.Cluster.Name, i.GetTypeName(), i.GetID())
+}
+
+// networkDef returns the definition of a network managed by Kubitect.
+// The first address of each CIDR is assigned to the host, while the rest
+// of the addresses are served by DHCP.
+func networkDef(name string, mode config.NetworkMode, bridge config.NetworkBridge, cidr config.CIDRv4, cidr6 config.CIDRv6) networkXML {
+	n := networkXML{
+		Name:    name,
+		Forward: &networkForwardXML{Mode: string(mode)},
+		DNS:     networkDNSXML{Enable: "yes"},
+	}
+
+	if bridge != "" {
+		n.Bridge = &networkBridgeXML{Name: string(bridge), STP: "on"}
+	}
+
+	for _, c := range []string{string(cidr), string(cidr6)} {
+		prefix, err := netip.ParsePrefix(c)
+		if err != nil {
+			continue
+		}
+
+		prefix = prefix.Masked()
+		ip := networkIPXML{
+			Address: prefix.Addr().Next().String(),
+			Prefix:  prefix.Bits(),
+			DHCP: &networkDHCPXML{
+				Ranges: []networkDHCPRangeXML{{
+					Start: prefix.Addr().Next().Next().String(),
+					End:   lastAddr(prefix).Prev().String(),
+				}},
+			},
+		}
+
+		if prefix.Addr().Is6() {
+			ip.Family = "ipv6"
+		}
+
+		n.IPs = append(n.IPs, ip)
+	}
+
+	return n
+}
+
+// dhcpHostDef returns a static DHCP lease of the given network.
+func dhcpHostDef(net resource, name string, mac string, ip string) (resource, error) {
+	r, err := newResource(kindDHCPHost, name, dhcpHostXML{MAC: mac, Name: name, IP: ip}, net.Hash)
+	r.Network = net.Name
+	return r, err
+}
+
+// domainDef returns the domain of the given instance without disks and
+// network interfaces.
+func domainDef(name string, i config.Instance, cpuMode config.CpuMode) domainXML {
+	cpu, ram := instanceResources(i)
+
+	return domainXML{
+		Type:     "kvm",
+		Name:     name,
+		Memory:   volumeSizeXML{Unit: "MiB", Value: uint64(ram) * 1024},
+		VCPU:     int(cpu),
+		OS:       domainOSXML{Type: "hvm"},
+		Features: domainFeaturesXML{ACPI: &struct{}{}, APIC: &struct{}{}},
+		CPU:      domainCPUXML{Mode: string(cpuMode)},
+		Devices: domainDevicesXML{
+			Serials: []domainConsoleXML{
+				{Type: "pty", Target: domainConsoleTargetXML{Port: "0"}},
+			},
+			Consoles: []domainConsoleXML{
+				{Type: "pty", Target: domainConsoleTargetXML{Type: "serial", Port: "0"}},
+				{Type: "pty", Target: domainConsoleTargetXML{Type: "virtio", Port: "1"}},
+			},
+			Channels: []domainChannelXML{
+				{Type: "unix", Target: domainChannelTargetXML{Type: "virtio", Name: "org.qemu.guest_agent.0"}},
+			},
+			Graphics: []domainGraphicsXML{
+				{Type: "vnc", AutoPort: "yes", Listen: domainGraphicsListenXML{Type: "address"}},
+			},
+		},
+	}
+}
+
+// diskDef returns a disk backed by the given volume.
+func diskDef(pool string, volume string, format string, dev string, bus string) domainDiskXML {
+	return domainDiskXML{
+		Type:   "volume",
+		Device: "disk",
+		Driver: domainDiskDriverXML{Name: "qemu", Type: format},
+		Source: domainDiskSourceXML{Pool: pool, Volume: volume},
+		Target: domainDiskTargetXML{Dev: dev, Bus: bus},
+	}
+}
+
+// instanceHost returns the name of the host on which the instance is
+// placed.
+func instanceHost(i config.Instance) string {
+	switch i := i.(type) {
+	case config.LBInstance:
+		return i.Host
+	case config.MasterInstance:
+		return i.Host
+	case config.WorkerInstance:
+		return i.Host
+	}
+
+	return ""
+}
+
+// instanceResources returns the number of virtual CPUs and the amount of
+// RAM (in GiB) of the given instance.
+func instanceResources(i config.Instance) (config.VCpu, config.GB) {
+	switch i := i.(type) {
+	case config.LBInstance:
+		return i.CPU, i.RAM
+	case config.MasterInstance:
+		return i.CPU, i.RAM
+	case config.WorkerInstance:
+		return i.CPU, i.RAM
+	}
+
+	return 0, 0
+}
+
+// mainDiskSize returns the size of the main disk of the given instance.
+func mainDiskSize(i config.Instance) config.GB {
+	switch i := i.(type) {
+	case config.LBInstance:
+		return i.MainDiskSize
+	case config.MasterInstance:
+		return i.MainDiskSize
+	case config.WorkerInstance:
+		return i.MainDiskSize
+	}
+
+	return 0
+}
+
+// dataDisks returns data disks of the given instance sorted by name.
+// Load balancers have no data disks.
+func dataDisks(i config.Instance) []config.DataDisk {
+	var disks []config.DataDisk
+
+	switch i := i.(type) {
+	case config.MasterInstance:
+		disks = append(disks, i.DataDisks...)
+	case config.WorkerInstance:
+		disks = append(disks, i.DataDisks...)
+	}
+
+	for k := range disks {
+		if disks[k].Pool == "" {
+			disks[k].Pool = "main"
+		}
+	}
+
+	sort.Slice(disks, func(a, b int) bool {
+		return disks[a].Name < disks[b].Name
+	})
+
+	return disks
+}
+
+// defaultHost returns the name of the host on which instances without
+// a host are placed.
+func defaultHost(hosts []config.Host) string {
+	for _, h := range hosts {
+		if h.Default {
+			return h.Name
+		}
+	}
+
+	if len(hosts) > 0 {
+		return hosts[0].Name
+	}
+
+	return ""
+}
+
+// isManagedNetwork returns true if the network in the given mode is
+// created by Kubitect.
+func isManagedNetwork(mode config.NetworkMode) bool {
+	return mode == config.NAT || mode == config.ROUTE
+}
+
+// poolPath returns the path of the pool with the given name within the
+// given directory.
+func poolPath(dir string, name string) string {
+	if p, err := expandHome(dir); err == nil {
+		dir = p
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// stableMAC returns a MAC address derived from the given value.
+func stableMAC(v string) string {
+	sum := md5.Sum([]byte(v))
+	return fmt.Sprintf("52:54:00:%02x:%02x:%02x", sum[0], sum[1], sum[2])
+}
+
+// diskWWN returns a stable WWN of the data disk, so that the disk can be
+// identified within the virtual machine regardless of the order in which
+// disks are detected. The WWN matches the one set by the Terraform
+// provisioner.
+func diskWWN(vm string, disk string) string {
+	return fmt.Sprintf("05abcd%x", md5.Sum([]byte(vm+"-"+disk)))[:16]
+}
+
+// diskDevice returns the path under which the data disk is available
+// within the virtual machine.
+func diskDevice(vm string, disk string) string {
+	return "/dev/disk/by-id/wwn-0x" + diskWWN(vm, disk)
+}
+
+// withPrefix returns the IP address with the prefix length of the given
+// CIDR (e.g. "10.10.0.5/24").
+func withPrefix(ip string, cidr string) string {
+	_, bits, _ := strings.Cut(cidr, "/")
+	return ip + "/" + bits
+}
+
+// lastAddr returns the last address of the given prefix.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+
+	a, _ := netip.AddrFromSlice(b)
+	return a
+}
+
+// hash returns a SHA256 hash of the given values.
+func hash(values ...string) string {
+	h := sha256.New()
+
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
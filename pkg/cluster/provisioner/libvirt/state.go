@@ -0,0 +1,168 @@
+package libvirt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+// state contains resources created by the provisioner. It is used to
+// compute changes between the applied and the desired resources, and to
+// remove resources of hosts that are no longer configured.
+type state struct {
+	Hosts []hostResources `yaml:"hosts"`
+}
+
+// readState reads the state from the given path. If the state file does
+// not exist, an empty state is returned.
+func readState(path string) (*state, error) {
+	if !file.Exists(path) {
+		return &state{}, nil
+	}
+
+	s, err := file.ReadYaml(path, state{})
+	if err != nil {
+		return nil, fmt.Errorf("read state: %v", err)
+	}
+
+	return s, nil
+}
+
+// write writes the state to the given path.
+func (s *state) write(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = file.WriteYaml(s, path, 0600)
+	if err != nil {
+		return fmt.Errorf("write state: %v", err)
+	}
+
+	return nil
+}
+
+// host returns resources of the host with the given name.
+func (s *state) host(name string) *hostResources {
+	for i := range s.Hosts {
+		if s.Hosts[i].Host.Name == name {
+			return &s.Hosts[i]
+		}
+	}
+
+	return nil
+}
+
+// add records a created resource of the given host.
+func (s *state) add(h hostResources, r resource) {
+	hs := s.host(h.Host.Name)
+	if hs == nil {
+		s.Hosts = append(s.Hosts, hostResources{Host: h.Host})
+		hs = &s.Hosts[len(s.Hosts)-1]
+	}
+
+	hs.Host = h.Host
+	hs.Resources = append(hs.Resources, r)
+}
+
+// remove removes a deleted resource of the given host. Hosts without
+// resources are removed as well.
+func (s *state) remove(host string, r resource) {
+	hs := s.host(host)
+	if hs == nil {
+		return
+	}
+
+	for i := range hs.Resources {
+		if hs.Resources[i].id() == r.id() {
+			hs.Resources = append(hs.Resources[:i], hs.Resources[i+1:]...)
+			break
+		}
+	}
+
+	if len(hs.Resources) > 0 {
+		return
+	}
+
+	for i := range s.Hosts {
+		if s.Hosts[i].Host.Name == host {
+			s.Hosts = append(s.Hosts[:i], s.Hosts[i+1:]...)
+			break
+		}
+	}
+}
+
+// changes are resources of a host that need to be created or deleted.
+// Replaced resources are both deleted and created.
+type changes struct {
+	host   hostResources
+	create []resource
+	delete []resource
+}
+
+// diff returns changes required to transition from the current state to
+// the desired resources. Changes of hosts that are no longer desired are
+// returned as well, so their resources are removed.
+func diff(current *state, desired []hostResources) []changes {
+	var all []changes
+
+	for _, d := range desired {
+		c := changes{host: d}
+
+		var applied []resource
+		if hs := current.host(d.Host.Name); hs != nil {
+			applied = hs.Resources
+		}
+
+		want := make(map[string]resource)
+		for _, r := range d.Resources {
+			want[r.id()] = r
+		}
+
+		have := make(map[string]resource)
+		for i := len(applied) - 1; i >= 0; i-- {
+			r := applied[i]
+			have[r.id()] = r
+
+			if w, ok := want[r.id()]; !ok || w.Hash != r.Hash {
+				c.delete = append(c.delete, r)
+			}
+		}
+
+		for _, r := range d.Resources {
+			if h, ok := have[r.id()]; !ok || h.Hash != r.Hash {
+				c.create = append(c.create, r)
+			}
+		}
+
+		all = append(all, c)
+	}
+
+	for _, hs := range current.Hosts {
+		if hostDesired(desired, hs.Host.Name) {
+			continue
+		}
+
+		c := changes{host: hostResources{Host: hs.Host}}
+		for i := len(hs.Resources) - 1; i >= 0; i-- {
+			c.delete = append(c.delete, hs.Resources[i])
+		}
+
+		all = append(all, c)
+	}
+
+	return all
+}
+
+func hostDesired(desired []hostResources, name string) bool {
+	for _, d := range desired {
+		if d.Host.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
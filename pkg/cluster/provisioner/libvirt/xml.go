@@ -0,0 +1,216 @@
+package libvirt
+
+import (
+	"encoding/xml"
+)
+
+// XML definitions of the libvirt objects. Only elements that are
+// configured by Kubitect are defined, while libvirt fills in the rest.
+
+type (
+	poolXML struct {
+		XMLName xml.Name      `xml:"pool"`
+		Type    string        `xml:"type,attr"`
+		Name    string        `xml:"name"`
+		Target  poolTargetXML `xml:"target"`
+	}
+
+	poolTargetXML struct {
+		Path string `xml:"path"`
+	}
+
+	volumeXML struct {
+		XMLName      xml.Name         `xml:"volume"`
+		Name         string           `xml:"name"`
+		Capacity     volumeSizeXML    `xml:"capacity"`
+		Target       volumeTargetXML  `xml:"target"`
+		BackingStore *volumeTargetXML `xml:"backingStore,omitempty"`
+	}
+
+	volumeSizeXML struct {
+		Unit  string `xml:"unit,attr"`
+		Value uint64 `xml:",chardata"`
+	}
+
+	volumeTargetXML struct {
+		Path   string          `xml:"path,omitempty"`
+		Format volumeFormatXML `xml:"format"`
+	}
+
+	volumeFormatXML struct {
+		Type string `xml:"type,attr"`
+	}
+
+	networkXML struct {
+		XMLName xml.Name           `xml:"network"`
+		Name    string             `xml:"name"`
+		Forward *networkForwardXML `xml:"forward,omitempty"`
+		Bridge  *networkBridgeXML  `xml:"bridge,omitempty"`
+		DNS     networkDNSXML      `xml:"dns"`
+		IPs     []networkIPXML     `xml:"ip"`
+	}
+
+	networkForwardXML struct {
+		Mode string `xml:"mode,attr"`
+	}
+
+	networkBridgeXML struct {
+		Name string `xml:"name,attr,omitempty"`
+		STP  string `xml:"stp,attr"`
+	}
+
+	networkDNSXML struct {
+		Enable string `xml:"enable,attr"`
+	}
+
+	networkIPXML struct {
+		Family  string          `xml:"family,attr,omitempty"`
+		Address string          `xml:"address,attr"`
+		Prefix  int             `xml:"prefix,attr"`
+		DHCP    *networkDHCPXML `xml:"dhcp,omitempty"`
+	}
+
+	networkDHCPXML struct {
+		Ranges []networkDHCPRangeXML `xml:"range"`
+	}
+
+	networkDHCPRangeXML struct {
+		Start string `xml:"start,attr"`
+		End   string `xml:"end,attr"`
+	}
+
+	// dhcpHostXML is a static DHCP lease within the libvirt network.
+	dhcpHostXML struct {
+		XMLName xml.Name `xml:"host"`
+		MAC     string   `xml:"mac,attr"`
+		Name    string   `xml:"name,attr"`
+		IP      string   `xml:"ip,attr"`
+	}
+
+	domainXML struct {
+		XMLName  xml.Name          `xml:"domain"`
+		Type     string            `xml:"type,attr"`
+		Name     string            `xml:"name"`
+		Memory   volumeSizeXML     `xml:"memory"`
+		VCPU     int               `xml:"vcpu"`
+		OS       domainOSXML       `xml:"os"`
+		Features domainFeaturesXML `xml:"features"`
+		CPU      domainCPUXML      `xml:"cpu"`
+		Devices  domainDevicesXML  `xml:"devices"`
+	}
+
+	domainOSXML struct {
+		Type string `xml:"type"`
+	}
+
+	domainFeaturesXML struct {
+		ACPI *struct{} `xml:"acpi"`
+		APIC *struct{} `xml:"apic"`
+	}
+
+	domainCPUXML struct {
+		Mode string `xml:"mode,attr"`
+	}
+
+	domainDevicesXML struct {
+		Disks       []domainDiskXML       `xml:"disk"`
+		Controllers []domainControllerXML `xml:"controller"`
+		Interfaces  []domainInterfaceXML  `xml:"interface"`
+		Serials     []domainConsoleXML    `xml:"serial"`
+		Consoles    []domainConsoleXML    `xml:"console"`
+		Channels    []domainChannelXML    `xml:"channel"`
+		Graphics    []domainGraphicsXML   `xml:"graphics"`
+	}
+
+	domainDiskXML struct {
+		Type     string              `xml:"type,attr"`
+		Device   string              `xml:"device,attr"`
+		Driver   domainDiskDriverXML `xml:"driver"`
+		Source   domainDiskSourceXML `xml:"source"`
+		Target   domainDiskTargetXML `xml:"target"`
+		WWN      string              `xml:"wwn,omitempty"`
+		ReadOnly *struct{}           `xml:"readonly"`
+	}
+
+	domainDiskDriverXML struct {
+		Name string `xml:"name,attr"`
+		Type string `xml:"type,attr"`
+	}
+
+	domainDiskSourceXML struct {
+		Pool   string `xml:"pool,attr"`
+		Volume string `xml:"volume,attr"`
+	}
+
+	domainDiskTargetXML struct {
+		Dev string `xml:"dev,attr"`
+		Bus string `xml:"bus,attr"`
+	}
+
+	domainControllerXML struct {
+		Type  string `xml:"type,attr"`
+		Model string `xml:"model,attr"`
+	}
+
+	domainInterfaceXML struct {
+		Type   string                   `xml:"type,attr"`
+		Source domainInterfaceSourceXML `xml:"source"`
+		MAC    *domainInterfaceMACXML   `xml:"mac"`
+		Model  domainInterfaceModelXML  `xml:"model"`
+	}
+
+	domainInterfaceSourceXML struct {
+		Network string `xml:"network,attr,omitempty"`
+		Bridge  string `xml:"bridge,attr,omitempty"`
+		Dev     string `xml:"dev,attr,omitempty"`
+		Mode    string `xml:"mode,attr,omitempty"`
+	}
+
+	domainInterfaceMACXML struct {
+		Address string `xml:"address,attr"`
+	}
+
+	domainInterfaceModelXML struct {
+		Type string `xml:"type,attr"`
+	}
+
+	domainConsoleXML struct {
+		Type   string                 `xml:"type,attr"`
+		Target domainConsoleTargetXML `xml:"target"`
+	}
+
+	domainConsoleTargetXML struct {
+		Type string `xml:"type,attr,omitempty"`
+		Port string `xml:"port,attr"`
+	}
+
+	domainChannelXML struct {
+		Type   string                 `xml:"type,attr"`
+		Target domainChannelTargetXML `xml:"target"`
+	}
+
+	domainChannelTargetXML struct {
+		Type string `xml:"type,attr"`
+		Name string `xml:"name,attr"`
+	}
+
+	domainGraphicsXML struct {
+		Type     string                  `xml:"type,attr"`
+		AutoPort string                  `xml:"autoport,attr"`
+		Listen   domainGraphicsListenXML `xml:"listen"`
+	}
+
+	domainGraphicsListenXML struct {
+		Type string `xml:"type,attr"`
+	}
+)
+
+// marshalXML returns the indented XML of the given libvirt object.
+func marshalXML(v any) (string, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -8,3 +8,9 @@ type Provisioner interface {
 	Apply() error
 	Destroy() error
 }
+
+// StateMigrator is implemented by provisioners whose state can be moved
+// between storage backends.
+type StateMigrator interface {
+	MigrateState() error
+}
@@ -12,6 +12,7 @@ func (m provisionerMock) Init([]event.Event) error { return nil }
 func (m provisionerMock) Plan() (bool, error)      { return true, nil }
 func (m provisionerMock) Apply() error             { return nil }
 func (m provisionerMock) Destroy() error           { return nil }
+func (m provisionerMock) MigrateState() error      { return nil }
 
 func MockProvisioner(t *testing.T) Provisioner {
 	return provisionerMock{}
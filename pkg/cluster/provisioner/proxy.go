@@ -0,0 +1,64 @@
+package provisioner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+)
+
+// ProxyBootCmd returns cloud-init boot commands that configure the proxy
+// on the provisioned nodes. The proxy is set for login sessions, systemd
+// services and package managers (apt and dnf). Nil is returned if the
+// proxy is not configured.
+func ProxyBootCmd(cfg *config.Config) []string {
+	env := cfg.ProxyEnv()
+	if env == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var envLines, systemdEnv []string
+	for _, k := range keys {
+		envLines = append(envLines, shellQuote(fmt.Sprintf("%s=%s", k, env[k])))
+		systemdEnv = append(systemdEnv, fmt.Sprintf("%q", fmt.Sprintf("%s=%s", k, env[k])))
+	}
+
+	var aptLines []string
+	if cfg.Proxy.HTTP != "" {
+		aptLines = append(aptLines, shellQuote(fmt.Sprintf("Acquire::http::Proxy %q;", cfg.Proxy.HTTP)))
+	}
+
+	if cfg.Proxy.HTTPS != "" {
+		aptLines = append(aptLines, shellQuote(fmt.Sprintf("Acquire::https::Proxy %q;", cfg.Proxy.HTTPS)))
+	}
+
+	// Dnf uses a single proxy for all repositories.
+	dnfProxy := cfg.Proxy.HTTPS
+	if dnfProxy == "" {
+		dnfProxy = cfg.Proxy.HTTP
+	}
+
+	return []string{
+		fmt.Sprintf("sed -i -E '/^(%s)=/d' /etc/environment", strings.Join(keys, "|")),
+		fmt.Sprintf("printf '%%s\\n' %s >> /etc/environment", strings.Join(envLines, " ")),
+		"mkdir -p /etc/systemd/system.conf.d",
+		fmt.Sprintf("printf '%%s\\n' '[Manager]' %s > /etc/systemd/system.conf.d/kubitect-proxy.conf", shellQuote("DefaultEnvironment="+strings.Join(systemdEnv, " "))),
+		"cloud-init-per once kubitect-proxy-reexec systemctl daemon-reexec",
+		fmt.Sprintf("if [ -d /etc/apt/apt.conf.d ]; then printf '%%s\\n' %s > /etc/apt/apt.conf.d/95kubitect-proxy; fi", strings.Join(aptLines, " ")),
+		fmt.Sprintf("if [ -f /etc/dnf/dnf.conf ]; then sed -i '/^proxy=/d' /etc/dnf/dnf.conf && echo %s >> /etc/dnf/dnf.conf; fi", shellQuote("proxy="+string(dnfProxy))),
+	}
+}
+
+// shellQuote returns the value enclosed in single quotes, so that it is
+// passed to the shell command as is.
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
@@ -0,0 +1,27 @@
+package provisioner
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyBootCmd(t *testing.T) {
+	cfg := config.MockConfig(t)
+	assert.Nil(t, ProxyBootCmd(&cfg))
+
+	cfg.Proxy.HTTP = "http://proxy.example.com:3128"
+	cfg.Proxy.HTTPS = "http://secure.example.com:3128"
+
+	cmds := ProxyBootCmd(&cfg)
+	assert.Contains(t, cmds, `printf '%s\n' 'HTTPS_PROXY=http://secure.example.com:3128' 'HTTP_PROXY=http://proxy.example.com:3128' 'NO_PROXY=localhost,127.0.0.1,192.168.113.0/24,10.233.64.0/18,10.233.0.0/18' 'http_proxy=http://proxy.example.com:3128' 'https_proxy=http://secure.example.com:3128' 'no_proxy=localhost,127.0.0.1,192.168.113.0/24,10.233.64.0/18,10.233.0.0/18' >> /etc/environment`)
+	assert.Contains(t, cmds, `if [ -d /etc/apt/apt.conf.d ]; then printf '%s\n' 'Acquire::http::Proxy "http://proxy.example.com:3128";' 'Acquire::https::Proxy "http://secure.example.com:3128";' > /etc/apt/apt.conf.d/95kubitect-proxy; fi`)
+	assert.Contains(t, cmds, `if [ -f /etc/dnf/dnf.conf ]; then sed -i '/^proxy=/d' /etc/dnf/dnf.conf && echo 'proxy=http://secure.example.com:3128' >> /etc/dnf/dnf.conf; fi`)
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'value'`, shellQuote("value"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -0,0 +1,255 @@
+package static
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/cluster/event"
+	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	"github.com/MusicDin/kubitect/pkg/utils/exec"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+// resetScript removes Kubernetes installed by any of the supported
+// managers, while leaving the machine itself intact.
+const resetScript = `set -u
+
+# Kubeadm and Kubespray.
+if command -v kubeadm > /dev/null; then
+	kubeadm reset --force
+fi
+
+# K3s and RKE2.
+for s in /usr/local/bin/k3s-uninstall.sh /usr/local/bin/k3s-agent-uninstall.sh /usr/local/bin/rke2-uninstall.sh /usr/bin/rke2-uninstall.sh; do
+	if [ -x "$s" ]; then
+		"$s"
+	fi
+done
+
+rm -rf /etc/kubernetes /etc/cni/net.d /var/lib/etcd /var/lib/kubelet
+`
+
+type (
+	static struct {
+		// Path where infrastructure configuration is written.
+		infraConfigPath string
+
+		// Path to the private key used to access the machines.
+		sshPrivateKeyPath string
+
+		// Configuration file containing existing machines.
+		cfg *config.Config
+
+		// Runs scripts on the machines.
+		nodes nodeRunner
+	}
+
+	// nodeRunner runs shell scripts on the machines.
+	nodeRunner interface {
+		Run(host string, script string, sudo bool) error
+	}
+
+	// sshRunner runs scripts on the machines over SSH.
+	sshRunner struct {
+		user           string
+		privateKeyPath string
+	}
+)
+
+func (r sshRunner) Run(host string, script string, sudo bool) error {
+	ssh := exec.NewSSHClient(r.user, host).
+		WithPrivateKeyFile(r.privateKeyPath).
+		WithSuperUser(sudo)
+
+	defer ssh.Close()
+
+	ssh.SetStdin(strings.NewReader(script))
+	ssh.SetStdout(ui.Streams().Out().File())
+	ssh.SetStderr(ui.Streams().Err().File())
+
+	return ssh.Run("bash", "-s")
+}
+
+// NewStaticProvisioner returns a provisioner that uses existing machines
+// instead of provisioning new ones. Machines are never created or
+// deleted.
+func NewStaticProvisioner(
+	infraConfigPath string,
+	sshPrivateKeyPath string,
+	cfg *config.Config,
+) provisioner.Provisioner {
+	return &static{
+		infraConfigPath:   infraConfigPath,
+		sshPrivateKeyPath: sshPrivateKeyPath,
+		cfg:               cfg,
+	}
+}
+
+// Init ensures the configuration is present. Events are ignored, since
+// machines are never created or removed.
+func (s *static) Init([]event.Event) error {
+	if s.cfg == nil {
+		return fmt.Errorf("static: configuration is required")
+	}
+
+	if s.nodes == nil {
+		s.nodes = sshRunner{
+			user:           string(s.cfg.Cluster.NodeTemplate.User),
+			privateKeyPath: s.sshPrivateKeyPath,
+		}
+	}
+
+	return nil
+}
+
+// Plan verifies that all machines are reachable over SSH and returns
+// true if the infrastructure configuration has changed.
+func (s *static) Plan() (bool, error) {
+	infraCfg := s.infraConfig()
+
+	for _, i := range infraCfg.Nodes.Instances() {
+		ui.Printf(ui.INFO, "Verifying connectivity to machine %q (%s)...\n", s.instanceName(i), i.GetIP())
+
+		err := s.nodes.Run(string(i.GetIP()), "true", false)
+		if err != nil {
+			return false, fmt.Errorf("static: machine %q (%s) is not reachable over SSH: %v", s.instanceName(i), i.GetIP(), err)
+		}
+	}
+
+	if !file.Exists(s.infraConfigPath) {
+		return true, nil
+	}
+
+	current, err := file.ReadYaml(s.infraConfigPath, infra.Config{})
+	if err != nil {
+		return false, fmt.Errorf("static: read infrastructure file: %v", err)
+	}
+
+	return !reflect.DeepEqual(*current, infraCfg), nil
+}
+
+// Apply verifies connectivity to the machines and writes infrastructure
+// configuration.
+func (s *static) Apply() error {
+	_, err := s.Plan()
+	if err != nil {
+		return err
+	}
+
+	err = file.WriteYaml(s.infraConfig(), s.infraConfigPath, 0600)
+	if err != nil {
+		return fmt.Errorf("static: write infrastructure file: %v", err)
+	}
+
+	return nil
+}
+
+// Destroy resets Kubernetes on all master and worker nodes. Machines are
+// not deleted.
+func (s *static) Destroy() error {
+	err := s.Init(nil)
+	if err != nil {
+		return err
+	}
+
+	infraCfg := s.infraConfig()
+
+	if file.Exists(s.infraConfigPath) {
+		current, err := file.ReadYaml(s.infraConfigPath, infra.Config{})
+		if err != nil {
+			return fmt.Errorf("static: read infrastructure file: %v", err)
+		}
+
+		infraCfg = *current
+	}
+
+	type node struct {
+		name string
+		ip   config.IPv4
+	}
+
+	var nodes []node
+	for _, i := range infraCfg.Nodes.Master.Instances {
+		nodes = append(nodes, node{i.Name, i.IP})
+	}
+
+	for _, i := range infraCfg.Nodes.Worker.Instances {
+		nodes = append(nodes, node{i.Name, i.IP})
+	}
+
+	for _, n := range nodes {
+		ui.Printf(ui.INFO, "Resetting Kubernetes on machine %q (%s)...\n", n.name, n.ip)
+
+		err := s.nodes.Run(string(n.ip), resetScript, true)
+		if err != nil {
+			return fmt.Errorf("static: reset Kubernetes on machine %q: %v", n.name, err)
+		}
+	}
+
+	return nil
+}
+
+// infraConfig returns infrastructure configuration of the configured
+// machines. Virtual IP is selected the same way as by the Terraform
+// provisioner.
+func (s *static) infraConfig() infra.Config {
+	cfgNodes := s.cfg.Cluster.Nodes
+
+	var nodes config.Nodes
+
+	for _, i := range cfgNodes.LoadBalancer.Instances {
+		nodes.LoadBalancer.Instances = append(nodes.LoadBalancer.Instances, config.LBInstance{
+			Id:   i.Id,
+			Name: s.instanceName(i),
+			IP:   i.IP,
+			IP6:  i.IP6,
+		})
+	}
+
+	for _, i := range cfgNodes.Master.Instances {
+		nodes.Master.Instances = append(nodes.Master.Instances, config.MasterInstance{
+			Id:        i.Id,
+			Name:      s.instanceName(i),
+			IP:        i.IP,
+			IP6:       i.IP6,
+			DataDisks: i.DataDisks,
+		})
+	}
+
+	for _, i := range cfgNodes.Worker.Instances {
+		nodes.Worker.Instances = append(nodes.Worker.Instances, config.WorkerInstance{
+			Id:        i.Id,
+			Name:      s.instanceName(i),
+			IP:        i.IP,
+			IP6:       i.IP6,
+			DataDisks: i.DataDisks,
+		})
+	}
+
+	lbs := nodes.LoadBalancer.Instances
+
+	switch {
+	case len(lbs) == 0 && len(nodes.Master.Instances) > 0:
+		nodes.LoadBalancer.VIP = nodes.Master.Instances[0].IP
+		nodes.LoadBalancer.VIP6 = nodes.Master.Instances[0].IP6
+	case len(lbs) == 1:
+		nodes.LoadBalancer.VIP = defaults.Default(cfgNodes.LoadBalancer.VIP, lbs[0].IP)
+		nodes.LoadBalancer.VIP6 = defaults.Default(cfgNodes.LoadBalancer.VIP6, lbs[0].IP6)
+	default:
+		nodes.LoadBalancer.VIP = cfgNodes.LoadBalancer.VIP
+		nodes.LoadBalancer.VIP6 = cfgNodes.LoadBalancer.VIP6
+	}
+
+	return infra.Config{Nodes: nodes}
+}
+
+// instanceName returns the name of the given instance, which matches the
+// name of the instance provisioned by the Terraform provisioner.
+func (s *static) instanceName(i config.Instance) string {
+	return fmt.Sprintf("%s-%s-%s", s.cfg.Cluster.Name, i.GetTypeName(), i.GetID())
+}
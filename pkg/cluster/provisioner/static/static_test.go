@@ -0,0 +1,104 @@
+package static
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/models/infra"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeRunnerMock records hosts on which scripts are run and fails for
+// unreachable hosts.
+type nodeRunnerMock struct {
+	hosts       []string
+	scripts     []string
+	unreachable string
+}
+
+func (r *nodeRunnerMock) Run(host string, script string, sudo bool) error {
+	if host == r.unreachable {
+		return errors.New("connection refused")
+	}
+
+	r.hosts = append(r.hosts, host)
+	r.scripts = append(r.scripts, script)
+	return nil
+}
+
+func MockStaticProvisioner(t *testing.T) (*static, *nodeRunnerMock) {
+	cfg := &config.Config{}
+	cfg.Cluster.Name = "mock"
+	cfg.Cluster.Nodes.Master.Instances = []config.MasterInstance{
+		{Id: "1", IP: "10.10.0.10"},
+	}
+	cfg.Cluster.Nodes.Worker.Instances = []config.WorkerInstance{
+		{Id: "1", IP: "10.10.0.20"},
+	}
+
+	runner := &nodeRunnerMock{}
+
+	s := NewStaticProvisioner(filepath.Join(t.TempDir(), "infrastructure.yaml"), "id_rsa", cfg).(*static)
+	s.nodes = runner
+
+	return s, runner
+}
+
+func TestStatic_Apply(t *testing.T) {
+	s, runner := MockStaticProvisioner(t)
+	require.NoError(t, s.Init(nil))
+
+	changes, err := s.Plan()
+	require.NoError(t, err)
+	assert.True(t, changes)
+
+	require.NoError(t, s.Apply())
+	assert.Equal(t, []string{"10.10.0.10", "10.10.0.20", "10.10.0.10", "10.10.0.20"}, runner.hosts)
+
+	infraCfg, err := file.ReadYaml(s.infraConfigPath, infra.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "mock-master-1", infraCfg.Nodes.Master.Instances[0].Name)
+	assert.Equal(t, "mock-worker-1", infraCfg.Nodes.Worker.Instances[0].Name)
+	assert.Equal(t, config.IPv4("10.10.0.10"), infraCfg.Nodes.LoadBalancer.VIP)
+
+	changes, err = s.Plan()
+	require.NoError(t, err)
+	assert.False(t, changes)
+}
+
+func TestStatic_Unreachable(t *testing.T) {
+	s, runner := MockStaticProvisioner(t)
+	runner.unreachable = "10.10.0.20"
+
+	require.NoError(t, s.Init(nil))
+	assert.EqualError(t, s.Apply(), `static: machine "mock-worker-1" (10.10.0.20) is not reachable over SSH: connection refused`)
+	assert.False(t, file.Exists(s.infraConfigPath))
+}
+
+func TestStatic_Destroy(t *testing.T) {
+	s, runner := MockStaticProvisioner(t)
+	require.NoError(t, s.Destroy())
+
+	assert.Equal(t, []string{"10.10.0.10", "10.10.0.20"}, runner.hosts)
+	assert.Equal(t, resetScript, runner.scripts[0])
+}
+
+func TestStatic_VIP(t *testing.T) {
+	s, _ := MockStaticProvisioner(t)
+
+	s.cfg.Cluster.Nodes.LoadBalancer.Instances = []config.LBInstance{{Id: "1", IP: "10.10.0.5"}}
+	assert.Equal(t, config.IPv4("10.10.0.5"), s.infraConfig().Nodes.LoadBalancer.VIP)
+
+	s.cfg.Cluster.Nodes.LoadBalancer.VIP = "10.10.0.100"
+	assert.Equal(t, config.IPv4("10.10.0.100"), s.infraConfig().Nodes.LoadBalancer.VIP)
+}
+
+func TestStatic_NoConfig(t *testing.T) {
+	s := NewStaticProvisioner("", "", nil)
+	assert.EqualError(t, s.Init(nil), "static: configuration is required")
+}
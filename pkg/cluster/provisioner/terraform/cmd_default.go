@@ -5,22 +5,24 @@ package terraform
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/MusicDin/kubitect/pkg/ui"
 )
 
-// runCmd runs terraform command and returns exit code with
+// runCmd runs terraform (or OpenTofu) command and returns exit code with
 // a potential error.
 func (t *terraform) runCmd(action string, args []string, showOutput bool) (int, error) {
-	args = append([]string{action}, args...)
+	// Action may contain a subcommand (e.g. "providers mirror"),
+	// therefore flags are placed right after it.
+	cmdArgs := strings.Fields(action)
 
 	if !ui.HasColor() {
-		args = append(args, flag("no-color"))
+		cmdArgs = append(cmdArgs, flag("no-color"))
 	}
 
-	cmd := exec.Command(t.binPath, args...)
+	cmd := exec.Command(t.binPath, append(cmdArgs, args...)...)
 	cmd.Dir = t.projectDir
 
 	cmd.Stderr = ui.Streams().Err().File()
@@ -28,7 +30,7 @@ func (t *terraform) runCmd(action string, args []string, showOutput bool) (int,
 		cmd.Stdout = ui.Streams().Out().File()
 	}
 
-	cmd.Env = []string{fmt.Sprintf("PATH=%s", os.Getenv("PATH"))}
+	cmd.Env = t.env()
 	if ui.Debug() {
 		cmd.Env = append(cmd.Env, "TF_LOG=INFO")
 	}
@@ -37,7 +39,7 @@ func (t *terraform) runCmd(action string, args []string, showOutput bool) (int,
 	exitCode := cmd.ProcessState.ExitCode()
 
 	if err != nil {
-		err = fmt.Errorf("terraform %s failed: %v", action, err)
+		err = fmt.Errorf("%s %s failed: %v", t.binary, action, err)
 	}
 
 	return exitCode, err
@@ -0,0 +1,246 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// openTofuReleasesUrl is the base URL of OpenTofu release artifacts.
+var openTofuReleasesUrl = "https://github.com/opentofu/opentofu/releases/download"
+
+// openTofuKeyUrl is the URL of the public key that signs checksums of
+// OpenTofu releases.
+var openTofuKeyUrl = "https://get.opentofu.org/opentofu.asc"
+
+// openTofuKeyFingerprint is the fingerprint of the OpenTofu signing key.
+// A downloaded key is trusted only if it matches this fingerprint.
+var openTofuKeyFingerprint = "E3E6E43D84CB852EADB0051D0C0AF313E5FD9F80"
+
+// openTofuBinary is the name of the OpenTofu executable.
+const openTofuBinary = "tofu"
+
+// findOpenTofu searches for OpenTofu binary of the given version, first
+// in the given binDir and then in PATH. If binary is found, its path is
+// returned.
+func findOpenTofu(ver, binDir string) (string, error) {
+	paths := []string{filepath.Join(binDir, openTofuBinary)}
+
+	if p, err := exec.LookPath(openTofuBinary); err == nil {
+		paths = append(paths, p)
+	}
+
+	for _, p := range paths {
+		v, err := openTofuVersion(p)
+		if err == nil && v == ver {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("OpenTofu %s not found", ver)
+}
+
+// openTofuVersion returns the version of the given OpenTofu binary.
+func openTofuVersion(binPath string) (string, error) {
+	out, err := exec.Command(binPath, "version", "-json").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// OpenTofu reports its version under the same key as Terraform.
+	var v struct {
+		Version string `json:"terraform_version"`
+	}
+
+	err = json.Unmarshal(out, &v)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse version of %q: %v", binPath, err)
+	}
+
+	return v.Version, nil
+}
+
+// installOpenTofu downloads OpenTofu release archive of the provided
+// version, verifies it against the checksums published with the release
+// and extracts the binary into a given directory. Checksums are trusted
+// only if they are signed with the OpenTofu signing key.
+func installOpenTofu(ver, binDir string) (string, error) {
+	if err := os.MkdirAll(binDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	archive := fmt.Sprintf("tofu_%s_%s_%s.zip", ver, runtime.GOOS, runtime.GOARCH)
+	releaseUrl := fmt.Sprintf("%s/v%s", openTofuReleasesUrl, ver)
+
+	sums, err := download(fmt.Sprintf("%s/tofu_%s_SHA256SUMS", releaseUrl, ver))
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := download(fmt.Sprintf("%s/tofu_%s_SHA256SUMS.gpgsig", releaseUrl, ver))
+	if err != nil {
+		return "", err
+	}
+
+	err = verifyOpenTofuSignature(sums, sig)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, ok := findChecksum(sums, archive)
+	if !ok {
+		return "", fmt.Errorf("checksum of %q is not published", archive)
+	}
+
+	data, err := download(fmt.Sprintf("%s/%s", releaseUrl, archive))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return "", fmt.Errorf("checksum mismatch for %q (expected: %s, actual: %x)", archive, checksum, sum)
+	}
+
+	return extractOpenTofu(data, binDir)
+}
+
+// verifyOpenTofuSignature verifies the detached signature of the given
+// checksums using the OpenTofu signing key.
+func verifyOpenTofuSignature(sums []byte, sig []byte) error {
+	key, err := download(openTofuKeyUrl)
+	if err != nil {
+		return err
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		return fmt.Errorf("failed to read OpenTofu signing key: %v", err)
+	}
+
+	var trusted openpgp.EntityList
+	for _, e := range keyring {
+		if strings.EqualFold(hex.EncodeToString(e.PrimaryKey.Fingerprint), openTofuKeyFingerprint) {
+			trusted = append(trusted, e)
+		}
+	}
+
+	if len(trusted) == 0 {
+		return fmt.Errorf("OpenTofu signing key does not match fingerprint %s", openTofuKeyFingerprint)
+	}
+
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+
+	_, err = check(trusted, bytes.NewReader(sums), bytes.NewReader(sig), nil)
+	if err != nil {
+		return fmt.Errorf("invalid signature of OpenTofu checksums: %v", err)
+	}
+
+	return nil
+}
+
+// extractOpenTofu extracts OpenTofu binary from the given zip archive
+// into the binDir. The binary is written to a temporary file first, so
+// that binDir never contains a partially extracted binary.
+func extractOpenTofu(archive []byte, binDir string) (string, error) {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read OpenTofu archive: %v", err)
+	}
+
+	for _, f := range r.File {
+		if f.Name != openTofuBinary {
+			continue
+		}
+
+		src, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+
+		defer src.Close()
+
+		tmp, err := os.CreateTemp(binDir, ".download-*")
+		if err != nil {
+			return "", err
+		}
+
+		defer os.Remove(tmp.Name())
+
+		_, err = io.Copy(tmp, src)
+		tmp.Close()
+
+		if err != nil {
+			return "", fmt.Errorf("failed to extract OpenTofu binary: %v", err)
+		}
+
+		err = os.Chmod(tmp.Name(), 0755)
+		if err != nil {
+			return "", err
+		}
+
+		binPath := filepath.Join(binDir, openTofuBinary)
+
+		err = os.Rename(tmp.Name(), binPath)
+		if err != nil {
+			return "", err
+		}
+
+		return binPath, nil
+	}
+
+	return "", fmt.Errorf("OpenTofu archive does not contain %q", openTofuBinary)
+}
+
+// download returns the content of the given URL.
+func download(url string) ([]byte, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q: %v", url, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %q: %s", url, res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q: %v", url, err)
+	}
+
+	return data, nil
+}
+
+// findChecksum finds the SHA256 checksum of the given file in the
+// content of a checksum file ("<sum>  <file>").
+func findChecksum(sums []byte, name string) (string, bool) {
+	s := bufio.NewScanner(bytes.NewReader(sums))
+
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,183 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOpenTofuScript mocks OpenTofu binary that reports its version.
+const mockOpenTofuScript = `#!/bin/sh
+echo '{"terraform_version":"%s","platform":"linux_amd64"}'
+`
+
+// MockOpenTofuReleases serves a release of the mock OpenTofu binary with
+// the given version. If checksum is empty, the actual checksum of the
+// archive is published. Checksums are signed with a mock signing key,
+// which is trusted instead of the OpenTofu signing key.
+func MockOpenTofuReleases(t *testing.T, ver string, checksum string) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("tofu")
+	require.NoError(t, err)
+
+	_, err = fmt.Fprintf(f, mockOpenTofuScript, ver)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	archive := fmt.Sprintf("tofu_%s_%s_%s.zip", ver, runtime.GOOS, runtime.GOARCH)
+
+	if checksum == "" {
+		checksum = fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+	}
+
+	sums := fmt.Sprintf("%s  tofu_%s_other.zip\n%s  %s\n", checksum, ver, checksum, archive)
+
+	key, err := openpgp.NewEntity("mock", "", "mock@kubitect.io", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	require.NoError(t, err)
+
+	var sig bytes.Buffer
+	require.NoError(t, openpgp.DetachSign(&sig, key, bytes.NewReader([]byte(sums)), nil))
+
+	var pubKey bytes.Buffer
+	aw, err := armor.Encode(&pubKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, key.Serialize(aw))
+	require.NoError(t, aw.Close())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/opentofu.asc", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pubKey.Bytes())
+	})
+	mux.HandleFunc(fmt.Sprintf("/v%s/tofu_%s_SHA256SUMS", ver, ver), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, sums)
+	})
+	mux.HandleFunc(fmt.Sprintf("/v%s/tofu_%s_SHA256SUMS.gpgsig", ver, ver), func(w http.ResponseWriter, r *http.Request) {
+		w.Write(sig.Bytes())
+	})
+	mux.HandleFunc(fmt.Sprintf("/v%s/%s", ver, archive), func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	url := openTofuReleasesUrl
+	keyUrl := openTofuKeyUrl
+	fingerprint := openTofuKeyFingerprint
+	openTofuReleasesUrl = srv.URL
+	openTofuKeyUrl = srv.URL + "/opentofu.asc"
+	openTofuKeyFingerprint = fmt.Sprintf("%X", key.PrimaryKey.Fingerprint)
+	t.Cleanup(func() {
+		openTofuReleasesUrl = url
+		openTofuKeyUrl = keyUrl
+		openTofuKeyFingerprint = fingerprint
+	})
+}
+
+func TestNewTerraformProvisioner_OpenTofu(t *testing.T) {
+	prov := NewTerraformProvisioner("cluster", "shared", config.TerraformBinaryOpenTofu, false, nil, nil).(*terraform)
+	assert.Equal(t, env.ConstOpenTofuVersion, prov.version)
+	assert.Equal(t, path.Join("shared", "opentofu", env.ConstOpenTofuVersion), prov.binDir)
+	assert.Equal(t, "OpenTofu", prov.name())
+
+	prov = NewTerraformProvisioner("cluster", "shared", "", false, nil, nil).(*terraform)
+	assert.Equal(t, config.TerraformBinaryTerraform, prov.binary)
+	assert.Equal(t, path.Join("shared", "terraform", env.ConstTerraformVersion), prov.binDir)
+}
+
+func TestTerraform_UnsupportedBinary(t *testing.T) {
+	prov := NewTerraformProvisioner("cluster", "shared", "invalid", false, nil, nil).(*terraform)
+	assert.EqualError(t, prov.init(), `unsupported Terraform binary "invalid" (supported: terraform, opentofu)`)
+}
+
+func TestInstallOpenTofu(t *testing.T) {
+	MockOpenTofuReleases(t, "1.10.6", "")
+	binDir := t.TempDir()
+
+	_, err := findOpenTofu("1.10.6", binDir)
+	assert.EqualError(t, err, "OpenTofu 1.10.6 not found")
+
+	binPath, err := installOpenTofu("1.10.6", binDir)
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(binDir, "tofu"), binPath)
+
+	// Installed binary is found in binDir.
+	binPath, err = findOpenTofu("1.10.6", binDir)
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(binDir, "tofu"), binPath)
+
+	// Binary of a different version is ignored.
+	_, err = findOpenTofu("1.10.7", binDir)
+	assert.Error(t, err)
+}
+
+func TestInstallOpenTofu_ChecksumMismatch(t *testing.T) {
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("invalid")))
+	MockOpenTofuReleases(t, "1.10.6", sum)
+
+	_, err := installOpenTofu("1.10.6", t.TempDir())
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestInstallOpenTofu_UntrustedKey(t *testing.T) {
+	MockOpenTofuReleases(t, "1.10.6", "")
+	openTofuKeyFingerprint = "E3E6E43D84CB852EADB0051D0C0AF313E5FD9F80"
+
+	_, err := installOpenTofu("1.10.6", t.TempDir())
+	assert.EqualError(t, err, "OpenTofu signing key does not match fingerprint E3E6E43D84CB852EADB0051D0C0AF313E5FD9F80")
+}
+
+func TestVerifyOpenTofuSignature(t *testing.T) {
+	MockOpenTofuReleases(t, "1.10.6", "")
+
+	sums, err := download(openTofuReleasesUrl + "/v1.10.6/tofu_1.10.6_SHA256SUMS")
+	require.NoError(t, err)
+
+	sig, err := download(openTofuReleasesUrl + "/v1.10.6/tofu_1.10.6_SHA256SUMS.gpgsig")
+	require.NoError(t, err)
+
+	assert.NoError(t, verifyOpenTofuSignature(sums, sig))
+
+	// Modified checksums are rejected.
+	sums = append(sums, []byte("abc  tofu_1.10.6_linux_amd64.zip\n")...)
+	assert.ErrorContains(t, verifyOpenTofuSignature(sums, sig), "invalid signature of OpenTofu checksums")
+}
+
+func TestInstallOpenTofu_MissingRelease(t *testing.T) {
+	MockOpenTofuReleases(t, "1.10.6", "")
+
+	_, err := installOpenTofu("1.10.7", t.TempDir())
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestFindChecksum(t *testing.T) {
+	sums := []byte("AAA  tofu_a.zip\nbbb *tofu_b.zip\n")
+
+	sum, ok := findChecksum(sums, "tofu_a.zip")
+	assert.True(t, ok)
+	assert.Equal(t, "aaa", sum)
+
+	sum, ok = findChecksum(sums, "tofu_b.zip")
+	assert.True(t, ok)
+	assert.Equal(t, "bbb", sum)
+
+	_, ok = findChecksum(sums, "tofu_c.zip")
+	assert.False(t, ok)
+}
@@ -1,6 +1,8 @@
 package terraform
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -13,7 +15,28 @@ import (
 type MainTemplate struct {
 	Hosts        []config.Host
 	RemovedHosts []config.Host
-	projDir      string
+
+	// Local path of the cached OS image.
+	ImagePath string
+
+	// Names of the volumes containing the OS image on remote hosts,
+	// mapped by host name.
+	ImageVolumes map[string]string
+
+	// Backend that stores the Terraform state.
+	Backend config.StateBackend
+
+	// Binary that applies the project. Some backend arguments differ
+	// between Terraform and OpenTofu.
+	Binary config.TerraformBinary
+
+	// Name of the cluster, used for the default state key.
+	ClusterName string
+
+	// Cloud-init boot commands that configure the proxy on the nodes.
+	ProxyBootCmd []string
+
+	projDir string
 }
 
 func NewMainTemplate(projectDir string, hosts, removedHosts []config.Host) MainTemplate {
@@ -32,6 +55,7 @@ func (t MainTemplate) Functions() map[string]interface{} {
 	return map[string]interface{}{
 		"hostUri":     hostUri,
 		"defaultHost": defaultHost,
+		"hclList":     hclList,
 	}
 }
 
@@ -43,6 +67,25 @@ func (t MainTemplate) Write() error {
 	return template.WriteFrom(t, srcPath, dstPath)
 }
 
+// hclList returns the given values as an HCL list of strings. Template
+// sequences are escaped, so that values are used literally.
+func hclList(values []string) (string, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(values); err != nil {
+		return "", err
+	}
+
+	list := strings.TrimSpace(buf.String())
+	list = strings.ReplaceAll(list, "${", "$${")
+	list = strings.ReplaceAll(list, "%{", "%%{")
+
+	return list, nil
+}
+
 // defaultHost returns default host from a given list of hosts.
 func defaultHost(hosts []config.Host) (config.Host, error) {
 	if len(hosts) == 0 {
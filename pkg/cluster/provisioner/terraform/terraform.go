@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/MusicDin/kubitect/pkg/cluster/event"
 	"github.com/MusicDin/kubitect/pkg/cluster/provisioner"
 	"github.com/MusicDin/kubitect/pkg/env"
 	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
+	"github.com/MusicDin/kubitect/pkg/tools/images"
+	"github.com/MusicDin/kubitect/pkg/tools/virt"
 	"github.com/MusicDin/kubitect/pkg/ui"
 	"github.com/MusicDin/kubitect/pkg/utils/cmp"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 	"github.com/MusicDin/kubitect/pkg/utils/file"
 
 	"github.com/hashicorp/go-version"
@@ -22,10 +27,14 @@ import (
 
 type (
 	terraform struct {
-		// Required Terraform version
+		// Binary that applies the Terraform project
+		// (Terraform or OpenTofu).
+		binary config.TerraformBinary
+
+		// Required version of the binary.
 		version string
 
-		// Path where terraform binary will be installed
+		// Path where the binary will be installed
 		// if it is not found locally.
 		binDir string
 
@@ -35,9 +44,16 @@ type (
 		// Dir where main.tf is located (root Terraform dir).
 		projectDir string
 
+		// Dir of the OS image cache shared among all clusters.
+		imageDir string
+
 		// If true, Terraform plan will be shown.
 		showPlan bool
 
+		// Bundle from which the binary, providers and OS
+		// image are obtained instead of being downloaded.
+		bundle *bundle.Bundle
+
 		// Configuration file containing values required for
 		// main.tf template
 		cfg *config.Config
@@ -48,21 +64,41 @@ type (
 	}
 )
 
+// NewTerraformProvisioner returns a provisioner that applies the generated
+// Terraform project using the given binary. If the binary is not set,
+// HashiCorp Terraform is used. If bundle is set, external dependencies
+// are obtained from it.
 func NewTerraformProvisioner(
 	clusterPath,
 	sharedPath string,
+	binary config.TerraformBinary,
 	showPlan bool,
+	b *bundle.Bundle,
 	cfg *config.Config,
 ) provisioner.Provisioner {
+	binary = defaults.Default(binary, config.TerraformBinaryTerraform)
+
 	version := env.ConstTerraformVersion
-	binDir := path.Join(sharedPath, "terraform", version)
+	if binary == config.TerraformBinaryOpenTofu {
+		version = env.ConstOpenTofuVersion
+	}
+
+	binDir := path.Join(sharedPath, string(binary), version)
 	projDir := path.Join(clusterPath, "terraform")
 
+	imageDir := path.Join(sharedPath, "images")
+	if b != nil {
+		imageDir = b.ImageDir()
+	}
+
 	return &terraform{
+		binary:     binary,
 		version:    version,
 		binDir:     binDir,
 		projectDir: projDir,
+		imageDir:   imageDir,
 		showPlan:   showPlan,
+		bundle:     b,
 		cfg:        cfg,
 	}
 }
@@ -78,7 +114,65 @@ func (t *terraform) Init(events []event.Event) error {
 	hosts := t.cfg.Hosts
 	removedHosts := extractRemovedHosts(events)
 
-	return NewMainTemplate(t.projectDir, hosts, removedHosts).Write()
+	tpl := NewMainTemplate(t.projectDir, hosts, removedHosts)
+	tpl.Backend = t.cfg.Cluster.State.Backend
+	tpl.Binary = t.binary
+	tpl.ClusterName = t.cfg.Cluster.Name
+	tpl.ProxyBootCmd = provisioner.ProxyBootCmd(t.cfg)
+
+	err = t.prepareImage(&tpl)
+	if err != nil {
+		return fmt.Errorf("terraform: %v", err)
+	}
+
+	return tpl.Write()
+}
+
+// prepareImage ensures the OS image is present in the shared image cache
+// and passes its local path to the main.tf template. The image is also
+// uploaded to each remote host, unless the host already contains it.
+func (t *terraform) prepareImage(tpl *MainTemplate) error {
+	nodeOS := t.cfg.Cluster.NodeTemplate.OS
+
+	if nodeOS.Source == "" {
+		return nil
+	}
+
+	img, err := images.NewCache(t.imageDir).Ensure(string(nodeOS.Source), string(nodeOS.Checksum), string(nodeOS.ChecksumFile), nodeOS.SkipChecksum)
+	if err != nil {
+		return err
+	}
+
+	tpl.ImagePath = img.Path
+	tpl.ImageVolumes = make(map[string]string)
+
+	for _, h := range t.cfg.Hosts {
+		if h.Connection.Type != config.REMOTE {
+			continue
+		}
+
+		vol, err := uploadImage(h, *img)
+		if err != nil {
+			return fmt.Errorf("host %q: %v", h.Name, err)
+		}
+
+		tpl.ImageVolumes[h.Name] = vol
+	}
+
+	return nil
+}
+
+// uploadImage uploads the image to the given host over the libvirt RPC
+// protocol and returns the name of the volume containing the image.
+func uploadImage(h config.Host, img images.Image) (string, error) {
+	l, err := virt.Connect(h)
+	if err != nil {
+		return "", err
+	}
+
+	defer l.Disconnect()
+
+	return images.Upload(l, h.MainResourcePoolPath, img)
 }
 
 // init initializes a Terraform project.
@@ -94,6 +188,10 @@ func (t *terraform) init() error {
 
 	t.binPath = binPath
 
+	if err := t.writeCliConfig(); err != nil {
+		return err
+	}
+
 	args := []string{
 		flag("force-copy"),
 		flag("input", false),
@@ -110,6 +208,37 @@ func (t *terraform) init() error {
 	return err
 }
 
+// MigrateState initializes the Terraform project and moves the existing
+// state into the backend configured in the project. Project must be
+// generated (Init) beforehand.
+func (t *terraform) MigrateState() error {
+	binPath, err := t.findOrInstall()
+	if err != nil {
+		return err
+	}
+
+	t.binPath = binPath
+
+	if err := t.writeCliConfig(); err != nil {
+		return err
+	}
+
+	args := []string{
+		flag("migrate-state"),
+		flag("force-copy"),
+		flag("input", false),
+		flag("get", true),
+	}
+
+	_, err = t.runCmd("init", args, true)
+
+	if err == nil {
+		t.initialized = true
+	}
+
+	return err
+}
+
 // Plan shows Terraform project changes (plan).
 // It returns a potential error and whether there
 // are changes or not.
@@ -189,33 +318,157 @@ func (t *terraform) Destroy() error {
 	return err
 }
 
-// findOrInstall first searches for Terraform binary locally and
+// findOrInstall first searches for the binary locally and
 // if binary is not found, it is installed in given binDir.
 func (t *terraform) findOrInstall() (string, error) {
-	var binPath string
-	var err error
+	var find, install func(ver, binDir string) (string, error)
+
+	switch t.binary {
+	case config.TerraformBinaryTerraform:
+		find, install = findTerraform, installTerraform
+	case config.TerraformBinaryOpenTofu:
+		find, install = findOpenTofu, installOpenTofu
+	default:
+		return "", fmt.Errorf("unsupported Terraform binary %q (supported: %s, %s)", t.binary, config.TerraformBinaryTerraform, config.TerraformBinaryOpenTofu)
+	}
 
-	ui.Printf(ui.INFO, "Ensuring Terraform %s is installed...\n", t.version)
+	if t.bundle != nil {
+		install = t.installFromBundle
+	}
+
+	name := t.name()
+
+	ui.Printf(ui.INFO, "Ensuring %s %s is installed...\n", name, t.version)
 
-	binPath, err = findTerraform(t.version, t.binDir)
+	binPath, err := find(t.version, t.binDir)
 
 	if err == nil {
-		ui.Printf(ui.INFO, "Terraform %s found locally (%s).\n", t.version, binPath)
+		ui.Printf(ui.INFO, "%s %s found locally (%s).\n", name, t.version, binPath)
 		return binPath, nil
 	}
 
-	ui.Printf(ui.INFO, "Terraform %s could not be found locally.\n", t.version)
-	ui.Printf(ui.INFO, "Installing Terraform %s in '%s'...\n", t.version, t.binDir)
+	ui.Printf(ui.INFO, "%s %s could not be found locally.\n", name, t.version)
+	ui.Printf(ui.INFO, "Installing %s %s in '%s'...\n", name, t.version, t.binDir)
 
-	binPath, err = installTerraform(t.version, t.binDir)
+	binPath, err = install(t.version, t.binDir)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to install Terraform: %v", err)
+		return "", fmt.Errorf("failed to install %s: %v", name, err)
 	}
 
 	return binPath, nil
 }
 
+// installFromBundle copies the binary of the given version from the bundle
+// into the given directory.
+func (t *terraform) installFromBundle(ver, binDir string) (string, error) {
+	src, err := t.bundle.Binary(string(t.binary), ver)
+	if err != nil {
+		return "", err
+	}
+
+	dst := path.Join(binDir, path.Base(src))
+	if err := file.ForceCopy(src, dst, 0755); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// cliConfigPath returns the path of the CLI configuration file that
+// instructs the binary to install providers from the bundle.
+func (t *terraform) cliConfigPath() string {
+	return path.Join(t.projectDir, "bundle.tfrc")
+}
+
+// writeCliConfig writes the CLI configuration file that restricts the
+// provider installation to the mirror within the bundle. If bundle is
+// not set, nothing is written.
+func (t *terraform) writeCliConfig() error {
+	if t.bundle == nil {
+		return nil
+	}
+
+	dir, err := t.bundle.Providers()
+	if err != nil {
+		return err
+	}
+
+	cfg := fmt.Sprintf("provider_installation {\n  filesystem_mirror {\n    path = %q\n  }\n}\n", dir)
+
+	err = os.WriteFile(t.cliConfigPath(), []byte(cfg), 0644)
+	if err != nil {
+		return fmt.Errorf("terraform: failed to write CLI configuration: %v", err)
+	}
+
+	return nil
+}
+
+// Bundle adds the binary, required providers and the OS image into the
+// bundle.
+func (t *terraform) Bundle(w *bundle.Writer) error {
+	binPath, err := t.findOrInstall()
+	if err != nil {
+		return err
+	}
+
+	t.binPath = binPath
+
+	if err := w.AddBinary(string(t.binary), t.version, binPath); err != nil {
+		return err
+	}
+
+	ui.Printf(ui.INFO, "Mirroring %s providers...\n", t.name())
+
+	err = w.AddProviders(func(dst string) error {
+		return t.mirrorProviders(dst)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	nodeOS := t.cfg.Cluster.NodeTemplate.OS
+	if nodeOS.Source == "" {
+		return nil
+	}
+
+	_, err = images.NewCache(w.ImageDir()).Ensure(string(nodeOS.Source), string(nodeOS.Checksum), string(nodeOS.ChecksumFile), nodeOS.SkipChecksum)
+	return err
+}
+
+// mirrorProviders downloads providers required by the Terraform project
+// into the given directory. Only versions.tf of the project is used, as
+// it pins the versions of all required providers.
+func (t *terraform) mirrorProviders(dstPath string) error {
+	tmpDir, err := os.MkdirTemp("", "kubitect-providers-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	err = file.ForceCopy(path.Join(t.projectDir, "versions.tf"), path.Join(tmpDir, "versions.tf"), 0644)
+	if err != nil {
+		return err
+	}
+
+	m := *t
+	m.projectDir = tmpDir
+
+	_, err = m.runCmd("providers mirror", []string{dstPath}, ui.Debug())
+	return err
+}
+
+// name returns a human readable name of the binary.
+func (t *terraform) name() string {
+	if t.binary == config.TerraformBinaryOpenTofu {
+		return "OpenTofu"
+	}
+
+	return "Terraform"
+}
+
 // findTerraform searches for Terraform binary locally.
 // If binary is found, its path is returned.
 func findTerraform(ver, binDir string) (string, error) {
@@ -253,6 +506,60 @@ func flag(key string, value ...interface{}) string {
 	return fmt.Sprintf("-%s", key)
 }
 
+// env returns environment variables of the binary process.
+func (t *terraform) env() []string {
+	envs := []string{fmt.Sprintf("PATH=%s", os.Getenv("PATH"))}
+	envs = append(envs, backendEnv()...)
+	envs = append(envs, proxyEnv()...)
+
+	if t.bundle != nil {
+		envs = append(envs, fmt.Sprintf("TF_CLI_CONFIG_FILE=%s", t.cliConfigPath()))
+	}
+
+	return envs
+}
+
+// proxyEnvNames are names of environment variables that configure the
+// proxy used to download providers.
+var proxyEnvNames = []string{
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy",
+}
+
+// proxyEnv returns proxy environment variables of the current process.
+func proxyEnv() []string {
+	var envs []string
+
+	for _, n := range proxyEnvNames {
+		if v, ok := os.LookupEnv(n); ok {
+			envs = append(envs, fmt.Sprintf("%s=%s", n, v))
+		}
+	}
+
+	return envs
+}
+
+// backendEnvPrefixes are prefixes of environment variables that contain
+// credentials of the remote state backends.
+var backendEnvPrefixes = []string{"TF_HTTP_", "AWS_"}
+
+// backendEnv returns environment variables of the current process that
+// configure remote state backends.
+func backendEnv() []string {
+	var envs []string
+
+	for _, e := range os.Environ() {
+		for _, p := range backendEnvPrefixes {
+			if strings.HasPrefix(e, p) {
+				envs = append(envs, e)
+				break
+			}
+		}
+	}
+
+	return envs
+}
+
 // extractRemovedHosts iterates over provided events and extracts
 // hosts that have been removed.
 func extractRemovedHosts(events []event.Event) []config.Host {
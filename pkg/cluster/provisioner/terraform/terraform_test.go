@@ -1,15 +1,18 @@
 package terraform
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/MusicDin/kubitect/embed"
 	"github.com/MusicDin/kubitect/pkg/cluster/event"
 	"github.com/MusicDin/kubitect/pkg/env"
 	"github.com/MusicDin/kubitect/pkg/models/config"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/ui"
 	"github.com/MusicDin/kubitect/pkg/utils/cmp"
 
@@ -32,6 +35,7 @@ func MockTerraform(t *testing.T) *terraform {
 	require.NoError(t, err)
 
 	return &terraform{
+		binary:     config.TerraformBinaryTerraform,
 		version:    env.ConstTerraformVersion,
 		binDir:     binDir,
 		projectDir: projDir,
@@ -70,7 +74,7 @@ func TestNewTerraformProvisioner(t *testing.T) {
 	err := embed.MirrorResource("terraform/main.tf.tpl", clsPath)
 	require.NoError(t, err)
 
-	prov := NewTerraformProvisioner(clsPath, "shared/path", true, cfg)
+	prov := NewTerraformProvisioner(clsPath, "shared/path", "", true, nil, cfg)
 	assert.NoError(t, prov.Init(nil))
 }
 
@@ -83,10 +87,161 @@ func TestNewTerraformProvisioner_InvalidHosts(t *testing.T) {
 	err := embed.MirrorResource("terraform/main.tf.tpl", clsPath)
 	require.NoError(t, err)
 
-	prov := NewTerraformProvisioner(clsPath, "shared/path", true, cfg)
+	prov := NewTerraformProvisioner(clsPath, "shared/path", "", true, nil, cfg)
 	assert.ErrorContains(t, prov.Init(nil), "hosts list is empty")
 }
 
+func TestNewTerraformProvisioner_LocalImage(t *testing.T) {
+	clsPath := t.TempDir()
+
+	img := path.Join(t.TempDir(), "os.img")
+	require.NoError(t, os.WriteFile(img, []byte("image"), 0644))
+
+	cfg := &config.Config{
+		Hosts: []config.Host{config.MockLocalHost(t, "test", false)},
+	}
+
+	cfg.Cluster.NodeTemplate.OS.Source = config.OSSource(img)
+
+	err := embed.MirrorResource("terraform/main.tf.tpl", clsPath)
+	require.NoError(t, err)
+
+	prov := NewTerraformProvisioner(clsPath, t.TempDir(), "", true, nil, cfg)
+	require.NoError(t, prov.Init(nil))
+
+	main, err := os.ReadFile(path.Join(clsPath, "terraform", "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), fmt.Sprintf("cluster_nodeTemplate_os_source           = %q", img))
+	assert.Contains(t, string(main), "cluster_nodeTemplate_os_volume           = null")
+
+	// Image checksum is verified.
+	cfg.Cluster.NodeTemplate.OS.Checksum = config.OSChecksum(strings.Repeat("a", 64))
+	assert.ErrorContains(t, prov.Init(nil), "checksum mismatch")
+}
+
+func TestNewTerraformProvisioner_Proxy(t *testing.T) {
+	clsPath := t.TempDir()
+
+	cfg := &config.Config{
+		Hosts: []config.Host{config.MockLocalHost(t, "test", false)},
+	}
+
+	require.NoError(t, embed.MirrorResource("terraform/main.tf.tpl", clsPath))
+
+	prov := NewTerraformProvisioner(clsPath, t.TempDir(), "", true, nil, cfg)
+	require.NoError(t, prov.Init(nil))
+
+	main, err := os.ReadFile(path.Join(clsPath, "terraform", "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), "cluster_nodeTemplate_cloudInit           = try(local.config.cluster.nodeTemplate.cloudInit, {})")
+
+	// Proxy boot commands are prepended to the node template boot commands.
+	cfg.Proxy.HTTP = "http://proxy.example.com:3128"
+	require.NoError(t, prov.Init(nil))
+
+	main, err = os.ReadFile(path.Join(clsPath, "terraform", "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), `bootcmd = concat(["sed -i -E '/^(HTTP_PROXY|NO_PROXY|http_proxy|no_proxy)=/d' /etc/environment",`)
+	assert.Contains(t, string(main), `'Acquire::http::Proxy \"http://proxy.example.com:3128\";'`)
+}
+
+func TestHclList(t *testing.T) {
+	list, err := hclList([]string{"echo ${HOME} > /tmp/home", "echo %{x}"})
+	require.NoError(t, err)
+	assert.Equal(t, `["echo $${HOME} > /tmp/home","echo %%{x}"]`, list)
+}
+
+// MockBackendMainTf returns main.tf generated for the given state backend.
+func MockBackendMainTf(t *testing.T, binary config.TerraformBinary, backend config.StateBackend) string {
+	clsPath := t.TempDir()
+
+	cfg := &config.Config{
+		Hosts: []config.Host{config.MockLocalHost(t, "test", false)},
+	}
+
+	cfg.Cluster.Name = "mock"
+	cfg.Cluster.State.Backend = backend
+
+	err := embed.MirrorResource("terraform/main.tf.tpl", clsPath)
+	require.NoError(t, err)
+
+	prov := NewTerraformProvisioner(clsPath, t.TempDir(), binary, true, nil, cfg)
+	require.NoError(t, prov.Init(nil))
+
+	main, err := os.ReadFile(path.Join(clsPath, "terraform", "main.tf"))
+	require.NoError(t, err)
+
+	return string(main)
+}
+
+func TestNewTerraformProvisioner_LocalBackend(t *testing.T) {
+	main := MockBackendMainTf(t, "", config.StateBackend{})
+	assert.Contains(t, main, "backend \"local\" {\n    path = \"../config/terraform/terraform.tfstate\"\n  }")
+}
+
+func TestNewTerraformProvisioner_HTTPBackend(t *testing.T) {
+	main := MockBackendMainTf(t, "", config.StateBackend{
+		Type: config.StateBackendHTTP,
+		HTTP: config.HTTPStateBackend{
+			Address:     "https://state.example.com/mock",
+			LockAddress: "https://state.example.com/mock/lock",
+		},
+	})
+
+	assert.Contains(t, main, `backend "http" {`)
+	assert.Contains(t, main, `address = "https://state.example.com/mock"`)
+	assert.Contains(t, main, `lock_address = "https://state.example.com/mock/lock"`)
+	assert.NotContains(t, main, "unlock_address")
+	assert.NotContains(t, main, `backend "local"`)
+}
+
+func TestNewTerraformProvisioner_S3Backend(t *testing.T) {
+	backend := config.StateBackend{
+		Type: config.StateBackendS3,
+		S3: config.S3StateBackend{
+			Bucket:       "states",
+			Endpoint:     "http://minio.local:9000",
+			UsePathStyle: true,
+		},
+	}
+
+	main := MockBackendMainTf(t, config.TerraformBinaryTerraform, backend)
+	assert.Contains(t, main, `bucket = "states"`)
+	assert.Contains(t, main, `key    = "kubitect/mock/terraform.tfstate"`)
+	assert.Contains(t, main, `region = "us-east-1"`)
+	assert.Contains(t, main, `endpoint = "http://minio.local:9000"`)
+	assert.Contains(t, main, "force_path_style = true")
+	assert.Contains(t, main, "skip_credentials_validation = true")
+
+	// OpenTofu uses newer arguments of the S3 backend.
+	main = MockBackendMainTf(t, config.TerraformBinaryOpenTofu, backend)
+	assert.Contains(t, main, "endpoints = {\n      s3 = \"http://minio.local:9000\"\n    }")
+	assert.Contains(t, main, "use_path_style = true")
+	assert.NotContains(t, main, "force_path_style")
+}
+
+func TestBackendEnv(t *testing.T) {
+	t.Setenv("TF_HTTP_PASSWORD", "secret")
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("TF_LOG", "DEBUG")
+
+	envs := backendEnv()
+	assert.Contains(t, envs, "TF_HTTP_PASSWORD=secret")
+	assert.Contains(t, envs, "AWS_ACCESS_KEY_ID=key")
+	assert.NotContains(t, envs, "TF_LOG=DEBUG")
+}
+
+func TestProxyEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("no_proxy", "localhost")
+	t.Setenv("HTTP_PROXY_USER", "user")
+
+	envs := proxyEnv()
+	assert.Contains(t, envs, "HTTPS_PROXY=http://proxy.example.com:3128")
+	assert.Contains(t, envs, "no_proxy=localhost")
+	assert.NotContains(t, envs, "HTTP_PROXY_USER=user")
+}
+
 func TestTerraform_init(t *testing.T) {
 	tf := MockMissingTerraform(t)
 	tfPath := path.Join(tf.binDir, "terraform")
@@ -156,3 +311,49 @@ func TestExtractRemovedHosts(t *testing.T) {
 	hosts := extractRemovedHosts(events)
 	assert.Len(t, hosts, 1)
 }
+
+func MockBundle(t *testing.T) *bundle.Bundle {
+	tmpDir := t.TempDir()
+
+	binPath := path.Join(tmpDir, "terraform")
+	require.NoError(t, os.WriteFile(binPath, []byte("#!/bin/sh"), 0755))
+
+	w := bundle.NewWriter(path.Join(tmpDir, "bundle"))
+	require.NoError(t, w.AddBinary("terraform", env.ConstTerraformVersion, binPath))
+	require.NoError(t, w.AddProviders(func(dst string) error { return nil }))
+	require.NoError(t, w.Write(path.Join(tmpDir, "bundle.tar.gz")))
+
+	b, err := bundle.Open(path.Join(tmpDir, "bundle.tar.gz"), path.Join(tmpDir, "bundles"))
+	require.NoError(t, err)
+
+	return b
+}
+
+func TestTerraform_Bundle(t *testing.T) {
+	tf := MockTerraform(t)
+	tf.bundle = MockBundle(t)
+
+	binPath, err := tf.installFromBundle(tf.version, tf.binDir)
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(tf.binDir, "terraform"), binPath)
+	assert.FileExists(t, binPath)
+
+	_, err = tf.installFromBundle("0.0.0", tf.binDir)
+	assert.ErrorIs(t, err, bundle.ErrNotBundled)
+
+	providers, err := tf.bundle.Providers()
+	require.NoError(t, err)
+	require.NoError(t, tf.writeCliConfig())
+
+	cfg, err := os.ReadFile(tf.cliConfigPath())
+	require.NoError(t, err)
+	assert.Contains(t, string(cfg), fmt.Sprintf("path = %q", providers))
+	assert.Contains(t, tf.env(), "TF_CLI_CONFIG_FILE="+tf.cliConfigPath())
+}
+
+func TestTerraform_NoBundle(t *testing.T) {
+	tf := MockTerraform(t)
+	require.NoError(t, tf.writeCliConfig())
+	assert.NoFileExists(t, tf.cliConfigPath())
+	assert.NotContains(t, strings.Join(tf.env(), " "), "TF_CLI_CONFIG_FILE")
+}
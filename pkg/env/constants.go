@@ -14,6 +14,7 @@ const (
 	ConstKubesprayVersion  = "v2.29.0"
 	ConstKubernetesVersion = "1.33.4"
 	ConstTerraformVersion  = "1.5.2"
+	ConstOpenTofuVersion   = "1.10.6"
 )
 
 // ProjectRequiredApps define applications that Kubitect depends on.
@@ -44,37 +45,93 @@ var ProjectK8sVersions = []string{
 	"v1.31.0 - v1.31.13",
 }
 
-// ProjectOsPresets is a list of available OS distros.
+// ProjectRke2Versions define Kubernetes versions supported by the RKE2
+// manager. Each version must have a release in ProjectRke2Releases.
+var ProjectRke2Versions = []string{
+	"v1.34.1 - v1.34.1",
+	"v1.33.0 - v1.33.5",
+	"v1.32.0 - v1.32.9",
+}
+
+// ProjectRke2Releases map supported Kubernetes versions to the RKE2
+// releases that are installed by the RKE2 manager.
+var ProjectRke2Releases = map[string]string{
+	"v1.34.1": "v1.34.1+rke2r1",
+	"v1.33.0": "v1.33.0+rke2r1",
+	"v1.33.1": "v1.33.1+rke2r1",
+	"v1.33.2": "v1.33.2+rke2r1",
+	"v1.33.3": "v1.33.3+rke2r1",
+	"v1.33.4": "v1.33.4+rke2r1",
+	"v1.33.5": "v1.33.5+rke2r1",
+	"v1.32.0": "v1.32.0+rke2r1",
+	"v1.32.1": "v1.32.1+rke2r1",
+	"v1.32.2": "v1.32.2+rke2r1",
+	"v1.32.3": "v1.32.3+rke2r1",
+	"v1.32.4": "v1.32.4+rke2r1",
+	"v1.32.5": "v1.32.5+rke2r1",
+	"v1.32.6": "v1.32.6+rke2r1",
+	"v1.32.7": "v1.32.7+rke2r1",
+	"v1.32.8": "v1.32.8+rke2r1",
+	"v1.32.9": "v1.32.9+rke2r1",
+}
+
+// ProjectOsPresets is a list of available OS distros. Checksum file is
+// published next to the image and lists the checksum of its latest
+// release. Managers are Kubernetes managers that support the distro.
 var ProjectOsPresets = map[string]struct {
 	Source           string
+	ChecksumFile     string
 	NetworkInterface string
+	User             string
+	Managers         []string
 }{
 	"ubuntu20": {
 		Source:           "https://cloud-images.ubuntu.com/releases/focal/release/ubuntu-20.04-server-cloudimg-amd64.img",
+		ChecksumFile:     "https://cloud-images.ubuntu.com/releases/focal/release/SHA256SUMS",
 		NetworkInterface: "ens3",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm", "rke2"},
 	},
 	"ubuntu22": {
 		Source:           "https://cloud-images.ubuntu.com/releases/jammy/release/ubuntu-22.04-server-cloudimg-amd64.img",
+		ChecksumFile:     "https://cloud-images.ubuntu.com/releases/jammy/release/SHA256SUMS",
 		NetworkInterface: "ens3",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm", "rke2"},
 	},
 	"debian11": {
 		Source:           "https://cloud.debian.org/images/cloud/bullseye/latest/debian-11-genericcloud-amd64.qcow2",
+		ChecksumFile:     "https://cloud.debian.org/images/cloud/bullseye/latest/SHA512SUMS",
 		NetworkInterface: "ens3",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm"},
 	},
 	"debian12": {
 		Source:           "https://cloud.debian.org/images/cloud/bookworm/latest/debian-12-genericcloud-amd64.qcow2",
+		ChecksumFile:     "https://cloud.debian.org/images/cloud/bookworm/latest/SHA512SUMS",
 		NetworkInterface: "ens3",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm"},
 	},
 	"debian13": {
 		Source:           "https://cloud.debian.org/images/cloud/trixie/latest/debian-13-genericcloud-amd64.qcow2",
+		ChecksumFile:     "https://cloud.debian.org/images/cloud/trixie/latest/SHA512SUMS",
 		NetworkInterface: "ens3",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm"},
 	},
 	"centos9": {
 		Source:           "https://cloud.centos.org/centos/9-stream/x86_64/images/CentOS-Stream-GenericCloud-9-latest.x86_64.qcow2",
+		ChecksumFile:     "https://cloud.centos.org/centos/9-stream/x86_64/images/CentOS-Stream-GenericCloud-9-latest.x86_64.qcow2.SHA256SUM",
 		NetworkInterface: "eth0",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm", "rke2"},
 	},
 	"rocky9": {
 		Source:           "https://dl.rockylinux.org/pub/rocky/9/images/x86_64/Rocky-9-GenericCloud-Base.latest.x86_64.qcow2",
+		ChecksumFile:     "https://dl.rockylinux.org/pub/rocky/9/images/x86_64/Rocky-9-GenericCloud-Base.latest.x86_64.qcow2.CHECKSUM",
 		NetworkInterface: "eth0",
+		User:             "k8s",
+		Managers:         []string{"kubespray", "k3s", "kubeadm", "rke2"},
 	},
 }
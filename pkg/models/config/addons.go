@@ -1,27 +1,90 @@
 package config
 
-import v "github.com/MusicDin/kubitect/pkg/utils/validation"
+import (
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// rookCharts are release names of the Helm charts that are installed
+// by the Rook addon.
+var rookCharts = []string{"rook-operator", "rook-ceph-cluster"}
+
+// metallbCharts are release names of the Helm charts that are installed
+// by the MetalLB addon.
+var metallbCharts = []string{"metallb"}
 
 type Addons struct {
+	Charts    []HelmChart    `yaml:"charts,omitempty"`
+	K3s       K3s            `yaml:"k3s,omitempty"`
 	Kubespray map[string]any `yaml:"kubespray,omitempty" opt:"-"`
+	MetalLB   MetalLB        `yaml:"metallb,omitempty"`
 	Rook      Rook           `yaml:"rook,omitempty"`
 }
 
 func (a Addons) Validate() error {
 	return v.Struct(&a,
+		v.Field(&a.Charts, v.OmitEmpty(), v.UniqueField("Name"), reservedChartsValidator(a)),
+		v.Field(&a.K3s, managerValidator(!a.K3s.IsEmpty(), ManagerK3s)),
+		v.Field(&a.Kubespray, managerValidator(len(a.Kubespray) > 0, ManagerKubespray)),
+		v.Field(&a.MetalLB),
 		v.Field(&a.Rook),
 	)
 }
 
-type Rook struct {
-	Enabled      bool    `yaml:"enabled"`
-	Version      Version `yaml:"version"`
-	NodeSelector Labels  `yaml:"nodeSelector"`
+// HelmChart is a Helm chart that is installed into the cluster as
+// a release with the given name. Release is upgraded when the chart
+// properties change and uninstalled when the chart is removed from
+// the list.
+type HelmChart struct {
+	Name       string         `yaml:"name" opt:",id"`
+	Repo       string         `yaml:"repo"`
+	Chart      string         `yaml:"chart"`
+	Version    string         `yaml:"version,omitempty"`
+	Namespace  string         `yaml:"namespace,omitempty"`
+	Values     map[string]any `yaml:"values,omitempty"`
+	ValuesFile File           `yaml:"valuesFile,omitempty"`
 }
 
-func (r Rook) Validate() error {
-	return v.Struct(&r,
-		v.Field(&r.Version, v.OmitEmpty()),
-		v.Field(&r.NodeSelector, v.OmitEmpty()),
+func (c HelmChart) Validate() error {
+	return v.Struct(&c,
+		v.Field(&c.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(53)),
+		v.Field(&c.Repo, v.NotEmpty(), v.RegexAny("^https?://.+", "^oci://.+").Error("Field '{.Field}' must be a valid HTTP(S) or OCI repository URL. (actual: {.Value})")),
+		v.Field(&c.Chart, v.NotEmpty()),
+		v.Field(&c.Namespace, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&c.ValuesFile,
+			v.OmitEmpty(),
+			v.Fail().When(len(c.Values) > 0).Error("Field '{.Field}' cannot be set together with field 'values'."),
+		),
 	)
 }
+
+func (c *HelmChart) SetDefaults() {
+	c.Namespace = defaults.Default(c.Namespace, "default")
+}
+
+// reservedChartsValidator returns a validator that triggers an error if
+// any of the charts uses a release name that is reserved for the charts
+// of an enabled addon.
+func reservedChartsValidator(a Addons) v.Validator {
+	reserved := make(map[string]string)
+
+	if a.Rook.Enabled {
+		for _, n := range rookCharts {
+			reserved[n] = "Rook"
+		}
+	}
+
+	if a.MetalLB.Enabled {
+		for _, n := range metallbCharts {
+			reserved[n] = "MetalLB"
+		}
+	}
+
+	for _, c := range a.Charts {
+		if addon, ok := reserved[c.Name]; ok {
+			return v.Fail().Errorf("Chart name '%s' is reserved for the %s addon.", c.Name, addon)
+		}
+	}
+
+	return v.None
+}
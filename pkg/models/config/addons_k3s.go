@@ -0,0 +1,108 @@
+package config
+
+import (
+	"sort"
+
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// k3sReservedKeys are k3s configuration keys that are set by Kubitect,
+// either directly or from other configuration properties.
+var k3sReservedKeys = []string{
+	"token",
+	"server",
+	"tls-san",
+	"cluster-cidr",
+	"service-cidr",
+	"node-ip",
+	"node-label",
+	"node-taint",
+	"disable",
+}
+
+// K3s contains configuration that is applied only when k3s is used as
+// a Kubernetes manager. Server and agent configurations are passed to k3s
+// as they are, where keys correspond to k3s flags without leading dashes.
+// Registries are written into the k3s registries configuration file
+// (registries.yaml) on each node.
+type K3s struct {
+	Server     map[string]any `yaml:"server,omitempty"`
+	Agent      map[string]any `yaml:"agent,omitempty"`
+	Disable    []K3sComponent `yaml:"disable,omitempty"`
+	Registries map[string]any `yaml:"registries,omitempty"`
+}
+
+func (k K3s) Validate() error {
+	return v.Struct(&k,
+		v.Field(&k.Server, v.OmitEmpty(), k3sReservedKeysValidator(k.Server)),
+		v.Field(&k.Agent, v.OmitEmpty(), k3sReservedKeysValidator(k.Agent)),
+		v.Field(&k.Disable, v.OmitEmpty(), v.Unique()),
+		v.Field(&k.Registries, v.OmitEmpty(), k3sRegistriesValidator(k.Registries)),
+	)
+}
+
+// IsEmpty returns true if none of the k3s properties is set.
+func (k K3s) IsEmpty() bool {
+	return len(k.Server) == 0 && len(k.Agent) == 0 && len(k.Disable) == 0 && len(k.Registries) == 0
+}
+
+// K3sComponent is a component that is bundled with k3s and can be
+// disabled.
+type K3sComponent string
+
+const (
+	K3sTraefik       K3sComponent = "traefik"
+	K3sServiceLB     K3sComponent = "servicelb"
+	K3sLocalStorage  K3sComponent = "local-storage"
+	K3sMetricsServer K3sComponent = "metrics-server"
+	K3sCoreDNS       K3sComponent = "coredns"
+)
+
+func (c K3sComponent) Validate() error {
+	return v.Var(c, v.OneOf(K3sTraefik, K3sServiceLB, K3sLocalStorage, K3sMetricsServer, K3sCoreDNS))
+}
+
+// k3sRegistriesValidator returns a validator that triggers an error if
+// the registries configuration contains keys other than the ones that
+// are supported by the k3s registries configuration file.
+func k3sRegistriesValidator(registries map[string]any) v.Validator {
+	var unknown []string
+
+	for k := range registries {
+		if k != "mirrors" && k != "configs" {
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return v.None
+	}
+
+	sort.Strings(unknown)
+
+	return v.Fail().Errorf("Field '{.Field}' can only contain keys 'mirrors' and 'configs'. (unknown: %v)", unknown)
+}
+
+// k3sReservedKeysValidator returns a validator that triggers an error if
+// the k3s configuration contains any of the keys set by Kubitect.
+func k3sReservedKeysValidator(cfg map[string]any) v.Validator {
+	for _, k := range k3sReservedKeys {
+		if _, ok := cfg[k]; ok {
+			return v.Fail().Errorf("Field '{.Field}' cannot contain key '%s', since it is set by Kubitect.", k)
+		}
+	}
+
+	return v.None
+}
+
+// managerValidator returns a cross-validator that triggers an error if
+// the field is set, but the configured Kubernetes manager is not the one
+// to which the field applies.
+func managerValidator(isSet bool, m KubernetesManager) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !isSet || !ok || c == nil || c.Kubernetes.Manager == m {
+		return v.None
+	}
+
+	return v.Fail().Errorf("Field '{.Field}' can only be set when Kubernetes manager is '%s'. (actual: %s)", m, c.Kubernetes.Manager)
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddonK3s(t *testing.T) {
+	k := K3s{
+		Server:  map[string]any{"write-kubeconfig-mode": "0644"},
+		Agent:   map[string]any{"kubelet-arg": []string{"max-pods=200"}},
+		Disable: []K3sComponent{K3sTraefik, K3sServiceLB},
+		Registries: map[string]any{
+			"mirrors": map[string]any{
+				"docker.io": map[string]any{"endpoint": []string{"https://mirror.example.com"}},
+			},
+		},
+	}
+
+	assert.NoError(t, k.Validate())
+	assert.NoError(t, K3s{}.Validate())
+	assert.True(t, K3s{}.IsEmpty())
+	assert.False(t, k.IsEmpty())
+}
+
+func TestAddonK3s_Invalid(t *testing.T) {
+	k := K3s{Server: map[string]any{"cluster-cidr": "10.10.0.0/16"}}
+	assert.EqualError(t, k.Validate(), "Field 'server' cannot contain key 'cluster-cidr', since it is set by Kubitect.")
+
+	k = K3s{Agent: map[string]any{"node-label": []string{"a=b"}}}
+	assert.EqualError(t, k.Validate(), "Field 'agent' cannot contain key 'node-label', since it is set by Kubitect.")
+
+	k = K3s{Registries: map[string]any{"mirrors": nil, "auth": nil}}
+	assert.EqualError(t, k.Validate(), "Field 'registries' can only contain keys 'mirrors' and 'configs'. (unknown: [auth])")
+
+	k = K3s{Disable: []K3sComponent{"ingress"}}
+	assert.Error(t, k.Validate())
+
+	k = K3s{Disable: []K3sComponent{K3sTraefik, K3sTraefik}}
+	assert.Error(t, k.Validate())
+}
+
+func TestConfig_ManagerSpecificFields(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Addons.K3s.Disable = []K3sComponent{K3sTraefik}
+
+	assert.EqualError(t, cfg.Validate(), "Field 'k3s' can only be set when Kubernetes manager is 'k3s'. (actual: kubespray)")
+
+	cfg.Kubernetes.Manager = ManagerK3s
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Addons.Kubespray = map[string]any{"ingress_nginx_enabled": true}
+	assert.EqualError(t, cfg.Validate(), "Field 'kubespray' can only be set when Kubernetes manager is 'kubespray'. (actual: k3s)")
+
+	cfg.Addons.Kubespray = nil
+	cfg.Kubernetes.Other.AutoRenewCertificates = true
+	assert.EqualError(t, cfg.Validate(), "Field 'autoRenewCertificates' can only be set when Kubernetes manager is 'kubespray'. (actual: k3s)")
+}
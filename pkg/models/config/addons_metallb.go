@@ -0,0 +1,186 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// MetalLB is a load balancer implementation that assigns IP addresses from
+// the configured address pools to the services of type LoadBalancer. The
+// addresses are announced within the cluster network in L2 mode.
+type MetalLB struct {
+	Enabled bool          `yaml:"enabled"`
+	Version string        `yaml:"version,omitempty"`
+	Pools   []MetalLBPool `yaml:"pools,omitempty"`
+}
+
+func (m MetalLB) Validate() error {
+	return v.Struct(&m,
+		v.Field(&m.Pools,
+			v.MinLen(1).When(m.Enabled).Error("At least one address pool must be configured when MetalLB is enabled."),
+			v.OmitEmpty(),
+			v.UniqueField("Name"),
+			metallbOverlapValidator(m.Pools),
+		),
+		v.Field(&m.Enabled, metallbKubesprayValidator(m.Enabled)),
+	)
+}
+
+// MetalLBPool is a named pool of addresses from which MetalLB assigns
+// IP addresses to the services.
+type MetalLBPool struct {
+	Name      string           `yaml:"name" opt:",id"`
+	Addresses []MetalLBAddress `yaml:"addresses"`
+}
+
+func (p MetalLBPool) Validate() error {
+	return v.Struct(&p,
+		v.Field(&p.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&p.Addresses, v.MinLen(1), v.Unique()),
+	)
+}
+
+// MetalLBAddress is either a range of IP addresses in format
+// "<first>-<last>" or a subnet in CIDR notation.
+type MetalLBAddress string
+
+func (a MetalLBAddress) Validate() error {
+	_, _, ok := a.Bounds()
+
+	return v.Var(a,
+		v.Fail().When(!ok).Error("Field '{.Field}' must be a valid IP range (e.g. 10.10.0.10-10.10.0.20) or a subnet in CIDR notation. (actual: {.Value})"),
+		metallbAddressValidator(a),
+	)
+}
+
+// Bounds returns the first and the last address of the address range
+// or subnet. If the value is invalid, ok is set to false.
+func (a MetalLBAddress) Bounds() (first netip.Addr, last netip.Addr, ok bool) {
+	if !strings.Contains(string(a), "/") {
+		return IPRange(a).Bounds()
+	}
+
+	prefix, err := netip.ParsePrefix(string(a))
+	if err != nil {
+		return first, last, false
+	}
+
+	first = prefix.Masked().Addr()
+
+	// Set all host bits to get the last address of the subnet.
+	b := first.AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+
+	last, _ = netip.AddrFromSlice(b)
+
+	return first, last, true
+}
+
+// Contains returns true if the given IP address is within the address
+// range or subnet.
+func (a MetalLBAddress) Contains(ip string) bool {
+	first, last, ok := a.Bounds()
+	if !ok {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	return addr.Compare(first) >= 0 && addr.Compare(last) <= 0
+}
+
+// metallbAddressValidator returns a cross-validator that triggers an error
+// if the address range is not within the cluster network or if it contains
+// an IP address of any node instance, the VIP or the network gateway.
+func metallbAddressValidator(a MetalLBAddress) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil {
+		return v.None
+	}
+
+	first, last, ok := a.Bounds()
+	if !ok {
+		return v.None
+	}
+
+	network := c.Cluster.Network
+	cidr := string(network.CIDR)
+
+	if first.Is6() {
+		if network.CIDR6 == "" {
+			return v.Fail().Errorf("Address range '%s' can only be set when IPv6 network CIDR (cidr6) is configured.", a)
+		}
+
+		cidr = string(network.CIDR6)
+	}
+
+	for _, ip := range []netip.Addr{first, last} {
+		if err := v.Var(ip.String(), v.IPInRange(cidr)); err != nil {
+			return v.Fail().Errorf("Address range '%s' must be within the cluster network CIDR '%s'.", a, cidr)
+		}
+	}
+
+	ips := c.Cluster.Nodes.IPs()
+	ips = append(ips, string(c.Cluster.Nodes.LoadBalancer.VIP), string(c.Cluster.Nodes.LoadBalancer.VIP6))
+
+	if network.Gateway != nil {
+		ips = append(ips, string(*network.Gateway))
+	} else if prefix, err := netip.ParsePrefix(cidr); err == nil {
+		// Libvirt uses the first address as a gateway by default.
+		ips = append(ips, prefix.Masked().Addr().Next().String())
+	}
+
+	for _, ip := range ips {
+		if ip != "" && a.Contains(ip) {
+			return v.Fail().Errorf("Address range '%s' must not contain IP address '%s', which is already used by a node instance, VIP or network gateway.", a, ip)
+		}
+	}
+
+	return v.None
+}
+
+// metallbOverlapValidator returns a validator that triggers an error if
+// any two address ranges of the pools overlap.
+func metallbOverlapValidator(pools []MetalLBPool) v.Validator {
+	var addrs []MetalLBAddress
+
+	for _, p := range pools {
+		addrs = append(addrs, p.Addresses...)
+	}
+
+	for i := 0; i < len(addrs); i++ {
+		f1, l1, ok1 := addrs[i].Bounds()
+
+		for j := i + 1; j < len(addrs); j++ {
+			f2, l2, ok2 := addrs[j].Bounds()
+
+			if ok1 && ok2 && f1.Compare(l2) <= 0 && f2.Compare(l1) <= 0 {
+				return v.Fail().Errorf("Address ranges of MetalLB pools must not overlap. (overlapping: [%s, %s])", addrs[i], addrs[j])
+			}
+		}
+	}
+
+	return v.None
+}
+
+// metallbKubesprayValidator returns a cross-validator that triggers an
+// error if MetalLB is enabled both as an addon and as a Kubespray addon.
+func metallbKubesprayValidator(enabled bool) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !enabled || !ok || c == nil {
+		return v.None
+	}
+
+	if e, ok := c.Addons.Kubespray["metallb_enabled"].(bool); ok && e {
+		return v.Fail().Error("MetalLB addon cannot be enabled together with the Kubespray MetalLB addon (metallb_enabled).")
+	}
+
+	return v.None
+}
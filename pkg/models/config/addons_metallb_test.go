@@ -0,0 +1,123 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetalLBAddress_Bounds(t *testing.T) {
+	tests := []struct {
+		addr  MetalLBAddress
+		first string
+		last  string
+		ok    bool
+	}{
+		{"192.168.113.200-192.168.113.210", "192.168.113.200", "192.168.113.210", true},
+		{"192.168.113.192/27", "192.168.113.192", "192.168.113.223", true},
+		{"192.168.113.200/32", "192.168.113.200", "192.168.113.200", true},
+		{"fd00:113::100/120", "fd00:113::100", "fd00:113::1ff", true},
+		{"192.168.113.210-192.168.113.200", "", "", false},
+		{"192.168.113.200", "", "", false},
+		{"invalid/24", "", "", false},
+	}
+
+	for _, test := range tests {
+		first, last, ok := test.addr.Bounds()
+		assert.Equal(t, test.ok, ok, test.addr)
+
+		if test.ok {
+			assert.Equal(t, test.first, first.String())
+			assert.Equal(t, test.last, last.String())
+		}
+	}
+}
+
+func TestMetalLBAddress_Contains(t *testing.T) {
+	addr := MetalLBAddress("192.168.113.192/27")
+
+	assert.True(t, addr.Contains("192.168.113.200"))
+	assert.False(t, addr.Contains("192.168.113.10"))
+	assert.False(t, addr.Contains("invalid"))
+}
+
+func TestConfig_MetalLB(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Addons.MetalLB = MetalLB{
+		Enabled: true,
+		Pools: []MetalLBPool{
+			{Name: "default", Addresses: []MetalLBAddress{"192.168.113.200-192.168.113.210"}},
+			{Name: "reserved", Addresses: []MetalLBAddress{"192.168.113.224/28"}},
+		},
+	}
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_MetalLB_Invalid(t *testing.T) {
+	tests := []struct {
+		pools []MetalLBPool
+		err   string
+	}{
+		{
+			pools: nil,
+			err:   "At least one address pool must be configured when MetalLB is enabled.",
+		},
+		{
+			pools: []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"192.168.113.200"}}},
+			err:   "must be a valid IP range",
+		},
+		{
+			pools: []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"10.10.0.10-10.10.0.20"}}},
+			err:   "Address range '10.10.0.10-10.10.0.20' must be within the cluster network CIDR '192.168.113.0/24'.",
+		},
+		{
+			pools: []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"192.168.113.5-192.168.113.15"}}},
+			err:   "Address range '192.168.113.5-192.168.113.15' must not contain IP address '192.168.113.10'",
+		},
+		{
+			pools: []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"192.168.113.0/29"}}},
+			err:   "must not contain IP address '192.168.113.1',",
+		},
+		{
+			pools: []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"fd00::10-fd00::20"}}},
+			err:   "can only be set when IPv6 network CIDR (cidr6) is configured",
+		},
+		{
+			pools: []MetalLBPool{
+				{Name: "a", Addresses: []MetalLBAddress{"192.168.113.200-192.168.113.210"}},
+				{Name: "b", Addresses: []MetalLBAddress{"192.168.113.208/29"}},
+			},
+			err: "Address ranges of MetalLB pools must not overlap.",
+		},
+	}
+
+	for _, test := range tests {
+		cfg := MockConfig(t)
+		cfg.Addons.MetalLB = MetalLB{Enabled: true, Pools: test.pools}
+
+		assert.ErrorContains(t, cfg.Validate(), test.err)
+	}
+}
+
+func TestConfig_MetalLB_VIP(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Nodes.LoadBalancer.VIP = "192.168.113.205"
+	cfg.Addons.MetalLB = MetalLB{
+		Enabled: true,
+		Pools:   []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"192.168.113.200-192.168.113.210"}}},
+	}
+
+	assert.ErrorContains(t, cfg.Validate(), "must not contain IP address '192.168.113.205'")
+}
+
+func TestConfig_MetalLB_Kubespray(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Addons.Kubespray = map[string]any{"metallb_enabled": true}
+	cfg.Addons.MetalLB = MetalLB{
+		Enabled: true,
+		Pools:   []MetalLBPool{{Name: "default", Addresses: []MetalLBAddress{"192.168.113.200-192.168.113.210"}}},
+	}
+
+	assert.ErrorContains(t, cfg.Validate(), "cannot be enabled together with the Kubespray MetalLB addon")
+}
@@ -0,0 +1,433 @@
+package config
+
+import (
+	"slices"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// Rook deploys a Ceph cluster on the raw data disks of the nodes that
+// are eligible for Rook. If none of the block pools, filesystems and
+// object stores is configured, a default one of each is created.
+type Rook struct {
+	Enabled      bool              `yaml:"enabled"`
+	Version      Version           `yaml:"version"`
+	NodeSelector Labels            `yaml:"nodeSelector"`
+	DataDisks    []string          `yaml:"dataDisks,omitempty"`
+	Replicas     int               `yaml:"replicas,omitempty"`
+	Dashboard    RookDashboard     `yaml:"dashboard,omitempty"`
+	BlockPools   []RookBlockPool   `yaml:"blockPools,omitempty"`
+	FileSystems  []RookFileSystem  `yaml:"fileSystems,omitempty"`
+	ObjectStores []RookObjectStore `yaml:"objectStores,omitempty"`
+}
+
+func (r Rook) Validate() error {
+	var classes []RookStorageClass
+
+	for _, p := range r.BlockPools {
+		classes = append(classes, p.StorageClass)
+	}
+
+	for _, fs := range r.FileSystems {
+		classes = append(classes, fs.StorageClass)
+	}
+
+	for _, s := range r.ObjectStores {
+		classes = append(classes, s.StorageClass)
+	}
+
+	nb := len(r.BlockPools)
+	nf := len(r.FileSystems)
+
+	return v.Struct(&r,
+		v.Field(&r.Enabled, rookRawDisksValidator(r.Enabled)),
+		v.Field(&r.Version, v.OmitEmpty()),
+		v.Field(&r.NodeSelector, v.OmitEmpty()),
+		v.Field(&r.DataDisks, v.OmitEmpty(), v.Unique(), rookDataDisksValidator(r.DataDisks)),
+		v.Field(&r.Replicas, v.OmitEmpty(), v.Min(1), rookReplicasValidator(r.Replicas, FailureDomainHost)),
+		v.Field(&r.Dashboard),
+		v.Field(&r.BlockPools, v.OmitEmpty(), v.UniqueField("Name"), rookStorageClassesValidator(classes[:nb])),
+		v.Field(&r.FileSystems, v.OmitEmpty(), v.UniqueField("Name"), rookStorageClassesValidator(classes[:nb+nf])),
+		v.Field(&r.ObjectStores, v.OmitEmpty(), v.UniqueField("Name"), rookStorageClassesValidator(classes)),
+	)
+}
+
+// RookStorageClass is a StorageClass through which the volumes are
+// provisioned from the Ceph pool.
+type RookStorageClass struct {
+	Name          string            `yaml:"name,omitempty"`
+	Default       bool              `yaml:"default,omitempty"`
+	ReclaimPolicy RookReclaimPolicy `yaml:"reclaimPolicy,omitempty"`
+}
+
+func (sc RookStorageClass) Validate() error {
+	return v.Struct(&sc,
+		v.Field(&sc.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&sc.ReclaimPolicy, v.NotEmpty()),
+	)
+}
+
+func (sc *RookStorageClass) SetDefaults() {
+	sc.ReclaimPolicy = defaults.Default(sc.ReclaimPolicy, ReclaimPolicyDelete)
+}
+
+type RookReclaimPolicy string
+
+const (
+	ReclaimPolicyDelete RookReclaimPolicy = "Delete"
+	ReclaimPolicyRetain RookReclaimPolicy = "Retain"
+)
+
+func (p RookReclaimPolicy) Validate() error {
+	return v.Var(p, v.OneOf(ReclaimPolicyDelete, ReclaimPolicyRetain))
+}
+
+// RookFailureDomain determines across which failure domain the replicas
+// of the data are spread. When set to host, each replica is stored on
+// a different node.
+type RookFailureDomain string
+
+const (
+	FailureDomainHost RookFailureDomain = "host"
+	FailureDomainOSD  RookFailureDomain = "osd"
+)
+
+func (d RookFailureDomain) Validate() error {
+	return v.Var(d, v.OneOf(FailureDomainHost, FailureDomainOSD))
+}
+
+// RookBlockPool is a replicated Ceph pool that provides block storage
+// (RBD) through its StorageClass.
+type RookBlockPool struct {
+	Name          string            `yaml:"name" opt:",id"`
+	Replicas      int               `yaml:"replicas,omitempty"`
+	FailureDomain RookFailureDomain `yaml:"failureDomain,omitempty"`
+	StorageClass  RookStorageClass  `yaml:"storageClass,omitempty"`
+}
+
+func (p RookBlockPool) Validate() error {
+	return v.Struct(&p,
+		v.Field(&p.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&p.Replicas, v.OmitEmpty(), v.Min(1), rookReplicasValidator(p.Replicas, p.FailureDomain)),
+		v.Field(&p.FailureDomain, v.NotEmpty()),
+		v.Field(&p.StorageClass),
+	)
+}
+
+func (p *RookBlockPool) SetDefaults() {
+	p.FailureDomain = defaults.Default(p.FailureDomain, FailureDomainHost)
+	p.StorageClass.Name = defaults.Default(p.StorageClass.Name, p.Name)
+}
+
+// RookFileSystem is a shared filesystem (CephFS) whose metadata and data
+// pools are replicated. Volumes are provisioned through its StorageClass.
+type RookFileSystem struct {
+	Name          string            `yaml:"name" opt:",id"`
+	Replicas      int               `yaml:"replicas,omitempty"`
+	FailureDomain RookFailureDomain `yaml:"failureDomain,omitempty"`
+	StorageClass  RookStorageClass  `yaml:"storageClass,omitempty"`
+}
+
+func (fs RookFileSystem) Validate() error {
+	return v.Struct(&fs,
+		v.Field(&fs.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&fs.Replicas, v.OmitEmpty(), v.Min(1), rookReplicasValidator(fs.Replicas, fs.FailureDomain)),
+		v.Field(&fs.FailureDomain, v.NotEmpty()),
+		v.Field(&fs.StorageClass),
+	)
+}
+
+func (fs *RookFileSystem) SetDefaults() {
+	fs.FailureDomain = defaults.Default(fs.FailureDomain, FailureDomainHost)
+	fs.StorageClass.Name = defaults.Default(fs.StorageClass.Name, fs.Name)
+}
+
+// RookObjectStore is an S3 compatible object store whose buckets are
+// provisioned through its StorageClass.
+type RookObjectStore struct {
+	Name          string            `yaml:"name" opt:",id"`
+	Replicas      int               `yaml:"replicas,omitempty"`
+	FailureDomain RookFailureDomain `yaml:"failureDomain,omitempty"`
+	StorageClass  RookStorageClass  `yaml:"storageClass,omitempty"`
+}
+
+func (s RookObjectStore) Validate() error {
+	return v.Struct(&s,
+		v.Field(&s.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(63)),
+		v.Field(&s.Replicas, v.OmitEmpty(), v.Min(1), rookReplicasValidator(s.Replicas, s.FailureDomain)),
+		v.Field(&s.FailureDomain, v.NotEmpty()),
+		v.Field(&s.StorageClass,
+			v.Fail().When(s.StorageClass.Default).Error("Object store StorageClass cannot be set as a default StorageClass, since it provisions buckets instead of volumes."),
+		),
+	)
+}
+
+func (s *RookObjectStore) SetDefaults() {
+	s.FailureDomain = defaults.Default(s.FailureDomain, FailureDomainHost)
+	s.StorageClass.Name = defaults.Default(s.StorageClass.Name, s.Name)
+}
+
+// RookDashboard configures the Ceph dashboard. By default, the dashboard
+// is only reachable from within the cluster.
+type RookDashboard struct {
+	Enabled *bool               `yaml:"enabled"`
+	SSL     *bool               `yaml:"ssl"`
+	Port    Port                `yaml:"port,omitempty"`
+	Expose  RookDashboardExpose `yaml:"expose,omitempty"`
+}
+
+func (d RookDashboard) Validate() error {
+	return v.Struct(&d,
+		v.Field(&d.Port, v.OmitEmpty()),
+		v.Field(&d.Expose,
+			v.OmitEmpty(),
+			v.Fail().When(d.Expose != DashboardExposeNone && d.Enabled != nil && !*d.Enabled).Error("Field '{.Field}' can only be set when the dashboard is enabled."),
+			rookDashboardExposeValidator(d.Expose),
+		),
+	)
+}
+
+func (d *RookDashboard) SetDefaults() {
+	enabled := true
+	d.Enabled = defaults.Default(d.Enabled, &enabled)
+
+	ssl := true
+	d.SSL = defaults.Default(d.SSL, &ssl)
+
+	if *d.SSL {
+		d.Port = defaults.Default(d.Port, Port(8443))
+	} else {
+		d.Port = defaults.Default(d.Port, Port(7000))
+	}
+
+	d.Expose = defaults.Default(d.Expose, DashboardExposeNone)
+}
+
+// RookDashboardExpose determines how the dashboard is exposed outside
+// of the cluster.
+type RookDashboardExpose string
+
+const (
+	DashboardExposeNone         RookDashboardExpose = "none"
+	DashboardExposeNodePort     RookDashboardExpose = "nodePort"
+	DashboardExposeLoadBalancer RookDashboardExpose = "loadBalancer"
+)
+
+func (e RookDashboardExpose) Validate() error {
+	return v.Var(e, v.OneOf(DashboardExposeNone, DashboardExposeNodePort, DashboardExposeLoadBalancer))
+}
+
+// rookNodes returns node instances that are eligible for Rook. Rook is
+// deployed on worker nodes. If there are no worker nodes, master nodes
+// are used. Nodes are additionally filtered with the node selector.
+func rookNodes(c *Config) []rookNode {
+	var nodes []rookNode
+
+	if len(c.Cluster.Nodes.Worker.Instances) > 0 {
+		for _, i := range c.Cluster.Nodes.Worker.Instances {
+			nodes = append(nodes, rookNode{i.Name, i.Labels, i.DataDisks})
+		}
+	} else {
+		for _, i := range c.Cluster.Nodes.Master.Instances {
+			nodes = append(nodes, rookNode{i.Name, i.Labels, i.DataDisks})
+		}
+	}
+
+	var selected []rookNode
+
+	for _, n := range nodes {
+		if n.matches(c.Addons.Rook.NodeSelector) {
+			selected = append(selected, n)
+		}
+	}
+
+	return selected
+}
+
+// rookNode is a node instance that is eligible for Rook.
+type rookNode struct {
+	name   string
+	labels Labels
+	disks  []DataDisk
+}
+
+// matches returns true if the node has all labels of the node selector.
+// Selector labels with an empty value only require the label key to be
+// present.
+func (n rookNode) matches(selector Labels) bool {
+	for k, v := range selector {
+		l, ok := n.labels[k]
+		if !ok || (v != "" && l != v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// osdDisks returns raw data disks of the node that are consumed by Rook.
+// If data disks are not explicitly selected, all raw disks are consumed.
+func (n rookNode) osdDisks(selected []string) []DataDisk {
+	var disks []DataDisk
+
+	for _, d := range n.disks {
+		if !d.IsRaw() {
+			continue
+		}
+
+		if len(selected) == 0 || slices.Contains(selected, d.Name) {
+			disks = append(disks, d)
+		}
+	}
+
+	return disks
+}
+
+// rookRawDisksValidator returns a cross-validator that triggers an error
+// if Rook is enabled, but any of the nodes eligible for Rook has no raw
+// data disk, since Rook can only consume raw disks. If none of the nodes
+// has a data disk, Rook is not installed.
+func rookRawDisksValidator(enabled bool) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !enabled || !ok || c == nil {
+		return v.None
+	}
+
+	nodes := rookNodes(c)
+
+	if !slices.ContainsFunc(nodes, func(n rookNode) bool { return len(n.disks) > 0 }) {
+		return v.None
+	}
+
+	for _, n := range nodes {
+		if !slices.ContainsFunc(n.disks, DataDisk.IsRaw) {
+			return v.Fail().Errorf("Rook requires at least one raw data disk on each node eligible for Rook, but node '%s' has none. Data disks intended for Rook must be left raw, while nodes without them can be excluded using the node selector.", n.name)
+		}
+	}
+
+	return v.None
+}
+
+// rookDataDisksValidator returns a cross-validator that triggers an error
+// if any of the selected data disks does not exist on the nodes eligible
+// for Rook, if it has a filesystem set or if its device is unknown.
+func rookDataDisksValidator(names []string) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil {
+		return v.None
+	}
+
+	for _, name := range names {
+		found := false
+
+		for _, n := range rookNodes(c) {
+			for _, d := range n.disks {
+				if d.Name != name {
+					continue
+				}
+
+				if !d.IsRaw() {
+					return v.Fail().Errorf("Data disk '%s' cannot be consumed by Rook, since it has a filesystem set.", name)
+				}
+
+				// Selected disks are passed to Rook by their devices, which
+				// are known only for the provisioned virtual machines.
+				if d.Device == "" && c.Provisioner == ProvisionerStatic {
+					return v.Fail().Errorf("Data disk '%s' of node '%s' must have a device set to be consumed by Rook when provisioner is '%s'.", name, n.name, ProvisionerStatic)
+				}
+
+				found = true
+			}
+		}
+
+		if !found {
+			return v.Fail().Errorf("Data disk '%s' is not attached to any of the nodes eligible for Rook.", name)
+		}
+	}
+
+	return v.None
+}
+
+// rookReplicasValidator returns a cross-validator that triggers an error
+// if the replica count exceeds the number of failure domains available
+// to Rook. For the host failure domain, this is the number of nodes with
+// at least one disk consumed by Rook, and for the OSD failure domain,
+// the number of such disks.
+func rookReplicasValidator(replicas int, domain RookFailureDomain) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil || !c.Addons.Rook.Enabled {
+		return v.None
+	}
+
+	var hosts, osds int
+
+	for _, n := range rookNodes(c) {
+		disks := n.osdDisks(c.Addons.Rook.DataDisks)
+
+		if len(disks) > 0 {
+			hosts++
+			osds += len(disks)
+		}
+	}
+
+	// Rook is not deployed when there are no OSD nodes.
+	if hosts == 0 {
+		return v.None
+	}
+
+	if domain == FailureDomainOSD {
+		if replicas > osds {
+			return v.Fail().Errorf("Field '{.Field}' must not exceed the number of data disks consumed by Rook. (actual: %d, disks: %d)", replicas, osds)
+		}
+
+		return v.None
+	}
+
+	if replicas > hosts {
+		return v.Fail().Errorf("Field '{.Field}' must not exceed the number of nodes with data disks consumed by Rook. (actual: %d, nodes: %d)", replicas, hosts)
+	}
+
+	return v.None
+}
+
+// rookStorageClassesValidator returns a validator that triggers an error
+// if multiple StorageClasses share the same name or if more than one
+// StorageClass is set as default.
+func rookStorageClassesValidator(classes []RookStorageClass) v.Validator {
+	var def []string
+
+	for i, sc := range classes {
+		if sc.Default {
+			def = append(def, sc.Name)
+		}
+
+		for j := i + 1; j < len(classes); j++ {
+			if sc.Name != "" && sc.Name == classes[j].Name {
+				return v.Fail().Errorf("StorageClass name '%s' is used by multiple Rook pools.", sc.Name)
+			}
+		}
+	}
+
+	if len(def) > 1 {
+		return v.Fail().Errorf("Only one Rook StorageClass can be set as default. (defaults: %v)", def)
+	}
+
+	return v.None
+}
+
+// rookDashboardExposeValidator returns a cross-validator that triggers
+// an error if the dashboard is exposed through a LoadBalancer service,
+// but MetalLB addon is not enabled.
+func rookDashboardExposeValidator(expose RookDashboardExpose) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if expose != DashboardExposeLoadBalancer || !ok || c == nil {
+		return v.None
+	}
+
+	if !c.Addons.MetalLB.Enabled {
+		return v.Fail().Error("Rook dashboard can only be exposed through a LoadBalancer service when MetalLB addon is enabled.")
+	}
+
+	return v.None
+}
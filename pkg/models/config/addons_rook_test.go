@@ -0,0 +1,212 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRookConfig returns a configuration with Rook enabled and three
+// worker nodes. First two nodes have two raw data disks and are selected
+// for Rook, while the last one has only a formatted data disk.
+func mockRookConfig(t *testing.T) Config {
+	cfg := MockConfig(t)
+	cfg.Addons.Rook.Enabled = true
+	cfg.Addons.Rook.NodeSelector = Labels{"osd": ""}
+
+	raw := []DataDisk{
+		{Name: "osd-a", Size: 10},
+		{Name: "osd-b", Size: 10},
+	}
+
+	fs := []DataDisk{
+		{Name: "data", Size: 10, Filesystem: EXT4},
+	}
+
+	cfg.Cluster.Nodes.Worker.Instances = []WorkerInstance{
+		{Name: "cluster-mock-worker-1", Id: "1", IP: "192.168.113.21", DataDisks: append(raw, fs...), Labels: Labels{"osd": "", "storage": "ceph"}},
+		{Name: "cluster-mock-worker-2", Id: "2", IP: "192.168.113.22", DataDisks: raw, Labels: Labels{"osd": ""}},
+		{Name: "cluster-mock-worker-3", Id: "3", IP: "192.168.113.23", DataDisks: fs},
+	}
+
+	require.NoError(t, defaults.Set(&cfg))
+	return cfg
+}
+
+func TestAddonRook(t *testing.T) {
+	rook := Rook{
+		Version:      Version("v1.9.9"),
+		NodeSelector: Labels{"rook": "true"},
+	}
+
+	assert.NoError(t, rook.Validate())
+	assert.NoError(t, Rook{}.Validate())
+}
+
+func TestAddonRook_RawDisks(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Addons.Rook.Enabled = true
+
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Cluster.Nodes.Master.Instances[0].DataDisks = []DataDisk{
+		{Name: "data", Size: 10, Filesystem: EXT4, MountPath: "/data"},
+	}
+
+	assert.ErrorContains(t, cfg.Validate(), "Rook requires at least one raw data disk on each node eligible for Rook, but node 'cluster-mock-master-1' has none.")
+
+	cfg.Cluster.Nodes.Master.Instances[0].DataDisks = append(
+		cfg.Cluster.Nodes.Master.Instances[0].DataDisks,
+		DataDisk{Name: "rook", Size: 10},
+	)
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestAddonRook_RawDisks_NodeSelector(t *testing.T) {
+	cfg := mockRookConfig(t)
+
+	assert.NoError(t, cfg.Validate())
+
+	// Node without raw data disks is eligible for Rook.
+	cfg.Addons.Rook.NodeSelector = nil
+	assert.ErrorContains(t, cfg.Validate(), "but node 'cluster-mock-worker-3' has none.")
+
+	// Node without data disks is eligible for Rook.
+	cfg.Cluster.Nodes.Worker.Instances[2].DataDisks = nil
+	assert.ErrorContains(t, cfg.Validate(), "but node 'cluster-mock-worker-3' has none.")
+}
+
+func TestAddonRook_Defaults(t *testing.T) {
+	rook := Rook{
+		BlockPools:   []RookBlockPool{{Name: "fast"}},
+		ObjectStores: []RookObjectStore{{Name: "s3", StorageClass: RookStorageClass{Name: "bucket"}}},
+	}
+
+	defaults.Assign(&rook)
+
+	assert.Equal(t, FailureDomainHost, rook.BlockPools[0].FailureDomain)
+	assert.Equal(t, "fast", rook.BlockPools[0].StorageClass.Name)
+	assert.Equal(t, ReclaimPolicyDelete, rook.BlockPools[0].StorageClass.ReclaimPolicy)
+	assert.Equal(t, "bucket", rook.ObjectStores[0].StorageClass.Name)
+
+	assert.True(t, *rook.Dashboard.Enabled)
+	assert.True(t, *rook.Dashboard.SSL)
+	assert.Equal(t, Port(8443), rook.Dashboard.Port)
+	assert.Equal(t, DashboardExposeNone, rook.Dashboard.Expose)
+
+	ssl := false
+	dashboard := RookDashboard{SSL: &ssl}
+	defaults.Assign(&dashboard)
+
+	assert.Equal(t, Port(7000), dashboard.Port)
+}
+
+func TestAddonRook_DataDisks(t *testing.T) {
+	cfg := mockRookConfig(t)
+	cfg.Addons.Rook.DataDisks = []string{"osd-a"}
+
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Addons.Rook.DataDisks = []string{"data"}
+	assert.ErrorContains(t, cfg.Validate(), "Data disk 'data' cannot be consumed by Rook, since it has a filesystem set.")
+
+	cfg.Addons.Rook.DataDisks = []string{"missing"}
+	assert.ErrorContains(t, cfg.Validate(), "Data disk 'missing' is not attached to any of the nodes eligible for Rook.")
+
+	// Disks of the nodes that do not match the node selector are ignored.
+	cfg.Addons.Rook.NodeSelector = Labels{"storage": "other"}
+	cfg.Addons.Rook.DataDisks = []string{"osd-a"}
+	assert.ErrorContains(t, cfg.Validate(), "Data disk 'osd-a' is not attached to any of the nodes eligible for Rook.")
+}
+
+func TestAddonRook_DataDisks_Static(t *testing.T) {
+	cfg := mockRookConfig(t)
+	cfg.Provisioner = ProvisionerStatic
+	cfg.Hosts = nil
+	cfg.Cluster.NodeTemplate.SSH.PrivateKeyPath = MockPKey(t)
+
+	// All raw disks are consumed without listing their devices.
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Addons.Rook.DataDisks = []string{"osd-a"}
+	assert.ErrorContains(t, cfg.Validate(), "Data disk 'osd-a' of node 'cluster-mock-worker-1' must have a device set to be consumed by Rook when provisioner is 'static'.")
+
+	for k := range cfg.Cluster.Nodes.Worker.Instances[:2] {
+		i := &cfg.Cluster.Nodes.Worker.Instances[k]
+		i.DataDisks = []DataDisk{{Name: "osd-a", Size: 10, Device: "/dev/sdb"}}
+	}
+
+	assert.NoError(t, cfg.Validate())
+
+	// Device is set by the other provisioners.
+	cfg.Provisioner = ProvisionerTerraform
+	assert.ErrorContains(t, cfg.Validate(), "Field 'device' can only be set when provisioner is 'static'.")
+}
+
+func TestAddonRook_Replicas(t *testing.T) {
+	cfg := mockRookConfig(t)
+	cfg.Addons.Rook.Replicas = 2
+
+	assert.NoError(t, cfg.Validate())
+
+	// Only two nodes have raw data disks.
+	cfg.Addons.Rook.Replicas = 3
+	assert.ErrorContains(t, cfg.Validate(), "must not exceed the number of nodes with data disks consumed by Rook. (actual: 3, nodes: 2)")
+
+	cfg.Addons.Rook.Replicas = 1
+	cfg.Addons.Rook.NodeSelector = Labels{"storage": ""}
+	cfg.Addons.Rook.BlockPools = []RookBlockPool{{Name: "pool", Replicas: 2}}
+	defaults.Assign(&cfg)
+
+	assert.ErrorContains(t, cfg.Validate(), "(actual: 2, nodes: 1)")
+
+	// With the OSD failure domain, replicas are limited by data disks.
+	cfg.Addons.Rook.BlockPools[0].FailureDomain = FailureDomainOSD
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Addons.Rook.DataDisks = []string{"osd-b"}
+	assert.ErrorContains(t, cfg.Validate(), "must not exceed the number of data disks consumed by Rook. (actual: 2, disks: 1)")
+}
+
+func TestAddonRook_StorageClasses(t *testing.T) {
+	cfg := mockRookConfig(t)
+	cfg.Addons.Rook.BlockPools = []RookBlockPool{{Name: "block", StorageClass: RookStorageClass{Default: true}}}
+	cfg.Addons.Rook.FileSystems = []RookFileSystem{{Name: "fs"}}
+	cfg.Addons.Rook.ObjectStores = []RookObjectStore{{Name: "s3"}}
+	defaults.Assign(&cfg)
+
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Addons.Rook.FileSystems[0].StorageClass.Default = true
+	assert.ErrorContains(t, cfg.Validate(), "Only one Rook StorageClass can be set as default. (defaults: [block fs])")
+
+	cfg.Addons.Rook.FileSystems[0].StorageClass = RookStorageClass{Name: "block", ReclaimPolicy: ReclaimPolicyRetain}
+	assert.ErrorContains(t, cfg.Validate(), "StorageClass name 'block' is used by multiple Rook pools.")
+
+	cfg.Addons.Rook.FileSystems[0].StorageClass.Name = "fs"
+	cfg.Addons.Rook.BlockPools[0].StorageClass.Default = false
+	cfg.Addons.Rook.ObjectStores[0].StorageClass.Default = true
+	assert.ErrorContains(t, cfg.Validate(), "Object store StorageClass cannot be set as a default StorageClass")
+}
+
+func TestAddonRook_Dashboard(t *testing.T) {
+	cfg := mockRookConfig(t)
+	cfg.Addons.Rook.Dashboard.Expose = DashboardExposeNodePort
+
+	assert.NoError(t, cfg.Validate())
+
+	disabled := false
+	cfg.Addons.Rook.Dashboard.Enabled = &disabled
+	assert.ErrorContains(t, cfg.Validate(), "can only be set when the dashboard is enabled")
+
+	cfg.Addons.Rook.Dashboard = RookDashboard{Expose: DashboardExposeLoadBalancer}
+	defaults.Assign(&cfg)
+	assert.ErrorContains(t, cfg.Validate(), "Rook dashboard can only be exposed through a LoadBalancer service when MetalLB addon is enabled.")
+
+	cfg.Addons.Rook.Dashboard.Expose = "ingress"
+	assert.Error(t, cfg.Validate())
+}
@@ -3,15 +3,61 @@ package config
 import (
 	"testing"
 
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
 	"github.com/stretchr/testify/assert"
 )
 
-func TestAddonRook(t *testing.T) {
-	rook := Rook{
-		Version:      Version("v1.9.9"),
-		NodeSelector: Labels{"rook": "true"},
+func TestAddonHelmChart(t *testing.T) {
+	chart := HelmChart{
+		Name:      "ingress-nginx",
+		Repo:      "https://kubernetes.github.io/ingress-nginx",
+		Chart:     "ingress-nginx",
+		Version:   "4.8.3",
+		Namespace: "ingress",
+		Values:    map[string]any{"controller": map[string]any{"replicaCount": 2}},
+	}
+
+	assert.NoError(t, chart.Validate())
+
+	oci := chart
+	oci.Repo = "oci://registry-1.docker.io/bitnamicharts"
+	assert.NoError(t, oci.Validate())
+
+	invalid := chart
+	invalid.Repo = "charts.example.com"
+	assert.ErrorContains(t, invalid.Validate(), "must be a valid HTTP(S) or OCI repository URL")
+
+	invalid = chart
+	invalid.ValuesFile = MockPKey(t)
+	assert.ErrorContains(t, invalid.Validate(), "cannot be set together with field 'values'")
+
+	invalid = chart
+	invalid.Name = ""
+	assert.Error(t, invalid.Validate())
+}
+
+func TestAddonHelmChart_Defaults(t *testing.T) {
+	chart := HelmChart{}
+	defaults.Assign(&chart)
+
+	assert.Equal(t, "default", chart.Namespace)
+}
+
+func TestAddons_Charts(t *testing.T) {
+	chart := HelmChart{
+		Name:      "rook-operator",
+		Repo:      "https://charts.example.com",
+		Chart:     "operator",
+		Namespace: "default",
 	}
 
-	assert.NoError(t, rook.Validate())
-	assert.NoError(t, Rook{}.Validate())
+	addons := Addons{Charts: []HelmChart{chart, chart}}
+	assert.ErrorContains(t, addons.Validate(), "unique")
+
+	addons = Addons{Charts: []HelmChart{chart}}
+	assert.NoError(t, addons.Validate())
+
+	addons.Rook.Enabled = true
+	assert.EqualError(t, addons.Validate(), "Chart name 'rook-operator' is reserved for the Rook addon.")
 }
@@ -3,18 +3,22 @@ package config
 import v "github.com/MusicDin/kubitect/pkg/utils/validation"
 
 type Cluster struct {
-	Name         string       `yaml:"name"`
-	Network      Network      `yaml:"network"`
-	NodeTemplate NodeTemplate `yaml:"nodeTemplate"`
-	Nodes        Nodes        `yaml:"nodes"`
+	Name               string              `yaml:"name"`
+	Network            Network             `yaml:"network"`
+	AdditionalNetworks []AdditionalNetwork `yaml:"additionalNetworks,omitempty"`
+	NodeTemplate       NodeTemplate        `yaml:"nodeTemplate"`
+	Nodes              Nodes               `yaml:"nodes"`
+	State              State               `yaml:"state,omitempty"`
 }
 
 func (c Cluster) Validate() error {
 	return v.Struct(&c,
 		v.Field(&c.Name, v.NotEmpty(), v.AlphaNumericHyp()),
 		v.Field(&c.Network),
-		v.Field(&c.Nodes, c.uniqueIpValidator(), c.uniqueMacValidator()),
+		v.Field(&c.AdditionalNetworks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&c.Nodes, c.uniqueIpValidator(), c.uniqueNetworkIpValidator(), c.uniqueMacValidator()),
 		v.Field(&c.NodeTemplate),
+		v.Field(&c.State),
 	)
 }
 
@@ -40,6 +44,31 @@ func (c Cluster) uniqueIpValidator() v.Validator {
 	return v.Fail().Errorf("IP address of each node instance (including VIP) must be unique. (duplicates: %v)", duplicates)
 }
 
+// uniqueNetworkIpValidator returns a validator that triggers an error if
+// multiple nodes are assigned the same IP address within the same
+// additional network.
+func (c Cluster) uniqueNetworkIpValidator() v.Validator {
+	for _, n := range c.AdditionalNetworks {
+		var duplicates []string
+
+		ips := c.Nodes.NetworkIPs(n.Name)
+
+		for i := 0; i < len(ips); i++ {
+			for j := i + 1; j < len(ips); j++ {
+				if ips[i] == ips[j] {
+					duplicates = append(duplicates, ips[i])
+				}
+			}
+		}
+
+		if len(duplicates) > 0 {
+			return v.Fail().Errorf("IP address of each node instance must be unique within the additional network '%s'. (duplicates: %v)", n.Name, duplicates)
+		}
+	}
+
+	return v.None
+}
+
 // uniqueMacValidator returns a validator that triggers an error if multiple nodes
 // are assigned the same MAC address.
 func (c Cluster) uniqueMacValidator() v.Validator {
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"net/netip"
+
 	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 	v "github.com/MusicDin/kubitect/pkg/utils/validation"
 )
@@ -8,35 +10,89 @@ import (
 type NetworkMode string
 
 const (
-	NAT    NetworkMode = "nat"
-	ROUTE  NetworkMode = "route"
-	BRIDGE NetworkMode = "bridge"
+	NAT      NetworkMode = "nat"
+	ROUTE    NetworkMode = "route"
+	BRIDGE   NetworkMode = "bridge"
+	EXISTING NetworkMode = "existing"
 )
 
 func (mode NetworkMode) Validate() error {
-	return v.Var(mode, v.OneOf(NAT, ROUTE, BRIDGE))
+	return v.Var(mode, v.OneOf(NAT, ROUTE, BRIDGE, EXISTING))
 }
 
 type Network struct {
-	CIDR    CIDRv4        `yaml:"cidr"`
-	Gateway *IPv4         `yaml:"gateway,omitempty"`
-	Mode    NetworkMode   `yaml:"mode"`
-	Bridge  NetworkBridge `yaml:"bridge,omitempty"`
+	CIDR           CIDRv4           `yaml:"cidr"`
+	CIDR6          CIDRv6           `yaml:"cidr6,omitempty"`
+	Gateway        *IPv4            `yaml:"gateway,omitempty"`
+	Gateway6       *IPv6            `yaml:"gateway6,omitempty"`
+	Mode           NetworkMode      `yaml:"mode"`
+	Bridge         NetworkBridge    `yaml:"bridge,omitempty"`
+	Macvtap        NetworkInterface `yaml:"macvtap,omitempty"`
+	VLAN           VLAN             `yaml:"vlan,omitempty"`
+	LibvirtNetwork string           `yaml:"libvirtNetwork,omitempty"`
+	IPAM           NetworkIPAM      `yaml:"ipam,omitempty"`
 }
 
 func (n Network) Validate() error {
 	return v.Struct(&n,
 		v.Field(&n.CIDR, v.NotEmpty()),
+		v.Field(&n.CIDR6, v.OmitEmpty()),
 		v.Field(&n.Gateway),
+		v.Field(&n.Gateway6,
+			v.OmitEmpty(),
+			v.Fail().When(n.CIDR6 == "").Error("Field '{.Field}' can only be set when IPv6 network CIDR (cidr6) is configured."),
+			v.IPInRange(string(n.CIDR6)),
+		),
 		v.Field(&n.Mode),
-		v.Field(&n.Bridge, v.NotEmpty().When(n.Mode == BRIDGE).Errorf("Field '{.Field}' is required when network mode is set to '%v'.", BRIDGE)),
+		v.Field(&n.Bridge,
+			v.NotEmpty().When(n.Mode == BRIDGE && n.Macvtap == "").Errorf("Field '{.Field}' is required when network mode is set to '%v'.", BRIDGE),
+			v.Fail().When(n.Bridge != "" && n.Macvtap != "").Error("Fields 'bridge' and 'macvtap' are mutually exclusive."),
+		),
+		v.Field(&n.Macvtap, v.OmitEmpty(), modeOnlyValidator(n.Mode, BRIDGE)),
+		v.Field(&n.VLAN, v.OmitEmpty(), modeOnlyValidator(n.Mode, BRIDGE)),
+		v.Field(&n.LibvirtNetwork,
+			v.NotEmpty().When(n.Mode == EXISTING).Errorf("Field '{.Field}' is required when network mode is set to '%v'.", EXISTING),
+			v.OmitEmpty(),
+			modeOnlyValidator(n.Mode, EXISTING),
+		),
+		v.Field(&n.IPAM, n.reservedRangeValidator()),
 	)
 }
 
+// reservedRangeValidator returns a validator that triggers an error if any
+// of the IPAM reserved ranges is not within the network CIDR.
+func (n Network) reservedRangeValidator() v.Validator {
+	prefix, err := netip.ParsePrefix(string(n.CIDR))
+	if err != nil {
+		return v.None
+	}
+
+	for _, r := range n.IPAM.Reserved {
+		first, last, ok := r.Bounds()
+		if ok && (!prefix.Contains(first) || !prefix.Contains(last)) {
+			return v.Fail().Errorf("Reserved IP range '%s' must be within the network CIDR '%s'.", r, n.CIDR)
+		}
+	}
+
+	return v.None
+}
+
+// IsDualStack returns true if the network has both IPv4 and IPv6 CIDR
+// configured.
+func (n Network) IsDualStack() bool {
+	return n.CIDR != "" && n.CIDR6 != ""
+}
+
 func (n *Network) SetDefaults() {
 	n.Mode = defaults.Default(n.Mode, NAT)
 }
 
+// modeOnlyValidator returns a validator that triggers an error if the
+// field is set while the network mode differs from the expected one.
+func modeOnlyValidator(actual, expected NetworkMode) v.Validator {
+	return v.Fail().When(actual != expected).Errorf("Field '{.Field}' can only be set when network mode is set to '%v'.", expected)
+}
+
 type NetworkBridge string
 
 func (br NetworkBridge) Validate() error {
@@ -46,3 +102,38 @@ func (br NetworkBridge) Validate() error {
 		v.MaxLen(16),
 	)
 }
+
+// NetworkInterface is a name of the network interface on the host.
+type NetworkInterface string
+
+func (i NetworkInterface) Validate() error {
+	return v.Var(i,
+		v.OmitEmpty(),
+		v.AlphaNumericHypUS(),
+		v.MaxLen(15),
+	)
+}
+
+// VLAN is a VLAN ID that tags the traffic of node instances.
+type VLAN int
+
+func (id VLAN) Validate() error {
+	return v.Var(id, v.Min(1), v.Max(4094))
+}
+
+// NetworkIPAM configures the built-in IP address management. When enabled,
+// node instances without an IP address are assigned a free address from
+// the network CIDR.
+type NetworkIPAM struct {
+	Enabled  bool      `yaml:"enabled,omitempty"`
+	Reserved []IPRange `yaml:"reserved,omitempty"`
+}
+
+func (ipam NetworkIPAM) Validate() error {
+	return v.Struct(&ipam,
+		v.Field(&ipam.Enabled,
+			v.Fail().When(ipam.Enabled && isStaticProvisioner()).Errorf("Field '{.Field}' cannot be set when provisioner is '%s', since IP addresses of existing machines must be set explicitly.", ProvisionerStatic),
+		),
+		v.Field(&ipam.Reserved),
+	)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// AdditionalNetwork is a network that node instances can be attached to
+// besides the cluster network (e.g. a storage or a management network).
+type AdditionalNetwork struct {
+	Name           string        `yaml:"name" opt:",id"`
+	Mode           NetworkMode   `yaml:"mode"`
+	CIDR           CIDRv4        `yaml:"cidr"`
+	Bridge         NetworkBridge `yaml:"bridge,omitempty"`
+	LibvirtNetwork string        `yaml:"libvirtNetwork,omitempty"`
+}
+
+func (n AdditionalNetwork) Validate() error {
+	return v.Struct(&n,
+		v.Field(&n.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(16)),
+		v.Field(&n.Mode),
+		v.Field(&n.CIDR, v.NotEmpty()),
+		v.Field(&n.Bridge, v.NotEmpty().When(n.Mode == BRIDGE).Errorf("Field '{.Field}' is required when network mode is set to '%v'.", BRIDGE)),
+		v.Field(&n.LibvirtNetwork,
+			v.NotEmpty().When(n.Mode == EXISTING).Errorf("Field '{.Field}' is required when network mode is set to '%v'.", EXISTING),
+			v.OmitEmpty(),
+			modeOnlyValidator(n.Mode, EXISTING),
+		),
+	)
+}
+
+func (n *AdditionalNetwork) SetDefaults() {
+	n.Mode = defaults.Default(n.Mode, NAT)
+}
+
+// NetworkAttachment attaches a node instance to one of the additional
+// networks. If IP is omitted, the instance requests an IP address from
+// a DHCP server.
+type NetworkAttachment struct {
+	Network string `yaml:"network" opt:",id"`
+	IP      IPv4   `yaml:"ip,omitempty"`
+	MAC     MAC    `yaml:"mac,omitempty"`
+}
+
+func (a NetworkAttachment) Validate() error {
+	return v.Struct(&a,
+		v.Field(&a.Network, v.NotEmpty(), additionalNetworkValidator()),
+		v.Field(&a.IP, v.OmitEmpty(), additionalNetworkIPValidator(a.Network)),
+		v.Field(&a.MAC, v.OmitEmpty()),
+	)
+}
+
+// additionalNetworks returns additional networks of the configuration
+// that is being validated.
+func additionalNetworks() ([]AdditionalNetwork, bool) {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil {
+		return nil, false
+	}
+
+	return c.Cluster.AdditionalNetworks, true
+}
+
+// additionalNetworkValidator returns a cross-validator that checks whether
+// an additional network with a given name has been configured.
+func additionalNetworkValidator() v.Validator {
+	nets, ok := additionalNetworks()
+	if !ok {
+		return v.None
+	}
+
+	if len(nets) == 0 {
+		return v.Fail().Error("Field '{.Field}' points to an additional network, but none is configured.")
+	}
+
+	var names []string
+
+	for _, n := range nets {
+		names = append(names, n.Name)
+	}
+
+	return v.OneOf(names...).Errorf("Field '{.Field}' must point to one of the configured additional networks: [%s] (actual: {.Value})", strings.Join(names, "|"))
+}
+
+// additionalNetworkIPValidator returns a cross-validator that checks
+// whether an IP address is within the CIDR of the given additional network.
+func additionalNetworkIPValidator(name string) v.Validator {
+	nets, _ := additionalNetworks()
+
+	for _, n := range nets {
+		if n.Name == name {
+			return v.IPInRange(string(n.CIDR))
+		}
+	}
+
+	return v.IPv4()
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdditionalNetwork(t *testing.T) {
+	n := AdditionalNetwork{
+		Name: "storage",
+		CIDR: "10.10.0.0/24",
+	}
+
+	assert.NoError(t, defaults.Assign(&n).Validate())
+	assert.Equal(t, NAT, n.Mode)
+}
+
+func TestAdditionalNetwork_BridgeRequired(t *testing.T) {
+	n := AdditionalNetwork{
+		Name: "storage",
+		Mode: BRIDGE,
+		CIDR: "10.10.0.0/24",
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&n).Validate(), "Field 'bridge' is required when network mode is set to 'bridge'.")
+}
+
+func TestAdditionalNetwork_MissingCIDR(t *testing.T) {
+	n := AdditionalNetwork{Name: "storage"}
+	assert.ErrorContains(t, defaults.Assign(&n).Validate(), "Field 'cidr' is required and cannot be empty.")
+}
+
+func TestAdditionalNetwork_Existing(t *testing.T) {
+	n := AdditionalNetwork{
+		Name:           "storage",
+		Mode:           EXISTING,
+		CIDR:           "10.10.0.0/24",
+		LibvirtNetwork: "storage-net",
+	}
+
+	assert.NoError(t, defaults.Assign(&n).Validate())
+
+	n.LibvirtNetwork = ""
+	assert.ErrorContains(t, n.Validate(), "Field 'libvirtNetwork' is required when network mode is set to 'existing'.")
+}
+
+func TestConfig_AdditionalNetworks(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.AdditionalNetworks = []AdditionalNetwork{
+		{Name: "storage", CIDR: "10.10.0.0/24"},
+		{Name: "mgmt", Mode: ROUTE, CIDR: "10.20.0.0/24"},
+	}
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "storage", IP: "10.10.0.10"},
+		{Network: "mgmt"},
+	}
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+}
+
+func TestConfig_AdditionalNetworks_UnknownNetwork(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.AdditionalNetworks = []AdditionalNetwork{
+		{Name: "storage", CIDR: "10.10.0.0/24"},
+	}
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "mgmt"},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'network' must point to one of the configured additional networks: [storage] (actual: mgmt)")
+}
+
+func TestConfig_AdditionalNetworks_NoneConfigured(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "storage"},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'network' points to an additional network, but none is configured.")
+}
+
+func TestConfig_AdditionalNetworks_IPOutOfRange(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.AdditionalNetworks = []AdditionalNetwork{
+		{Name: "storage", CIDR: "10.10.0.0/24"},
+	}
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "storage", IP: "10.20.0.10"},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'ip' must be a valid IP address within '10.10.0.0/24' subnet.")
+}
+
+func TestConfig_AdditionalNetworks_DuplicateAttachment(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.AdditionalNetworks = []AdditionalNetwork{
+		{Name: "storage", CIDR: "10.10.0.0/24"},
+	}
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "storage"},
+		{Network: "storage"},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "must be unique")
+}
+
+func TestConfig_AdditionalNetworks_DuplicateIP(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.AdditionalNetworks = []AdditionalNetwork{
+		{Name: "storage", CIDR: "10.10.0.0/24"},
+		{Name: "mgmt", CIDR: "10.10.0.0/24"},
+	}
+	cfg.Cluster.Nodes.Master.Instances[0].Networks = []NetworkAttachment{
+		{Network: "storage", IP: "10.10.0.10"},
+		{Network: "mgmt", IP: "10.10.0.10"},
+	}
+	cfg.Cluster.Nodes.Worker.Instances = []WorkerInstance{
+		{Id: "1", Networks: []NetworkAttachment{{Network: "storage", IP: "10.10.0.10"}}},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "IP address of each node instance must be unique within the additional network 'storage'. (duplicates: [10.10.0.10])")
+}
@@ -22,6 +22,7 @@ func TestNetMode(t *testing.T) {
 	assert.NoError(t, NetworkMode("nat").Validate())
 	assert.NoError(t, NetworkMode("bridge").Validate())
 	assert.NoError(t, NetworkMode("route").Validate())
+	assert.NoError(t, NetworkMode("existing").Validate())
 	assert.NoError(t, NetworkMode(NAT).Validate())
 }
 
@@ -51,3 +52,95 @@ func TestNetwork(t *testing.T) {
 	assert.ErrorContains(t, Network{}.Validate(), "Field 'cidr' is required and cannot be empty.")
 	assert.ErrorContains(t, Network{}.Validate(), "Field 'mode' must be one of the following values")
 }
+
+func TestNetwork_IPAM(t *testing.T) {
+	net := Network{
+		CIDR: "192.168.113.0/24",
+		IPAM: NetworkIPAM{
+			Enabled:  true,
+			Reserved: []IPRange{"192.168.113.1-192.168.113.9"},
+		},
+	}
+
+	assert.NoError(t, defaults.Assign(&net).Validate())
+
+	net.IPAM.Reserved = []IPRange{"192.168.114.1-192.168.114.9"}
+	assert.EqualError(t, defaults.Assign(&net).Validate(), "Reserved IP range '192.168.114.1-192.168.114.9' must be within the network CIDR '192.168.113.0/24'.")
+
+	net.IPAM.Reserved = []IPRange{"192.168.113.9"}
+	assert.ErrorContains(t, defaults.Assign(&net).Validate(), "must be a valid IP range")
+}
+
+func TestNetwork_DualStack(t *testing.T) {
+	gw := IPv6("fd00:113::1")
+
+	net := Network{
+		CIDR:     "192.168.113.0/24",
+		CIDR6:    "fd00:113::/64",
+		Gateway6: &gw,
+	}
+
+	assert.NoError(t, defaults.Assign(&net).Validate())
+	assert.True(t, net.IsDualStack())
+
+	net.CIDR6 = "192.168.114.0/24"
+	assert.ErrorContains(t, defaults.Assign(&net).Validate(), "Field 'cidr6' must be a valid CIDRv6 address")
+
+	net.CIDR6 = "fd00:114::/64"
+	assert.EqualError(t, defaults.Assign(&net).Validate(), "Field 'gateway6' must be a valid IP address within 'fd00:114::/64' subnet. (actual: fd00:113::1)")
+
+	net.CIDR6 = ""
+	assert.EqualError(t, defaults.Assign(&net).Validate(), "Field 'gateway6' can only be set when IPv6 network CIDR (cidr6) is configured.")
+	assert.False(t, net.IsDualStack())
+}
+
+func TestNetwork_Existing(t *testing.T) {
+	net := Network{
+		CIDR:           "192.168.122.0/24",
+		Mode:           EXISTING,
+		LibvirtNetwork: "default",
+	}
+
+	assert.NoError(t, defaults.Assign(&net).Validate())
+
+	net.LibvirtNetwork = ""
+	assert.EqualError(t, net.Validate(), "Field 'libvirtNetwork' is required when network mode is set to 'existing'.")
+
+	net.Mode = NAT
+	net.LibvirtNetwork = "default"
+	assert.EqualError(t, net.Validate(), "Field 'libvirtNetwork' can only be set when network mode is set to 'existing'.")
+}
+
+func TestNetwork_Macvtap(t *testing.T) {
+	net := Network{
+		CIDR:    "192.168.113.0/24",
+		Mode:    BRIDGE,
+		Macvtap: "enp1s0",
+		VLAN:    100,
+	}
+
+	assert.NoError(t, defaults.Assign(&net).Validate())
+
+	net.Bridge = "br0"
+	assert.EqualError(t, net.Validate(), "Fields 'bridge' and 'macvtap' are mutually exclusive.")
+
+	net.Bridge = ""
+	net.Mode = NAT
+	assert.ErrorContains(t, net.Validate(), "Field 'macvtap' can only be set when network mode is set to 'bridge'.")
+}
+
+func TestNetwork_VLAN(t *testing.T) {
+	net := Network{
+		CIDR:   "192.168.113.0/24",
+		Mode:   BRIDGE,
+		Bridge: "br0",
+		VLAN:   4095,
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&net).Validate(), "Maximum value for field 'vlan' is 4094 (actual: 4095).")
+
+	net.VLAN = 100
+	net.Mode = ROUTE
+	net.Bridge = ""
+	assert.EqualError(t, net.Validate(), "Field 'vlan' can only be set when network mode is set to 'bridge'.")
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
-	"github.com/MusicDin/kubitect/pkg/env"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
 	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 	v "github.com/MusicDin/kubitect/pkg/utils/validation"
 )
@@ -13,6 +17,7 @@ type NodeTemplate struct {
 	CpuMode      CpuMode         `yaml:"cpuMode,omitempty"`
 	DNS          []IP            `yaml:"dns,omitempty"`
 	UpdateOnBoot *bool           `yaml:"updateOnBoot"`
+	CloudInit    CloudInit       `yaml:"cloudInit,omitempty"`
 }
 
 func (n NodeTemplate) Validate() error {
@@ -22,21 +27,34 @@ func (n NodeTemplate) Validate() error {
 		v.Field(&n.SSH),
 		v.Field(&n.CpuMode),
 		v.Field(&n.DNS),
+		v.Field(&n.CloudInit, cloudInitValidator(n.CloudInit)),
 	)
 }
 
 func (n *NodeTemplate) SetDefaults() {
 	def := true
 
-	n.User = defaults.Default(n.User, "k8s")
+	preset, _ := BuiltinOSPresets().Find(n.OS.Distro)
+
+	n.User = defaults.Default(n.User, preset.DefaultUser())
 	n.CpuMode = defaults.Default(n.CpuMode, CUSTOM)
 	n.UpdateOnBoot = defaults.Default(n.UpdateOnBoot, &def)
 }
 
+// applyOSPreset sets the node template defaults that are derived from
+// the given OS preset.
+func (n *NodeTemplate) applyOSPreset(p OSPreset) {
+	n.User = defaults.Default(n.User, p.DefaultUser())
+	n.OS.applyPreset(p)
+}
+
 type OS struct {
 	Distro           OSDistro           `yaml:"distro"`
 	NetworkInterface OSNetworkInterface `yaml:"networkInterface"`
 	Source           OSSource           `yaml:"source"`
+	Checksum         OSChecksum         `yaml:"checksum,omitempty"`
+	ChecksumFile     OSChecksumFile     `yaml:"checksumFile,omitempty"`
+	SkipChecksum     bool               `yaml:"skipChecksum,omitempty"`
 }
 
 func (s OS) Validate() error {
@@ -44,15 +62,38 @@ func (s OS) Validate() error {
 		v.Field(&s.Distro),
 		v.Field(&s.NetworkInterface),
 		v.Field(&s.Source),
+		v.Field(&s.Checksum),
+		v.Field(&s.ChecksumFile,
+			v.Fail().When(s.ChecksumFile != "" && s.Checksum != "").Error("Field '{.Field}' cannot be set when the checksum is configured."),
+		),
+		v.Field(&s.SkipChecksum,
+			v.Fail().When(s.SkipChecksum && s.Checksum != "").Error("Field '{.Field}' cannot be set when the checksum is configured."),
+			v.Fail().When(s.SkipChecksum && s.ChecksumFile != "").Error("Field '{.Field}' cannot be set when the checksum file is configured."),
+		),
 	)
 }
 
 func (s *OS) SetDefaults() {
 	s.Distro = defaults.Default(s.Distro, UBUNTU22)
 
-	preset := env.ProjectOsPresets[string(s.Distro)]
-	s.NetworkInterface = defaults.Default(s.NetworkInterface, OSNetworkInterface(preset.NetworkInterface))
-	s.Source = defaults.Default(s.Source, OSSource(preset.Source))
+	preset, _ := BuiltinOSPresets().Find(s.Distro)
+	s.applyPreset(preset)
+}
+
+// applyPreset sets the OS defaults that are derived from the given
+// OS preset.
+func (s *OS) applyPreset(p OSPreset) {
+	s.NetworkInterface = defaults.Default(s.NetworkInterface, p.NetworkInterface)
+
+	// Preset checksum applies only to the preset source.
+	if s.Source == "" {
+		s.Source = p.Source
+
+		if !s.SkipChecksum && s.Checksum == "" && s.ChecksumFile == "" {
+			s.Checksum = p.Checksum
+			s.ChecksumFile = p.ChecksumFile
+		}
+	}
 }
 
 type OSDistro string
@@ -67,8 +108,21 @@ const (
 	ROCKY9   OSDistro = "rocky9"
 )
 
+// Validate checks whether the distro matches any of the known OS presets.
 func (d OSDistro) Validate() error {
-	return v.Var(d, v.OneOf(UBUNTU20, UBUNTU22, DEBIAN11, DEBIAN12, DEBIAN13, CENTOS9, ROCKY9))
+	var names []string
+
+	presets := BuiltinOSPresets()
+
+	if c, ok := v.TopParent().(*Config); ok && c != nil {
+		presets = c.OSPresets()
+	}
+
+	for _, p := range presets.List() {
+		names = append(names, p.Name)
+	}
+
+	return v.Var(d, v.OneOf(names...))
 }
 
 type OSNetworkInterface string
@@ -83,14 +137,35 @@ func (os OSSource) Validate() error {
 	return v.Var(os)
 }
 
+// OSChecksum is a SHA256 checksum of the OS image.
+type OSChecksum string
+
+func (c OSChecksum) Validate() error {
+	return v.Var(strings.ToLower(string(c)),
+		v.OmitEmpty(),
+		v.Tags("sha256").Error("Field '{.Field}' must be a valid SHA256 checksum (64 hexadecimal characters). (actual: {.Value})"),
+	)
+}
+
+// OSChecksumFile is a URL of the file that lists the checksum of the
+// OS image. Both SHA256 and SHA512 checksums are supported.
+type OSChecksumFile string
+
+func (f OSChecksumFile) Validate() error {
+	return v.Var(f, v.OmitEmpty(), v.URL())
+}
+
 type NodeTemplateSSH struct {
 	AddToKnownHosts bool `yaml:"addToKnownHosts"`
 	PrivateKeyPath  File `yaml:"privateKeyPath,omitempty"`
 }
 
 func (ssh NodeTemplateSSH) Validate() error {
-	return v.Struct(&ssh)
-	// v.Field(&ssh.PrivateKeyPath, v.Skip()),
+	// Existing machines of the static provisioner can only be accessed
+	// with the private key whose public key they already trust.
+	return v.Struct(&ssh,
+		v.Field(&ssh.PrivateKeyPath, staticRequiredValidator(), v.Skip()),
+	)
 }
 
 type CpuMode string
@@ -105,3 +180,100 @@ const (
 func (m CpuMode) Validate() error {
 	return v.Var(m, v.OneOf(CUSTOM, HOST_MODEL, HOST_PASSTHROUGH, MAXIMUM))
 }
+
+// CloudInit is a user supplied cloud-config that is merged into the
+// cloud-init user data generated for node instances. List values (e.g.
+// packages or runcmd) are appended to the generated ones, while other
+// values are set as they are.
+type CloudInit map[string]interface{}
+
+// cloudInitReservedKeys are cloud-config keys managed by Kubitect.
+var cloudInitReservedKeys = []string{
+	"fqdn",
+	"hostname",
+	"package_update",
+	"package_upgrade",
+	"preserve_hostname",
+	"users",
+}
+
+// cloudInitKeyKinds defines the expected value kinds of commonly used
+// cloud-config keys.
+var cloudInitKeyKinds = map[string]reflect.Kind{
+	"apt":                 reflect.Map,
+	"bootcmd":             reflect.Slice,
+	"ca_certs":            reflect.Map,
+	"fs_setup":            reflect.Slice,
+	"mounts":              reflect.Slice,
+	"ntp":                 reflect.Map,
+	"packages":            reflect.Slice,
+	"runcmd":              reflect.Slice,
+	"ssh_authorized_keys": reflect.Slice,
+	"timezone":            reflect.String,
+	"write_files":         reflect.Slice,
+	"yum_repos":           reflect.Map,
+}
+
+func (c CloudInit) Validate() error {
+	return v.Var(c, cloudInitValidator(c))
+}
+
+// cloudInitValidator returns a validator that triggers an error if the
+// given cloud-config contains keys managed by Kubitect or if any of the
+// known keys has an invalid value. Since maps are not validated
+// recursively, the validator must be set explicitly on the CloudInit
+// fields.
+func cloudInitValidator(c CloudInit) v.Validator {
+	var reserved []string
+
+	for _, k := range cloudInitReservedKeys {
+		if _, ok := c[k]; ok {
+			reserved = append(reserved, k)
+		}
+	}
+
+	if len(reserved) > 0 {
+		return v.Fail().Errorf("Field '{.Field}' contains cloud-config keys that are managed by Kubitect: [%s]", strings.Join(reserved, "|"))
+	}
+
+	var keys []string
+
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		kind, ok := cloudInitKeyKinds[k]
+		if !ok {
+			continue
+		}
+
+		if c[k] == nil || reflect.TypeOf(c[k]).Kind() != kind {
+			return v.Fail().Errorf("Field '{.Field}' is not a valid cloud-config: key '%s' must be %s.", k, kindName(kind))
+		}
+	}
+
+	files, _ := c["write_files"].([]interface{})
+
+	for i, f := range files {
+		entry, ok := f.(map[string]interface{})
+		if !ok || entry["path"] == nil || entry["path"] == "" {
+			return v.Fail().Errorf("Field '{.Field}' is not a valid cloud-config: write_files entry %d must have a path.", i)
+		}
+	}
+
+	return v.None
+}
+
+func kindName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Map:
+		return "a mapping"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return fmt.Sprintf("a %s", kind)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/MusicDin/kubitect/pkg/env"
@@ -29,6 +30,30 @@ func TestOSNetworkInterface(t *testing.T) {
 	assert.NoError(t, OSNetworkInterface("ens3").Validate())
 }
 
+func TestOSChecksum(t *testing.T) {
+	sum := "ab5ba3cbd73a1dda0a71ed4fbc7a3c43cf7c90d8d1a2d9b5f2f0e4c0fefc1a07"
+
+	assert.NoError(t, OSChecksum("").Validate())
+	assert.NoError(t, OSChecksum(sum).Validate())
+	assert.NoError(t, OSChecksum(strings.ToUpper(sum)).Validate())
+	assert.EqualError(t, OSChecksum("abc").Validate(), "Field must be a valid SHA256 checksum (64 hexadecimal characters). (actual: abc)")
+	assert.Error(t, OSChecksum(sum[1:]+"x").Validate())
+}
+
+func TestOS_SkipChecksum(t *testing.T) {
+	os := OS{
+		Distro:           UBUNTU22,
+		NetworkInterface: "ens3",
+		Source:           "https://example.com/os.img",
+		SkipChecksum:     true,
+	}
+
+	assert.NoError(t, os.Validate())
+
+	os.Checksum = "ab5ba3cbd73a1dda0a71ed4fbc7a3c43cf7c90d8d1a2d9b5f2f0e4c0fefc1a07"
+	assert.EqualError(t, os.Validate(), "Field 'skipChecksum' cannot be set when the checksum is configured.")
+}
+
 func TestOS_Empty(t *testing.T) {
 	assert.ErrorContains(t, OS{}.Validate(), "Field 'distro' must be one of the following values: [ubuntu20|")
 	assert.ErrorContains(t, OS{}.Validate(), "Field 'networkInterface' can contain only alphanumeric characters.")
@@ -75,3 +100,57 @@ func TestCpuMode(t *testing.T) {
 	assert.NoError(t, CpuMode("host-model").Validate())
 	assert.NoError(t, CpuMode("maximum").Validate())
 }
+
+func TestCloudInit(t *testing.T) {
+	ci := CloudInit{
+		"packages": []interface{}{"nfs-common"},
+		"runcmd":   []interface{}{"sysctl --system"},
+		"write_files": []interface{}{
+			map[string]interface{}{
+				"path":    "/etc/sysctl.d/90-kubitect.conf",
+				"content": "vm.max_map_count = 262144",
+			},
+		},
+		"ca_certs": map[string]interface{}{
+			"trusted": []interface{}{"-----BEGIN CERTIFICATE-----"},
+		},
+	}
+
+	assert.NoError(t, ci.Validate())
+	assert.NoError(t, CloudInit{}.Validate())
+}
+
+func TestCloudInit_ReservedKeys(t *testing.T) {
+	ci := CloudInit{
+		"hostname": "node",
+		"users":    []interface{}{},
+	}
+
+	assert.EqualError(t, ci.Validate(), "Field contains cloud-config keys that are managed by Kubitect: [hostname|users]")
+}
+
+func TestCloudInit_InvalidKind(t *testing.T) {
+	ci := CloudInit{
+		"packages": "nfs-common",
+	}
+
+	assert.EqualError(t, ci.Validate(), "Field is not a valid cloud-config: key 'packages' must be a list.")
+}
+
+func TestCloudInit_WriteFilesWithoutPath(t *testing.T) {
+	ci := CloudInit{
+		"write_files": []interface{}{
+			map[string]interface{}{"content": "test"},
+		},
+	}
+
+	assert.EqualError(t, ci.Validate(), "Field is not a valid cloud-config: write_files entry 0 must have a path.")
+}
+
+func TestNodeTemplate_CloudInit(t *testing.T) {
+	nt := NodeTemplate{
+		CloudInit: CloudInit{"packages": map[string]interface{}{}},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&nt).Validate(), "Field 'cloudInit' is not a valid cloud-config: key 'packages' must be a list.")
+}
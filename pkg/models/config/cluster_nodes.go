@@ -14,7 +14,9 @@ type Instance interface {
 	GetTypeName() string
 	GetID() string
 	GetIP() IPv4
+	GetIP6() IPv6
 	GetMAC() MAC
+	GetNetworks() []NetworkAttachment
 }
 
 type Nodes struct {
@@ -75,6 +77,11 @@ func (n Nodes) IPs() []string {
 		if ip != "" {
 			ips = append(ips, string(ip))
 		}
+
+		ip6 := i.GetIP6()
+		if ip6 != "" {
+			ips = append(ips, string(ip6))
+		}
 	}
 
 	return ips
@@ -89,7 +96,29 @@ func (n Nodes) MACs() []string {
 		if mac != "" {
 			macs = append(macs, string(mac))
 		}
+
+		for _, a := range i.GetNetworks() {
+			if a.MAC != "" {
+				macs = append(macs, string(a.MAC))
+			}
+		}
 	}
 
 	return macs
 }
+
+// NetworkIPs returns IP addresses of node instances attached to the
+// additional network with the given name.
+func (n Nodes) NetworkIPs(network string) []string {
+	var ips []string
+
+	for _, i := range n.Instances() {
+		for _, a := range i.GetNetworks() {
+			if a.Network == network && a.IP != "" {
+				ips = append(ips, string(a.IP))
+			}
+		}
+	}
+
+	return ips
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// NodeGroupIndex is a placeholder in the node group's id pattern that is
+// replaced with the index of the generated instance.
+const NodeGroupIndex = "{index}"
+
+// NodeGroup describes a group of identically configured node instances.
+// When defaults are set, each group is expanded into node instances whose
+// IDs and IPs are derived from the instance index. Therefore, instances
+// keep their IDs and IPs when the group is expanded again, and changing
+// the count only adds or removes the instances at the end of the group.
+type NodeGroup struct {
+	Name         string     `yaml:"name" opt:",id"`
+	Count        int        `yaml:"count"`
+	IdPattern    string     `yaml:"idPattern,omitempty"`
+	IPRange      IPRange    `yaml:"ipRange,omitempty"`
+	Host         string     `yaml:"host,omitempty"`
+	CPU          VCpu       `yaml:"cpu,omitempty"`
+	RAM          GB         `yaml:"ram,omitempty"`
+	MainDiskSize GB         `yaml:"mainDiskSize,omitempty"`
+	DataDisks    []DataDisk `yaml:"dataDisks,omitempty"`
+	Labels       Labels     `yaml:"labels,omitempty"`
+	Taints       []Taint    `yaml:"taints,omitempty"`
+}
+
+func (g NodeGroup) Validate() error {
+	defer v.RemoveCustomValidator(VALID_POOL)
+
+	v.RegisterCustomValidator(VALID_POOL, poolNameValidator(g.Host))
+
+	return v.Struct(&g,
+		v.Field(&g.Name, v.NotEmpty(), v.AlphaNumericHyp()),
+		v.Field(&g.Count, v.Min(0)),
+		v.Field(&g.IdPattern,
+			v.NotEmpty(),
+			v.Fail().When(g.Count > 1 && !strings.Contains(g.IdPattern, NodeGroupIndex)).Errorf("Field '{.Field}' must contain placeholder '%s' when group contains more than one instance.", NodeGroupIndex),
+		),
+		v.Field(&g.IPRange,
+			v.OmitEmpty(),
+			v.IPRange(),
+			v.Fail().When(!g.IPRange.Is4()).Errorf("Field '{.Field}' must be a range of IPv4 addresses. (actual: %s)", g.IPRange),
+			v.Fail().When(len(g.IPRange.Addresses(g.Count)) < g.Count).Errorf("IP range '%s' contains less than %d addresses required by the group '%s'.", g.IPRange, g.Count, g.Name),
+		),
+		v.Field(&g.Host, v.OmitEmpty(), v.Custom(VALID_HOST)),
+		v.Field(&g.CPU, v.OmitEmpty()),
+		v.Field(&g.RAM, v.OmitEmpty()),
+		v.Field(&g.MainDiskSize, v.OmitEmpty()),
+		v.Field(&g.DataDisks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&g.Labels),
+		v.Field(&g.Taints),
+	)
+}
+
+func (g *NodeGroup) SetDefaults() {
+	g.IdPattern = defaults.Default(g.IdPattern, fmt.Sprintf("%s-%s", g.Name, NodeGroupIndex))
+}
+
+// nodeGroupMember contains the generated properties of a single node
+// group instance.
+type nodeGroupMember struct {
+	Id string
+	IP IPv4
+}
+
+// members returns the generated IDs and IPs of all group instances.
+// Instance indices start with 1. If the group has no IPv4 range, the IPs
+// are left empty.
+func (g NodeGroup) members() []nodeGroupMember {
+	var members []nodeGroupMember
+
+	ips := g.IPRange.Addresses(g.Count)
+
+	for i := 0; i < g.Count; i++ {
+		m := nodeGroupMember{
+			Id: strings.ReplaceAll(g.IdPattern, NodeGroupIndex, fmt.Sprint(i+1)),
+		}
+
+		if i < len(ips) && ips[i].Is4() {
+			m.IP = IPv4(ips[i].String())
+		}
+
+		members = append(members, m)
+	}
+
+	return members
+}
+
+// expandNodeGroups replaces previously generated group instances with the
+// instances generated from the given groups. Function group returns the
+// name of the group the instance was generated from, while newInstance
+// creates an instance from the group and its member. Each instance gets
+// its own copy of the group's data disks, labels and taints.
+func expandNodeGroups[T any](instances []T, groups []NodeGroup, group func(T) string, newInstance func(NodeGroup, nodeGroupMember) T) []T {
+	expanded := make([]T, 0, len(instances))
+
+	for _, i := range instances {
+		if group(i) == "" {
+			expanded = append(expanded, i)
+		}
+	}
+
+	for _, g := range groups {
+		for _, m := range g.members() {
+			c := g
+			c.DataDisks = append([]DataDisk{}, g.DataDisks...)
+			c.Labels = maps.Clone(g.Labels)
+			c.Taints = slices.Clone(g.Taints)
+
+			expanded = append(expanded, newInstance(c, m))
+		}
+	}
+
+	return expanded
+}
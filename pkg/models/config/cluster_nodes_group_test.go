@@ -0,0 +1,213 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeGroup(t *testing.T) {
+	g := NodeGroup{
+		Name:    "group",
+		Count:   3,
+		IPRange: "192.168.113.10-192.168.113.20",
+	}
+
+	assert.NoError(t, defaults.Assign(&g).Validate())
+	assert.Equal(t, "group-{index}", g.IdPattern)
+}
+
+func TestNodeGroup_Members(t *testing.T) {
+	g := NodeGroup{
+		Name:      "group",
+		Count:     2,
+		IdPattern: "gpu-{index}",
+		IPRange:   "192.168.113.10-192.168.113.20",
+	}
+
+	expect := []nodeGroupMember{
+		{Id: "gpu-1", IP: "192.168.113.10"},
+		{Id: "gpu-2", IP: "192.168.113.11"},
+	}
+
+	assert.Equal(t, expect, g.members())
+}
+
+func TestNodeGroup_Members_NoIPRange(t *testing.T) {
+	g := defaults.Assign(&NodeGroup{Name: "group", Count: 2})
+
+	expect := []nodeGroupMember{
+		{Id: "group-1"},
+		{Id: "group-2"},
+	}
+
+	assert.Equal(t, expect, g.members())
+}
+
+func TestNodeGroup_MissingName(t *testing.T) {
+	g := NodeGroup{Count: 1}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "Field 'name' is required and cannot be empty.")
+}
+
+func TestNodeGroup_InvalidCount(t *testing.T) {
+	g := NodeGroup{Name: "group", Count: -1}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "Minimum value for field 'count' is 0 (actual: -1).")
+}
+
+func TestNodeGroup_IdPatternWithoutIndex(t *testing.T) {
+	g := NodeGroup{Name: "group", Count: 2, IdPattern: "node"}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "Field 'idPattern' must contain placeholder '{index}'")
+}
+
+func TestNodeGroup_IPRangeTooSmall(t *testing.T) {
+	g := NodeGroup{Name: "group", Count: 3, IPRange: "192.168.113.10-192.168.113.11"}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "contains less than 3 addresses required by the group 'group'")
+}
+
+func TestNodeGroup_InvalidIPRange(t *testing.T) {
+	g := NodeGroup{Name: "group", Count: 1, IPRange: "192.168.113.10"}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "Field 'ipRange' must be a valid IP range")
+}
+
+func TestNodeGroup_IPv6Range(t *testing.T) {
+	g := NodeGroup{Name: "group", Count: 1, IPRange: "2001:db8::10-2001:db8::20"}
+	assert.ErrorContains(t, defaults.Assign(&g).Validate(), "Field 'ipRange' must be a range of IPv4 addresses. (actual: 2001:db8::10-2001:db8::20)")
+
+	// IPv6 addresses are never assigned to the IPv4 field.
+	assert.Equal(t, []nodeGroupMember{{Id: "group-1"}}, defaults.Assign(&g).members())
+}
+
+func TestWorker_Groups(t *testing.T) {
+	w := Worker{
+		Default: WorkerDefault{
+			CPU: VCpu(4),
+		},
+		Groups: []NodeGroup{
+			{Name: "group", Count: 2, RAM: GB(16), IPRange: "192.168.113.10-192.168.113.20"},
+		},
+		Instances: []WorkerInstance{
+			{Id: "1"},
+		},
+	}
+
+	require.NoError(t, defaults.Assign(&w).Validate())
+	require.Len(t, w.Instances, 3)
+
+	assert.Equal(t, "1", w.Instances[0].Id)
+	assert.Empty(t, w.Instances[0].Group)
+
+	assert.Equal(t, "group-1", w.Instances[1].Id)
+	assert.Equal(t, "group", w.Instances[1].Group)
+	assert.Equal(t, IPv4("192.168.113.10"), w.Instances[1].IP)
+	assert.Equal(t, VCpu(4), w.Instances[1].CPU)
+	assert.Equal(t, GB(16), w.Instances[1].RAM)
+
+	assert.Equal(t, "group-2", w.Instances[2].Id)
+	assert.Equal(t, IPv4("192.168.113.11"), w.Instances[2].IP)
+}
+
+// Test ensures that existing group instances keep their IDs and IPs when
+// the group is expanded again.
+func TestWorker_Groups_Reexpand(t *testing.T) {
+	w := Worker{
+		Groups: []NodeGroup{
+			{Name: "group", Count: 2, IPRange: "192.168.113.10-192.168.113.20"},
+		},
+	}
+
+	require.NoError(t, defaults.Set(&w))
+	before := append([]WorkerInstance{}, w.Instances...)
+
+	w.Groups[0].Count = 3
+	require.NoError(t, defaults.Set(&w))
+	require.Len(t, w.Instances, 3)
+
+	assert.Equal(t, before[0].Id, w.Instances[0].Id)
+	assert.Equal(t, before[0].IP, w.Instances[0].IP)
+	assert.Equal(t, before[1].Id, w.Instances[1].Id)
+	assert.Equal(t, before[1].IP, w.Instances[1].IP)
+	assert.Equal(t, IPv4("192.168.113.12"), w.Instances[2].IP)
+
+	w.Groups[0].Count = 1
+	require.NoError(t, defaults.Set(&w))
+	require.Len(t, w.Instances, 1)
+	assert.Equal(t, before[0].Id, w.Instances[0].Id)
+}
+
+// Test ensures that group instances do not share labels, taints and data
+// disks with each other.
+func TestWorker_Groups_Copy(t *testing.T) {
+	w := Worker{
+		Groups: []NodeGroup{
+			{
+				Name:      "group",
+				Count:     2,
+				Labels:    Labels{"key": "value"},
+				Taints:    []Taint{"key=value:NoSchedule"},
+				DataDisks: []DataDisk{{Name: "disk", Size: 16}},
+			},
+		},
+	}
+
+	require.NoError(t, defaults.Set(&w))
+	require.Len(t, w.Instances, 2)
+
+	w.Instances[0].Labels["key"] = "changed"
+	w.Instances[0].Taints[0] = "changed"
+	w.Instances[0].DataDisks[0].Name = "changed"
+
+	assert.Equal(t, Labels{"key": "value"}, w.Instances[1].Labels)
+	assert.Equal(t, []Taint{"key=value:NoSchedule"}, w.Instances[1].Taints)
+	assert.Equal(t, "disk", w.Instances[1].DataDisks[0].Name)
+	assert.Equal(t, Labels{"key": "value"}, w.Groups[0].Labels)
+}
+
+func TestWorker_Groups_DuplicateId(t *testing.T) {
+	w := Worker{
+		Groups: []NodeGroup{
+			{Name: "group", Count: 1},
+		},
+		Instances: []WorkerInstance{
+			{Id: "group-1"},
+		},
+	}
+
+	assert.EqualError(t, defaults.Assign(&w).Validate(), "Field 'Id' must be unique for each element in 'instances'.")
+}
+
+func TestWorker_Groups_UniqueName(t *testing.T) {
+	w := Worker{
+		Groups: []NodeGroup{
+			{Name: "group", Count: 1, IdPattern: "a-{index}"},
+			{Name: "group", Count: 1, IdPattern: "b-{index}"},
+		},
+	}
+
+	assert.EqualError(t, defaults.Assign(&w).Validate(), "Field 'Name' must be unique for each element in 'groups'.")
+}
+
+func TestMaster_Groups(t *testing.T) {
+	m := Master{
+		Groups: []NodeGroup{
+			{Name: "cp", Count: 3, IPRange: "192.168.113.10-192.168.113.12"},
+		},
+	}
+
+	require.NoError(t, defaults.Assign(&m).Validate())
+	require.Len(t, m.Instances, 3)
+	assert.Equal(t, "cp-3", m.Instances[2].Id)
+	assert.Equal(t, IPv4("192.168.113.12"), m.Instances[2].IP)
+}
+
+func TestMaster_Groups_EvenCount(t *testing.T) {
+	m := Master{
+		Groups: []NodeGroup{
+			{Name: "cp", Count: 2},
+		},
+	}
+
+	assert.EqualError(t, defaults.Assign(&m).Validate(), "Number of master instances must be odd (1, 3, 5 etc.).")
+}
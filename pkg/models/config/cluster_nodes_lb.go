@@ -11,9 +11,10 @@ var (
 )
 
 type LBDefault struct {
-	CPU          VCpu `yaml:"cpu"`
-	RAM          GB   `yaml:"ram"`
-	MainDiskSize GB   `yaml:"mainDiskSize"`
+	CPU          VCpu      `yaml:"cpu"`
+	RAM          GB        `yaml:"ram"`
+	MainDiskSize GB        `yaml:"mainDiskSize"`
+	CloudInit    CloudInit `yaml:"cloudInit,omitempty"`
 }
 
 func (def LBDefault) Validate() error {
@@ -21,6 +22,7 @@ func (def LBDefault) Validate() error {
 		v.Field(&def.CPU),
 		v.Field(&def.RAM),
 		v.Field(&def.MainDiskSize),
+		v.Field(&def.CloudInit, cloudInitValidator(def.CloudInit)),
 	)
 }
 
@@ -32,6 +34,7 @@ func (def *LBDefault) SetDefaults() {
 
 type LB struct {
 	VIP             IPv4            `yaml:"vip,omitempty"`
+	VIP6            IPv6            `yaml:"vip6,omitempty"`
 	VirtualRouterId *Uint8          `yaml:"virtualRouterId,omitempty"`
 	Default         LBDefault       `yaml:"default"`
 	Instances       []LBInstance    `yaml:"instances,omitempty"`
@@ -45,6 +48,7 @@ func (lb LB) Validate() error {
 			v.OmitEmpty(),
 			v.Custom(IP_IN_CIDR),
 		),
+		v.Field(&lb.VIP6, v.OmitEmpty(), v.Custom(IP6_IN_CIDR)),
 		v.Field(&lb.VirtualRouterId),
 		v.Field(&lb.Default),
 		v.Field(&lb.Instances, v.UniqueField("Id")),
@@ -99,15 +103,17 @@ func (pft LBPortForwardTarget) Validate() error {
 }
 
 type LBInstance struct {
-	Name         string `yaml:"name,omitempty" opt:"-"`
-	Id           string `yaml:"id" opt:",id"`
-	Host         string `yaml:"host,omitempty"`
-	IP           IPv4   `yaml:"ip,omitempty"`
-	MAC          MAC    `yaml:"mac,omitempty"`
-	CPU          VCpu   `yaml:"cpu"`
-	RAM          GB     `yaml:"ram"`
-	MainDiskSize GB     `yaml:"mainDiskSize"`
-	Priority     *Uint8 `yaml:"priority,omitempty"`
+	Name         string              `yaml:"name,omitempty" opt:"-"`
+	Id           string              `yaml:"id" opt:",id"`
+	Host         string              `yaml:"host,omitempty"`
+	IP           IPv4                `yaml:"ip,omitempty"`
+	IP6          IPv6                `yaml:"ip6,omitempty"`
+	MAC          MAC                 `yaml:"mac,omitempty"`
+	Networks     []NetworkAttachment `yaml:"networks,omitempty"`
+	CPU          VCpu                `yaml:"cpu"`
+	RAM          GB                  `yaml:"ram"`
+	MainDiskSize GB                  `yaml:"mainDiskSize"`
+	Priority     *Uint8              `yaml:"priority,omitempty"`
 }
 
 func (i LBInstance) GetTypeName() string {
@@ -122,16 +128,26 @@ func (i LBInstance) GetIP() IPv4 {
 	return i.IP
 }
 
+func (i LBInstance) GetIP6() IPv6 {
+	return i.IP6
+}
+
 func (i LBInstance) GetMAC() MAC {
 	return i.MAC
 }
 
+func (i LBInstance) GetNetworks() []NetworkAttachment {
+	return i.Networks
+}
+
 func (i LBInstance) Validate() error {
 	return v.Struct(&i,
 		v.Field(&i.Id, v.NotEmpty(), v.AlphaNumericHypUS()),
 		v.Field(&i.Host, v.OmitEmpty(), v.Custom(VALID_HOST)),
-		v.Field(&i.IP, v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP, staticRequiredValidator(), v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP6, v.OmitEmpty(), v.Custom(IP6_IN_CIDR)),
 		v.Field(&i.MAC, v.OmitEmpty()),
+		v.Field(&i.Networks, v.OmitEmpty(), v.UniqueField("Network")),
 		v.Field(&i.CPU),
 		v.Field(&i.RAM),
 		v.Field(&i.MainDiskSize),
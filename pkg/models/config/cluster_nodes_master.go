@@ -12,6 +12,7 @@ type MasterDefault struct {
 	Labels       Labels     `yaml:"labels,omitempty"`
 	Taints       []Taint    `yaml:"taints,omitempty"`
 	DataDisks    []DataDisk `yaml:"dataDisks,omitempty"`
+	CloudInit    CloudInit  `yaml:"cloudInit,omitempty"`
 }
 
 func (d MasterDefault) Validate() error {
@@ -22,6 +23,7 @@ func (d MasterDefault) Validate() error {
 		v.Field(&d.Labels),
 		v.Field(&d.Taints),
 		v.Field(&d.DataDisks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&d.CloudInit, cloudInitValidator(d.CloudInit)),
 	)
 }
 
@@ -33,12 +35,14 @@ func (def *MasterDefault) SetDefaults() {
 
 type Master struct {
 	Default   MasterDefault    `yaml:"default"`
+	Groups    []NodeGroup      `yaml:"groups,omitempty"`
 	Instances []MasterInstance `yaml:"instances"`
 }
 
 func (m Master) Validate() error {
 	return v.Struct(&m,
 		v.Field(&m.Default),
+		v.Field(&m.Groups, v.UniqueField("Name")),
 		v.Field(&m.Instances,
 			v.MinLen(1).Error("At least one master instance must be configured."),
 			v.Fail().When(len(m.Instances)%2 == 0).Error("Number of master instances must be odd (1, 3, 5 etc.)."),
@@ -49,6 +53,8 @@ func (m Master) Validate() error {
 }
 
 func (m *Master) SetDefaults() {
+	m.expandGroups()
+
 	for i := range m.Instances {
 		m.Instances[i].CPU = defaults.Default(m.Instances[i].CPU, m.Default.CPU)
 		m.Instances[i].RAM = defaults.Default(m.Instances[i].RAM, m.Default.RAM)
@@ -57,18 +63,46 @@ func (m *Master) SetDefaults() {
 	}
 }
 
+// expandGroups replaces previously generated group instances with the
+// instances generated from the currently configured groups.
+func (m *Master) expandGroups() {
+	group := func(i MasterInstance) string {
+		return i.Group
+	}
+
+	newInstance := func(g NodeGroup, mem nodeGroupMember) MasterInstance {
+		return MasterInstance{
+			Id:           mem.Id,
+			Group:        g.Name,
+			Host:         g.Host,
+			IP:           mem.IP,
+			CPU:          g.CPU,
+			RAM:          g.RAM,
+			MainDiskSize: g.MainDiskSize,
+			DataDisks:    g.DataDisks,
+			Labels:       g.Labels,
+			Taints:       g.Taints,
+		}
+	}
+
+	m.Instances = expandNodeGroups(m.Instances, m.Groups, group, newInstance)
+}
+
 type MasterInstance struct {
-	Name         string     `yaml:"name,omitempty" opt:"-"`
-	Id           string     `yaml:"id" opt:",id"`
-	Host         string     `yaml:"host,omitempty"`
-	IP           IPv4       `yaml:"ip,omitempty"`
-	MAC          MAC        `yaml:"mac,omitempty"`
-	CPU          VCpu       `yaml:"cpu"`
-	RAM          GB         `yaml:"ram"`
-	MainDiskSize GB         `yaml:"mainDiskSize"`
-	DataDisks    []DataDisk `yaml:"dataDisks,omitempty"`
-	Labels       Labels     `yaml:"labels,omitempty"`
-	Taints       []Taint    `yaml:"taints,omitempty"`
+	Name         string              `yaml:"name,omitempty" opt:"-"`
+	Id           string              `yaml:"id" opt:",id"`
+	Group        string              `yaml:"group,omitempty" opt:"-"`
+	Host         string              `yaml:"host,omitempty"`
+	IP           IPv4                `yaml:"ip,omitempty"`
+	IP6          IPv6                `yaml:"ip6,omitempty"`
+	MAC          MAC                 `yaml:"mac,omitempty"`
+	Networks     []NetworkAttachment `yaml:"networks,omitempty"`
+	CPU          VCpu                `yaml:"cpu"`
+	RAM          GB                  `yaml:"ram"`
+	MainDiskSize GB                  `yaml:"mainDiskSize"`
+	DataDisks    []DataDisk          `yaml:"dataDisks,omitempty"`
+	Labels       Labels              `yaml:"labels,omitempty"`
+	Taints       []Taint             `yaml:"taints,omitempty"`
 }
 
 func (i MasterInstance) GetTypeName() string {
@@ -83,10 +117,18 @@ func (i MasterInstance) GetIP() IPv4 {
 	return i.IP
 }
 
+func (i MasterInstance) GetIP6() IPv6 {
+	return i.IP6
+}
+
 func (i MasterInstance) GetMAC() MAC {
 	return i.MAC
 }
 
+func (i MasterInstance) GetNetworks() []NetworkAttachment {
+	return i.Networks
+}
+
 func (i MasterInstance) Validate() error {
 	defer v.RemoveCustomValidator(VALID_POOL)
 
@@ -95,12 +137,14 @@ func (i MasterInstance) Validate() error {
 	return v.Struct(&i,
 		v.Field(&i.Id, v.NotEmpty(), v.AlphaNumericHypUS()),
 		v.Field(&i.Host, v.OmitEmpty(), v.Custom(VALID_HOST)),
-		v.Field(&i.IP, v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP, staticRequiredValidator(), v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP6, v.OmitEmpty(), v.Custom(IP6_IN_CIDR)),
 		v.Field(&i.MAC, v.OmitEmpty()),
+		v.Field(&i.Networks, v.OmitEmpty(), v.UniqueField("Network")),
 		v.Field(&i.CPU),
 		v.Field(&i.RAM),
 		v.Field(&i.MainDiskSize),
-		v.Field(&i.DataDisks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&i.DataDisks, v.OmitEmpty(), v.UniqueField("Name"), uniqueMountPathValidator(i.DataDisks)),
 		v.Field(&i.Labels),
 		v.Field(&i.Taints),
 	)
@@ -12,6 +12,7 @@ type WorkerDefault struct {
 	Labels       Labels     `yaml:"labels,omitempty"`
 	Taints       []Taint    `yaml:"taints,omitempty"`
 	DataDisks    []DataDisk `yaml:"dataDisks,omitempty"`
+	CloudInit    CloudInit  `yaml:"cloudInit,omitempty"`
 }
 
 func (d WorkerDefault) Validate() error {
@@ -22,6 +23,7 @@ func (d WorkerDefault) Validate() error {
 		v.Field(&d.Labels),
 		v.Field(&d.Taints),
 		v.Field(&d.DataDisks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&d.CloudInit, cloudInitValidator(d.CloudInit)),
 	)
 }
 
@@ -33,17 +35,21 @@ func (def *WorkerDefault) SetDefaults() {
 
 type Worker struct {
 	Default   WorkerDefault    `yaml:"default"`
+	Groups    []NodeGroup      `yaml:"groups,omitempty"`
 	Instances []WorkerInstance `yaml:"instances,omitempty"`
 }
 
 func (w Worker) Validate() error {
 	return v.Struct(&w,
 		v.Field(&w.Default),
+		v.Field(&w.Groups, v.UniqueField("Name")),
 		v.Field(&w.Instances, v.UniqueField("Id")),
 	)
 }
 
 func (w *Worker) SetDefaults() {
+	w.expandGroups()
+
 	for i := range w.Instances {
 		w.Instances[i].CPU = defaults.Default(w.Instances[i].CPU, w.Default.CPU)
 		w.Instances[i].RAM = defaults.Default(w.Instances[i].RAM, w.Default.RAM)
@@ -52,18 +58,46 @@ func (w *Worker) SetDefaults() {
 	}
 }
 
+// expandGroups replaces previously generated group instances with the
+// instances generated from the currently configured groups.
+func (w *Worker) expandGroups() {
+	group := func(i WorkerInstance) string {
+		return i.Group
+	}
+
+	newInstance := func(g NodeGroup, mem nodeGroupMember) WorkerInstance {
+		return WorkerInstance{
+			Id:           mem.Id,
+			Group:        g.Name,
+			Host:         g.Host,
+			IP:           mem.IP,
+			CPU:          g.CPU,
+			RAM:          g.RAM,
+			MainDiskSize: g.MainDiskSize,
+			DataDisks:    g.DataDisks,
+			Labels:       g.Labels,
+			Taints:       g.Taints,
+		}
+	}
+
+	w.Instances = expandNodeGroups(w.Instances, w.Groups, group, newInstance)
+}
+
 type WorkerInstance struct {
-	Name         string     `yaml:"name,omitempty" opt:"-"`
-	Id           string     `yaml:"id" opt:",id"`
-	Host         string     `yaml:"host,omitempty"`
-	IP           IPv4       `yaml:"ip,omitempty"`
-	MAC          MAC        `yaml:"mac,omitempty"`
-	CPU          VCpu       `yaml:"cpu"`
-	RAM          GB         `yaml:"ram"`
-	MainDiskSize GB         `yaml:"mainDiskSize"`
-	DataDisks    []DataDisk `yaml:"dataDisks,omitempty"`
-	Labels       Labels     `yaml:"labels,omitempty"`
-	Taints       []Taint    `yaml:"taints,omitempty"`
+	Name         string              `yaml:"name,omitempty" opt:"-"`
+	Id           string              `yaml:"id" opt:",id"`
+	Group        string              `yaml:"group,omitempty" opt:"-"`
+	Host         string              `yaml:"host,omitempty"`
+	IP           IPv4                `yaml:"ip,omitempty"`
+	IP6          IPv6                `yaml:"ip6,omitempty"`
+	MAC          MAC                 `yaml:"mac,omitempty"`
+	Networks     []NetworkAttachment `yaml:"networks,omitempty"`
+	CPU          VCpu                `yaml:"cpu"`
+	RAM          GB                  `yaml:"ram"`
+	MainDiskSize GB                  `yaml:"mainDiskSize"`
+	DataDisks    []DataDisk          `yaml:"dataDisks,omitempty"`
+	Labels       Labels              `yaml:"labels,omitempty"`
+	Taints       []Taint             `yaml:"taints,omitempty"`
 }
 
 func (i WorkerInstance) GetTypeName() string {
@@ -78,10 +112,18 @@ func (i WorkerInstance) GetIP() IPv4 {
 	return i.IP
 }
 
+func (i WorkerInstance) GetIP6() IPv6 {
+	return i.IP6
+}
+
 func (i WorkerInstance) GetMAC() MAC {
 	return i.MAC
 }
 
+func (i WorkerInstance) GetNetworks() []NetworkAttachment {
+	return i.Networks
+}
+
 func (i WorkerInstance) Validate() error {
 	defer v.RemoveCustomValidator(VALID_POOL)
 
@@ -90,12 +132,14 @@ func (i WorkerInstance) Validate() error {
 	return v.Struct(&i,
 		v.Field(&i.Id, v.NotEmpty(), v.AlphaNumericHypUS()),
 		v.Field(&i.Host, v.OmitEmpty(), v.Custom(VALID_HOST)),
-		v.Field(&i.IP, v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP, staticRequiredValidator(), v.OmitEmpty(), v.Custom(IP_IN_CIDR)),
+		v.Field(&i.IP6, v.OmitEmpty(), v.Custom(IP6_IN_CIDR)),
 		v.Field(&i.MAC, v.OmitEmpty()),
+		v.Field(&i.Networks, v.OmitEmpty(), v.UniqueField("Network")),
 		v.Field(&i.CPU),
 		v.Field(&i.RAM),
 		v.Field(&i.MainDiskSize),
-		v.Field(&i.DataDisks, v.OmitEmpty(), v.UniqueField("Name")),
+		v.Field(&i.DataDisks, v.OmitEmpty(), v.UniqueField("Name"), uniqueMountPathValidator(i.DataDisks)),
 		v.Field(&i.Labels),
 		v.Field(&i.Taints),
 	)
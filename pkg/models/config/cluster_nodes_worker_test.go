@@ -22,6 +22,14 @@ func TestWorkerDefault(t *testing.T) {
 	assert.ErrorContains(t, WorkerDefault{}.Validate(), "Minimum value for field 'mainDiskSize' is 1 (actual: 0).")
 }
 
+func TestWorkerDefault_CloudInit(t *testing.T) {
+	def := WorkerDefault{
+		CloudInit: CloudInit{"preserve_hostname": true},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&def).Validate(), "Field 'cloudInit' contains cloud-config keys that are managed by Kubitect: [preserve_hostname]")
+}
+
 func TestWorker_Type(t *testing.T) {
 	assert.Equal(t, WorkerInstance{}.GetTypeName(), "worker")
 }
@@ -0,0 +1,115 @@
+package config
+
+import (
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// State configures where the state of the provisioned infrastructure is
+// stored.
+type State struct {
+	Backend StateBackend `yaml:"backend,omitempty"`
+}
+
+func (s State) Validate() error {
+	return v.Struct(&s,
+		v.Field(&s.Backend, remoteBackendValidator(s.Backend)),
+	)
+}
+
+// StateBackend is a Terraform backend that stores the state. By default,
+// the state is stored locally within the cluster directory.
+type StateBackend struct {
+	Type StateBackendType `yaml:"type,omitempty"`
+	HTTP HTTPStateBackend `yaml:"http,omitempty"`
+	S3   S3StateBackend   `yaml:"s3,omitempty"`
+}
+
+func (b StateBackend) Validate() error {
+	reqErr := "Field '{.Field}' is required when backend type is set to '%s'."
+
+	return v.Struct(&b,
+		v.Field(&b.Type, v.OmitEmpty()),
+		v.Field(&b.HTTP, v.Skip().When(b.Type != StateBackendHTTP), v.NotEmpty().Errorf(reqErr, StateBackendHTTP)),
+		v.Field(&b.S3, v.Skip().When(b.Type != StateBackendS3), v.NotEmpty().Errorf(reqErr, StateBackendS3)),
+	)
+}
+
+// IsRemote returns true if the state is stored outside of the cluster
+// directory.
+func (b StateBackend) IsRemote() bool {
+	return b.Type != "" && b.Type != StateBackendLocal
+}
+
+// String returns the type of the backend.
+func (b StateBackend) String() string {
+	if b.Type == "" {
+		return string(StateBackendLocal)
+	}
+
+	return string(b.Type)
+}
+
+type StateBackendType string
+
+const (
+	StateBackendLocal StateBackendType = "local"
+	StateBackendHTTP  StateBackendType = "http"
+	StateBackendS3    StateBackendType = "s3"
+)
+
+func (t StateBackendType) Validate() error {
+	return v.Var(t, v.OneOf(StateBackendLocal, StateBackendHTTP, StateBackendS3))
+}
+
+// HTTPStateBackend stores the state using a REST client. Credentials are
+// read from the TF_HTTP_USERNAME and TF_HTTP_PASSWORD environment
+// variables.
+type HTTPStateBackend struct {
+	Address              URL  `yaml:"address"`
+	LockAddress          URL  `yaml:"lockAddress,omitempty"`
+	UnlockAddress        URL  `yaml:"unlockAddress,omitempty"`
+	SkipCertVerification bool `yaml:"skipCertVerification,omitempty"`
+}
+
+func (b HTTPStateBackend) Validate() error {
+	return v.Struct(&b,
+		v.Field(&b.Address, v.NotEmpty()),
+		v.Field(&b.LockAddress, v.OmitEmpty()),
+		v.Field(&b.UnlockAddress, v.OmitEmpty()),
+	)
+}
+
+// S3StateBackend stores the state in an S3 bucket. Any S3 compatible
+// storage (e.g. MinIO) can be used by setting the endpoint. Credentials
+// are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// environment variables.
+type S3StateBackend struct {
+	Bucket       string `yaml:"bucket"`
+	Key          string `yaml:"key,omitempty"`
+	Region       string `yaml:"region,omitempty"`
+	Endpoint     URL    `yaml:"endpoint,omitempty"`
+	UsePathStyle bool   `yaml:"usePathStyle,omitempty"`
+}
+
+func (b S3StateBackend) Validate() error {
+	return v.Struct(&b,
+		v.Field(&b.Bucket, v.NotEmpty()),
+		v.Field(&b.Endpoint, v.OmitEmpty()),
+	)
+}
+
+// remoteBackendValidator returns a validator that triggers an error if
+// a remote state backend is configured while the provisioner does not
+// use Terraform.
+func remoteBackendValidator(b StateBackend) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil || !b.IsRemote() {
+		return v.None
+	}
+
+	if c.Provisioner == "" || c.Provisioner == ProvisionerTerraform {
+		return v.None
+	}
+
+	return v.Fail().Errorf("Remote state backend can only be used when provisioner is '%s'.", ProvisionerTerraform)
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateBackendType(t *testing.T) {
+	assert.NoError(t, StateBackendLocal.Validate())
+	assert.NoError(t, StateBackendHTTP.Validate())
+	assert.NoError(t, StateBackendS3.Validate())
+	assert.EqualError(t, StateBackendType("gcs").Validate(), "Field must be one of the following values: [local|http|s3] (actual: gcs).")
+}
+
+func TestStateBackend(t *testing.T) {
+	http := StateBackend{
+		Type: StateBackendHTTP,
+		HTTP: HTTPStateBackend{Address: "https://state.example.com/cluster"},
+	}
+
+	s3 := StateBackend{
+		Type: StateBackendS3,
+		S3:   S3StateBackend{Bucket: "states", Endpoint: "http://minio.local:9000", UsePathStyle: true},
+	}
+
+	assert.NoError(t, StateBackend{}.Validate())
+	assert.NoError(t, http.Validate())
+	assert.NoError(t, s3.Validate())
+	assert.ErrorContains(t, StateBackend{Type: StateBackendHTTP}.Validate(), "Field 'http' is required when backend type is set to 'http'.")
+	assert.ErrorContains(t, StateBackend{Type: StateBackendS3}.Validate(), "Field 's3' is required when backend type is set to 's3'.")
+}
+
+func TestStateBackend_Invalid(t *testing.T) {
+	http := StateBackend{
+		Type: StateBackendHTTP,
+		HTTP: HTTPStateBackend{Address: "invalid"},
+	}
+
+	s3 := StateBackend{
+		Type: StateBackendS3,
+		S3:   S3StateBackend{Region: "eu-central-1"},
+	}
+
+	assert.ErrorContains(t, http.Validate(), "Field 'address' must be a valid URL")
+	assert.ErrorContains(t, s3.Validate(), "Field 'bucket' is required and cannot be empty.")
+}
+
+func TestStateBackend_IsRemote(t *testing.T) {
+	assert.False(t, StateBackend{}.IsRemote())
+	assert.False(t, StateBackend{Type: StateBackendLocal}.IsRemote())
+	assert.True(t, StateBackend{Type: StateBackendS3}.IsRemote())
+	assert.Equal(t, "local", StateBackend{}.String())
+	assert.Equal(t, "http", StateBackend{Type: StateBackendHTTP}.String())
+}
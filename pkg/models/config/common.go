@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/netip"
 	"os"
 	"strings"
 
@@ -54,6 +55,67 @@ func (cidr CIDRv4) Validate() error {
 	return v.Var(cidr, v.CIDRv4())
 }
 
+type IPv6 string
+
+func (ip IPv6) Validate() error {
+	return v.Var(ip, v.IPv6())
+}
+
+type CIDRv6 string
+
+func (cidr CIDRv6) Validate() error {
+	return v.Var(cidr, v.CIDRv6())
+}
+
+// IPRange is a range of consecutive IP addresses in format "<first>-<last>".
+type IPRange string
+
+func (r IPRange) Validate() error {
+	return v.Var(r, v.IPRange())
+}
+
+// Bounds returns the first and the last address of the range. If the range
+// is invalid, ok is set to false.
+func (r IPRange) Bounds() (first netip.Addr, last netip.Addr, ok bool) {
+	return v.ParseIPRange(string(r))
+}
+
+// Is4 returns true if the range is a valid range of IPv4 addresses.
+func (r IPRange) Is4() bool {
+	first, _, ok := r.Bounds()
+	return ok && first.Is4()
+}
+
+// Addresses returns at most n addresses from the range in ascending order.
+func (r IPRange) Addresses(n int) []netip.Addr {
+	first, last, ok := r.Bounds()
+	if !ok {
+		return nil
+	}
+
+	var addrs []netip.Addr
+	for ip := first; ip.IsValid() && ip.Compare(last) <= 0 && len(addrs) < n; ip = ip.Next() {
+		addrs = append(addrs, ip)
+	}
+
+	return addrs
+}
+
+// Contains returns true if the given IP address is within the range.
+func (r IPRange) Contains(ip string) bool {
+	first, last, ok := r.Bounds()
+	if !ok {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	return addr.Compare(first) >= 0 && addr.Compare(last) <= 0
+}
+
 type MAC string
 
 func (mac MAC) Validate() error {
@@ -101,10 +163,17 @@ func (l Labels) Validate() error {
 	return v.Var(l, v.Required())
 }
 
+// DataDisk is an additional disk attached to a node instance. By default,
+// the disk is left raw (e.g. to be consumed by Rook). If filesystem is set,
+// the disk is formatted on the first boot and optionally mounted.
 type DataDisk struct {
-	Name string `yaml:"name" opt:",id"`
-	Pool string `yaml:"pool"`
-	Size GB     `yaml:"size"`
+	Name         string             `yaml:"name" opt:",id"`
+	Pool         string             `yaml:"pool"`
+	Size         GB                 `yaml:"size"`
+	Filesystem   DataDiskFilesystem `yaml:"filesystem,omitempty"`
+	MountPath    string             `yaml:"mountPath,omitempty"`
+	MountOptions []string           `yaml:"mountOptions,omitempty"`
+	Device       string             `yaml:"device,omitempty"`
 }
 
 func (d DataDisk) Validate() error {
@@ -112,9 +181,71 @@ func (d DataDisk) Validate() error {
 		v.Field(&d.Name, v.NotEmpty(), v.AlphaNumericHyp()),
 		v.Field(&d.Pool, v.OmitEmpty(), v.Skip().When(d.Pool == "main"), v.Custom(VALID_POOL)),
 		v.Field(&d.Size, v.NotEmpty()),
+		v.Field(&d.Filesystem, v.OmitEmpty()),
+		v.Field(&d.MountPath,
+			v.OmitEmpty(),
+			v.Fail().When(d.Filesystem == "").Error("Field '{.Field}' can only be set when the data disk filesystem is set."),
+			v.RegexAny("^/").Error("Field '{.Field}' must be an absolute path. (actual: {.Value})"),
+			v.Fail().When(d.MountPath == "/").Error("Field '{.Field}' cannot be the root directory."),
+		),
+		v.Field(&d.MountOptions,
+			v.OmitEmpty(),
+			v.Fail().When(d.MountPath == "").Error("Field '{.Field}' can only be set when the data disk mount path is set."),
+			v.Unique(),
+		),
+		v.Field(&d.Device,
+			v.OmitEmpty(),
+			v.Fail().When(isProvisionedConfig()).Errorf("Field '{.Field}' can only be set when provisioner is '%s'.", ProvisionerStatic),
+			v.RegexAny("^/dev/").Error("Field '{.Field}' must be a path within the '/dev' directory. (actual: {.Value})"),
+		),
 	)
 }
 
+// IsRaw returns true if the data disk is not formatted by Kubitect.
+func (d DataDisk) IsRaw() bool {
+	return d.Filesystem == ""
+}
+
+type DataDiskFilesystem string
+
+const (
+	EXT4  DataDiskFilesystem = "ext4"
+	XFS   DataDiskFilesystem = "xfs"
+	BTRFS DataDiskFilesystem = "btrfs"
+)
+
+func (fs DataDiskFilesystem) Validate() error {
+	return v.Var(fs, v.OneOf(EXT4, XFS, BTRFS))
+}
+
+// uniqueMountPathValidator returns a validator that triggers an error if
+// multiple data disks of the same node are mounted on the same path.
+func uniqueMountPathValidator(disks []DataDisk) v.Validator {
+	var duplicates []string
+
+	paths := make(map[string]bool)
+
+	for _, d := range disks {
+		if d.MountPath == "" {
+			continue
+		}
+
+		p := strings.TrimSuffix(d.MountPath, "/")
+
+		if paths[p] {
+			duplicates = append(duplicates, d.MountPath)
+		}
+
+		paths[p] = true
+	}
+
+	if len(duplicates) == 0 {
+		return v.None
+	}
+
+	return v.Fail().Errorf("Data disks of the same node cannot be mounted on the same path. (duplicates: %v)", duplicates)
+}
+
 type Version string
 
 func (ver Version) Validate() error {
@@ -3,6 +3,8 @@ package config
 import (
 	"testing"
 
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -97,6 +99,48 @@ func TestDataDisk(t *testing.T) {
 	assert.ErrorContains(t, DataDisk{}.Validate(), "Field 'name' is required and cannot be empty.")
 }
 
+func TestDataDisk_Filesystem(t *testing.T) {
+	dd := DataDisk{
+		Name:         "disk",
+		Size:         GB(5),
+		Filesystem:   XFS,
+		MountPath:    "/var/lib/data",
+		MountOptions: []string{"defaults", "noatime"},
+	}
+
+	assert.NoError(t, dd.Validate())
+	assert.False(t, dd.IsRaw())
+	assert.True(t, DataDisk{Name: "disk"}.IsRaw())
+
+	dd.Filesystem = "ntfs"
+	assert.ErrorContains(t, dd.Validate(), "Field 'filesystem' must be one of the following values: [ext4|xfs|btrfs] (actual: ntfs).")
+
+	dd.Filesystem = ""
+	assert.ErrorContains(t, dd.Validate(), "Field 'mountPath' can only be set when the data disk filesystem is set.")
+
+	dd.Filesystem = EXT4
+	dd.MountPath = "data"
+	assert.ErrorContains(t, dd.Validate(), "Field 'mountPath' must be an absolute path. (actual: data)")
+
+	dd.MountPath = "/"
+	assert.ErrorContains(t, dd.Validate(), "Field 'mountPath' cannot be the root directory.")
+
+	dd.MountPath = ""
+	assert.ErrorContains(t, dd.Validate(), "Field 'mountOptions' can only be set when the data disk mount path is set.")
+}
+
+func TestUniqueMountPathValidator(t *testing.T) {
+	disks := []DataDisk{
+		{Name: "a", Size: 1, Filesystem: EXT4, MountPath: "/data"},
+		{Name: "b", Size: 1, Filesystem: EXT4, MountPath: "/data/"},
+		{Name: "c", Size: 1},
+		{Name: "d", Size: 1},
+	}
+
+	assert.EqualError(t, v.Var(disks, uniqueMountPathValidator(disks)), "Data disks of the same node cannot be mounted on the same path. (duplicates: [/data/])")
+	assert.NoError(t, v.Var(disks[1:], uniqueMountPathValidator(disks[1:])))
+}
+
 func TestVersion(t *testing.T) {
 	assert.Error(t, Version("1.2.3").Validate())
 	assert.Error(t, Version("v1.2").Validate())
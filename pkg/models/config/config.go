@@ -3,33 +3,45 @@ package config
 import (
 	"strings"
 
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
 	v "github.com/MusicDin/kubitect/pkg/utils/validation"
 )
 
 // Keys of custom validators
 const (
 	IP_IN_CIDR  = "ipInCidr"
+	IP6_IN_CIDR = "ip6InCidr"
 	LB_REQUIRED = "lbRequired"
 	VALID_HOST  = "validHost"
 	VALID_POOL  = "validPool"
 )
 
 type Config struct {
-	Hosts      []Host     `yaml:"hosts"`
-	Cluster    Cluster    `yaml:"cluster"`
-	Kubernetes Kubernetes `yaml:"kubernetes"`
-	Addons     Addons     `yaml:"addons,omitempty"`
+	Provisioner Provisioner `yaml:"provisioner,omitempty"`
+	Terraform   Terraform   `yaml:"terraform,omitempty"`
+	Proxy       Proxy       `yaml:"proxy,omitempty"`
+	Hosts       []Host      `yaml:"hosts"`
+	Cluster     Cluster     `yaml:"cluster"`
+	Kubernetes  Kubernetes  `yaml:"kubernetes"`
+	Addons      Addons      `yaml:"addons,omitempty"`
+
+	// OS presets from which the node template defaults are derived.
+	osPresets OSPresetRegistry
 }
 
 func (c Config) Validate() error {
 	defer v.ClearCustomValidators()
 
 	v.RegisterCustomValidator(IP_IN_CIDR, c.ipInCidrValidator())
+	v.RegisterCustomValidator(IP6_IN_CIDR, c.ip6InCidrValidator())
 	v.RegisterCustomValidator(VALID_HOST, c.hostNameValidator())
 
 	return v.Struct(&c,
+		v.Field(&c.Provisioner),
+		v.Field(&c.Terraform),
+		v.Field(&c.Proxy),
 		v.Field(&c.Hosts,
-			v.MinLen(1).Error("At least {.Param} host must be configured."),
+			v.MinLen(1).When(c.Provisioner != ProvisionerStatic).Error("At least {.Param} host must be configured."),
 			v.UniqueField("Name"),
 			c.singleDefaultHostValidator(),
 		),
@@ -40,6 +52,8 @@ func (c Config) Validate() error {
 }
 
 func (c *Config) SetDefaults() {
+	c.Provisioner = defaults.Default(c.Provisioner, ProvisionerTerraform)
+
 	// If no host is set as the default host,
 	// then set the first one as the default host.
 	if len(c.Hosts) > 0 {
@@ -53,6 +67,31 @@ func (c *Config) SetDefaults() {
 	}
 }
 
+// SetOSPresets sets the OS presets available to the config and applies
+// the defaults of the selected preset to the node template. It has to be
+// called before the config defaults are set, since otherwise the node
+// template defaults are derived from the built-in presets.
+func (c *Config) SetOSPresets(presets OSPresetRegistry) {
+	c.osPresets = presets
+
+	nt := &c.Cluster.NodeTemplate
+	nt.OS.Distro = defaults.Default(nt.OS.Distro, UBUNTU22)
+
+	if p, ok := presets.Find(nt.OS.Distro); ok {
+		nt.applyOSPreset(p)
+	}
+}
+
+// OSPresets returns the OS presets available to the config. If none are
+// set, the built-in presets are returned.
+func (c Config) OSPresets() OSPresetRegistry {
+	if c.osPresets == nil {
+		return BuiltinOSPresets()
+	}
+
+	return c.osPresets
+}
+
 // singleDefaultHostValidator returns a validator that triggers an error
 // if multiple hosts are configured as default.
 func (c Config) singleDefaultHostValidator() v.Validator {
@@ -77,6 +116,16 @@ func (c Config) ipInCidrValidator() v.Validator {
 	return v.IPInRange(string(c.Cluster.Network.CIDR))
 }
 
+// ip6InCidrValidator registers a custom validator that checks whether
+// an IPv6 address is within the configured IPv6 network CIDR.
+func (c Config) ip6InCidrValidator() v.Validator {
+	if c.Cluster.Network.CIDR6 == "" {
+		return v.Fail().Error("Field '{.Field}' can only be set when IPv6 network CIDR (cidr6) is configured.")
+	}
+
+	return v.IPInRange(string(c.Cluster.Network.CIDR6))
+}
+
 // hostNameValidator returns a custom cross-validator that checks whether
 // a host with a given name has been configured.
 func (c Config) hostNameValidator() v.Validator {
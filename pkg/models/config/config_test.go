@@ -171,3 +171,96 @@ func TestConfig_MissingHostName(t *testing.T) {
 
 	assert.EqualError(t, defaults.Assign(&cfg).Validate(), "Field 'name' is required and cannot be empty.")
 }
+
+func TestConfig_DualStack(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Network.CIDR6 = "fd00:113::/64"
+	cfg.Cluster.Nodes.Master.Instances[0].IP6 = "fd00:113::10"
+	cfg.Kubernetes.Network.PodSubnet6 = "fd00:42::/56"
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+}
+
+func TestConfig_InvalidIP6(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Network.CIDR6 = "fd00:113::/64"
+	cfg.Cluster.Nodes.Master.Instances[0].IP6 = "fd00:114::10"
+
+	assert.EqualError(t, defaults.Assign(&cfg).Validate(), "Field 'ip6' must be a valid IP address within 'fd00:113::/64' subnet. (actual: fd00:114::10)")
+}
+
+func TestConfig_IP6WithoutCIDR6(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Nodes.Master.Instances[0].IP6 = "fd00:113::10"
+
+	assert.EqualError(t, defaults.Assign(&cfg).Validate(), "Field 'ip6' can only be set when IPv6 network CIDR (cidr6) is configured.")
+}
+
+func TestConfig_PodSubnet6WithoutCIDR6(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Kubernetes.Network.PodSubnet6 = "fd00:42::/56"
+
+	assert.EqualError(t, defaults.Assign(&cfg).Validate(), "Field 'podSubnet6' can only be set when IPv6 network CIDR (cidr6) is configured.")
+}
+
+func TestConfig_DuplicateIP6(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.Network.CIDR6 = "fd00:113::/64"
+	cfg.Cluster.Nodes.Master.Instances[0].IP6 = "fd00:113::10"
+	cfg.Cluster.Nodes.Worker.Instances = []WorkerInstance{
+		{Id: "1", IP6: "fd00:113::10"},
+	}
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "IP address of each node instance (including VIP) must be unique. (duplicates: [fd00:113::10])")
+}
+
+func TestConfig_Provisioner(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Provisioner = "invalid"
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'provisioner' must be one of the following values: [terraform|libvirt|static]")
+
+	cfg.Provisioner = ProvisionerLibvirt
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+}
+
+func TestConfig_Static(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Provisioner = ProvisionerStatic
+	cfg.Hosts = nil
+	cfg.Cluster.NodeTemplate.SSH.PrivateKeyPath = MockPKey(t)
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+}
+
+func TestConfig_StaticRequiredFields(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Provisioner = ProvisionerStatic
+	cfg.Cluster.Nodes.Master.Instances[0].IP = ""
+
+	err := defaults.Assign(&cfg).Validate()
+	assert.ErrorContains(t, err, "Field 'ip' is required when provisioner is 'static'.")
+	assert.ErrorContains(t, err, "Field 'privateKeyPath' is required when provisioner is 'static'.")
+}
+
+func TestConfig_StaticIPAM(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Provisioner = ProvisionerStatic
+	cfg.Cluster.NodeTemplate.SSH.PrivateKeyPath = MockPKey(t)
+	cfg.Cluster.Network.IPAM.Enabled = true
+
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'enabled' cannot be set when provisioner is 'static'")
+}
+
+func TestConfig_RemoteStateBackend(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Cluster.State.Backend = StateBackend{
+		Type: StateBackendS3,
+		S3:   S3StateBackend{Bucket: "states"},
+	}
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+
+	cfg.Provisioner = ProvisionerLibvirt
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Remote state backend can only be used when provisioner is 'terraform'.")
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/MusicDin/kubitect/pkg/env"
@@ -14,14 +15,19 @@ type Kubernetes struct {
 	Manager       KubernetesManager `yaml:"manager"`
 	DnsMode       DnsMode           `yaml:"dnsMode"`
 	NetworkPlugin NetworkPlugin     `yaml:"networkPlugin"`
+	Network       KubernetesNetwork `yaml:"network,omitempty"`
+	Registries    Registries        `yaml:"registries,omitempty"`
 	Other         Other             `yaml:"other"`
 }
 
 func (k Kubernetes) Validate() error {
 	return v.Struct(&k,
 		v.Field(&k.Version, v.NotEmpty()),
+		v.Field(&k.Manager, v.OmitEmpty(), osPresetManagerValidator(), kubeadmDualStackValidator(k.Manager)),
 		v.Field(&k.DnsMode, v.NotEmpty()),
-		v.Field(&k.NetworkPlugin, v.NotEmpty()),
+		v.Field(&k.NetworkPlugin, v.NotEmpty(), managerNetworkPluginValidator(k.Manager, k.NetworkPlugin)),
+		v.Field(&k.Network),
+		v.Field(&k.Registries, registriesManagerValidator(k.Registries)),
 		v.Field(&k.Other),
 	)
 }
@@ -39,11 +45,12 @@ func (ver KubernetesVersion) Validate() error {
 	var err error
 
 	version := strings.TrimPrefix(string(ver), "v")
+	versions := supportedK8sVersions()
 
 	msg := fmt.Sprintf("Unsupported Kubernetes version (%s). ", ver)
-	msg += fmt.Sprintf("Supported versions are:\n%s", strings.Join(env.ProjectK8sVersions, "\n"))
+	msg += fmt.Sprintf("Supported versions are:\n%s", strings.Join(versions, "\n"))
 
-	for _, verRange := range env.ProjectK8sVersions {
+	for _, verRange := range versions {
 		verRange = strings.ReplaceAll(verRange, " ", "")
 		verRange = strings.ReplaceAll(verRange, "v", "")
 
@@ -61,15 +68,58 @@ func (ver KubernetesVersion) Validate() error {
 	return err
 }
 
+// supportedK8sVersions returns Kubernetes version ranges supported by
+// the Kubernetes manager of the configuration being validated.
+func supportedK8sVersions() []string {
+	c, ok := v.TopParent().(*Config)
+	if ok && c != nil && c.Kubernetes.Manager == ManagerRke2 {
+		return env.ProjectRke2Versions
+	}
+
+	return env.ProjectK8sVersions
+}
+
 type KubernetesManager string
 
 const (
 	ManagerKubespray = "kubespray"
 	ManagerK3s       = "k3s"
+	ManagerKubeadm   = "kubeadm"
+	ManagerRke2      = "rke2"
 )
 
+// builtinManagers are Kubernetes managers that are part of Kubitect.
+var builtinManagers = []KubernetesManager{ManagerKubespray, ManagerK3s, ManagerKubeadm, ManagerRke2}
+
+// Validate ensures the manager is either a built-in manager or a name of
+// an external manager plugin.
 func (m KubernetesManager) Validate() error {
-	return v.Var(m, v.OneOf(ManagerKubespray, ManagerK3s))
+	if !m.IsPlugin() {
+		return nil
+	}
+
+	return v.Var(m, v.RegexAny("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$").Errorf(
+		"Field '{.Field}' must be one of %v or a name of an external manager plugin, which can contain only lowercase alphanumeric characters and hyphens. (actual: {.Value})",
+		builtinManagers,
+	))
+}
+
+// IsPlugin returns true if the manager is not a built-in manager and is
+// therefore provided by an external plugin (kubitect-manager-<name>).
+func (m KubernetesManager) IsPlugin() bool {
+	return !slices.Contains(builtinManagers, m)
+}
+
+// kubeadmDualStackValidator returns a cross-validator that triggers an
+// error if the kubeadm manager is used in a dual-stack cluster, since
+// network plugins installed by the kubeadm manager are configured only
+// for IPv4.
+func kubeadmDualStackValidator(m KubernetesManager) v.Validator {
+	if m != ManagerKubeadm || !isDualStack() {
+		return v.None
+	}
+
+	return v.Fail().Error("Kubeadm manager does not support dual-stack clusters.")
 }
 
 type DnsMode string
@@ -95,7 +145,82 @@ func (p NetworkPlugin) Validate() error {
 	return v.Var(p, v.OneOf(CALICO, CILIUM, FLANNEL, KUBE_ROUTER))
 }
 
+// managerNetworkPlugins contains network plugins supported by Kubernetes
+// managers that do not support all of them.
+var managerNetworkPlugins = map[KubernetesManager][]NetworkPlugin{
+	ManagerKubeadm: {CALICO, FLANNEL},
+	ManagerRke2:    {CALICO, CILIUM, FLANNEL},
+}
+
+// managerNetworkPluginValidator returns a validator that triggers an
+// error if the network plugin is not supported by the Kubernetes manager.
+func managerNetworkPluginValidator(m KubernetesManager, p NetworkPlugin) v.Validator {
+	plugins, ok := managerNetworkPlugins[m]
+	if !ok || slices.Contains(plugins, p) {
+		return v.None
+	}
+
+	return v.Fail().Errorf("Field '{.Field}' must be one of %v when Kubernetes manager is '%s'. (actual: %s)", plugins, m, p)
+}
+
+// Default pod and service subnets of the built-in Kubernetes managers,
+// which are used when the subnets are not configured. RKE2 uses the
+// same defaults as k3s.
+const (
+	KubesprayDefaultPodSubnet      = "10.233.64.0/18"
+	KubesprayDefaultServiceSubnet  = "10.233.0.0/18"
+	KubesprayDefaultPodSubnet6     = "fd85:ee78:d8a6:8607::1:0000/112"
+	KubesprayDefaultServiceSubnet6 = "fd85:ee78:d8a6:8607::1000/116"
+	KubeadmDefaultPodSubnet        = "10.244.0.0/16"
+	KubeadmDefaultServiceSubnet    = "10.96.0.0/12"
+	K3sDefaultPodSubnet            = "10.42.0.0/16"
+	K3sDefaultServiceSubnet        = "10.43.0.0/16"
+	K3sDefaultPodSubnet6           = "fd00:10:42::/56"
+	K3sDefaultServiceSubnet6       = "fd00:10:43::/112"
+)
+
+// KubernetesNetwork contains pod and service subnets. If subnets are
+// not set, defaults of the selected manager are used. IPv6 subnets are
+// used only in dual-stack clusters.
+type KubernetesNetwork struct {
+	PodSubnet      CIDRv4 `yaml:"podSubnet,omitempty"`
+	PodSubnet6     CIDRv6 `yaml:"podSubnet6,omitempty"`
+	ServiceSubnet  CIDRv4 `yaml:"serviceSubnet,omitempty"`
+	ServiceSubnet6 CIDRv6 `yaml:"serviceSubnet6,omitempty"`
+}
+
+func (n KubernetesNetwork) Validate() error {
+	dualStack := isDualStack()
+
+	return v.Struct(&n,
+		v.Field(&n.PodSubnet, v.OmitEmpty()),
+		v.Field(&n.PodSubnet6,
+			v.OmitEmpty(),
+			v.Fail().When(!dualStack).Error("Field '{.Field}' can only be set when IPv6 network CIDR (cidr6) is configured."),
+		),
+		v.Field(&n.ServiceSubnet, v.OmitEmpty()),
+		v.Field(&n.ServiceSubnet6,
+			v.OmitEmpty(),
+			v.Fail().When(!dualStack).Error("Field '{.Field}' can only be set when IPv6 network CIDR (cidr6) is configured."),
+		),
+	)
+}
+
+// isDualStack returns true if the cluster network of the configuration
+// being validated is a dual-stack network.
+func isDualStack() bool {
+	c, ok := v.TopParent().(*Config)
+	return ok && c != nil && c.Cluster.Network.IsDualStack()
+}
+
 type Other struct {
 	AutoRenewCertificates bool `yaml:"autoRenewCertificates"`
 	MergeKubeconfig       bool `yaml:"mergeKubeconfig"`
 }
+
+func (o Other) Validate() error {
+	return v.Struct(&o,
+		// k3s renews its certificates automatically on restart.
+		v.Field(&o.AutoRenewCertificates, managerValidator(o.AutoRenewCertificates, ManagerKubespray)),
+	)
+}
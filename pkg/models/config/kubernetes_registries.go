@@ -0,0 +1,108 @@
+package config
+
+import (
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// Registries configure container registries used by the cluster nodes.
+// Configuration is translated for the selected Kubernetes manager.
+type Registries struct {
+	Mirrors []RegistryMirror `yaml:"mirrors,omitempty"`
+	Configs []RegistryConfig `yaml:"configs,omitempty"`
+}
+
+func (r Registries) Validate() error {
+	return v.Struct(&r,
+		v.Field(&r.Mirrors, v.OmitEmpty(), v.UniqueField("Registry")),
+		v.Field(&r.Configs, v.OmitEmpty(), v.UniqueField("Registry")),
+	)
+}
+
+// IsEmpty returns true if neither mirrors nor registry configurations
+// are set.
+func (r Registries) IsEmpty() bool {
+	return len(r.Mirrors) == 0 && len(r.Configs) == 0
+}
+
+// RegistryMirror redirects image pulls from the registry (e.g. docker.io)
+// to the given endpoints. Endpoints are tried in order.
+type RegistryMirror struct {
+	Registry  string `yaml:"registry"`
+	Endpoints []URL  `yaml:"endpoints"`
+}
+
+func (m RegistryMirror) Validate() error {
+	return v.Struct(&m,
+		v.Field(&m.Registry, v.NotEmpty(), registryHostValidator()),
+		v.Field(&m.Endpoints, v.NotEmpty()),
+	)
+}
+
+// RegistryConfig configures authentication and TLS of the registry with
+// the given host (e.g. harbor.example.com:5000). Configuration also
+// applies to mirror endpoints on the same host.
+type RegistryConfig struct {
+	Registry           string       `yaml:"registry"`
+	Auth               RegistryAuth `yaml:"auth,omitempty"`
+	CA                 File         `yaml:"ca,omitempty"`
+	InsecureSkipVerify bool         `yaml:"insecureSkipVerify,omitempty"`
+}
+
+func (c RegistryConfig) Validate() error {
+	return v.Struct(&c,
+		v.Field(&c.Registry, v.NotEmpty(), registryHostValidator()),
+		v.Field(&c.Auth, v.OmitEmpty()),
+		v.Field(&c.CA, v.OmitEmpty()),
+	)
+}
+
+// RegistryAuth contains registry credentials. Password is never set
+// directly, but read from a file or an environment variable whenever
+// the nodes are configured. This way, it is not stored in the cluster
+// configuration.
+type RegistryAuth struct {
+	Username     string `yaml:"username"`
+	PasswordFile File   `yaml:"passwordFile,omitempty"`
+	PasswordEnv  string `yaml:"passwordEnv,omitempty"`
+}
+
+func (a RegistryAuth) Validate() error {
+	passErr := "Exactly one of the fields 'passwordFile' and 'passwordEnv' must be set."
+
+	return v.Struct(&a,
+		v.Field(&a.Username, v.NotEmpty()),
+		v.Field(&a.PasswordFile,
+			v.OmitEmpty(),
+			v.Fail().When(a.PasswordEnv != "").Error(passErr),
+		),
+		v.Field(&a.PasswordEnv,
+			v.NotEmpty().When(a.PasswordFile == "").Error(passErr),
+			v.OmitEmpty(),
+			v.RegexAny("^[A-Za-z_][A-Za-z0-9_]*$").Error("Field '{.Field}' must be a valid environment variable name. (actual: {.Value})"),
+		),
+	)
+}
+
+// registryHostValidator returns a validator that triggers an error if
+// the value is not a registry host with an optional port.
+func registryHostValidator() v.Validator {
+	return v.RegexAny(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?$`).
+		Error("Field '{.Field}' must be a registry host with an optional port (e.g. registry.example.com:5000). (actual: {.Value})")
+}
+
+// registriesManagerValidator returns a cross-validator that triggers an
+// error if registries are configured, but the Kubernetes manager does
+// not support them.
+func registriesManagerValidator(r Registries) v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if r.IsEmpty() || !ok || c == nil {
+		return v.None
+	}
+
+	m := c.Kubernetes.Manager
+	if m == ManagerKubespray || m == ManagerK3s || m.IsPlugin() {
+		return v.None
+	}
+
+	return v.Fail().Errorf("Field '{.Field}' can only be set when Kubernetes manager is '%s' or '%s'. (actual: %s)", ManagerKubespray, ManagerK3s, m)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func MockRegistryCA(t *testing.T) File {
+	caPath := path.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caPath, []byte("ca"), 0644))
+	return File(caPath)
+}
+
+func TestRegistries(t *testing.T) {
+	r := Registries{
+		Mirrors: []RegistryMirror{
+			{Registry: "docker.io", Endpoints: []URL{"https://harbor.example.com/v2/dockerhub"}},
+		},
+		Configs: []RegistryConfig{
+			{
+				Registry: "harbor.example.com:5000",
+				Auth:     RegistryAuth{Username: "robot", PasswordEnv: "HARBOR_PASSWORD"},
+				CA:       MockRegistryCA(t),
+			},
+			{
+				Registry:           "registry.local",
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+
+	assert.NoError(t, r.Validate())
+	assert.False(t, r.IsEmpty())
+	assert.True(t, Registries{}.IsEmpty())
+}
+
+func TestRegistryMirror_Invalid(t *testing.T) {
+	assert.ErrorContains(t, RegistryMirror{Registry: "docker.io"}.Validate(), "Field 'endpoints' is required and cannot be empty.")
+	assert.ErrorContains(t, RegistryMirror{Registry: "https://docker.io", Endpoints: []URL{"https://mirror.local"}}.Validate(), "Field 'registry' must be a registry host with an optional port")
+	assert.ErrorContains(t, RegistryMirror{Registry: "docker.io", Endpoints: []URL{"mirror"}}.Validate(), "must be a valid URL")
+}
+
+func TestRegistries_Duplicate(t *testing.T) {
+	r := Registries{
+		Configs: []RegistryConfig{
+			{Registry: "registry.local", InsecureSkipVerify: true},
+			{Registry: "registry.local"},
+		},
+	}
+
+	assert.ErrorContains(t, r.Validate(), "Field 'Registry' must be unique for each element in 'configs'.")
+}
+
+func TestRegistryAuth(t *testing.T) {
+	passPath := path.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passPath, []byte("secret"), 0600))
+
+	assert.NoError(t, RegistryAuth{Username: "user", PasswordFile: File(passPath)}.Validate())
+	assert.NoError(t, RegistryAuth{Username: "user", PasswordEnv: "PASSWORD"}.Validate())
+}
+
+func TestRegistryAuth_Invalid(t *testing.T) {
+	passErr := "Exactly one of the fields 'passwordFile' and 'passwordEnv' must be set."
+
+	assert.ErrorContains(t, RegistryAuth{PasswordEnv: "PASSWORD"}.Validate(), "Field 'username' is required and cannot be empty.")
+	assert.ErrorContains(t, RegistryAuth{Username: "user"}.Validate(), passErr)
+	assert.ErrorContains(t, RegistryAuth{Username: "user", PasswordFile: MockRegistryCA(t), PasswordEnv: "PASSWORD"}.Validate(), passErr)
+	assert.ErrorContains(t, RegistryAuth{Username: "user", PasswordEnv: "HARBOR-PASSWORD"}.Validate(), "Field 'passwordEnv' must be a valid environment variable name.")
+	assert.ErrorContains(t, RegistryAuth{Username: "user", PasswordFile: "missing"}.Validate(), "missing")
+}
+
+func TestConfig_Registries(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Kubernetes.Registries = Registries{
+		Mirrors: []RegistryMirror{
+			{Registry: "docker.io", Endpoints: []URL{"https://mirror.local"}},
+		},
+	}
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+
+	cfg.Kubernetes.Manager = ManagerK3s
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+
+	cfg.Kubernetes.Manager = ManagerKubeadm
+	assert.ErrorContains(t, defaults.Assign(&cfg).Validate(), "Field 'registries' can only be set when Kubernetes manager is 'kubespray' or 'k3s'. (actual: kubeadm)")
+}
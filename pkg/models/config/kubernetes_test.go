@@ -30,6 +30,16 @@ func TestNetworkPlugin(t *testing.T) {
 	assert.NoError(t, CILIUM.Validate())
 }
 
+func TestKubernetesManager(t *testing.T) {
+	assert.NoError(t, KubernetesManager(ManagerRke2).Validate())
+	assert.NoError(t, KubernetesManager("custom-manager").Validate())
+	assert.False(t, KubernetesManager(ManagerKubespray).IsPlugin())
+	assert.True(t, KubernetesManager("custom").IsPlugin())
+	assert.ErrorContains(t, KubernetesManager("Custom").Validate(), "or a name of an external manager plugin")
+	assert.ErrorContains(t, KubernetesManager("custom-").Validate(), "or a name of an external manager plugin")
+	assert.ErrorContains(t, KubernetesManager("../custom").Validate(), "or a name of an external manager plugin")
+}
+
 func TestKubernetes_Empty(t *testing.T) {
 	k8s := Kubernetes{}
 	assert.ErrorContains(t, k8s.Validate(), "Field 'version' is required and cannot be empty.")
@@ -57,3 +67,37 @@ func TestDefault(t *testing.T) {
 	assert.NoError(t, defaults.Set(&k))
 	assert.Equal(t, COREDNS, k.DnsMode)
 }
+
+func TestConfig_KubeadmManager(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Kubernetes.Manager = ManagerKubeadm
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+
+	cfg.Kubernetes.NetworkPlugin = CILIUM
+	assert.EqualError(t, cfg.Validate(), "Field 'networkPlugin' must be one of [calico flannel] when Kubernetes manager is 'kubeadm'. (actual: cilium)")
+
+	cfg.Kubernetes.NetworkPlugin = FLANNEL
+	cfg.Cluster.Network.CIDR6 = "fd00:113::/64"
+	assert.EqualError(t, cfg.Validate(), "Kubeadm manager does not support dual-stack clusters.")
+}
+
+func TestConfig_Rke2Manager(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Kubernetes.Manager = ManagerRke2
+	cfg.Kubernetes.Version = "v1.34.1"
+
+	assert.NoError(t, defaults.Assign(&cfg).Validate())
+
+	// RKE2 has its own matrix of supported versions.
+	cfg.Kubernetes.Version = "v1.31.5"
+	assert.ErrorContains(t, cfg.Validate(), "Unsupported Kubernetes version (v1.31.5)")
+
+	cfg.Kubernetes.Manager = ManagerKubespray
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Kubernetes.Manager = ManagerRke2
+	cfg.Kubernetes.Version = "v1.33.4"
+	cfg.Kubernetes.NetworkPlugin = KUBE_ROUTER
+	assert.EqualError(t, cfg.Validate(), "Field 'networkPlugin' must be one of [calico cilium flannel] when Kubernetes manager is 'rke2'. (actual: kube-router)")
+}
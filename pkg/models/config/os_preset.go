@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// defaultOSUser is a default user of the OS presets that do not declare
+// a user.
+const defaultOSUser = "k8s"
+
+// builtinOSDistros are names of the built-in OS presets in the order
+// in which they are listed.
+var builtinOSDistros = []OSDistro{
+	UBUNTU20,
+	UBUNTU22,
+	DEBIAN11,
+	DEBIAN12,
+	DEBIAN13,
+	CENTOS9,
+	ROCKY9,
+}
+
+// OSPreset describes an OS image that can be selected using the 'distro'
+// property of the node template. Besides the built-in presets, presets
+// can be loaded from YAML files, where each file name represents the
+// preset name.
+type OSPreset struct {
+	Name             string              `yaml:"-"`
+	Source           OSSource            `yaml:"source"`
+	Checksum         OSChecksum          `yaml:"checksum,omitempty"`
+	ChecksumFile     OSChecksumFile      `yaml:"checksumFile,omitempty"`
+	NetworkInterface OSNetworkInterface  `yaml:"networkInterface"`
+	User             User                `yaml:"user,omitempty"`
+	Managers         []KubernetesManager `yaml:"managers,omitempty"`
+	Builtin          bool                `yaml:"-"`
+}
+
+func (p OSPreset) Validate() error {
+	return v.Struct(&p,
+		v.Field(&p.Name, v.NotEmpty(), v.AlphaNumericHyp(), v.MaxLen(32)),
+		v.Field(&p.Source, v.NotEmpty()),
+		v.Field(&p.Checksum),
+		v.Field(&p.ChecksumFile,
+			v.Fail().When(p.ChecksumFile != "" && p.Checksum != "").Error("Field '{.Field}' cannot be set when the checksum is configured."),
+		),
+		v.Field(&p.NetworkInterface, v.NotEmpty()),
+		v.Field(&p.User, v.OmitEmpty()),
+		v.Field(&p.Managers, v.OmitEmpty(), v.Unique()),
+	)
+}
+
+// DefaultUser returns the user declared by the preset or the default
+// user if none is declared.
+func (p OSPreset) DefaultUser() User {
+	if p.User == "" {
+		return defaultOSUser
+	}
+
+	return p.User
+}
+
+// SupportsManager returns true if the preset supports the given
+// Kubernetes manager. Presets that do not declare managers support
+// all of them.
+func (p OSPreset) SupportsManager(m KubernetesManager) bool {
+	if len(p.Managers) == 0 {
+		return true
+	}
+
+	for _, pm := range p.Managers {
+		if pm == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OSPresetRegistry contains OS presets by name.
+type OSPresetRegistry map[OSDistro]OSPreset
+
+// BuiltinOSPresets returns a registry of OS presets that are compiled
+// into Kubitect.
+func BuiltinOSPresets() OSPresetRegistry {
+	presets := make(OSPresetRegistry)
+
+	for _, d := range builtinOSDistros {
+		p := env.ProjectOsPresets[string(d)]
+
+		var managers []KubernetesManager
+
+		for _, m := range p.Managers {
+			managers = append(managers, KubernetesManager(m))
+		}
+
+		presets[d] = OSPreset{
+			Name:             string(d),
+			Source:           OSSource(p.Source),
+			ChecksumFile:     OSChecksumFile(p.ChecksumFile),
+			NetworkInterface: OSNetworkInterface(p.NetworkInterface),
+			User:             User(p.User),
+			Managers:         managers,
+			Builtin:          true,
+		}
+	}
+
+	return presets
+}
+
+// LoadOSPresets returns a registry of the built-in OS presets extended
+// with the presets loaded from YAML files within the given directory.
+// Presets with the name of a built-in preset override it. Missing
+// directory is ignored.
+func LoadOSPresets(dir string) (OSPresetRegistry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	presets := BuiltinOSPresets()
+
+	for _, p := range paths {
+		preset, err := file.ReadYamlStrict(p, OSPreset{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OS preset %q: %v", p, err)
+		}
+
+		preset.Name = strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+
+		if err := preset.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid OS preset %q: %v", p, err)
+		}
+
+		presets[OSDistro(preset.Name)] = *preset
+	}
+
+	return presets, nil
+}
+
+// Find returns an OS preset with the given name.
+func (r OSPresetRegistry) Find(distro OSDistro) (OSPreset, bool) {
+	p, ok := r[distro]
+	return p, ok
+}
+
+// List returns all OS presets in the registry. Built-in presets are
+// listed first, followed by the loaded presets sorted by name.
+func (r OSPresetRegistry) List() []OSPreset {
+	var presets []OSPreset
+	var loaded []OSPreset
+
+	for _, d := range builtinOSDistros {
+		if p, ok := r[d]; ok {
+			presets = append(presets, p)
+		}
+	}
+
+	for _, p := range r {
+		if !isBuiltinOSDistro(OSDistro(p.Name)) {
+			loaded = append(loaded, p)
+		}
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].Name < loaded[j].Name
+	})
+
+	return append(presets, loaded...)
+}
+
+// OSPresetsDir returns the directory within the given home directory
+// from which OS presets are loaded.
+func OSPresetsDir(homeDir string) string {
+	return filepath.Join(homeDir, "presets", "os")
+}
+
+func isBuiltinOSDistro(d OSDistro) bool {
+	for _, b := range builtinOSDistros {
+		if b == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// osPresetManagerValidator returns a cross-validator that checks whether
+// the selected OS preset supports the Kubernetes manager.
+func osPresetManagerValidator() v.Validator {
+	c, ok := v.TopParent().(*Config)
+	if !ok || c == nil {
+		return v.None
+	}
+
+	p, ok := c.OSPresets().Find(c.Cluster.NodeTemplate.OS.Distro)
+	if !ok || p.SupportsManager(c.Kubernetes.Manager) {
+		return v.None
+	}
+
+	var managers []string
+
+	for _, m := range p.Managers {
+		managers = append(managers, string(m))
+	}
+
+	return v.Fail().Errorf("Field '{.Field}' must be set to a manager supported by the OS distro '%s': [%s] (actual: %s)", p.Name, strings.Join(managers, "|"), c.Kubernetes.Manager)
+}
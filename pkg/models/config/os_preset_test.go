@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockOSPresetsDir(t *testing.T, presets map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range presets {
+		err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+func TestOSPresets_Builtin(t *testing.T) {
+	presets := BuiltinOSPresets().List()
+	require.Len(t, presets, len(env.ProjectOsPresets))
+	assert.Equal(t, string(UBUNTU20), presets[0].Name)
+
+	for _, p := range presets {
+		assert.NoError(t, p.Validate())
+		assert.True(t, p.Builtin)
+		assert.NotEmpty(t, p.User)
+		assert.NotEmpty(t, p.ChecksumFile)
+		assert.NotEmpty(t, p.Managers)
+	}
+
+	p, ok := BuiltinOSPresets().Find(UBUNTU22)
+	require.True(t, ok)
+	assert.Equal(t, User("k8s"), p.DefaultUser())
+	assert.True(t, p.SupportsManager(ManagerK3s))
+
+	p, ok = BuiltinOSPresets().Find(DEBIAN12)
+	require.True(t, ok)
+	assert.False(t, p.SupportsManager(ManagerRke2))
+}
+
+func TestLoadOSPresets(t *testing.T) {
+	dir := mockOSPresetsDir(t, map[string]string{
+		"ubuntu24": `
+source: https://cloud-images.ubuntu.com/noble/current/noble-server-cloudimg-amd64.img
+networkInterface: ens3
+user: ubuntu
+managers: [kubespray]
+`,
+		"ubuntu22": `
+source: /images/golden.img
+networkInterface: enp1s0
+`,
+	})
+
+	r, err := LoadOSPresets(dir)
+	require.NoError(t, err)
+
+	presets := r.List()
+	require.Len(t, presets, len(env.ProjectOsPresets)+1)
+	assert.Equal(t, "ubuntu24", presets[len(presets)-1].Name)
+
+	p, ok := r.Find("ubuntu24")
+	require.True(t, ok)
+	assert.False(t, p.Builtin)
+	assert.Equal(t, User("ubuntu"), p.DefaultUser())
+	assert.True(t, p.SupportsManager(ManagerKubespray))
+	assert.False(t, p.SupportsManager(ManagerK3s))
+
+	// Built-in preset is overridden.
+	p, ok = r.Find(UBUNTU22)
+	require.True(t, ok)
+	assert.False(t, p.Builtin)
+	assert.Equal(t, OSSource("/images/golden.img"), p.Source)
+
+	// Loaded presets do not leak into the built-in ones.
+	p, ok = BuiltinOSPresets().Find(UBUNTU22)
+	require.True(t, ok)
+	assert.True(t, p.Builtin)
+	assert.Error(t, OSDistro("ubuntu24").Validate())
+}
+
+func TestLoadOSPresets_MissingDir(t *testing.T) {
+	r, err := LoadOSPresets(filepath.Join(t.TempDir(), "missing"))
+	assert.NoError(t, err)
+	assert.Len(t, r.List(), len(env.ProjectOsPresets))
+}
+
+func TestLoadOSPresets_Invalid(t *testing.T) {
+	dir := mockOSPresetsDir(t, map[string]string{
+		"invalid": "networkInterface: ens3",
+	})
+
+	_, err := LoadOSPresets(dir)
+	assert.ErrorContains(t, err, "Field 'source' is required")
+
+	dir = mockOSPresetsDir(t, map[string]string{
+		"unknown": "source: /images/os.img\nnetworkInterface: ens3\nunknown: true",
+	})
+
+	_, err = LoadOSPresets(dir)
+	assert.ErrorContains(t, err, "failed to read OS preset")
+}
+
+func TestOS_PresetDefaults(t *testing.T) {
+	sum := "ab5ba3cbd73a1dda0a71ed4fbc7a3c43cf7c90d8d1a2d9b5f2f0e4c0fefc1a07"
+
+	dir := mockOSPresetsDir(t, map[string]string{
+		"golden": "source: /images/golden.img\nchecksum: " + sum + "\nnetworkInterface: enp1s0\nuser: admin",
+	})
+
+	r, err := LoadOSPresets(dir)
+	require.NoError(t, err)
+
+	cfg1 := Config{Cluster: Cluster{NodeTemplate: NodeTemplate{OS: OS{Distro: "golden"}}}}
+	cfg2 := Config{Cluster: Cluster{NodeTemplate: NodeTemplate{OS: OS{Distro: "golden", Source: "/images/other.img"}}}}
+
+	cfg1.SetOSPresets(r)
+	cfg2.SetOSPresets(r)
+
+	defaults.Assign(&cfg1)
+	defaults.Assign(&cfg2)
+
+	nt1 := cfg1.Cluster.NodeTemplate
+	nt2 := cfg2.Cluster.NodeTemplate
+
+	assert.Equal(t, User("admin"), nt1.User)
+	assert.Equal(t, OSSource("/images/golden.img"), nt1.OS.Source)
+	assert.Equal(t, OSChecksum(sum), nt1.OS.Checksum)
+	assert.Equal(t, OSNetworkInterface("enp1s0"), nt1.OS.NetworkInterface)
+
+	// Preset checksum is not applied to a custom source.
+	assert.Equal(t, OSSource("/images/other.img"), nt2.OS.Source)
+	assert.Empty(t, nt2.OS.Checksum)
+}
+
+func TestOS_BuiltinPresetDefaults(t *testing.T) {
+	nt := defaults.Assign(&NodeTemplate{OS: OS{Distro: DEBIAN12}})
+
+	assert.Equal(t, User("k8s"), nt.User)
+	assert.Equal(t, OSSource(env.ProjectOsPresets["debian12"].Source), nt.OS.Source)
+	assert.Equal(t, OSChecksumFile(env.ProjectOsPresets["debian12"].ChecksumFile), nt.OS.ChecksumFile)
+
+	// Skipped checksum verification does not use the checksum file.
+	nt = defaults.Assign(&NodeTemplate{OS: OS{Distro: DEBIAN12, SkipChecksum: true}})
+	assert.Empty(t, nt.OS.ChecksumFile)
+	assert.NoError(t, nt.OS.Validate())
+}
+
+func TestConfig_OSPresetManager(t *testing.T) {
+	dir := mockOSPresetsDir(t, map[string]string{
+		"golden": "source: /images/golden.img\nnetworkInterface: ens3\nmanagers: [k3s]",
+	})
+
+	r, err := LoadOSPresets(dir)
+	require.NoError(t, err)
+
+	cfg := MockConfig(t)
+	cfg.SetOSPresets(r)
+	cfg.Cluster.NodeTemplate.OS.Distro = "golden"
+
+	assert.EqualError(t, cfg.Validate(), "Field 'manager' must be set to a manager supported by the OS distro 'golden': [k3s] (actual: kubespray)")
+
+	cfg.Kubernetes.Manager = ManagerK3s
+	assert.NoError(t, cfg.Validate())
+
+	// Presets of one config are not available to the others.
+	cfg = MockConfig(t)
+	cfg.Cluster.NodeTemplate.OS.Distro = "golden"
+	assert.ErrorContains(t, cfg.Validate(), "Field 'distro' must be one of the following values")
+}
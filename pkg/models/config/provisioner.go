@@ -0,0 +1,46 @@
+package config
+
+import (
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// Provisioner is responsible for provisioning cluster nodes.
+type Provisioner string
+
+const (
+	// ProvisionerTerraform provisions libvirt virtual machines using
+	// Terraform.
+	ProvisionerTerraform Provisioner = "terraform"
+
+	// ProvisionerLibvirt provisions libvirt virtual machines by talking
+	// to libvirt directly over its RPC protocol, without Terraform.
+	ProvisionerLibvirt Provisioner = "libvirt"
+
+	// ProvisionerStatic uses existing machines (e.g. bare-metal servers
+	// or pre-created virtual machines) that are reachable over SSH.
+	ProvisionerStatic Provisioner = "static"
+)
+
+func (p Provisioner) Validate() error {
+	return v.Var(p, v.OneOf(ProvisionerTerraform, ProvisionerLibvirt, ProvisionerStatic))
+}
+
+// isStaticProvisioner returns true if the configuration being validated
+// uses the static provisioner.
+func isStaticProvisioner() bool {
+	c, ok := v.TopParent().(*Config)
+	return ok && c != nil && c.Provisioner == ProvisionerStatic
+}
+
+// isProvisionedConfig returns true if the configuration being validated
+// uses a provisioner that creates the nodes.
+func isProvisionedConfig() bool {
+	c, ok := v.TopParent().(*Config)
+	return ok && c != nil && c.Provisioner != ProvisionerStatic
+}
+
+// staticRequiredValidator returns a validator that triggers an error if
+// the field is empty and the static provisioner is used.
+func staticRequiredValidator() v.Validator {
+	return v.NotEmpty().When(isStaticProvisioner()).Errorf("Field '{.Field}' is required when provisioner is '%s'.", ProvisionerStatic)
+}
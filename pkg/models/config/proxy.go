@@ -0,0 +1,113 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/utils/defaults"
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// Proxy configures HTTP and HTTPS proxies used by Kubitect and the
+// cluster nodes.
+type Proxy struct {
+	HTTP    URL      `yaml:"http,omitempty"`
+	HTTPS   URL      `yaml:"https,omitempty"`
+	NoProxy []string `yaml:"noProxy,omitempty"`
+}
+
+func (p Proxy) Validate() error {
+	return v.Struct(&p,
+		v.Field(&p.HTTP, v.OmitEmpty()),
+		v.Field(&p.HTTPS, v.OmitEmpty()),
+		v.Field(&p.NoProxy,
+			v.OmitEmpty(),
+			v.Fail().When(p.IsEmpty()).Error("Field '{.Field}' can only be set when either 'http' or 'https' proxy is set."),
+		),
+	)
+}
+
+// IsEmpty returns true if neither HTTP nor HTTPS proxy is set.
+func (p Proxy) IsEmpty() bool {
+	return p.HTTP == "" && p.HTTPS == ""
+}
+
+// NoProxy returns addresses that are accessed without the proxy. Besides
+// the configured addresses, it contains localhost and all cluster CIDRs,
+// so that the traffic within the cluster never goes through the proxy.
+func (c Config) NoProxy() []string {
+	addrs := []string{
+		"localhost",
+		"127.0.0.1",
+		string(c.Cluster.Network.CIDR),
+		string(c.Cluster.Network.CIDR6),
+	}
+
+	addrs = append(addrs, c.k8sSubnets()...)
+	addrs = append(addrs, c.Proxy.NoProxy...)
+
+	var out []string
+	for _, a := range addrs {
+		if a != "" && !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
+
+// k8sSubnets returns pod and service subnets of the cluster. Subnets that
+// are not configured default to the subnets of the Kubernetes manager.
+func (c Config) k8sSubnets() []string {
+	var pod, pod6, svc, svc6 string
+
+	dualStack := c.Cluster.Network.IsDualStack()
+
+	switch c.Kubernetes.Manager {
+	case ManagerKubespray:
+		pod, svc = KubesprayDefaultPodSubnet, KubesprayDefaultServiceSubnet
+		if dualStack {
+			pod6, svc6 = KubesprayDefaultPodSubnet6, KubesprayDefaultServiceSubnet6
+		}
+	case ManagerKubeadm:
+		pod, svc = KubeadmDefaultPodSubnet, KubeadmDefaultServiceSubnet
+	case ManagerK3s, ManagerRke2:
+		pod, svc = K3sDefaultPodSubnet, K3sDefaultServiceSubnet
+		if dualStack {
+			pod6, svc6 = K3sDefaultPodSubnet6, K3sDefaultServiceSubnet6
+		}
+	}
+
+	net := c.Kubernetes.Network
+
+	return []string{
+		defaults.Default(string(net.PodSubnet), pod),
+		defaults.Default(string(net.PodSubnet6), pod6),
+		defaults.Default(string(net.ServiceSubnet), svc),
+		defaults.Default(string(net.ServiceSubnet6), svc6),
+	}
+}
+
+// ProxyEnv returns environment variables that configure the proxy. Each
+// variable is set both in upper and lower case, since tools differ in
+// which one they respect. Nil is returned if the proxy is not set.
+func (c Config) ProxyEnv() map[string]string {
+	if c.Proxy.IsEmpty() {
+		return nil
+	}
+
+	env := make(map[string]string)
+
+	set := func(key string, value string) {
+		if value != "" {
+			env[strings.ToUpper(key)] = value
+			env[strings.ToLower(key)] = value
+		}
+	}
+
+	set("HTTP_PROXY", string(c.Proxy.HTTP))
+	set("HTTPS_PROXY", string(c.Proxy.HTTPS))
+	set("NO_PROXY", strings.Join(c.NoProxy(), ","))
+
+	return env
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy(t *testing.T) {
+	assert.NoError(t, Proxy{}.Validate())
+	assert.NoError(t, Proxy{HTTP: "http://proxy.example.com:3128"}.Validate())
+	assert.NoError(t, Proxy{HTTPS: "http://proxy.example.com:3128", NoProxy: []string{".example.com"}}.Validate())
+}
+
+func TestProxy_Invalid(t *testing.T) {
+	assert.ErrorContains(t, Proxy{HTTP: "proxy"}.Validate(), "Field 'http' must be a valid URL.")
+	assert.ErrorContains(t, Proxy{NoProxy: []string{".example.com"}}.Validate(), "Field 'noProxy' can only be set when either 'http' or 'https' proxy is set.")
+}
+
+func TestConfig_NoProxy(t *testing.T) {
+	cfg := MockConfig(t)
+	cfg.Kubernetes.Network.PodSubnet = "10.10.0.0/16"
+	cfg.Proxy.NoProxy = []string{".example.com", "localhost"}
+
+	expect := []string{
+		"localhost",
+		"127.0.0.1",
+		"192.168.113.0/24",
+		"10.10.0.0/16",
+		"10.233.0.0/18",
+		".example.com",
+	}
+
+	assert.Equal(t, expect, cfg.NoProxy())
+}
+
+func TestConfig_NoProxy_ManagerDefaults(t *testing.T) {
+	cfg := MockConfig(t)
+
+	cfg.Kubernetes.Manager = ManagerKubeadm
+	assert.Equal(t, []string{"localhost", "127.0.0.1", "192.168.113.0/24", "10.244.0.0/16", "10.96.0.0/12"}, cfg.NoProxy())
+
+	cfg.Kubernetes.Manager = ManagerRke2
+	cfg.Cluster.Network.CIDR6 = "2001:db8::/64"
+	assert.Equal(t, []string{
+		"localhost",
+		"127.0.0.1",
+		"192.168.113.0/24",
+		"2001:db8::/64",
+		"10.42.0.0/16",
+		"fd00:10:42::/56",
+		"10.43.0.0/16",
+		"fd00:10:43::/112",
+	}, cfg.NoProxy())
+
+	// External managers have no known defaults.
+	cfg.Kubernetes.Manager = "plugin"
+	cfg.Cluster.Network.CIDR6 = ""
+	assert.Equal(t, []string{"localhost", "127.0.0.1", "192.168.113.0/24"}, cfg.NoProxy())
+}
+
+func TestConfig_ProxyEnv(t *testing.T) {
+	cfg := MockConfig(t)
+	assert.Nil(t, cfg.ProxyEnv())
+
+	cfg.Proxy.HTTP = "http://proxy.example.com:3128"
+
+	expect := map[string]string{
+		"HTTP_PROXY": "http://proxy.example.com:3128",
+		"http_proxy": "http://proxy.example.com:3128",
+		"NO_PROXY":   "localhost,127.0.0.1,192.168.113.0/24,10.233.64.0/18,10.233.0.0/18",
+		"no_proxy":   "localhost,127.0.0.1,192.168.113.0/24,10.233.64.0/18,10.233.0.0/18",
+	}
+
+	assert.Equal(t, expect, cfg.ProxyEnv())
+}
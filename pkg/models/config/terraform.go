@@ -0,0 +1,33 @@
+package config
+
+import (
+	v "github.com/MusicDin/kubitect/pkg/utils/validation"
+)
+
+// Terraform configures the binary used by the Terraform provisioner.
+type Terraform struct {
+	Binary TerraformBinary `yaml:"binary,omitempty"`
+}
+
+func (t Terraform) Validate() error {
+	return v.Struct(&t,
+		v.Field(&t.Binary, v.OmitEmpty()),
+	)
+}
+
+// TerraformBinary is a binary that applies generated Terraform
+// configuration.
+type TerraformBinary string
+
+const (
+	// TerraformBinaryTerraform is HashiCorp Terraform.
+	TerraformBinaryTerraform TerraformBinary = "terraform"
+
+	// TerraformBinaryOpenTofu is OpenTofu, an open source fork of
+	// Terraform.
+	TerraformBinaryOpenTofu TerraformBinary = "opentofu"
+)
+
+func (b TerraformBinary) Validate() error {
+	return v.Var(b, v.OneOf(TerraformBinaryTerraform, TerraformBinaryOpenTofu))
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerraformBinary(t *testing.T) {
+	assert.Error(t, TerraformBinary("").Validate())
+	assert.Error(t, TerraformBinary("tofu").Validate())
+	assert.NoError(t, TerraformBinaryTerraform.Validate())
+	assert.NoError(t, TerraformBinaryOpenTofu.Validate())
+}
+
+func TestTerraform(t *testing.T) {
+	assert.NoError(t, Terraform{}.Validate())
+	assert.NoError(t, Terraform{Binary: TerraformBinaryOpenTofu}.Validate())
+	assert.ErrorContains(t, Terraform{Binary: "wrong"}.Validate(), "Field 'binary' must be one of the following values: [terraform|opentofu]")
+}
@@ -3,10 +3,15 @@ package ansible
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/MusicDin/kubitect/embed"
 	"github.com/MusicDin/kubitect/pkg/ui"
 
 	"github.com/apenella/go-ansible/pkg/execute"
@@ -14,6 +19,14 @@ import (
 	"github.com/apenella/go-ansible/pkg/playbook"
 )
 
+// Name of the stdout callback plugin that writes playbook events as
+// JSON lines.
+const callbackName = "kubitect"
+
+// maxLogs is the maximum number of playbook logs kept in the cache
+// directory. The oldest logs are removed first.
+const maxLogs = 50
+
 type Playbook struct {
 	Inventory  string
 	Tags       []string
@@ -88,10 +101,43 @@ func (a *ansible) Exec(pb Playbook) error {
 		ui.Printf(ui.WARN, "%s=%s\n", k, v)
 	}
 
+	name := filepath.Base(pb.Path)
+
+	pluginDir, err := writeCallbackPlugin()
+	if err != nil {
+		return fmt.Errorf("ansible-playbook (%s): %v", name, err)
+	}
+	defer os.RemoveAll(pluginDir)
+
+	// Raw output is logged only when the cache directory is set.
+	var log io.Writer = io.Discard
+	var logPath string
+
+	if a.cacheDir != "" {
+		f, err := a.createLog(name)
+		if err != nil {
+			return fmt.Errorf("ansible-playbook (%s): %v", name, err)
+		}
+		defer f.Close()
+
+		log = f
+		logPath = f.Name()
+
+		ui.Printf(ui.DEBUG, "Writing ansible-playbook (%s) log to: %s\n", name, logPath)
+	}
+
+	progress := newProgress(log)
+
 	executor := &execute.DefaultExecute{
 		CmdRunDir:   filepath.Dir(pb.Path),
-		Write:       ui.Streams().Out().File(),
-		WriterError: ui.Streams().Err().File(),
+		Write:       progress.Stdout(),
+		WriterError: progress.Stderr(),
+		EnvVars: map[string]string{
+			"ANSIBLE_CALLBACK_PLUGINS": pluginDir,
+			// Set on the command, because go-ansible overwrites the
+			// stdout callback in the environment of the current process.
+			"ANSIBLE_STDOUT_CALLBACK": callbackName,
+		},
 	}
 
 	if pb.WorkingDir != "" {
@@ -105,14 +151,6 @@ func (a *ansible) Exec(pb Playbook) error {
 		Options:                    playbookOptions,
 		ConnectionOptions:          connectionOptions,
 		PrivilegeEscalationOptions: privilegeEscalationOptions,
-		StdoutCallback:             "yaml",
-	}
-
-	if !ui.HasColor() {
-		options.AnsibleSetEnv("ANSIBLE_NO_COLOR", "true")
-	} else {
-		// options.AnsibleForceColor()
-		options.AnsibleSetEnv("ANSIBLE_FORCE_COLOR", "true")
 	}
 
 	if ui.Debug() {
@@ -122,20 +160,99 @@ func (a *ansible) Exec(pb Playbook) error {
 		options.AnsibleSetEnv("ANSIBLE_INVENTORY_UNPARSED_WARNING", "false")
 	}
 
+	options.AnsibleSetEnv("ANSIBLE_NO_COLOR", "true")
 	options.AnsibleSetEnv("ANSIBLE_CACHE_PLUGIN", "jsonfile")
 	options.AnsibleSetEnv("ANSIBLE_CACHE_PLUGIN_CONNECTION", a.cacheDir)
 	options.AnsibleSetEnv("ANSIBLE_CACHE_PLUGIN_TIMEOUT", "86400")
-
-	options.AnsibleSetEnv("ANSIBLE_CALLBACKS_ENABLED", "yaml")
 	options.AnsibleSetEnv("ANSIBLE_HOST_PATTERN_MISMATCH", "ignore")
-	options.AnsibleSetEnv("ANSIBLE_DISPLAY_FAILED_STDERR", "true")
-	options.AnsibleSetEnv("ANSIBLE_DISPLAY_SKIPPED_HOSTS", "false")
-	options.AnsibleSetEnv("ANSIBLE_STDOUT_CALLBACK", "yaml")
-	options.AnsibleSetEnv("ANSIBLE_STDERR_CALLBACK", "yaml")
 
-	err := playbook.Run(context.TODO())
+	progress.Start()
+	err = playbook.Run(context.TODO())
+	progress.Stop()
+
+	if err == nil {
+		return nil
+	}
+
+	failures := progress.Failures()
+	if len(failures) == 0 {
+		if out := progress.Output(); len(out) > 0 {
+			ui.PrintBlockE(newPlaybookOutputError(name, out, logPath))
+		}
+
+		return fmt.Errorf("ansible-playbook (%s): %v", name, err)
+	}
+
+	for _, f := range failures {
+		ui.PrintBlockE(newTaskFailureError(f, logPath))
+	}
+
+	if logPath == "" {
+		return fmt.Errorf("ansible-playbook (%s): %d task(s) failed", name, len(failures))
+	}
+
+	return fmt.Errorf("ansible-playbook (%s): %d task(s) failed (log: %s)", name, len(failures), logPath)
+}
+
+// writeCallbackPlugin writes the kubitect stdout callback plugin into
+// a temporary directory and returns the plugin's directory. The caller
+// is responsible for removing the directory.
+func writeCallbackPlugin() (string, error) {
+	res, err := embed.GetResource(path.Join("ansible", "callback_plugins", callbackName+".py"))
 	if err != nil {
-		return fmt.Errorf("ansible-playbook (%s): %v", filepath.Base(pb.Path), err)
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "kubitect-callback-*")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(filepath.Join(dir, res.Name), res.Content, 0600)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// createLog creates a file in the cache directory, where the raw output
+// of the playbook is written. Logs are rotated, so that at most maxLogs
+// logs are kept.
+func (a *ansible) createLog(name string) (*os.File, error) {
+	dir := filepath.Join(a.cacheDir, "logs")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if err := rotateLogs(dir, maxLogs-1); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = fmt.Sprintf("%s-%s.log", time.Now().Format("20060102-150405"), name)
+
+	return os.Create(filepath.Join(dir, name))
+}
+
+// rotateLogs removes the oldest logs in the given directory, so that at
+// most n logs remain. Log names start with a timestamp, therefore they
+// are sorted from the oldest to the newest.
+func rotateLogs(dir string, n int) error {
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(logs)
+
+	for len(logs) > n {
+		if err := os.Remove(logs[0]); err != nil {
+			return err
+		}
+
+		logs = logs[1:]
 	}
 
 	return nil
@@ -1,11 +1,16 @@
 package ansible
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/MusicDin/kubitect/pkg/ui"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAnsible_InvalidPath(t *testing.T) {
@@ -49,3 +54,125 @@ func TestAnsible_InvalidBinPath2(t *testing.T) {
 
 	assert.ErrorContains(t, a.Exec(pb), "ansible-playbook (pb.yaml): Binary file")
 }
+
+// mockPlaybookBin creates a fake ansible-playbook binary that writes the
+// given output and exits with the given code. The binary fails if the
+// callback plugin is not found.
+func mockPlaybookBin(t *testing.T, output string, code int) string {
+	t.Helper()
+
+	binDir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\ntest -f \"$ANSIBLE_CALLBACK_PLUGINS/kubitect.py\" || exit 3\ncat <<'EOF'\n%s\nEOF\nexit %d\n", output, code)
+
+	err := os.WriteFile(filepath.Join(binDir, "ansible-playbook"), []byte(script), 0700)
+	require.NoError(t, err)
+
+	return binDir
+}
+
+func TestAnsible_Exec(t *testing.T) {
+	m := ui.MockGlobalUi(t, ui.UiOptions{NoColor: true})
+
+	output := strings.Join([]string{
+		`{"event": "play_start", "play": "Test"}`,
+		`{"event": "task_start", "task": "Ping"}`,
+		`{"event": "runner_ok", "host": "node", "task": "Ping", "changed": true, "result": {}}`,
+		`{"event": "stats", "stats": {"node": {"ok": 1, "changed": 1}}}`,
+	}, "\n")
+
+	cacheDir := t.TempDir()
+	a := NewAnsible(mockPlaybookBin(t, output, 0), cacheDir)
+
+	pb := Playbook{
+		Path:  "pb.yaml",
+		Local: true,
+	}
+
+	require.NoError(t, a.Exec(pb))
+	assert.Equal(t, cacheDir, os.Getenv("ANSIBLE_CACHE_PLUGIN_CONNECTION"))
+
+	logs, err := filepath.Glob(filepath.Join(cacheDir, "logs", "*-pb.log"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	log, err := os.ReadFile(logs[0])
+	require.NoError(t, err)
+	assert.Equal(t, output+"\n", string(log))
+
+	stdout := m.ReadStdout(t)
+	assert.Contains(t, stdout, "PLAY Test")
+	assert.Contains(t, stdout, "TASK Ping")
+	assert.Contains(t, stdout, "node  ok=1    changed=1    failed=0    unreachable=0    skipped=0")
+}
+
+func TestAnsible_Exec_TaskFailure(t *testing.T) {
+	m := ui.MockGlobalUi(t, ui.UiOptions{NoColor: true})
+
+	output := strings.Join([]string{
+		`{"event": "task_start", "task": "Install packages"}`,
+		`{"event": "runner_failed", "host": "node", "task": "Install packages", "result": {"msg": "non-zero return code", "stderr": "package not found"}}`,
+	}, "\n")
+
+	a := NewAnsible(mockPlaybookBin(t, output, 2), t.TempDir())
+
+	pb := Playbook{
+		Path:  "pb.yaml",
+		Local: true,
+	}
+
+	assert.ErrorContains(t, a.Exec(pb), "ansible-playbook (pb.yaml): 1 task(s) failed")
+
+	stderr := m.ReadStderr(t)
+	assert.Contains(t, stderr, "Install packages")
+	assert.Contains(t, stderr, "non-zero return code")
+	assert.Contains(t, stderr, "package not found")
+}
+
+func TestAnsible_Exec_PlaybookFailure(t *testing.T) {
+	m := ui.MockGlobalUi(t, ui.UiOptions{NoColor: true})
+
+	a := NewAnsible(mockPlaybookBin(t, "ERROR! the playbook could not be found", 1), t.TempDir())
+
+	pb := Playbook{
+		Path:  "pb.yaml",
+		Local: true,
+	}
+
+	assert.ErrorContains(t, a.Exec(pb), "ansible-playbook (pb.yaml):")
+	assert.Contains(t, m.ReadStderr(t), "ERROR! the playbook could not be found")
+}
+
+func TestAnsible_Exec_NoCacheDir(t *testing.T) {
+	ui.MockGlobalUi(t, ui.UiOptions{NoColor: true})
+
+	output := `{"event": "runner_failed", "host": "node", "task": "Ping", "result": {"msg": "failed"}}`
+	a := NewAnsible(mockPlaybookBin(t, output, 2), "")
+
+	wd := t.TempDir()
+	pb := Playbook{
+		Path:       "pb.yaml",
+		Local:      true,
+		WorkingDir: wd,
+	}
+
+	// Log is not written without the cache directory.
+	assert.EqualError(t, a.Exec(pb), "ansible-playbook (pb.yaml): 1 task(s) failed")
+
+	entries, err := os.ReadDir(wd)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRotateLogs(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"20240102-000000-b.log", "20240101-000000-a.log", "20240103-000000-c.log", "other.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	require.NoError(t, rotateLogs(dir, 2))
+	assert.NoFileExists(t, filepath.Join(dir, "20240101-000000-a.log"))
+	assert.FileExists(t, filepath.Join(dir, "20240102-000000-b.log"))
+	assert.FileExists(t, filepath.Join(dir, "20240103-000000-c.log"))
+	assert.FileExists(t, filepath.Join(dir, "other.txt"))
+}
@@ -0,0 +1,51 @@
+package ansible
+
+import (
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/ui"
+)
+
+// maxOutputLines is the maximum number of playbook output lines shown
+// when the playbook fails before any task is run.
+const maxOutputLines = 20
+
+func newTaskFailureError(f taskFailure, logPath string) error {
+	content := []ui.Content{
+		ui.NewErrorLine("Error type:", "Ansible Task Failure"),
+		ui.NewErrorSection("Task:", f.Task),
+		ui.NewErrorSection("Host:", f.Host),
+	}
+
+	if f.Msg != "" {
+		content = append(content, ui.NewErrorSection("Error:", f.Msg))
+	}
+
+	if f.Stderr != "" {
+		content = append(content, ui.NewErrorSection("Stderr:", strings.Split(f.Stderr, "\n")...))
+	}
+
+	if logPath != "" {
+		content = append(content, ui.NewErrorSection("Log:", logPath))
+	}
+
+	return ui.NewErrorBlock(ui.ERROR, content)
+}
+
+func newPlaybookOutputError(playbook string, output []string, logPath string) error {
+	if len(output) > maxOutputLines {
+		output = output[len(output)-maxOutputLines:]
+	}
+
+	content := []ui.Content{
+		ui.NewErrorLine("Error type:", "Ansible Playbook Failure"),
+		ui.NewErrorSection("Playbook:", playbook),
+		ui.NewErrorSection("Output:", output...),
+	}
+
+	if logPath != "" {
+		content = append(content, ui.NewErrorSection("Log:", logPath))
+	}
+
+	return ui.NewErrorBlock(ui.ERROR, content)
+}
@@ -0,0 +1,431 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MusicDin/kubitect/pkg/ui"
+)
+
+// event is a playbook event written by the kubitect callback plugin.
+type event struct {
+	Event   string               `json:"event"`
+	Play    string               `json:"play"`
+	Task    string               `json:"task"`
+	Host    string               `json:"host"`
+	Changed bool                 `json:"changed"`
+	Ignored bool                 `json:"ignored"`
+	Result  map[string]any       `json:"result"`
+	Stats   map[string]hostStats `json:"stats"`
+}
+
+// hostStats is a final summary of the playbook run for a single host.
+type hostStats struct {
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Failures    int `json:"failures"`
+	Unreachable int `json:"unreachable"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
+}
+
+// hostCounters counts task results of a single host.
+type hostCounters struct {
+	ok      int
+	changed int
+	failed  int
+}
+
+// taskFailure describes a task that failed on a specific host.
+type taskFailure struct {
+	Task   string
+	Host   string
+	Msg    string
+	Stderr string
+}
+
+// progress parses playbook events as they are written and displays the
+// current play, task and per-host counters. Every received line is also
+// written to the log.
+type progress struct {
+	mu sync.Mutex
+
+	log      io.Writer
+	start    time.Time
+	task     string
+	hosts    []string
+	counters map[string]*hostCounters
+	failures []taskFailure
+	output   []string
+
+	// Number of status lines currently drawn on the terminal.
+	drawn    int
+	finished bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func newProgress(log io.Writer) *progress {
+	return &progress{
+		log:      log,
+		start:    time.Now(),
+		counters: make(map[string]*hostCounters),
+	}
+}
+
+// Stdout returns a writer for the playbook's standard output.
+func (p *progress) Stdout() io.Writer {
+	return &lineWriter{handle: p.handleStdout}
+}
+
+// Stderr returns a writer for the playbook's error output.
+func (p *progress) Stderr() io.Writer {
+	return &lineWriter{handle: p.handleStderr}
+}
+
+// Start periodically redraws the status, so that the elapsed time is
+// updated even when the task takes a while to complete. The status is
+// only drawn when the output stream is a terminal.
+func (p *progress) Start() {
+	if !isTerminal() {
+		return
+	}
+
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+
+	go func() {
+		defer close(p.stopped)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.redraw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing and clears the status from the terminal.
+func (p *progress) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.stopped
+		p.stop = nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finished = true
+	p.clear()
+}
+
+// Failures returns tasks that failed on any host. Failures of tasks with
+// ignored errors are not included.
+func (p *progress) Failures() []taskFailure {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.failures
+}
+
+// Output returns lines that were written by the playbook, but are not
+// playbook events, such as warnings and errors.
+func (p *progress) Output() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.output
+}
+
+func (p *progress) handleStdout(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(p.log, line)
+
+	var e event
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &e) != nil || e.Event == "" {
+		p.handleOutput(line)
+		return
+	}
+
+	p.handleEvent(e)
+}
+
+func (p *progress) handleStderr(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(p.log, line)
+	p.handleOutput(line)
+}
+
+// handleOutput keeps lines that are not events, so they can be shown if
+// the playbook fails before any task is run. In debug mode, the lines are
+// printed immediately.
+func (p *progress) handleOutput(line string) {
+	p.output = append(p.output, line)
+
+	if ui.Debug() {
+		p.println(ui.DEBUG, line)
+	}
+}
+
+func (p *progress) handleEvent(e event) {
+	switch e.Event {
+	case "play_start":
+		p.task = ""
+		p.println(ui.INFO, fmt.Sprintf("%s %s", p.elapsed(), colorize(ui.Colors.BLUE, "PLAY "+e.Play)))
+
+	case "task_start":
+		p.task = e.Task
+		if !isTerminal() {
+			p.println(ui.INFO, fmt.Sprintf("%s TASK %s", p.elapsed(), e.Task))
+		}
+
+	case "runner_ok":
+		c := p.host(e.Host)
+		c.ok++
+		if e.Changed {
+			c.changed++
+		}
+
+	case "runner_failed", "runner_unreachable":
+		if e.Ignored {
+			p.host(e.Host).ok++
+			break
+		}
+
+		p.host(e.Host).failed++
+		p.failures = append(p.failures, newTaskFailure(e))
+
+	case "stats":
+		p.finished = true
+		p.clear()
+		p.printRecap(e.Stats)
+		return
+	}
+
+	p.redraw()
+}
+
+// host returns counters of the given host. Hosts are kept in the order
+// in which they first appear.
+func (p *progress) host(name string) *hostCounters {
+	c, ok := p.counters[name]
+	if !ok {
+		c = &hostCounters{}
+		p.counters[name] = c
+		p.hosts = append(p.hosts, name)
+	}
+
+	return c
+}
+
+// printRecap prints the final per-host summary of the playbook run.
+func (p *progress) printRecap(stats map[string]hostStats) {
+	hosts := make([]string, 0, len(stats))
+	width := 0
+
+	for h := range stats {
+		hosts = append(hosts, h)
+		width = max(width, len(h))
+	}
+
+	sort.Strings(hosts)
+
+	ui.Printf(ui.INFO, "%s %s\n", p.elapsed(), colorize(ui.Colors.BLUE, "RECAP"))
+
+	for _, h := range hosts {
+		s := stats[h]
+
+		color := ui.Colors.GREEN
+		if s.Failures > 0 || s.Unreachable > 0 {
+			color = ui.Colors.RED
+		} else if s.Changed > 0 {
+			color = ui.Colors.YELLOW
+		}
+
+		ui.Printf(ui.INFO, "  %s  ok=%-4d changed=%-4d failed=%-4d unreachable=%-4d skipped=%d\n",
+			colorize(color, fmt.Sprintf("%-*s", width, h)),
+			s.Ok, s.Changed, s.Failures, s.Unreachable, s.Skipped,
+		)
+	}
+}
+
+// println prints the line above the status.
+func (p *progress) println(level ui.Level, line string) {
+	p.clear()
+	ui.Println(level, line)
+	p.redraw()
+}
+
+// statusLines returns the current task and per-host counters, trimmed
+// to the width of the terminal.
+func (p *progress) statusLines() []string {
+	if p.task == "" && len(p.hosts) == 0 {
+		return nil
+	}
+
+	width := max(ui.Streams().Out().Columns()-1, 20)
+
+	lines := []string{
+		fmt.Sprintf("%s TASK %s", p.elapsed(), p.task),
+	}
+
+	nameWidth := 0
+	for _, h := range p.hosts {
+		nameWidth = max(nameWidth, len(h))
+	}
+
+	for _, h := range p.hosts {
+		c := p.counters[h]
+		lines = append(lines, fmt.Sprintf("  %-*s  ok=%-4d changed=%-4d failed=%d", nameWidth, h, c.ok, c.changed, c.failed))
+	}
+
+	for i, l := range lines {
+		if r := []rune(l); len(r) > width {
+			lines[i] = string(r[:width])
+		}
+	}
+
+	return lines
+}
+
+// redraw replaces the drawn status with the current one.
+func (p *progress) redraw() {
+	if p.finished || !isTerminal() {
+		return
+	}
+
+	p.clear()
+
+	lines := p.statusLines()
+	for _, l := range lines {
+		ui.Println(ui.INFO, l)
+	}
+
+	p.drawn = len(lines)
+}
+
+// clear removes the drawn status from the terminal.
+func (p *progress) clear() {
+	if p.drawn == 0 {
+		return
+	}
+
+	// Move the cursor to the first status line and clear everything
+	// below it.
+	ui.Printf(ui.INFO, "\033[%dF\033[J", p.drawn)
+	p.drawn = 0
+}
+
+// elapsed returns time elapsed since the playbook has started.
+func (p *progress) elapsed() string {
+	d := time.Since(p.start).Round(time.Second)
+	return fmt.Sprintf("[%02d:%02d]", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// newTaskFailure extracts the error message and stderr from the failed
+// task result. For loops, the first failed item is used.
+func newTaskFailure(e event) taskFailure {
+	f := taskFailure{
+		Task:   e.Task,
+		Host:   e.Host,
+		Msg:    resultString(e.Result["msg"]),
+		Stderr: resultString(e.Result["stderr"]),
+	}
+
+	if f.Stderr == "" {
+		f.Stderr = resultString(e.Result["module_stderr"])
+	}
+
+	items, _ := e.Result["results"].([]any)
+	for _, i := range items {
+		item, ok := i.(map[string]any)
+		if !ok || item["failed"] != true {
+			continue
+		}
+
+		if m := resultString(item["msg"]); m != "" {
+			f.Msg = m
+		}
+
+		if s := resultString(item["stderr"]); s != "" {
+			f.Stderr = s
+		}
+
+		break
+	}
+
+	return f
+}
+
+// resultString converts the value of a task result to a string.
+func resultString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return string(b)
+	}
+}
+
+func isTerminal() bool {
+	return ui.Streams().Out().IsTerminal()
+}
+
+func colorize(c ui.Color, s string) string {
+	if !ui.HasColor() {
+		return s
+	}
+
+	return c(s)
+}
+
+// lineWriter splits written data into lines and passes each complete
+// line to the handle function. The remaining data is kept until the
+// line is completed.
+type lineWriter struct {
+	handle func(line string)
+	buf    []byte
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.handle(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(b), nil
+}
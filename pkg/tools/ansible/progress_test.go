@@ -0,0 +1,142 @@
+package ansible
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/ui"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+
+	w := &lineWriter{
+		handle: func(l string) { lines = append(lines, l) },
+	}
+
+	fmt.Fprint(w, "first\nsec")
+	fmt.Fprint(w, "ond\r\nthird")
+
+	assert.Equal(t, []string{"first", "second"}, lines)
+}
+
+func TestProgress_Counters(t *testing.T) {
+	ui.MockGlobalUi(t)
+
+	var log bytes.Buffer
+	p := newProgress(&log)
+
+	events := []string{
+		`{"event": "task_start", "task": "Task"}`,
+		`{"event": "runner_ok", "host": "node1", "changed": true}`,
+		`{"event": "runner_ok", "host": "node2"}`,
+		`{"event": "runner_failed", "host": "node1", "ignored": true, "result": {"msg": "ignored"}}`,
+		`{"event": "runner_failed", "host": "node2", "task": "Task", "result": {"msg": "failed"}}`,
+		`{"event": "runner_unreachable", "host": "node3", "task": "Task", "result": {"msg": "unreachable"}}`,
+		`not an event`,
+	}
+
+	for _, e := range events {
+		fmt.Fprintln(p.Stdout(), e)
+	}
+
+	assert.Equal(t, []string{"node1", "node2", "node3"}, p.hosts)
+	assert.Equal(t, hostCounters{ok: 2, changed: 1}, *p.counters["node1"])
+	assert.Equal(t, hostCounters{ok: 1, failed: 1}, *p.counters["node2"])
+	assert.Equal(t, hostCounters{failed: 1}, *p.counters["node3"])
+
+	expect := []taskFailure{
+		{Task: "Task", Host: "node2", Msg: "failed"},
+		{Task: "Task", Host: "node3", Msg: "unreachable"},
+	}
+
+	assert.Equal(t, expect, p.Failures())
+	assert.Equal(t, []string{"not an event"}, p.Output())
+	assert.Equal(t, len(events), bytes.Count(log.Bytes(), []byte("\n")))
+}
+
+func TestProgress_Stderr(t *testing.T) {
+	ui.MockGlobalUi(t)
+
+	var log bytes.Buffer
+	p := newProgress(&log)
+
+	fmt.Fprintln(p.Stderr(), "[WARNING]: warning")
+
+	assert.Equal(t, []string{"[WARNING]: warning"}, p.Output())
+	assert.Equal(t, "[WARNING]: warning\n", log.String())
+}
+
+func TestProgress_Terminal(t *testing.T) {
+	m := ui.MockGlobalTerminalUi(t, ui.UiOptions{NoColor: true})
+
+	var log bytes.Buffer
+	p := newProgress(&log)
+
+	fmt.Fprintln(p.Stdout(), `{"event": "task_start", "task": "Task"}`)
+	fmt.Fprintln(p.Stdout(), `{"event": "runner_ok", "host": "node", "changed": true}`)
+
+	assert.Equal(t, 2, p.drawn)
+
+	p.Stop()
+
+	out := m.ReadStdout(t)
+	assert.Contains(t, out, "[00:00] TASK Task\n  node  ok=1    changed=1    failed=0\n")
+	assert.True(t, strings.HasSuffix(out, "\033[2F\033[J"))
+	assert.Equal(t, 0, p.drawn)
+}
+
+func TestProgress_NonTerminal(t *testing.T) {
+	m := ui.MockGlobalUi(t, ui.UiOptions{NoColor: true})
+
+	var log bytes.Buffer
+	p := newProgress(&log)
+
+	fmt.Fprintln(p.Stdout(), `{"event": "play_start", "play": "Play"}`)
+	fmt.Fprintln(p.Stdout(), `{"event": "task_start", "task": "Task"}`)
+	fmt.Fprintln(p.Stdout(), `{"event": "runner_ok", "host": "node"}`)
+
+	assert.Equal(t, "[00:00] PLAY Play\n[00:00] TASK Task\n", m.ReadStdout(t))
+	assert.Equal(t, 0, p.drawn)
+}
+
+func TestNewTaskFailure(t *testing.T) {
+	e := event{
+		Task: "Task",
+		Host: "node",
+		Result: map[string]any{
+			"msg":           "MODULE FAILURE",
+			"module_stderr": "Traceback",
+		},
+	}
+
+	expect := taskFailure{Task: "Task", Host: "node", Msg: "MODULE FAILURE", Stderr: "Traceback"}
+	assert.Equal(t, expect, newTaskFailure(e))
+}
+
+func TestNewTaskFailure_Loop(t *testing.T) {
+	e := event{
+		Task: "Task",
+		Host: "node",
+		Result: map[string]any{
+			"msg": "One or more items failed",
+			"results": []any{
+				map[string]any{"failed": false, "msg": "ok"},
+				map[string]any{"failed": true, "msg": "non-zero return code", "stderr": "error\n"},
+			},
+		},
+	}
+
+	expect := taskFailure{Task: "Task", Host: "node", Msg: "non-zero return code", Stderr: "error"}
+	assert.Equal(t, expect, newTaskFailure(e))
+}
+
+func TestResultString(t *testing.T) {
+	assert.Equal(t, "", resultString(nil))
+	assert.Equal(t, "msg", resultString(" msg\n"))
+	assert.Equal(t, `["a","b"]`, resultString([]any{"a", "b"}))
+}
@@ -0,0 +1,218 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pack writes the content of the source directory into a gzip compressed
+// tar archive on the given path.
+func pack(srcDir string, archivePath string) error {
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+
+		defer src.Close()
+
+		_, err = io.Copy(tw, src)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+// extract extracts the gzip compressed tar archive into the destination
+// directory. Archive is first extracted into a temporary directory, so
+// that the destination directory never contains a partially extracted
+// archive.
+func extract(archivePath string, dstDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	defer gr.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dstDir), 0700); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dstDir), ".extract-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Prevent entries from escaping the destination directory.
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if !isLocal(name) {
+			return fmt.Errorf("invalid archive entry %q", hdr.Name)
+		}
+
+		// Prevent entries from being written through symlinks, which
+		// could point outside of the destination directory.
+		if err := checkSymlinks(tmpDir, name); err != nil {
+			return fmt.Errorf("invalid archive entry %q: %v", hdr.Name, err)
+		}
+
+		dst := filepath.Join(tmpDir, name)
+		mode := fs.FileMode(hdr.Mode).Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dst, mode|0700)
+		case tar.TypeSymlink:
+			// Symlinks must point to the paths within the destination
+			// directory.
+			link := filepath.FromSlash(hdr.Linkname)
+			if filepath.IsAbs(link) || !isLocal(filepath.Join(filepath.Dir(name), link)) {
+				return fmt.Errorf("invalid archive entry %q: symlink target %q is outside of the archive", hdr.Name, hdr.Linkname)
+			}
+
+			err = os.Symlink(hdr.Linkname, dst)
+		case tar.TypeReg:
+			err = writeFile(tr, dst, mode)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	os.RemoveAll(dstDir)
+	return os.Rename(tmpDir, dstDir)
+}
+
+// isLocal returns true if the given relative path does not escape the
+// directory it is relative to.
+func isLocal(path string) bool {
+	path = filepath.Clean(path)
+	return !filepath.IsAbs(path) && path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+// checkSymlinks returns an error if the given path relative to the root
+// directory or any of its parent directories is an existing symlink.
+func checkSymlinks(root string, path string) error {
+	var rel string
+	for _, elem := range strings.Split(path, string(filepath.Separator)) {
+		rel = filepath.Join(rel, elem)
+
+		info, err := os.Lstat(filepath.Join(root, rel))
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("path %q is a symlink", filepath.ToSlash(rel))
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes the content of the reader into a file on the given path.
+func writeFile(r io.Reader, path string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,172 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+const manifestFile = "bundle.yaml"
+
+var ErrNotBundled = errors.New("not included in the bundle")
+
+// Manifest describes external dependencies contained in a bundle. Paths
+// are relative to the bundle directory.
+type Manifest struct {
+	// Version of Kubitect that created the bundle.
+	Version string `yaml:"version"`
+
+	Repositories []Repository   `yaml:"repositories,omitempty"`
+	Requirements []Requirements `yaml:"requirements,omitempty"`
+	Binaries     []Binary       `yaml:"binaries,omitempty"`
+
+	// Directory containing a mirror of Terraform providers.
+	Providers string `yaml:"providers,omitempty"`
+}
+
+// Repository is a checked out Git repository.
+type Repository struct {
+	URL  string `yaml:"url"`
+	Ref  string `yaml:"ref"`
+	Path string `yaml:"path"`
+}
+
+// Requirements is a directory of Python packages that satisfy the pip
+// requirements file with the given checksum.
+type Requirements struct {
+	Checksum string `yaml:"checksum"`
+	Path     string `yaml:"path"`
+}
+
+// Binary is an executable with a specific version.
+type Binary struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Path    string `yaml:"path"`
+}
+
+// Bundler is implemented by components that depend on external resources,
+// so that they can add them into a bundle.
+type Bundler interface {
+	Bundle(w *Writer) error
+}
+
+// Bundle is an extracted archive of external dependencies that allows
+// a cluster to be applied without network access.
+type Bundle struct {
+	dir      string
+	manifest Manifest
+}
+
+// Open extracts the bundle archive into a subdirectory of the given
+// directory and returns the bundle. Archive is extracted only once, as
+// the subdirectory is named after the archive checksum.
+func Open(archivePath string, dir string) (*Bundle, error) {
+	sum, err := checksum(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %v", err)
+	}
+
+	b := &Bundle{dir: filepath.Join(dir, sum[:16])}
+
+	if !file.Exists(filepath.Join(b.dir, manifestFile)) {
+		if err := extract(archivePath, b.dir); err != nil {
+			return nil, fmt.Errorf("open bundle: %v", err)
+		}
+	}
+
+	m, err := file.ReadYaml(filepath.Join(b.dir, manifestFile), Manifest{})
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: read manifest: %v", err)
+	}
+
+	b.manifest = *m
+	return b, nil
+}
+
+// Manifest returns the manifest of the bundle.
+func (b *Bundle) Manifest() Manifest {
+	return b.manifest
+}
+
+// Repository returns the path of the repository with the given URL and
+// reference.
+func (b *Bundle) Repository(url string, ref string) (string, error) {
+	for _, r := range b.manifest.Repositories {
+		if r.URL == url && r.Ref == ref {
+			return b.path(r.Path), nil
+		}
+	}
+
+	return "", fmt.Errorf("repository %q (%s) is %w", url, ref, ErrNotBundled)
+}
+
+// Requirements returns the directory of Python packages required by the
+// given pip requirements file.
+func (b *Bundle) Requirements(reqPath string) (string, error) {
+	sum, err := checksum(reqPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range b.manifest.Requirements {
+		if r.Checksum == sum {
+			return b.path(r.Path), nil
+		}
+	}
+
+	return "", fmt.Errorf("pip requirements %q are %w", reqPath, ErrNotBundled)
+}
+
+// Binary returns the path of the binary with the given name and version.
+func (b *Bundle) Binary(name string, version string) (string, error) {
+	for _, bin := range b.manifest.Binaries {
+		if bin.Name == name && bin.Version == version {
+			return b.path(bin.Path), nil
+		}
+	}
+
+	return "", fmt.Errorf("binary %s %s is %w", name, version, ErrNotBundled)
+}
+
+// Providers returns the directory containing a mirror of Terraform
+// providers.
+func (b *Bundle) Providers() (string, error) {
+	if b.manifest.Providers == "" {
+		return "", fmt.Errorf("Terraform providers are %w", ErrNotBundled)
+	}
+
+	return b.path(b.manifest.Providers), nil
+}
+
+// ImageDir returns the directory of the OS image cache within the bundle.
+func (b *Bundle) ImageDir() string {
+	return b.path("images")
+}
+
+func (b *Bundle) path(rel string) string {
+	return filepath.Join(b.dir, rel)
+}
+
+// checksum returns the SHA256 checksum of the file on the given path.
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
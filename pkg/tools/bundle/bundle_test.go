@@ -0,0 +1,238 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockFile(t *testing.T, path string, content string, mode os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), mode))
+}
+
+// mockArchive writes a gzip compressed tar archive with the given
+// entries. Regular files contain their own name.
+func mockArchive(t *testing.T, path string, entries ...tar.Header) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	for _, hdr := range entries {
+		hdr.Mode = 0644
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+
+		require.NoError(t, tw.WriteHeader(&hdr))
+
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(hdr.Name))
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+}
+
+func MockBundle(t *testing.T) (string, string) {
+	t.Helper()
+
+	tmp := t.TempDir()
+	reqPath := filepath.Join(tmp, "requirements.txt")
+	binPath := filepath.Join(tmp, "terraform")
+
+	mockFile(t, reqPath, "ansible==2.0.0", 0644)
+	mockFile(t, binPath, "#!/bin/sh", 0755)
+
+	w := NewWriter(filepath.Join(tmp, "writer"))
+
+	err := w.AddRepository("https://example.com/repo", "v1.0.0", func(dst string) error {
+		mockFile(t, filepath.Join(dst, "README.md"), "repo", 0644)
+		return os.Symlink("README.md", filepath.Join(dst, "README"))
+	})
+	require.NoError(t, err)
+
+	err = w.AddRequirements(reqPath, func(dst string) error {
+		mockFile(t, filepath.Join(dst, "ansible-2.0.0.tar.gz"), "pkg", 0644)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = w.AddProviders(func(dst string) error {
+		mockFile(t, filepath.Join(dst, "registry.terraform.io", "index.json"), "{}", 0644)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, w.AddBinary("terraform", "1.5.2", binPath))
+
+	archive := filepath.Join(tmp, "bundle.tar.gz")
+	require.NoError(t, w.Write(archive))
+
+	return archive, reqPath
+}
+
+func TestBundle(t *testing.T) {
+	archive, reqPath := MockBundle(t)
+
+	b, err := Open(archive, t.TempDir())
+	require.NoError(t, err)
+
+	repo, err := b.Repository("https://example.com/repo", "v1.0.0")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(repo, "README.md"))
+
+	link, err := os.Readlink(filepath.Join(repo, "README"))
+	require.NoError(t, err)
+	assert.Equal(t, "README.md", link)
+
+	req, err := b.Requirements(reqPath)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(req, "ansible-2.0.0.tar.gz"))
+
+	bin, err := b.Binary("terraform", "1.5.2")
+	require.NoError(t, err)
+
+	info, err := os.Stat(bin)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	providers, err := b.Providers()
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(providers, "registry.terraform.io", "index.json"))
+}
+
+func TestBundle_NotBundled(t *testing.T) {
+	archive, _ := MockBundle(t)
+
+	b, err := Open(archive, t.TempDir())
+	require.NoError(t, err)
+
+	reqPath := filepath.Join(t.TempDir(), "requirements.txt")
+	mockFile(t, reqPath, "ansible==3.0.0", 0644)
+
+	_, err = b.Repository("https://example.com/repo", "v2.0.0")
+	assert.ErrorIs(t, err, ErrNotBundled)
+
+	_, err = b.Requirements(reqPath)
+	assert.ErrorIs(t, err, ErrNotBundled)
+
+	_, err = b.Binary("tofu", "1.5.2")
+	assert.ErrorIs(t, err, ErrNotBundled)
+}
+
+func TestOpen_Reuse(t *testing.T) {
+	archive, _ := MockBundle(t)
+	dir := t.TempDir()
+
+	b1, err := Open(archive, dir)
+	require.NoError(t, err)
+
+	b2, err := Open(archive, dir)
+	require.NoError(t, err)
+	assert.Equal(t, b1.dir, b2.dir)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestOpen_InvalidArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	mockFile(t, archive, "invalid archive content", 0644)
+
+	_, err := Open(archive, t.TempDir())
+	assert.ErrorContains(t, err, "open bundle: gzip: invalid header")
+}
+
+func TestOpen_MissingArchive(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "bundle.tar.gz"), t.TempDir())
+	assert.ErrorContains(t, err, "no such file or directory")
+}
+
+func TestExtract_Malicious(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tar.Header
+		err     string
+	}{
+		{
+			name:    "PathTraversal",
+			entries: []tar.Header{{Name: "../evil", Typeflag: tar.TypeReg}},
+			err:     `invalid archive entry "../evil"`,
+		},
+		{
+			name:    "AbsoluteSymlink",
+			entries: []tar.Header{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}},
+			err:     `invalid archive entry "link": symlink target "/etc" is outside of the archive`,
+		},
+		{
+			name:    "EscapingSymlink",
+			entries: []tar.Header{{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}},
+			err:     `invalid archive entry "dir/link": symlink target "../../etc" is outside of the archive`,
+		},
+		{
+			name: "WriteThroughSymlinkDir",
+			entries: []tar.Header{
+				{Name: "dir", Typeflag: tar.TypeDir},
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "dir"},
+				{Name: "link/evil", Typeflag: tar.TypeReg},
+			},
+			err: `invalid archive entry "link/evil": path "link" is a symlink`,
+		},
+		{
+			name: "OverwriteSymlink",
+			entries: []tar.Header{
+				{Name: "file", Typeflag: tar.TypeReg},
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "file"},
+				{Name: "link", Typeflag: tar.TypeReg},
+			},
+			err: `invalid archive entry "link": path "link" is a symlink`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			archive := filepath.Join(tmp, "bundle.tar.gz")
+			dst := filepath.Join(tmp, "bundle")
+
+			mockArchive(t, archive, test.entries...)
+
+			assert.EqualError(t, extract(archive, dst), test.err)
+			assert.NoDirExists(t, dst)
+			assert.NoFileExists(t, filepath.Join(tmp, "evil"))
+		})
+	}
+}
+
+func TestExtract_LocalSymlink(t *testing.T) {
+	tmp := t.TempDir()
+	archive := filepath.Join(tmp, "bundle.tar.gz")
+	dst := filepath.Join(tmp, "bundle")
+
+	mockArchive(t, archive,
+		tar.Header{Name: "dir/file", Typeflag: tar.TypeReg},
+		tar.Header{Name: "dir/sub", Typeflag: tar.TypeDir},
+		tar.Header{Name: "dir/sub/link", Typeflag: tar.TypeSymlink, Linkname: "../file"},
+	)
+
+	require.NoError(t, extract(archive, dst))
+
+	data, err := os.ReadFile(filepath.Join(dst, "dir", "sub", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "dir/file", string(data))
+}
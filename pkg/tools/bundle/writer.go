@@ -0,0 +1,165 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+// Writer collects external dependencies into a directory and packs them
+// into a bundle archive.
+type Writer struct {
+	dir      string
+	manifest Manifest
+}
+
+// NewWriter returns a bundle writer that collects dependencies into the
+// given directory.
+func NewWriter(dir string) *Writer {
+	return &Writer{
+		dir: dir,
+		manifest: Manifest{
+			Version: env.ConstProjectVersion,
+		},
+	}
+}
+
+// AddRepository adds a Git repository with the given URL and reference
+// into the bundle. The clone function is expected to clone the repository
+// into the provided directory.
+func (w *Writer) AddRepository(url string, ref string, clone func(dst string) error) error {
+	for _, r := range w.manifest.Repositories {
+		if r.URL == url && r.Ref == ref {
+			return nil
+		}
+	}
+
+	rel := filepath.Join("git", hash(url+"@"+ref))
+	if err := os.MkdirAll(w.path(rel), 0700); err != nil {
+		return err
+	}
+
+	if err := clone(w.path(rel)); err != nil {
+		return fmt.Errorf("bundle repository %q: %v", url, err)
+	}
+
+	w.manifest.Repositories = append(w.manifest.Repositories, Repository{
+		URL:  url,
+		Ref:  ref,
+		Path: rel,
+	})
+
+	return nil
+}
+
+// AddRequirements adds Python packages for the given pip requirements
+// file into the bundle. The download function is expected to download
+// packages into the provided directory.
+func (w *Writer) AddRequirements(reqPath string, download func(dst string) error) error {
+	sum, err := checksum(reqPath)
+	if err != nil {
+		return fmt.Errorf("bundle pip requirements: %v", err)
+	}
+
+	for _, r := range w.manifest.Requirements {
+		if r.Checksum == sum {
+			return nil
+		}
+	}
+
+	rel := filepath.Join("pip", sum[:16])
+	if err := os.MkdirAll(w.path(rel), 0700); err != nil {
+		return err
+	}
+
+	if err := download(w.path(rel)); err != nil {
+		return fmt.Errorf("bundle pip requirements %q: %v", reqPath, err)
+	}
+
+	w.manifest.Requirements = append(w.manifest.Requirements, Requirements{
+		Checksum: sum,
+		Path:     rel,
+	})
+
+	return nil
+}
+
+// AddBinary copies the binary on the given path into the bundle.
+func (w *Writer) AddBinary(name string, version string, srcPath string) error {
+	for _, b := range w.manifest.Binaries {
+		if b.Name == name && b.Version == version {
+			return nil
+		}
+	}
+
+	rel := filepath.Join("bin", name, version, filepath.Base(srcPath))
+	if err := file.ForceCopy(srcPath, w.path(rel), 0755); err != nil {
+		return fmt.Errorf("bundle binary %s: %v", name, err)
+	}
+
+	w.manifest.Binaries = append(w.manifest.Binaries, Binary{
+		Name:    name,
+		Version: version,
+		Path:    rel,
+	})
+
+	return nil
+}
+
+// AddProviders adds a mirror of Terraform providers into the bundle. The
+// mirror function is expected to mirror providers into the provided
+// directory.
+func (w *Writer) AddProviders(mirror func(dst string) error) error {
+	rel := filepath.Join("terraform", "providers")
+	if err := os.MkdirAll(w.path(rel), 0700); err != nil {
+		return err
+	}
+
+	if err := mirror(w.path(rel)); err != nil {
+		return fmt.Errorf("bundle Terraform providers: %v", err)
+	}
+
+	w.manifest.Providers = rel
+	return nil
+}
+
+// ImageDir returns the directory of the OS image cache within the bundle.
+// Images are added by ensuring them in an image cache rooted in this
+// directory.
+func (w *Writer) ImageDir() string {
+	return w.path("images")
+}
+
+// Write writes the manifest and packs the collected dependencies into
+// an archive on the given path.
+func (w *Writer) Write(archivePath string) error {
+	if err := os.MkdirAll(w.dir, 0700); err != nil {
+		return err
+	}
+
+	err := file.WriteYaml(w.manifest, w.path(manifestFile), 0644)
+	if err != nil {
+		return fmt.Errorf("write bundle manifest: %v", err)
+	}
+
+	if err := pack(w.dir, archivePath); err != nil {
+		return fmt.Errorf("write bundle: %v", err)
+	}
+
+	return nil
+}
+
+func (w *Writer) path(rel string) string {
+	return filepath.Join(w.dir, rel)
+}
+
+// hash returns a short SHA256 hash of the given string.
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:16]
+}
@@ -7,7 +7,9 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -28,6 +30,7 @@ type GitRepo struct {
 	url        string
 	version    string
 	commitHash string
+	bundle     *bundle.Bundle
 }
 
 // NewGitRepo returns new instance of Git repository linked to the
@@ -52,10 +55,27 @@ func (r GitRepo) WithCommitHash(commitHash string) GitRepo {
 	return r
 }
 
+// WithBundle sets the bundle from which the repository is copied instead
+// of being cloned.
+func (r GitRepo) WithBundle(b *bundle.Bundle) GitRepo {
+	r.bundle = b
+	return r
+}
+
 func (p GitRepo) Url() string {
 	return p.url
 }
 
+// Ref returns the commit hash if set, otherwise the branch or tag used
+// when cloning the repository.
+func (p GitRepo) Ref() string {
+	if p.commitHash != "" {
+		return p.commitHash
+	}
+
+	return p.version
+}
+
 // Clone clones a git project with the given URL and version into
 // a specific directory.
 func (g GitRepo) Clone(dstPath string) error {
@@ -63,6 +83,15 @@ func (g GitRepo) Clone(dstPath string) error {
 		return ErrInvalidRepositoryURL
 	}
 
+	if g.bundle != nil {
+		src, err := g.bundle.Repository(g.url, g.Ref())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCloneFailed, err)
+		}
+
+		return file.CopyDir(src, dstPath)
+	}
+
 	opts := &git.CloneOptions{
 		URL:               g.url,
 		Tags:              git.NoTags,
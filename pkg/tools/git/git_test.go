@@ -1,9 +1,12 @@
 package git
 
 import (
+	"os"
+	"path"
 	"testing"
 
 	"github.com/MusicDin/kubitect/pkg/env"
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,3 +53,25 @@ func TestClone_InvalidDestination(t *testing.T) {
 	repo := NewGitRepo(env.ConstProjectUrl).WithRef("master")
 	assert.ErrorContains(t, repo.Clone(""), ": no such file or directory")
 }
+
+func TestClone_Bundle(t *testing.T) {
+	tmp := t.TempDir()
+
+	w := bundle.NewWriter(path.Join(tmp, "bundle"))
+	err := w.AddRepository(env.ConstProjectUrl, "main", func(dst string) error {
+		return os.WriteFile(path.Join(dst, "README.md"), []byte("test"), 0644)
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(path.Join(tmp, "bundle.tar.gz")))
+
+	b, err := bundle.Open(path.Join(tmp, "bundle.tar.gz"), path.Join(tmp, "bundles"))
+	require.NoError(t, err)
+
+	dst := t.TempDir()
+	repo := NewGitRepo(env.ConstProjectUrl).WithRef("main").WithBundle(b)
+	require.NoError(t, repo.Clone(dst))
+	assert.FileExists(t, path.Join(dst, "README.md"))
+
+	repo = NewGitRepo(env.ConstProjectUrl).WithRef("v2.0.0").WithBundle(b)
+	assert.ErrorIs(t, repo.Clone(t.TempDir()), ErrCloneFailed)
+}
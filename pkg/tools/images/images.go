@@ -0,0 +1,494 @@
+package images
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MusicDin/kubitect/pkg/ui"
+	"github.com/MusicDin/kubitect/pkg/utils/file"
+)
+
+const metadataFile = "image.yaml"
+
+// checksumFiles are names of the files in which image checksums are
+// commonly published next to the images.
+var checksumFiles = []string{
+	"SHA256SUMS",
+	"SHA256SUM",
+	"CHECKSUM",
+}
+
+// Image is an OS image stored in the image cache.
+type Image struct {
+	// Source from which the image has been obtained.
+	Source string `yaml:"source"`
+
+	// SHA256 checksum of the image.
+	Checksum string `yaml:"checksum,omitempty"`
+
+	// SHA512 checksum of the image.
+	ChecksumSHA512 string `yaml:"checksumSha512,omitempty"`
+
+	// Size of the image in bytes.
+	Size int64 `yaml:"size"`
+
+	// Time of the image download.
+	Downloaded time.Time `yaml:"downloaded"`
+
+	// Local path of the image.
+	Path string `yaml:"-"`
+}
+
+// Cache is a directory of OS images that are shared among all clusters.
+// Each image is downloaded only once and verified against its SHA256 or
+// SHA512 checksum.
+type Cache struct {
+	dir    string
+	client *http.Client
+}
+
+// NewCache returns an image cache rooted in the given directory.
+func NewCache(dir string) Cache {
+	return Cache{
+		dir:    dir,
+		client: http.DefaultClient,
+	}
+}
+
+// IsRemote returns true if the given image source is a URL.
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Ensure returns an image for the given source. Remote images are
+// downloaded into the cache, unless a cached image with a matching
+// checksum already exists. If checksum is empty, the checksum is looked
+// up in the given checksum file or, if none is given, in the checksum
+// files published next to the image. If neither checksum is available,
+// the image is downloaded only when skipChecksum is set. Local images
+// are only verified.
+func (c Cache) Ensure(source string, checksum string, checksumFile string, skipChecksum bool) (*Image, error) {
+	checksum = strings.ToLower(checksum)
+
+	if checksum == "" && checksumFile != "" {
+		sum, err := c.fileChecksum(checksumFile, imageName(source))
+		if err != nil {
+			ui.Printf(ui.DEBUG, "Checksum of image %q not found: %v\n", source, err)
+		}
+
+		checksum = sum
+	}
+
+	if !IsRemote(source) {
+		return c.ensureLocal(source, checksum)
+	}
+
+	if checksum == "" && checksumFile == "" {
+		sum, err := c.publishedChecksum(source)
+		if err != nil {
+			ui.Printf(ui.DEBUG, "Published checksum of image %q not found: %v\n", source, err)
+		}
+
+		checksum = sum
+	}
+
+	img, err := c.read(c.imageDir(source))
+	if err == nil && (checksum == "" || img.matches(checksum)) {
+		return img, nil
+	}
+
+	if checksum == "" {
+		if !skipChecksum {
+			return nil, fmt.Errorf("checksum of image %q is neither configured nor published, therefore the image cannot be verified (configure the checksum or explicitly skip its verification)", source)
+		}
+
+		ui.Printf(ui.WARN, "Checksum of image %q is neither configured nor published. Downloaded image is not verified.\n", source)
+	}
+
+	return c.download(source, checksum)
+}
+
+// List returns all cached images sorted by their source.
+func (c Cache) List() ([]Image, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read image cache: %v", err)
+	}
+
+	var imgs []Image
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		img, err := c.read(filepath.Join(c.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+
+		imgs = append(imgs, *img)
+	}
+
+	sort.Slice(imgs, func(i, j int) bool {
+		return imgs[i].Source < imgs[j].Source
+	})
+
+	return imgs, nil
+}
+
+// Remove removes an image with the given source from the cache.
+func (c Cache) Remove(source string) error {
+	err := os.RemoveAll(c.imageDir(source))
+	if err != nil {
+		return fmt.Errorf("failed to remove image %q: %v", source, err)
+	}
+
+	return nil
+}
+
+// ensureLocal verifies the image on the local filesystem.
+func (c Cache) ensureLocal(source string, checksum string) (*Image, error) {
+	p := source
+
+	if strings.HasPrefix(p, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		p = strings.Replace(p, "~", home, 1)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("image %q not found: %v", source, err)
+	}
+
+	if checksum != "" {
+		sum, err := fileChecksum(p, checksumHash(checksum))
+		if err != nil {
+			return nil, err
+		}
+
+		if sum != checksum {
+			return nil, fmt.Errorf("checksum mismatch for image %q (expected: %s, actual: %s)", source, checksum, sum)
+		}
+	}
+
+	return &Image{
+		Source:   source,
+		Checksum: checksum,
+		Size:     info.Size(),
+		Path:     p,
+	}, nil
+}
+
+// download downloads the image into the cache and verifies its checksum.
+// The image is written to a temporary file first, so that the cache never
+// contains partially downloaded images.
+func (c Cache) download(source string, checksum string) (*Image, error) {
+	dir := c.imageDir(source)
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image cache directory: %v", err)
+	}
+
+	ui.Printf(ui.INFO, "Downloading image %q...\n", source)
+
+	res, err := c.client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image %q: %v", source, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image %q: %s", source, res.Status)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	hash256 := sha256.New()
+	hash512 := sha512.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash256, hash512), res.Body)
+	tmp.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image %q: %v", source, err)
+	}
+
+	img := &Image{
+		Source:         source,
+		Checksum:       hex.EncodeToString(hash256.Sum(nil)),
+		ChecksumSHA512: hex.EncodeToString(hash512.Sum(nil)),
+		Size:           size,
+		Downloaded:     time.Now().UTC().Truncate(time.Second),
+		Path:           filepath.Join(dir, imageName(source)),
+	}
+
+	if checksum != "" && !img.matches(checksum) {
+		sum := img.Checksum
+		if len(checksum) == sha512.Size*2 {
+			sum = img.ChecksumSHA512
+		}
+
+		return nil, fmt.Errorf("checksum mismatch for image %q (expected: %s, actual: %s)", source, checksum, sum)
+	}
+
+	err = os.Rename(tmp.Name(), img.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store image %q: %v", source, err)
+	}
+
+	err = file.WriteYaml(img, filepath.Join(dir, metadataFile), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store metadata of image %q: %v", source, err)
+	}
+
+	return img, nil
+}
+
+// read reads a cached image from the given directory.
+func (c Cache) read(dir string) (*Image, error) {
+	img, err := file.ReadYaml(filepath.Join(dir, metadataFile), Image{})
+	if err != nil {
+		return nil, err
+	}
+
+	img.Path = filepath.Join(dir, imageName(img.Source))
+
+	if !file.Exists(img.Path) {
+		return nil, fmt.Errorf("image %q is missing", img.Path)
+	}
+
+	return img, nil
+}
+
+// matches returns true if the given SHA256 or SHA512 checksum matches
+// the image.
+func (img Image) matches(checksum string) bool {
+	if len(checksum) == sha512.Size*2 {
+		return img.ChecksumSHA512 == checksum
+	}
+
+	return img.Checksum == checksum
+}
+
+// imageDir returns the cache directory of the image with the given source.
+func (c Cache) imageDir(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])[:16])
+}
+
+// publishedChecksum looks up the image checksum in the checksum files
+// published next to the image.
+func (c Cache) publishedChecksum(source string) (string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(u.Path)
+	urls := []string{source + ".sha256"}
+
+	for _, f := range checksumFiles {
+		cu := *u
+		cu.Path = path.Join(path.Dir(u.Path), f)
+		urls = append(urls, cu.String())
+	}
+
+	for _, cu := range urls {
+		res, err := c.client.Get(cu)
+		if err != nil {
+			continue
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			continue
+		}
+
+		sum, ok := parseChecksum(res.Body, name)
+		res.Body.Close()
+
+		if ok {
+			return sum, nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum file contains image %q", name)
+}
+
+// fileChecksum looks up the checksum of the image with the given name in
+// the checksum file with the given URL. Files that list the checksum of
+// a single image are accepted regardless of the listed image name, since
+// images with a rolling name (e.g. "latest") are listed under the name
+// of the actual release.
+func (c Cache) fileChecksum(fileUrl string, name string) (string, error) {
+	res, err := c.client.Get(fileUrl)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum file %q: %v", fileUrl, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download checksum file %q: %s", fileUrl, res.Status)
+	}
+
+	sums := parseChecksums(res.Body)
+
+	if sum, ok := sums[name]; ok {
+		return sum, nil
+	}
+
+	if sum, ok := sums[""]; ok {
+		return sum, nil
+	}
+
+	if len(sums) == 1 {
+		for _, sum := range sums {
+			return sum, nil
+		}
+	}
+
+	return "", fmt.Errorf("checksum file %q does not contain image %q", fileUrl, name)
+}
+
+// parseChecksum finds the checksum of the given file in checksum file
+// content. A line containing only a checksum is accepted as well.
+func parseChecksum(r io.Reader, name string) (string, bool) {
+	sums := parseChecksums(r)
+
+	if sum, ok := sums[name]; ok {
+		return sum, true
+	}
+
+	sum, ok := sums[""]
+	return sum, ok
+}
+
+// parseChecksums returns SHA256 and SHA512 checksums by file name from
+// checksum file content. Both the coreutils ("<sum> [*]<file>") and the
+// BSD ("SHA256 (<file>) = <sum>") formats are supported. A line
+// containing only a checksum is returned under an empty name.
+func parseChecksums(r io.Reader) map[string]string {
+	sums := make(map[string]string)
+	s := bufio.NewScanner(r)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		fields := strings.Fields(line)
+
+		var name, sum string
+
+		switch {
+		case len(fields) == 1:
+			sum = fields[0]
+		case len(fields) == 2:
+			name = strings.TrimPrefix(fields[1], "*")
+			sum = fields[0]
+		case len(fields) == 4 && strings.HasPrefix(fields[1], "(") && strings.HasSuffix(fields[1], ")") && fields[2] == "=":
+			name = strings.TrimSuffix(strings.TrimPrefix(fields[1], "("), ")")
+			sum = fields[3]
+
+			if checksumHash(strings.ToLower(sum)) != fields[0] {
+				continue
+			}
+		default:
+			continue
+		}
+
+		sum = strings.ToLower(sum)
+
+		if _, exists := sums[name]; !exists && isChecksum(sum) {
+			sums[name] = sum
+		}
+	}
+
+	return sums
+}
+
+// FileChecksum returns SHA256 checksum of the given file.
+func FileChecksum(path string) (string, error) {
+	return fileChecksum(path, "SHA256")
+}
+
+// fileChecksum returns the checksum of the given file, computed with the
+// given hash algorithm (SHA256 or SHA512).
+func fileChecksum(path string, algorithm string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	var h hash.Hash = sha256.New()
+	if algorithm == "SHA512" {
+		h = sha512.New()
+	}
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute checksum of %q: %v", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// imageName returns the file name of the image with the given source.
+func imageName(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return "image"
+	}
+
+	return path.Base(u.Path)
+}
+
+func isChecksum(s string) bool {
+	return checksumHash(s) != ""
+}
+
+// checksumHash returns the name of the hash algorithm (SHA256 or SHA512)
+// of the given checksum, or an empty string if the value is not a valid
+// checksum.
+func checksumHash(s string) string {
+	if _, err := hex.DecodeString(s); err != nil {
+		return ""
+	}
+
+	switch len(s) {
+	case sha256.Size * 2:
+		return "SHA256"
+	case sha512.Size * 2:
+		return "SHA512"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,290 @@
+package images
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MusicDin/kubitect/pkg/ui"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imageContent = "image content"
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// mockServer serves an image and optionally a SHA256SUMS file. It counts
+// the number of image downloads.
+func mockServer(t *testing.T, sums string, downloads *int) *httptest.Server {
+	ui.MockGlobalUi(t)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/images/os.img", func(w http.ResponseWriter, r *http.Request) {
+		*downloads++
+		fmt.Fprint(w, imageContent)
+	})
+
+	mux.HandleFunc("/images/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		if sums == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, sums)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestEnsure_Download(t *testing.T) {
+	var downloads int
+
+	srv := mockServer(t, "", &downloads)
+	c := NewCache(t.TempDir())
+	src := srv.URL + "/images/os.img"
+
+	img, err := c.Ensure(src, checksum(imageContent), "", false)
+	require.NoError(t, err)
+	assert.Equal(t, src, img.Source)
+	assert.Equal(t, checksum(imageContent), img.Checksum)
+	assert.Equal(t, int64(len(imageContent)), img.Size)
+	assert.Equal(t, "os.img", filepath.Base(img.Path))
+
+	content, err := os.ReadFile(img.Path)
+	require.NoError(t, err)
+	assert.Equal(t, imageContent, string(content))
+
+	// Cached image is reused.
+	_, err = c.Ensure(src, checksum(imageContent), "", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, downloads)
+}
+
+func TestEnsure_ChecksumMismatch(t *testing.T) {
+	var downloads int
+
+	srv := mockServer(t, "", &downloads)
+	c := NewCache(t.TempDir())
+
+	_, err := c.Ensure(srv.URL+"/images/os.img", checksum("other"), "", false)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	imgs, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, imgs)
+}
+
+func TestEnsure_PublishedChecksum(t *testing.T) {
+	var downloads int
+
+	sums := fmt.Sprintf("%s *other.img\n%s *os.img\n", checksum("other"), checksum(imageContent))
+	srv := mockServer(t, sums, &downloads)
+	c := NewCache(t.TempDir())
+
+	img, err := c.Ensure(srv.URL+"/images/os.img", "", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, checksum(imageContent), img.Checksum)
+}
+
+func TestEnsure_PublishedChecksumMismatch(t *testing.T) {
+	var downloads int
+
+	sums := fmt.Sprintf("SHA256 (os.img) = %s\n", checksum("other"))
+	srv := mockServer(t, sums, &downloads)
+	c := NewCache(t.TempDir())
+
+	_, err := c.Ensure(srv.URL+"/images/os.img", "", "", false)
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestEnsure_MissingChecksum(t *testing.T) {
+	var downloads int
+
+	srv := mockServer(t, "", &downloads)
+	c := NewCache(t.TempDir())
+
+	_, err := c.Ensure(srv.URL+"/images/os.img", "", "", false)
+	assert.ErrorContains(t, err, "is neither configured nor published")
+	assert.Equal(t, 0, downloads)
+}
+
+func TestEnsure_ChecksumFile(t *testing.T) {
+	var downloads int
+
+	sum := sha512.Sum512([]byte(imageContent))
+	sum512 := hex.EncodeToString(sum[:])
+
+	srv := mockServer(t, "", &downloads)
+	src := srv.URL + "/images/os.img"
+
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/SHA512SUMS":
+			fmt.Fprintf(w, "%s  other.img\n%s  os.img\n", strings.Repeat("0", 128), sum512)
+		case "/CHECKSUM":
+			fmt.Fprintf(w, "# os-1.0.img: 13 bytes\nSHA256 (os-1.0.img) = %s\n", checksum(imageContent))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(files.Close)
+
+	c := NewCache(t.TempDir())
+
+	img, err := c.Ensure(src, "", files.URL+"/SHA512SUMS", false)
+	require.NoError(t, err)
+	assert.Equal(t, sum512, img.ChecksumSHA512)
+
+	// Cached image is reused.
+	_, err = c.Ensure(src, "", files.URL+"/SHA512SUMS", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, downloads)
+
+	// Checksum file of a single image is accepted regardless of the name.
+	c = NewCache(t.TempDir())
+
+	img, err = c.Ensure(src, "", files.URL+"/CHECKSUM", false)
+	require.NoError(t, err)
+	assert.Equal(t, checksum(imageContent), img.Checksum)
+
+	// Missing checksum file is not replaced by the published checksums.
+	c = NewCache(t.TempDir())
+
+	_, err = c.Ensure(src, "", files.URL+"/missing", false)
+	assert.ErrorContains(t, err, "is neither configured nor published")
+}
+
+func TestEnsure_ChecksumChanged(t *testing.T) {
+	var downloads int
+
+	srv := mockServer(t, "", &downloads)
+	c := NewCache(t.TempDir())
+	src := srv.URL + "/images/os.img"
+
+	_, err := c.Ensure(src, "", "", true)
+	require.NoError(t, err)
+
+	// Image is downloaded again if cached checksum does not match.
+	_, err = c.Ensure(src, strings.ToUpper(checksum(imageContent)), "", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, downloads)
+
+	_, err = c.Ensure(src, checksum("other"), "", false)
+	assert.Error(t, err)
+	assert.Equal(t, 2, downloads)
+}
+
+func TestEnsure_Local(t *testing.T) {
+	ui.MockGlobalUi(t)
+
+	p := filepath.Join(t.TempDir(), "os.img")
+	require.NoError(t, os.WriteFile(p, []byte(imageContent), 0644))
+
+	c := NewCache(t.TempDir())
+
+	img, err := c.Ensure(p, checksum(imageContent), "", false)
+	require.NoError(t, err)
+	assert.Equal(t, p, img.Path)
+
+	_, err = c.Ensure(p, checksum("other"), "", false)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	_, err = c.Ensure(p+".missing", "", "", false)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestListAndRemove(t *testing.T) {
+	var downloads int
+
+	srv := mockServer(t, "", &downloads)
+	c := NewCache(t.TempDir())
+	src := srv.URL + "/images/os.img"
+
+	_, err := c.Ensure(src, "", "", true)
+	require.NoError(t, err)
+
+	imgs, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, imgs, 1)
+	assert.Equal(t, src, imgs[0].Source)
+	assert.FileExists(t, imgs[0].Path)
+
+	require.NoError(t, c.Remove(src))
+
+	imgs, err = c.List()
+	require.NoError(t, err)
+	assert.Empty(t, imgs)
+}
+
+func TestList_MissingCache(t *testing.T) {
+	c := NewCache(filepath.Join(t.TempDir(), "missing"))
+
+	imgs, err := c.List()
+	assert.NoError(t, err)
+	assert.Empty(t, imgs)
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := checksum(imageContent)
+
+	tests := []struct {
+		content string
+		ok      bool
+	}{
+		{sum + "  os.img", true},
+		{sum + " *os.img", true},
+		{"SHA256 (os.img) = " + sum, true},
+		{sum, true},
+		{sum + "  other.img", false},
+		{"SHA512 (os.img) = " + sum, false},
+		{"SHA512 (os.img) = " + sum + sum, true},
+		{"invalid  os.img", false},
+	}
+
+	for _, test := range tests {
+		actual, ok := parseChecksum(strings.NewReader(test.content), "os.img")
+		assert.Equal(t, test.ok, ok, test.content)
+
+		if test.ok {
+			assert.True(t, strings.HasPrefix(actual, sum))
+		}
+	}
+}
+
+func TestVolumeName(t *testing.T) {
+	img := Image{
+		Source:   "https://example.com/images/os.img",
+		Checksum: checksum(imageContent),
+	}
+
+	name, err := VolumeName(img)
+	require.NoError(t, err)
+	assert.Equal(t, checksum(imageContent)[:12]+"-os.img", name)
+}
+
+func TestRemoteXML(t *testing.T) {
+	pool, err := xml.Marshal(poolXML{Type: "dir", Name: RemotePool, Path: "/var/lib/libvirt/images/kubitect-images"})
+	require.NoError(t, err)
+	assert.Equal(t, `<pool type="dir"><name>kubitect-images</name><target><path>/var/lib/libvirt/images/kubitect-images</path></target></pool>`, string(pool))
+
+	vol, err := xml.Marshal(volumeXML{Name: "os.img", Capacity: volumeSizeXML{Unit: "bytes", Value: 42}, Format: volumeFormatXML{Type: "raw"}})
+	require.NoError(t, err)
+	assert.Equal(t, `<volume><name>os.img</name><capacity unit="bytes">42</capacity><target><format type="raw"></format></target></volume>`, string(vol))
+}
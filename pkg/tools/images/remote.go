@@ -0,0 +1,174 @@
+package images
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/tools/virt"
+	"github.com/MusicDin/kubitect/pkg/ui"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+)
+
+// RemotePool is a name of the libvirt storage pool on remote hosts into
+// which images are uploaded. The pool is shared among all clusters on
+// the host.
+const RemotePool = "kubitect-images"
+
+// VolumeName returns the name of the volume that contains the given image
+// within the remote pool. Since the name is derived from the image
+// checksum, different versions of the same image never collide.
+func VolumeName(img Image) (string, error) {
+	sum := img.Checksum
+
+	if sum == "" {
+		var err error
+
+		sum, err = FileChecksum(img.Path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%s-%s", sum[:12], imageName(img.Source)), nil
+}
+
+// Upload uploads the image into the remote pool of the connected libvirt
+// host, unless the host already contains it. The pool is created within
+// the given directory if it does not exist yet. The name of the volume
+// containing the image is returned.
+func Upload(l *golibvirt.Libvirt, poolDir string, img Image) (string, error) {
+	name, err := VolumeName(img)
+	if err != nil {
+		return "", err
+	}
+
+	pool, err := l.StoragePoolLookupByName(RemotePool)
+	if virt.IsNotFound(err) {
+		pool, err = createRemotePool(l, poolDir)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to create pool %q: %v", RemotePool, err)
+	}
+
+	_, err = l.StorageVolLookupByName(pool, name)
+	if err == nil {
+		return name, nil
+	}
+
+	if !virt.IsNotFound(err) {
+		return "", err
+	}
+
+	ui.Printf(ui.INFO, "Uploading image %q...\n", img.Source)
+
+	err = uploadVolume(l, pool, name, img)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image %q: %v", img.Source, err)
+	}
+
+	// Refresh the pool, so that libvirt detects the actual image format.
+	if err := l.StoragePoolRefresh(pool, 0); err != nil {
+		return "", fmt.Errorf("failed to refresh pool %q: %v", RemotePool, err)
+	}
+
+	return name, nil
+}
+
+// createRemotePool creates the remote pool within the given directory.
+func createRemotePool(l *golibvirt.Libvirt, poolDir string) (golibvirt.StoragePool, error) {
+	def := poolXML{
+		Type: "dir",
+		Name: RemotePool,
+		Path: strings.TrimSuffix(poolDir, "/") + "/" + RemotePool,
+	}
+
+	b, err := xml.Marshal(def)
+	if err != nil {
+		return golibvirt.StoragePool{}, err
+	}
+
+	pool, err := l.StoragePoolDefineXML(string(b), 0)
+	if err != nil {
+		return pool, err
+	}
+
+	err = l.StoragePoolBuild(pool, 0)
+	if err == nil {
+		err = l.StoragePoolCreate(pool, 0)
+	}
+
+	if err == nil {
+		err = l.StoragePoolSetAutostart(pool, 1)
+	}
+
+	if err != nil {
+		_ = l.StoragePoolUndefine(pool)
+	}
+
+	return pool, err
+}
+
+// uploadVolume creates a volume with the given name and uploads the image
+// into it. If the upload fails, the volume is removed.
+func uploadVolume(l *golibvirt.Libvirt, pool golibvirt.StoragePool, name string, img Image) error {
+	f, err := os.Open(img.Path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	def := volumeXML{
+		Name:     name,
+		Capacity: volumeSizeXML{Unit: "bytes", Value: uint64(img.Size)},
+		Format:   volumeFormatXML{Type: "raw"},
+	}
+
+	b, err := xml.Marshal(def)
+	if err != nil {
+		return err
+	}
+
+	vol, err := l.StorageVolCreateXML(pool, string(b), 0)
+	if err != nil {
+		return err
+	}
+
+	err = l.StorageVolUpload(vol, f, 0, uint64(img.Size), 0)
+	if err != nil {
+		_ = l.StorageVolDelete(vol, 0)
+		return err
+	}
+
+	return nil
+}
+
+// XML definitions of the libvirt objects created on remote hosts.
+type (
+	poolXML struct {
+		XMLName xml.Name `xml:"pool"`
+		Type    string   `xml:"type,attr"`
+		Name    string   `xml:"name"`
+		Path    string   `xml:"target>path"`
+	}
+
+	volumeXML struct {
+		XMLName  xml.Name        `xml:"volume"`
+		Name     string          `xml:"name"`
+		Capacity volumeSizeXML   `xml:"capacity"`
+		Format   volumeFormatXML `xml:"target>format"`
+	}
+
+	volumeSizeXML struct {
+		Unit  string `xml:"unit,attr"`
+		Value uint64 `xml:",chardata"`
+	}
+
+	volumeFormatXML struct {
+		Type string `xml:"type,attr"`
+	}
+)
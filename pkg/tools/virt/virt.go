@@ -0,0 +1,74 @@
+package virt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MusicDin/kubitect/pkg/models/config"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/digitalocean/go-libvirt/socket/dialers"
+)
+
+// Connect connects to libvirt on the given host over the libvirt RPC
+// protocol. Local hosts are reached over the libvirt socket, while remote
+// hosts are reached over SSH.
+func Connect(h config.Host) (*golibvirt.Libvirt, error) {
+	var l *golibvirt.Libvirt
+
+	switch h.Connection.Type {
+	case config.REMOTE:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		keyfile := string(h.Connection.SSH.Keyfile)
+		if strings.HasPrefix(keyfile, "~") {
+			keyfile = filepath.Join(home, strings.TrimPrefix(keyfile, "~"))
+		}
+
+		opts := []dialers.SSHOption{
+			dialers.UseSSHUsername(string(h.Connection.User)),
+			dialers.UseSSHPort(strconv.Itoa(int(h.Connection.SSH.Port))),
+			dialers.UseKeyFile(keyfile),
+			dialers.WithSSHAuthMethods((&dialers.SSHAuthMethods{}).PrivKey()),
+		}
+
+		if h.Connection.SSH.Verify {
+			opts = append(opts, dialers.UseKnownHostsFile(filepath.Join(home, ".ssh", "known_hosts")))
+		} else {
+			opts = append(opts, dialers.WithInsecureIgnoreHostKey())
+		}
+
+		l = golibvirt.NewWithDialer(dialers.NewSSH(string(h.Connection.IP), opts...))
+	default:
+		l = golibvirt.NewWithDialer(dialers.NewLocal())
+	}
+
+	err := l.ConnectToURI(golibvirt.QEMUSystem)
+	if err != nil {
+		return nil, fmt.Errorf("connect to host %q: %v", h.Name, err)
+	}
+
+	return l, nil
+}
+
+// IsNotFound returns true if the error reports a missing libvirt object.
+func IsNotFound(err error) bool {
+	var e golibvirt.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	switch golibvirt.ErrorNumber(e.Code) {
+	case golibvirt.ErrNoDomain, golibvirt.ErrNoNetwork, golibvirt.ErrNoStoragePool, golibvirt.ErrNoStorageVol:
+		return true
+	}
+
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/ui"
 )
 
@@ -14,6 +15,7 @@ type VirtualEnv struct {
 	path             string
 	requirementsPath string
 	initialized      bool
+	bundle           *bundle.Bundle
 }
 
 // NewVirtualEnv returns new virtual environment (VE). It expects VE path
@@ -26,6 +28,13 @@ func NewVirtualEnv(virtualEnvPath, reqPath string) *VirtualEnv {
 	}
 }
 
+// WithBundle sets the bundle from which pip3 requirements are installed
+// instead of being downloaded from the package index.
+func (e *VirtualEnv) WithBundle(b *bundle.Bundle) *VirtualEnv {
+	e.bundle = b
+	return e
+}
+
 // Init creates virtual environment in the cluster path
 // and installs required pip3 and ansible dependencies.
 func (e *VirtualEnv) Init() error {
@@ -91,7 +100,18 @@ func (e *VirtualEnv) create() error {
 
 // installPipReq installs pip3 requirements into virtual environment.
 func (e *VirtualEnv) installPipReq() error {
-	cmd := exec.Command("pip3", "install", "-r", e.requirementsPath)
+	args := []string{"install", "-r", e.requirementsPath}
+
+	if e.bundle != nil {
+		dir, err := e.bundle.Requirements(e.requirementsPath)
+		if err != nil {
+			return fmt.Errorf("failed to install pip3 requirements: %v", err)
+		}
+
+		args = append(args, "--no-index", "--find-links", dir)
+	}
+
+	cmd := exec.Command("pip3", args...)
 	cmd.Path = filepath.Join(e.path, "bin", "pip3")
 	cmd.Dir = filepath.Dir(e.path)
 
@@ -108,3 +128,27 @@ func (e *VirtualEnv) installPipReq() error {
 
 	return nil
 }
+
+// Download initializes the virtual environment and downloads pip3
+// requirements into the destination directory, so that they can be
+// installed later without access to the package index.
+func (e *VirtualEnv) Download(dstPath string) error {
+	if err := e.Init(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("pip3", "download", "-r", e.requirementsPath, "-d", dstPath)
+	cmd.Path = filepath.Join(e.path, "bin", "pip3")
+	cmd.Dir = filepath.Dir(e.path)
+
+	if ui.Debug() {
+		cmd.Stdout = ui.Streams().Out().File()
+		cmd.Stderr = ui.Streams().Err().File()
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to download pip3 requirements: %v", err)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/MusicDin/kubitect/pkg/tools/bundle"
 	"github.com/MusicDin/kubitect/pkg/ui"
 
 	"github.com/stretchr/testify/require"
@@ -59,3 +60,15 @@ func TestInit_InvalidReqPath(t *testing.T) {
 	env := NewVirtualEnv(t.TempDir(), "")
 	require.ErrorContains(t, env.Init(), "failed to install pip3 requirements:")
 }
+
+func TestInstallPipReq_NotBundled(t *testing.T) {
+	tmpDir := t.TempDir()
+	archive := path.Join(tmpDir, "bundle.tar.gz")
+	require.NoError(t, bundle.NewWriter(path.Join(tmpDir, "bundle")).Write(archive))
+
+	b, err := bundle.Open(archive, path.Join(tmpDir, "bundles"))
+	require.NoError(t, err)
+
+	env := MockVirtualEnv(t).WithBundle(b)
+	require.ErrorContains(t, env.installPipReq(), "are not included in the bundle")
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -86,9 +87,20 @@ type localClient struct {
 }
 
 // NewLocalClient initializes a client for running local commands.
+// Commands inherit the environment of the current process, so that
+// variables such as PATH and proxy settings are preserved.
 func NewLocalClient() localClient {
+	c := newCommonClient()
+
+	for _, e := range os.Environ() {
+		k, v, ok := strings.Cut(e, "=")
+		if ok {
+			c.envs[k] = v
+		}
+	}
+
 	return localClient{
-		commonClient: newCommonClient(),
+		commonClient: c,
 	}
 }
 
@@ -160,11 +172,18 @@ type remoteClient struct {
 	port           string
 	privateKeyPath string
 	publicKeyPath  string
-	initialized    bool
 	sudo           bool
 
-	client *ssh.Client
-	mux    *sync.Mutex
+	// conn is shared between copies of the client, so that the
+	// established connection is reused and can be closed.
+	conn *remoteConn
+}
+
+// remoteConn holds the SSH connection of the remote client.
+type remoteConn struct {
+	client      *ssh.Client
+	initialized bool
+	mux         sync.Mutex
 }
 
 // NewSSHClient initializes a new remote SSH client.
@@ -174,7 +193,7 @@ func NewSSHClient(user string, host string) remoteClient {
 		user:         user,
 		host:         host,
 		port:         "22",
-		mux:          &sync.Mutex{},
+		conn:         &remoteConn{},
 	}
 }
 
@@ -230,13 +249,13 @@ func (c remoteClient) Close() error {
 		return nil
 	}
 
-	return c.client.Close()
+	return c.conn.client.Close()
 }
 
 // Run establishes new connection with the remote host and executes
 // the given command.
 func (c remoteClient) Run(command string, args ...string) error {
-	return c.RunCtx(context.Background(), command)
+	return c.RunCtx(context.Background(), command, args...)
 }
 
 // RunCtx establishes new connection with the remote host and executes
@@ -245,42 +264,43 @@ func (c remoteClient) RunCtx(ctx context.Context, command string, args ...string
 	command, args = splitOneLineCommand(command, args)
 
 	// Ensure SSH client is initialized.
-	if c.client == nil {
-		err := c.initClient(ctx)
-		if err != nil {
-			c.mux.Unlock()
-			return err
-		}
+	err := c.initClient(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Initiate new SSH session.
-	c.mux.Lock()
-	session, err := c.client.NewSession()
+	c.conn.mux.Lock()
+	session, err := c.conn.client.NewSession()
+	c.conn.mux.Unlock()
 	if err != nil {
-		c.mux.Unlock()
 		return fmt.Errorf("create session for %q: %v", c.Endpoint(), err)
 	}
 	defer session.Close()
-	c.mux.Unlock()
 
 	// Prepare command.
 	session.Stdin = c.stdin
 	session.Stdout = c.stdout
 	session.Stderr = c.stderr
 
-	for k, v := range c.envs {
-		err := session.Setenv(k, v)
-		if err != nil {
-			return fmt.Errorf("set env variable %q: %v", k, err)
-		}
-	}
-
-	// Run the command.
+	// Run the command. Environment variables are set as a part of the
+	// command, since SSH servers usually reject requests for setting
+	// environment variables that are not explicitly allowed.
 	cmd := command
 	if len(args) > 0 {
 		cmd = fmt.Sprintf("%s %s", command, strings.Join(args, " "))
 	}
 
+	if len(c.envs) > 0 {
+		envs := make([]string, 0, len(c.envs))
+		for k, v := range c.envs {
+			envs = append(envs, fmt.Sprintf("%s='%s'", k, strings.ReplaceAll(v, "'", `'\''`)))
+		}
+
+		sort.Strings(envs)
+		cmd = fmt.Sprintf("env %s %s", strings.Join(envs, " "), cmd)
+	}
+
 	if c.sudo {
 		cmd = fmt.Sprintf("sudo --preserve-env %s", cmd)
 	}
@@ -288,9 +308,15 @@ func (c remoteClient) RunCtx(ctx context.Context, command string, args ...string
 	return session.Run(cmd)
 }
 
-func (c *remoteClient) initClient(ctx context.Context) error {
-	c.mux.Lock()
-	defer c.mux.Unlock()
+// initClient establishes the SSH connection, unless it is already
+// established.
+func (c remoteClient) initClient(ctx context.Context) error {
+	c.conn.mux.Lock()
+	defer c.conn.mux.Unlock()
+
+	if c.conn.initialized {
+		return nil
+	}
 
 	config := &ssh.ClientConfig{}
 	config.User = c.user
@@ -340,16 +366,16 @@ func (c *remoteClient) initClient(ctx context.Context) error {
 		return fmt.Errorf("dial %q: %v", c.Endpoint(), err)
 	}
 
-	c.client = client
-	c.initialized = true
+	c.conn.client = client
+	c.conn.initialized = true
 
 	return nil
 }
 
 func (c remoteClient) isInitialized() bool {
-	c.mux.Lock()
-	defer c.mux.Unlock()
-	return c.initialized
+	c.conn.mux.Lock()
+	defer c.conn.mux.Unlock()
+	return c.conn.initialized
 }
 
 // Run is a shorthand for running the given command locally.
@@ -363,7 +389,7 @@ func RunCtx(ctx context.Context, command string, args ...string) error {
 	c.SetStdout(os.Stdout)
 	c.SetStderr(os.Stderr)
 
-	return c.RunCtx(ctx, command)
+	return c.RunCtx(ctx, command, args...)
 }
 
 // splitOneLineCommand splits the command by spaces when no list of
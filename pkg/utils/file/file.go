@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
@@ -71,6 +72,42 @@ func ForceCopy(srcPath, dstPath string, mode fs.FileMode) error {
 	return nil
 }
 
+// CopyDir recursively copies the source directory into the destination
+// directory. File permissions and symbolic links are preserved.
+func CopyDir(srcPath, dstPath string) error {
+	return filepath.WalkDir(srcPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcPath, p)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(dstPath, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(target, dst)
+		default:
+			return ForceCopy(p, dst, info.Mode().Perm())
+		}
+	})
+}
+
 // ReadYaml reads yaml file on the given path and unmarshals it into the given
 // type.
 func ReadYaml[T any](path string, typ T) (*T, error) {
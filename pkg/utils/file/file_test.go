@@ -85,6 +85,24 @@ func TestForceCopy(t *testing.T) {
 	assert.Equal(t, "source", out)
 }
 
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("echo"), 0755))
+	require.NoError(t, os.Symlink("sub/run.sh", filepath.Join(src, "link")))
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, CopyDir(src, dst))
+
+	info, err := os.Stat(filepath.Join(dst, "sub", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	target, err := os.Readlink(filepath.Join(dst, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "sub/run.sh", target)
+}
+
 func TestAppend(t *testing.T) {
 	src := tmpFile(t, "src.file", "source\n")
 
@@ -0,0 +1,106 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// Allocator hands out free IP addresses from a network prefix. Network and
+// broadcast addresses of the prefix are never allocated.
+type Allocator struct {
+	prefix   netip.Prefix
+	reserved map[netip.Addr]struct{}
+	ranges   [][2]netip.Addr
+}
+
+// NewAllocator returns an allocator for the given CIDR.
+func NewAllocator(cidr string) (*Allocator, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("ipam: invalid CIDR %q: %v", cidr, err)
+	}
+
+	a := &Allocator{
+		prefix:   prefix.Masked(),
+		reserved: make(map[netip.Addr]struct{}),
+	}
+
+	// Reserve network address.
+	a.reserved[a.prefix.Addr()] = struct{}{}
+
+	// Reserve broadcast address (IPv4 only).
+	if a.prefix.Addr().Is4() {
+		a.reserved[a.last()] = struct{}{}
+	}
+
+	return a, nil
+}
+
+// Reserve marks the given addresses as used. Invalid addresses and
+// addresses outside of the allocator's prefix are ignored.
+func (a *Allocator) Reserve(addrs ...string) {
+	for _, s := range addrs {
+		addr, err := netip.ParseAddr(s)
+		if err != nil || !a.prefix.Contains(addr) {
+			continue
+		}
+
+		a.reserved[addr] = struct{}{}
+	}
+}
+
+// ReserveRange marks all addresses between first and last (inclusive)
+// as used.
+func (a *Allocator) ReserveRange(first, last netip.Addr) {
+	a.ranges = append(a.ranges, [2]netip.Addr{first, last})
+}
+
+// IsFree returns true if the given address is within the allocator's
+// prefix and is not reserved.
+func (a *Allocator) IsFree(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !a.prefix.Contains(addr) {
+		return false
+	}
+
+	if _, ok := a.reserved[addr]; ok {
+		return false
+	}
+
+	for _, r := range a.ranges {
+		if addr.Compare(r[0]) >= 0 && addr.Compare(r[1]) <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Allocate reserves and returns the lowest free address.
+func (a *Allocator) Allocate() (string, error) {
+	for addr := a.prefix.Addr(); a.prefix.Contains(addr); addr = addr.Next() {
+		if a.IsFree(addr.String()) {
+			a.reserved[addr] = struct{}{}
+			return addr.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("ipam: no free addresses left in %s", a.prefix)
+}
+
+// last returns the last address of the allocator's prefix.
+func (a *Allocator) last() netip.Addr {
+	b := a.prefix.Addr().AsSlice()
+	bits := a.prefix.Bits()
+
+	for i := range b {
+		for j := 0; j < 8; j++ {
+			if i*8+j >= bits {
+				b[i] |= 1 << (7 - j)
+			}
+		}
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
@@ -0,0 +1,68 @@
+package ipam
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAllocator_InvalidCIDR(t *testing.T) {
+	_, err := NewAllocator("192.168.113.0")
+	assert.ErrorContains(t, err, "ipam: invalid CIDR")
+}
+
+func TestAllocate(t *testing.T) {
+	a, err := NewAllocator("192.168.113.0/24")
+	require.NoError(t, err)
+
+	a.Reserve("192.168.113.1")
+
+	ip, err := a.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.113.2", ip)
+
+	ip, err = a.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.113.3", ip)
+}
+
+func TestAllocate_ReservedRange(t *testing.T) {
+	a, err := NewAllocator("192.168.113.0/24")
+	require.NoError(t, err)
+
+	a.ReserveRange(netip.MustParseAddr("192.168.113.1"), netip.MustParseAddr("192.168.113.99"))
+
+	ip, err := a.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.113.100", ip)
+}
+
+func TestAllocate_Exhausted(t *testing.T) {
+	a, err := NewAllocator("192.168.113.0/30")
+	require.NoError(t, err)
+
+	_, err = a.Allocate()
+	require.NoError(t, err)
+
+	_, err = a.Allocate()
+	require.NoError(t, err)
+
+	_, err = a.Allocate()
+	assert.EqualError(t, err, "ipam: no free addresses left in 192.168.113.0/30")
+}
+
+func TestIsFree(t *testing.T) {
+	a, err := NewAllocator("192.168.113.0/24")
+	require.NoError(t, err)
+
+	a.Reserve("192.168.113.10", "10.10.0.1", "invalid")
+
+	assert.False(t, a.IsFree("192.168.113.0"))   // network
+	assert.False(t, a.IsFree("192.168.113.255")) // broadcast
+	assert.False(t, a.IsFree("192.168.113.10"))
+	assert.False(t, a.IsFree("10.10.0.2"))
+	assert.False(t, a.IsFree("invalid"))
+	assert.True(t, a.IsFree("192.168.113.11"))
+}
@@ -62,6 +62,7 @@ func initialize() {
 	validate.RegisterValidation("extra_semverinrange", extra_SemVersionInRange)
 	validate.RegisterValidation("extra_ipinrange", extra_IPInRange)
 	validate.RegisterValidation("extra_cidrv4", extra_CIDRv4)
+	validate.RegisterValidation("extra_iprange", extra_IPRange)
 	validate.RegisterValidation("extra_uniquefield", extra_UniqueField)
 	validate.RegisterValidation("extra_regexany", extra_RegexAny)
 	validate.RegisterValidation("extra_regexall", extra_RegexAll)
@@ -298,6 +299,15 @@ func IPInRange(cidr string) Validator {
 	}
 }
 
+// IPRange checks whether the field value is a valid range of IP addresses
+// in format "<first>-<last>" (e.g. 10.10.0.10-10.10.0.20).
+func IPRange() Validator {
+	return Validator{
+		Tags: "extra_iprange",
+		Err:  "Field '{.Field}' must be a valid IP range in format '<first>-<last>' (actual: {.Value}).",
+	}
+}
+
 // MAC checks whether the field value is a valid MAC address.
 func MAC() Validator {
 	return Validator{
@@ -3,6 +3,7 @@ package validation
 import (
 	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
 	"strings"
 
@@ -119,6 +120,35 @@ func extra_CIDRv4(fl validator.FieldLevel) bool {
 	return err == nil && ip.To4() != nil
 }
 
+// extra_IPRange returns true if struct field is a valid range of IP addresses.
+func extra_IPRange(fl validator.FieldLevel) bool {
+	_, _, ok := ParseIPRange(fl.Field().String())
+	return ok
+}
+
+// ParseIPRange parses a range of IP addresses in format "<first>-<last>"
+// and returns its first and last address. The range is valid (ok is true)
+// if both addresses are of the same family and the first address is not
+// greater than the last one.
+func ParseIPRange(r string) (first netip.Addr, last netip.Addr, ok bool) {
+	bounds := strings.Split(r, "-")
+	if len(bounds) != 2 {
+		return first, last, false
+	}
+
+	first, err := netip.ParseAddr(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return first, last, false
+	}
+
+	last, err = netip.ParseAddr(strings.TrimSpace(bounds[1]))
+	if err != nil {
+		return first, last, false
+	}
+
+	return first, last, first.BitLen() == last.BitLen() && first.Compare(last) <= 0
+}
+
 // extra_UniqueField returns true if struct field with a given name is unique for
 // all slice elements.
 func extra_UniqueField(fl validator.FieldLevel) bool {
@@ -214,6 +214,16 @@ func TestIPInRange(t *testing.T) {
 	assert.NoError(t, Var("192.168.113.113", IPInRange("192.168.113.1/24")))
 }
 
+func TestIPRange(t *testing.T) {
+	assert.Error(t, Var("42", IPRange()))
+	assert.Error(t, Var("192.168.113.10", IPRange()))
+	assert.Error(t, Var("192.168.113.20-192.168.113.10", IPRange()))
+	assert.Error(t, Var("192.168.113.10-2001:db8::1", IPRange()))
+	assert.NoError(t, Var("192.168.113.10-192.168.113.10", IPRange()))
+	assert.NoError(t, Var("192.168.113.10-192.168.113.20", IPRange()))
+	assert.NoError(t, Var("2001:db8::10-2001:db8::20", IPRange()))
+}
+
 func TestMAC(t *testing.T) {
 	assert.Error(t, Var("42", MAC()))
 	assert.NoError(t, Var("AA:BB:CC:DD:EE:FF", MAC()))